@@ -0,0 +1,17 @@
+package cache
+
+import "time"
+
+// Cache stores serialized values under string keys. Counters created by Incr are
+// never evicted, they are used to version groups of keys so that a whole group can
+// be invalidated at once.
+type Cache interface {
+	// Get reports false when the key is missing or expired
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+	// Incr increments a counter and returns its new value
+	Incr(key string) (int64, error)
+	// Counter returns the current value of a counter, 0 when it was never incremented
+	Counter(key string) (int64, error)
+}
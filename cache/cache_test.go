@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCacheSuite(t *testing.T, c Cache) {
+	_, ok, err := c.Get("wager:1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set("wager:1", []byte(`{"id":1}`), time.Minute))
+	require.NoError(t, c.Set("wager:2", []byte(`{"id":2}`), time.Minute))
+	value, ok, err := c.Get("wager:1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `{"id":1}`, string(value))
+
+	require.NoError(t, c.Delete("wager:1", "wager:2", "wager:3"))
+	_, ok, _ = c.Get("wager:1")
+	assert.False(t, ok)
+	_, ok, _ = c.Get("wager:2")
+	assert.False(t, ok)
+
+	counter, err := c.Counter("generation")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), counter)
+	counter, err = c.Incr("generation")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counter)
+	counter, err = c.Counter("generation")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counter)
+}
+
+func Test_LRU(t *testing.T) {
+	runCacheSuite(t, NewLRU(10))
+}
+
+func Test_Redis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	runCacheSuite(t, NewRedis(client))
+
+	require.NoError(t, NewRedis(client).Set("wager:1", []byte("1"), time.Second))
+	server.FastForward(time.Second)
+	_, ok, err := NewRedis(client).Get("wager:1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func Test_LRU_Eviction(t *testing.T) {
+	c := NewLRU(2)
+	c.Set("a", []byte("a"), time.Minute)
+	c.Set("b", []byte("b"), time.Minute)
+
+	// reading a makes b the least recently used entry
+	c.Get("a")
+	c.Set("c", []byte("c"), time.Minute)
+
+	_, ok, _ := c.Get("b")
+	assert.False(t, ok)
+	_, ok, _ = c.Get("a")
+	assert.True(t, ok)
+	_, ok, _ = c.Get("c")
+	assert.True(t, ok)
+
+	// counters are not subject to eviction
+	c.Incr("generation")
+	c.Set("d", []byte("d"), time.Minute)
+	c.Set("e", []byte("e"), time.Minute)
+	counter, _ := c.Counter("generation")
+	assert.Equal(t, int64(1), counter)
+}
+
+func Test_LRU_TTL(t *testing.T) {
+	now := time.Unix(1642484487, 0)
+	c := NewLRU(10).(*lruCache)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", []byte("a"), time.Second)
+	_, ok, _ := c.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok, _ = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.order.Len())
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisCache works with any server speaking the Redis protocol. Counters are plain
+// keys without expiry, the server should not be configured to evict them.
+type redisCache struct {
+	client *redis.Client
+}
+
+func NewRedis(client *redis.Client) Cache {
+	return &redisCache{client: client}
+}
+
+func (c *redisCache) Get(key string) ([]byte, bool, error) {
+	value, err := c.client.Get(context.Background(), key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *redisCache) Set(key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(context.Background(), key, value, ttl).Err()
+}
+
+func (c *redisCache) Delete(keys ...string) error {
+	return c.client.Del(context.Background(), keys...).Err()
+}
+
+func (c *redisCache) Incr(key string) (int64, error) {
+	return c.client.Incr(context.Background(), key).Result()
+}
+
+func (c *redisCache) Counter(key string) (int64, error) {
+	value, err := c.client.Get(context.Background(), key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return value, err
+}
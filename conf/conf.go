@@ -1,41 +1,386 @@
 package conf
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	STORAGE_SQL    = "sql"
+	STORAGE_MEMORY = "memory"
+
+	DIALECT_MYSQL    = "mysql"
+	DIALECT_POSTGRES = "postgres"
+	DIALECT_SQLITE   = "sqlite"
+
+	CACHE_NONE   = "none"
+	CACHE_MEMORY = "memory"
+	CACHE_REDIS  = "redis"
+)
 
 type HandlePath struct {
-	CreateWager  string
-	GetWagerList string
-	BuyWager     string
+	CreateWager        string
+	CreateWagers       string
+	GetWagerList       string
+	GetWager           string
+	BuyWager           string
+	QuoteWager         string
+	BuyWagers          string
+	GetPurchases       string
+	GetPurchase        string
+	Refund             string
+	ReserveWager       string
+	ConfirmReservation string
+	ReleaseReservation string
+	PlaceBid           string
+	GetBook            string
+	AcceptBid          string
+	CancelBid          string
+	LowerPrice         string
+	GetPositions       string
+	CreateListing      string
+	GetListings        string
+	BuyListing         string
+	CancelListing      string
+	GetTransfers       string
+	GetStats           string
+	CreateEvent        string
+	GetEvents          string
+	GetEvent           string
+	UpdateEvent        string
+	DeleteEvent        string
+	CreateMarket       string
+	GetMarkets         string
+	GetMarket          string
+	UpdateMarket       string
+	DeleteMarket       string
+	SettleMarket       string
+	StreamWagers       string
+	Socket             string
 }
 
 type SQLConfig struct {
+	Dialect         string
 	DatabaseAddress string
 	Username        string
 	Password        string
-	WagerTable      string
-	PurchaseTable   string
+	// Schema optionally qualifies the table names
+	Schema           string
+	WagerTable       string
+	PurchaseTable    string
+	ReservationTable string
+	BidTable         string
+	PositionTable    string
+	ListingTable     string
+	TransferTable    string
+	EventTable       string
+	MarketTable      string
+	SelectionTable   string
+	BuyerTable       string
+
+	// ReplicaAddresses are read replicas, in the same format as DatabaseAddress
+	ReplicaAddresses     []string
+	ReplicaRetryInterval time.Duration
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// PrepareStatements caches prepared statements for the hot queries
+	PrepareStatements bool
+
+	// Transactions failing on deadlocks or lock wait timeouts are retried
+	TxMaxRetries     int
+	TxRetryBaseDelay time.Duration
+	TxRetryMaxDelay  time.Duration
+}
+
+func (c SQLConfig) Tables() []string {
+	return []string{c.WagerTable, c.PurchaseTable, c.ReservationTable, c.BidTable, c.PositionTable, c.ListingTable, c.TransferTable, c.EventTable, c.MarketTable, c.SelectionTable, c.BuyerTable}
+}
+
+// identifierPattern is deliberately stricter than what the databases accept, since
+// table names are spliced into SQL statements
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// Validate checks the identifiers which end up in SQL statements
+func (c SQLConfig) Validate() error {
+	if c.Schema != "" && !identifierPattern.MatchString(c.Schema) {
+		return fmt.Errorf("invalid sql schema %q", c.Schema)
+	}
+
+	for _, table := range c.Tables() {
+		if !identifierPattern.MatchString(table) {
+			return fmt.Errorf("invalid sql table name %q", table)
+		}
+	}
+
+	return nil
+}
+
+type CacheConfig struct {
+	// Backend is one of CACHE_NONE, CACHE_MEMORY or CACHE_REDIS
+	Backend string
+	TTL     time.Duration
+	// Capacity bounds the number of entries of the in-memory cache
+	Capacity     int
+	RedisAddress string
+}
+
+type PurchaseConfig struct {
+	// RefundWindow is how long after bought_at a purchase can be refunded
+	RefundWindow time.Duration
+}
+
+type ReservationConfig struct {
+	// TTL is how long a reservation holds its amount when the request sets no TTL
+	TTL    time.Duration
+	MaxTTL time.Duration
+	// Expired reservations are released every SweepInterval, SweepBatchSize at a time
+	SweepInterval  time.Duration
+	SweepBatchSize int
+}
+
+type BidConfig struct {
+	// TTL is how long a bid rests in the book when the request sets no TTL
+	TTL    time.Duration
+	MaxTTL time.Duration
+	// Expired bids are swept, and bids on wagers with a price schedule matched,
+	// every SweepInterval, SweepBatchSize at a time
+	SweepInterval  time.Duration
+	SweepBatchSize int
+}
+
+// StreamConfig sizes the wager event stream
+type StreamConfig struct {
+	// ReplaySize is how many of the last events are kept for resuming subscribers
+	ReplaySize int
+	// BufferSize is how many events a subscriber can lag behind before it is dropped
+	BufferSize int
+}
+
+// SocketConfig bounds the connections of /ws
+type SocketConfig struct {
+	// MessageRate is how many messages per second a connection can send, in bursts
+	// of up to MessageBurst
+	MessageRate  float64
+	MessageBurst int
+	// MaxMessageSize is the largest message in bytes a connection can send
+	MaxMessageSize int64
+	// SendBufferSize is how many messages can wait to be written to a connection
+	SendBufferSize int
+	// A ping is sent every PingInterval, a connection which does not answer within
+	// PongWait or does not take a message within WriteWait is closed
+	PingInterval time.Duration
+	PongWait     time.Duration
+	WriteWait    time.Duration
+}
+
+// BuyerLimitConfig caps what a single buyer can hold, a zero limit is not checked
+type BuyerLimitConfig struct {
+	// MaxWagerPercentage is the largest percentage of a wager's selling price a buyer can buy
+	MaxWagerPercentage float64
+	// MaxOpenExposure is the largest amount a buyer can hold across all open wagers
+	MaxOpenExposure      float64
+	MaxPurchasesPerWager int
+}
+
+func (c BuyerLimitConfig) Enabled() bool {
+	return c.MaxWagerPercentage > 0 || c.MaxOpenExposure > 0 || c.MaxPurchasesPerWager > 0
+}
+
+// FeeSchedule charges Percentage of the buying price of a purchase, raised to Min
+// and capped at Max when they are set. A fee never exceeds the buying price.
+type FeeSchedule struct {
+	Percentage float64
+	Min        float64
+	Max        float64
+}
+
+// FeeTier replaces the percentages of the fee schedules for users whose volume is
+// at least Volume
+type FeeTier struct {
+	Volume          float64
+	MakerPercentage float64
+	TakerPercentage float64
+}
+
+// FeeConfig charges makers, the sellers of wagers, and takers, their buyers, on
+// every purchase. The volume of a seller is what was bought from their wagers, the
+// volume of a buyer is what they bought, refunded purchases are left out of both.
+type FeeConfig struct {
+	Maker FeeSchedule
+	Taker FeeSchedule
+	// Tiers are in ascending Volume, the last tier reached applies
+	Tiers []FeeTier
+}
+
+// Tier returns the tier reached by volume, nil below the first tier
+func (c FeeConfig) Tier(volume float64) *FeeTier {
+	var tier *FeeTier
+	for i := range c.Tiers {
+		if volume < c.Tiers[i].Volume {
+			break
+		}
+		tier = &c.Tiers[i]
+	}
+	return tier
+}
+
+// ParseFeeTiers reads tiers written as volume:maker:taker, separated by commas,
+// with the percentages of each tier
+func ParseFeeTiers(value string) ([]FeeTier, error) {
+	tiers := []FeeTier{}
+	if strings.TrimSpace(value) == "" {
+		return tiers, nil
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid fee tier %q, expected volume:maker:taker", item)
+		}
+		numbers := make([]float64, len(parts))
+		for i, part := range parts {
+			number, err := strconv.ParseFloat(part, 64)
+			if err != nil || number < 0 {
+				return nil, fmt.Errorf("invalid fee tier %q, expected volume:maker:taker", item)
+			}
+			numbers[i] = number
+		}
+		if len(tiers) > 0 && numbers[0] <= tiers[len(tiers)-1].Volume {
+			return nil, fmt.Errorf("fee tiers must be in ascending volume, %q is not", item)
+		}
+		tiers = append(tiers, FeeTier{Volume: numbers[0], MakerPercentage: numbers[1], TakerPercentage: numbers[2]})
+	}
+	return tiers, nil
 }
 
 type Config struct {
-	ServerPort int
-	Handlers   HandlePath
-	SQL        SQLConfig
+	ServerPort  int
+	Storage     string
+	Handlers    HandlePath
+	SQL         SQLConfig
+	Cache       CacheConfig
+	Purchase    PurchaseConfig
+	Reservation ReservationConfig
+	BuyerLimit  BuyerLimitConfig
+	Bid         BidConfig
+	Fee         FeeConfig
+	Stream      StreamConfig
+	Socket      SocketConfig
 }
 
 func GetDefaultConfig() *Config {
 	return &Config{
 		ServerPort: 8080,
+		Storage:    STORAGE_SQL,
 		Handlers: HandlePath{
-			CreateWager:  "/wagers",
-			GetWagerList: "/wagers",
-			BuyWager:     "/buy/{wager_id}",
+			CreateWager:        "/wagers",
+			CreateWagers:       "/wagers/batch",
+			GetWagerList:       "/wagers",
+			GetWager:           "/wagers/{wager_id}",
+			BuyWager:           "/buy/{wager_id}",
+			QuoteWager:         "/wagers/{wager_id}/quote",
+			BuyWagers:          "/purchases/batch",
+			GetPurchases:       "/purchases",
+			GetPurchase:        "/purchases/{purchase_id}",
+			Refund:             "/purchases/{purchase_id}/refund",
+			ReserveWager:       "/wagers/{wager_id}/reservations",
+			ConfirmReservation: "/reservations/{reservation_id}/confirm",
+			ReleaseReservation: "/reservations/{reservation_id}/release",
+			PlaceBid:           "/wagers/{wager_id}/bids",
+			GetBook:            "/wagers/{wager_id}/book",
+			AcceptBid:          "/bids/{bid_id}/accept",
+			CancelBid:          "/bids/{bid_id}/cancel",
+			LowerPrice:         "/wagers/{wager_id}/price",
+			GetPositions:       "/positions",
+			CreateListing:      "/positions/{position_id}/listings",
+			GetListings:        "/wagers/{wager_id}/listings",
+			BuyListing:         "/listings/{listing_id}/buy",
+			CancelListing:      "/listings/{listing_id}/cancel",
+			GetTransfers:       "/purchases/{purchase_id}/transfers",
+			GetStats:           "/admin/stats",
+			CreateEvent:        "/events",
+			GetEvents:          "/events",
+			GetEvent:           "/events/{event_id}",
+			UpdateEvent:        "/events/{event_id}",
+			DeleteEvent:        "/events/{event_id}",
+			CreateMarket:       "/events/{event_id}/markets",
+			GetMarkets:         "/events/{event_id}/markets",
+			GetMarket:          "/markets/{market_id}",
+			UpdateMarket:       "/markets/{market_id}",
+			DeleteMarket:       "/markets/{market_id}",
+			SettleMarket:       "/markets/{market_id}/settle",
+			StreamWagers:       "/wagers/stream",
+			Socket:             "/ws",
 		},
 		SQL: SQLConfig{
-			DatabaseAddress: "tcp(db:3306)/demo",
-			Username:        os.Getenv("MYSQL_USER"),
-			Password:        os.Getenv("MYSQL_PASSWORD"),
-			WagerTable:      "wagers",
-			PurchaseTable:   "purchase",
+			Dialect:          DIALECT_MYSQL,
+			DatabaseAddress:  "tcp(db:3306)/demo",
+			Username:         os.Getenv("MYSQL_USER"),
+			Password:         os.Getenv("MYSQL_PASSWORD"),
+			WagerTable:       "wagers",
+			PurchaseTable:    "purchase",
+			ReservationTable: "reservation",
+			BidTable:         "bid",
+			PositionTable:    "position",
+			ListingTable:     "listing",
+			TransferTable:    "transfer",
+			EventTable:       "event",
+			MarketTable:      "market",
+			SelectionTable:   "selection",
+			BuyerTable:       "buyer",
+
+			ReplicaRetryInterval: 10 * time.Second,
+
+			MaxOpenConns:      25,
+			MaxIdleConns:      25,
+			ConnMaxLifetime:   5 * time.Minute,
+			ConnMaxIdleTime:   time.Minute,
+			PrepareStatements: true,
+
+			TxMaxRetries:     3,
+			TxRetryBaseDelay: 10 * time.Millisecond,
+			TxRetryMaxDelay:  200 * time.Millisecond,
+		},
+		Cache: CacheConfig{
+			Backend:      CACHE_MEMORY,
+			TTL:          10 * time.Second,
+			Capacity:     1000,
+			RedisAddress: "redis:6379",
+		},
+		Purchase: PurchaseConfig{
+			RefundWindow: 5 * time.Minute,
+		},
+		Reservation: ReservationConfig{
+			TTL:            2 * time.Minute,
+			MaxTTL:         15 * time.Minute,
+			SweepInterval:  10 * time.Second,
+			SweepBatchSize: 100,
+		},
+		Bid: BidConfig{
+			TTL:            24 * time.Hour,
+			MaxTTL:         7 * 24 * time.Hour,
+			SweepInterval:  10 * time.Second,
+			SweepBatchSize: 100,
+		},
+		Stream: StreamConfig{
+			ReplaySize: 1000,
+			BufferSize: 256,
+		},
+		Socket: SocketConfig{
+			MessageRate:    20,
+			MessageBurst:   40,
+			MaxMessageSize: 4096,
+			SendBufferSize: 256,
+			PingInterval:   30 * time.Second,
+			PongWait:       60 * time.Second,
+			WriteWait:      10 * time.Second,
 		},
 	}
 }
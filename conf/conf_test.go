@@ -0,0 +1,64 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SQLConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		schema  string
+		table   string
+		isValid bool
+	}{
+		{name: "Default", table: "wagers", isValid: true},
+		{name: "With schema", schema: "trading", table: "wager_2022", isValid: true},
+		{name: "Empty table", table: "", isValid: false},
+		{name: "Leading digit", table: "1wagers", isValid: false},
+		{name: "Qualified table", table: "demo.wagers", isValid: false},
+		{name: "Quote", table: "wagers`", isValid: false},
+		{name: "Statement", table: "wagers; DROP TABLE purchase", isValid: false},
+		{name: "Comment in schema", schema: "demo--", table: "wagers", isValid: false},
+		{name: "Too long", table: "w012345678901234567890123456789012345678901234567890123456789012", isValid: false},
+	}
+
+	for _, testcase := range testCases {
+		t.Run(testcase.name, func(t *testing.T) {
+			config := GetDefaultConfig().SQL
+			config.Schema = testcase.schema
+			config.WagerTable = testcase.table
+			err := config.Validate()
+			if testcase.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func Test_ParseFeeTiers(t *testing.T) {
+	tiers, err := ParseFeeTiers("1000:0.8:1.5, 10000:0.5:1")
+	assert.NoError(t, err)
+	assert.Equal(t, []FeeTier{{Volume: 1000, MakerPercentage: 0.8, TakerPercentage: 1.5}, {Volume: 10000, MakerPercentage: 0.5, TakerPercentage: 1}}, tiers)
+
+	tiers, err = ParseFeeTiers("")
+	assert.NoError(t, err)
+	assert.Empty(t, tiers)
+
+	for _, value := range []string{"1000:0.8", "1000:a:1", "1000:-1:1", "1000:1:1,500:1:1", "1000:1:1,1000:0.5:0.5"} {
+		_, err := ParseFeeTiers(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func Test_FeeConfig_Tier(t *testing.T) {
+	config := FeeConfig{Tiers: []FeeTier{{Volume: 1000, TakerPercentage: 1.5}, {Volume: 10000, TakerPercentage: 1}}}
+	assert.Nil(t, config.Tier(999.99))
+	assert.Equal(t, 1.5, config.Tier(1000).TakerPercentage)
+	assert.Equal(t, 1.5, config.Tier(9999).TakerPercentage)
+	assert.Equal(t, float64(1), config.Tier(50000).TakerPercentage)
+	assert.Nil(t, FeeConfig{}.Tier(50000))
+}
@@ -0,0 +1,87 @@
+// Package currency knows the ISO 4217 currencies wagers can be placed in and the
+// precision of their amounts.
+//
+// Amounts are floats in the major unit of their currency, like dollars, with at most
+// the minor-unit decimal places of the currency: 2 for USD, 0 for JPY and 3 for
+// KWD. Computed amounts are rounded half away from zero to the minor unit.
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	// DEFAULT is the currency of wagers placed without one
+	DEFAULT = "USD"
+	// MAX_MINOR_UNITS is the precision of the most precise currency, amounts given
+	// before their currency is known are checked against it
+	MAX_MINOR_UNITS = 3
+)
+
+var ErrUnknown = errors.New("must be an ISO 4217 currency code like USD")
+
+// minorUnits are the decimal places of the supported currencies
+var minorUnits = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0,
+	"CNY": 2, "COP": 2, "CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2,
+	"IDR": 2, "ILS": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"MXN": 2, "MYR": 2, "NGN": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2, "PLN": 2,
+	"RON": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2,
+	"UAH": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+type Currency struct {
+	Code       string
+	MinorUnits int
+}
+
+// Get returns the currency of an ISO 4217 code
+func Get(code string) (Currency, error) {
+	units, ok := minorUnits[code]
+	if !ok {
+		return Currency{}, fmt.Errorf("%q %w", code, ErrUnknown)
+	}
+	return Currency{Code: code, MinorUnits: units}, nil
+}
+
+// Of returns the currency of code, which was checked when it was stored. Wagers
+// stored before they had a currency are in DEFAULT.
+func Of(code string) Currency {
+	if c, err := Get(code); err == nil {
+		return c
+	}
+	return Currency{Code: DEFAULT, MinorUnits: minorUnits[DEFAULT]}
+}
+
+// Valid reports whether code is a supported currency
+func Valid(code string) bool {
+	_, ok := minorUnits[code]
+	return ok
+}
+
+// ToMinor converts amount to a whole number of minor units, like cents
+func (c Currency) ToMinor(amount float64) int64 {
+	return int64(math.Round(amount * c.scale()))
+}
+
+// Round rounds amount to the minor unit
+func (c Currency) Round(amount float64) float64 {
+	return float64(c.ToMinor(amount)) / c.scale()
+}
+
+// Exact reports whether amount has no more decimal places than the currency
+func (c Currency) Exact(amount float64) bool {
+	return Exact(amount, c.MinorUnits)
+}
+
+// Exact reports whether amount has at most places decimal places
+func Exact(amount float64, places int) bool {
+	scaled := amount * math.Pow10(places)
+	return math.Abs(scaled-math.Round(scaled)) < 1e-6
+}
+
+func (c Currency) scale() float64 {
+	return math.Pow10(c.MinorUnits)
+}
@@ -0,0 +1,53 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Get(t *testing.T) {
+	usd, err := Get("USD")
+	assert.NoError(t, err)
+	assert.Equal(t, Currency{Code: "USD", MinorUnits: 2}, usd)
+
+	jpy, err := Get("JPY")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, jpy.MinorUnits)
+
+	for _, code := range []string{"usd", "XYZ", ""} {
+		_, err = Get(code)
+		assert.ErrorIs(t, err, ErrUnknown)
+		assert.False(t, Valid(code))
+	}
+
+	assert.Equal(t, usd, Of(""))
+	assert.Equal(t, Currency{Code: "KWD", MinorUnits: 3}, Of("KWD"))
+}
+
+func Test_Precision(t *testing.T) {
+	tests := []struct {
+		code    string
+		amount  float64
+		exact   bool
+		rounded float64
+		minor   int64
+	}{
+		{code: "USD", amount: 10.25, exact: true, rounded: 10.25, minor: 1025},
+		{code: "USD", amount: 0.29, exact: true, rounded: 0.29, minor: 29},
+		{code: "USD", amount: 10.255, rounded: 10.26, minor: 1026},
+		{code: "JPY", amount: 1500, exact: true, rounded: 1500, minor: 1500},
+		{code: "JPY", amount: 1500.5, rounded: 1501, minor: 1501},
+		{code: "KWD", amount: 1.125, exact: true, rounded: 1.125, minor: 1125},
+		{code: "KWD", amount: 1.1255, rounded: 1.126, minor: 1126},
+	}
+	for _, tc := range tests {
+		c := Of(tc.code)
+		assert.Equal(t, tc.exact, c.Exact(tc.amount), "%v %v", tc.code, tc.amount)
+		assert.Equal(t, tc.rounded, c.Round(tc.amount), "%v %v", tc.code, tc.amount)
+		assert.Equal(t, tc.minor, c.ToMinor(tc.amount), "%v %v", tc.code, tc.amount)
+	}
+
+	assert.True(t, Exact(1.125, MAX_MINOR_UNITS))
+	assert.False(t, Exact(1.1255, MAX_MINOR_UNITS))
+}
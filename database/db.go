@@ -4,62 +4,249 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"sync"
+	"sync/atomic"
 )
 
 type DBRows interface {
 	Close() error
 	Next() bool
 	Scan(...interface{}) error
+	Err() error
 }
 
-type DBManager interface {
-	BeginTx() (*sql.Tx, error)
-	CommitTx(tx *sql.Tx) error
-	RollbackTx(tx *sql.Tx) error
+// Executor runs statements either directly on the database or inside a transaction
+type Executor interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	ExecWithContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	Query(query string, args ...interface{}) (DBRows, error)
 	QueryWithContext(ctx context.Context, query string, args ...interface{}) (DBRows, error)
 }
 
+type DBTx interface {
+	Executor
+	Commit() error
+	Rollback() error
+}
+
+// DBManager runs writes and transactions on the primary database. Reads outside a
+// transaction go to a read replica when replicas are configured.
+type DBManager interface {
+	Executor
+	BeginTx() (DBTx, error)
+	CommitTx(tx DBTx) error
+	RollbackTx(tx DBTx) error
+	// CacheStatements marks hot queries to be prepared on first use and reused afterwards,
+	// both on the database and inside transactions
+	CacheStatements(queries ...string)
+	// Primary returns an Executor which never reads from a replica, for reads which
+	// must see the writes made earlier in the same request
+	Primary() Executor
+	// Session returns a DBManager for the statements of one request. Its reads go to
+	// a replica until it runs a write or begins a transaction, from then on they stay
+	// on the primary so that the request reads its own writes.
+	Session() DBManager
+}
+
 type database struct {
-	db *sql.DB
+	primary  *node
+	replicas *replicaSet
 }
 
 func NewDB(db *sql.DB) DBManager {
-	return &database{db: db}
+	return &database{primary: newNode(db)}
 }
 
-func (d *database) BeginTx() (*sql.Tx, error) {
-	return d.db.Begin()
+func (d *database) BeginTx() (DBTx, error) {
+	tx, err := d.primary.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{tx: tx, stmts: d.primary.stmts}, nil
 }
 
-func (d *database) CommitTx(tx *sql.Tx) error {
+func (d *database) CommitTx(tx DBTx) error {
 	if tx == nil {
 		return errors.New("invalid transaction")
 	}
 	return tx.Commit()
 }
 
-func (d *database) RollbackTx(tx *sql.Tx) error {
+func (d *database) RollbackTx(tx DBTx) error {
 	if tx == nil {
 		return errors.New("invalid transaction")
 	}
 	return tx.Rollback()
 }
 
+func (d *database) CacheStatements(queries ...string) {
+	d.primary.stmts.add(queries...)
+	if d.replicas != nil {
+		for _, replica := range d.replicas.replicas {
+			replica.stmts.add(queries...)
+		}
+	}
+}
+
+func (d *database) Primary() Executor {
+	return d.primary
+}
+
 func (d *database) ExecWithContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return d.db.ExecContext(ctx, query, args...)
+	return d.primary.ExecWithContext(ctx, query, args...)
 }
 
 func (d *database) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return d.db.Exec(query, args...)
+	return d.primary.ExecWithContext(context.Background(), query, args...)
 }
 
 func (d *database) Query(query string, args ...interface{}) (DBRows, error) {
-	return d.db.Query(query, args...)
+	return d.QueryWithContext(context.Background(), query, args...)
 }
 
 func (d *database) QueryWithContext(ctx context.Context, query string, args ...interface{}) (DBRows, error) {
-	return d.db.QueryContext(ctx, query, args...)
+	if d.replicas != nil {
+		if rows, ok := d.replicas.query(ctx, query, args...); ok {
+			return rows, nil
+		}
+	}
+	return d.primary.QueryWithContext(ctx, query, args...)
+}
+
+// node is a single database server with its own statement cache
+type node struct {
+	db    *sql.DB
+	stmts *statementCache
+}
+
+func newNode(db *sql.DB) *node {
+	return &node{
+		db:    db,
+		stmts: &statementCache{db: db, stmts: make(map[string]*preparedQuery)},
+	}
+}
+
+func (n *node) ExecWithContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := n.stmts.get(query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt != nil {
+		return stmt.ExecContext(ctx, args...)
+	}
+	return n.db.ExecContext(ctx, query, args...)
+}
+
+func (n *node) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return n.ExecWithContext(context.Background(), query, args...)
+}
+
+func (n *node) Query(query string, args ...interface{}) (DBRows, error) {
+	return n.QueryWithContext(context.Background(), query, args...)
+}
+
+func (n *node) QueryWithContext(ctx context.Context, query string, args ...interface{}) (DBRows, error) {
+	stmt, err := n.stmts.get(query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt != nil {
+		return stmt.QueryContext(ctx, args...)
+	}
+	return n.db.QueryContext(ctx, query, args...)
+}
+
+type transaction struct {
+	tx    *sql.Tx
+	stmts *statementCache
+}
+
+func (t *transaction) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *transaction) Rollback() error {
+	return t.tx.Rollback()
+}
+
+func (t *transaction) ExecWithContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := t.stmts.get(query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt != nil {
+		return t.tx.StmtContext(ctx, stmt).ExecContext(ctx, args...)
+	}
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+func (t *transaction) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.ExecWithContext(context.Background(), query, args...)
+}
+
+func (t *transaction) Query(query string, args ...interface{}) (DBRows, error) {
+	return t.QueryWithContext(context.Background(), query, args...)
+}
+
+func (t *transaction) QueryWithContext(ctx context.Context, query string, args ...interface{}) (DBRows, error) {
+	stmt, err := t.stmts.get(query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt != nil {
+		return t.tx.StmtContext(ctx, stmt).QueryContext(ctx, args...)
+	}
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+// statementCache prepares registered queries on first use. Its lock only guards the
+// map of queries, each query is prepared under its own lock so that a slow prepare
+// does not hold back the other queries.
+type statementCache struct {
+	db    *sql.DB
+	mu    sync.RWMutex
+	stmts map[string]*preparedQuery
+}
+
+type preparedQuery struct {
+	mu sync.Mutex
+	// stmt holds the *sql.Stmt once the query is prepared
+	stmt atomic.Value
+}
+
+// add registers queries, they are prepared by the first get
+func (c *statementCache) add(queries ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, query := range queries {
+		if _, ok := c.stmts[query]; !ok {
+			c.stmts[query] = &preparedQuery{}
+		}
+	}
+}
+
+// get returns the prepared statement of a registered query, or nil for any other
+// query. A failed prepare is tried again by the next get.
+func (c *statementCache) get(query string) (*sql.Stmt, error) {
+	c.mu.RLock()
+	prepared, ok := c.stmts[query]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	if stmt, ok := prepared.stmt.Load().(*sql.Stmt); ok {
+		return stmt, nil
+	}
+
+	prepared.mu.Lock()
+	defer prepared.mu.Unlock()
+	if stmt, ok := prepared.stmt.Load().(*sql.Stmt); ok {
+		return stmt, nil
+	}
+	stmt, err := c.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	prepared.stmt.Store(stmt)
+	return stmt, nil
 }
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CacheStatements(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	db := NewDB(sqlDB)
+
+	cached := "SELECT id FROM wagers WHERE id=?"
+	db.CacheStatements(cached)
+
+	prepared := mock.ExpectPrepare(cached)
+	prepared.ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	prepared.ExpectQuery().WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	for _, id := range []int{1, 2} {
+		rows, err := db.Query(cached, id)
+		assert.NoError(t, err)
+		rows.Close()
+	}
+
+	rows, err := db.Query("SELECT 1")
+	assert.NoError(t, err)
+	rows.Close()
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_CacheStatements_PrepareError(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	db := NewDB(sqlDB)
+
+	cached := "SELECT id FROM wagers WHERE id=?"
+	db.CacheStatements(cached)
+
+	mock.ExpectPrepare(cached).WillReturnError(errors.New("connection reset"))
+	mock.ExpectPrepare(cached).ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	_, err = db.Query(cached, 1)
+	assert.Error(t, err)
+
+	rows, err := db.Query(cached, 1)
+	assert.NoError(t, err)
+	rows.Close()
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
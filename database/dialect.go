@@ -0,0 +1,156 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"wager/conf"
+)
+
+// Dialect hides the differences between the supported SQL databases. Queries are
+// written with MySQL style ? placeholders and rebound before they are executed.
+type Dialect interface {
+	Name() string
+	DriverName() string
+	DataSourceName(config conf.SQLConfig) string
+	Rebind(query string) string
+	// QuoteIdentifier quotes a table or schema name which has been validated with conf.SQLConfig.Validate
+	QuoteIdentifier(name string) string
+	// LockClause is appended to a SELECT to lock the selected rows until the transaction ends
+	LockClause() string
+	// InsertReturningID tells whether generated ids must be read with RETURNING instead of LastInsertId
+	InsertReturningID() bool
+	// InsertIgnore turns an INSERT into one which skips the rows whose key is taken
+	InsertIgnore(insert string) string
+}
+
+func GetDialect(name string) (Dialect, error) {
+	switch name {
+	case conf.DIALECT_MYSQL:
+		return mysqlDialect{}, nil
+	case conf.DIALECT_POSTGRES:
+		return postgresDialect{}, nil
+	case conf.DIALECT_SQLITE:
+		return sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported sql dialect %q", name)
+	}
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return conf.DIALECT_MYSQL
+}
+
+func (mysqlDialect) DriverName() string {
+	return "mysql"
+}
+
+func (mysqlDialect) DataSourceName(config conf.SQLConfig) string {
+	return fmt.Sprintf("%v:%v@%v", config.Username, config.Password, config.DatabaseAddress)
+}
+
+func (mysqlDialect) Rebind(query string) string {
+	return query
+}
+
+func (mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + name + "`"
+}
+
+func (mysqlDialect) LockClause() string {
+	return " FOR UPDATE"
+}
+
+func (mysqlDialect) InsertReturningID() bool {
+	return false
+}
+
+func (mysqlDialect) InsertIgnore(insert string) string {
+	return strings.Replace(insert, "INSERT INTO", "INSERT IGNORE INTO", 1)
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return conf.DIALECT_POSTGRES
+}
+
+func (postgresDialect) DriverName() string {
+	return "postgres"
+}
+
+// DataSourceName expects DatabaseAddress in the form host:port/dbname[?options]
+func (postgresDialect) DataSourceName(config conf.SQLConfig) string {
+	return fmt.Sprintf("postgres://%v@%v", url.UserPassword(config.Username, config.Password), config.DatabaseAddress)
+}
+
+// Rebind replaces ? placeholders with $1, $2, ...
+func (postgresDialect) Rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c != '?' {
+			b.WriteRune(c)
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+func (postgresDialect) QuoteIdentifier(name string) string {
+	return `"` + name + `"`
+}
+
+func (postgresDialect) LockClause() string {
+	return " FOR UPDATE"
+}
+
+func (postgresDialect) InsertReturningID() bool {
+	return true
+}
+
+func (postgresDialect) InsertIgnore(insert string) string {
+	return insert + " ON CONFLICT DO NOTHING"
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return conf.DIALECT_SQLITE
+}
+
+func (sqliteDialect) DriverName() string {
+	return "sqlite3"
+}
+
+// DataSourceName expects DatabaseAddress to be a file path. Transactions take the
+// write lock when they begin, which is what LockClause does on the other dialects.
+func (sqliteDialect) DataSourceName(config conf.SQLConfig) string {
+	return fmt.Sprintf("file:%v?_txlock=immediate&_foreign_keys=on&_busy_timeout=5000", config.DatabaseAddress)
+}
+
+func (sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) QuoteIdentifier(name string) string {
+	return `"` + name + `"`
+}
+
+func (sqliteDialect) LockClause() string {
+	return ""
+}
+
+func (sqliteDialect) InsertReturningID() bool {
+	return true
+}
+
+func (sqliteDialect) InsertIgnore(insert string) string {
+	return insert + " ON CONFLICT DO NOTHING"
+}
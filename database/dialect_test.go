@@ -0,0 +1,49 @@
+package database
+
+import (
+	"testing"
+	"wager/conf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetDialect(t *testing.T) {
+	for _, name := range []string{conf.DIALECT_MYSQL, conf.DIALECT_POSTGRES, conf.DIALECT_SQLITE} {
+		dialect, err := GetDialect(name)
+		assert.NoError(t, err)
+		assert.Equal(t, name, dialect.Name())
+	}
+
+	_, err := GetDialect("oracle")
+	assert.Error(t, err)
+}
+
+func Test_Dialect_Rebind(t *testing.T) {
+	query := "UPDATE wagers SET current_selling_price=?, amount_sold=? WHERE id=?"
+
+	mysql, _ := GetDialect(conf.DIALECT_MYSQL)
+	assert.Equal(t, query, mysql.Rebind(query))
+
+	postgres, _ := GetDialect(conf.DIALECT_POSTGRES)
+	assert.Equal(t, "UPDATE wagers SET current_selling_price=$1, amount_sold=$2 WHERE id=$3", postgres.Rebind(query))
+}
+
+func Test_Dialect_InsertIgnore(t *testing.T) {
+	insert := "INSERT INTO buyer (name) VALUES (?)"
+
+	mysql, _ := GetDialect(conf.DIALECT_MYSQL)
+	assert.Equal(t, "INSERT IGNORE INTO buyer (name) VALUES (?)", mysql.InsertIgnore(insert))
+
+	postgres, _ := GetDialect(conf.DIALECT_POSTGRES)
+	assert.Equal(t, "INSERT INTO buyer (name) VALUES (?) ON CONFLICT DO NOTHING", postgres.InsertIgnore(insert))
+}
+
+func Test_Dialect_DataSourceName(t *testing.T) {
+	config := conf.SQLConfig{Username: "user", Password: "p@ss", DatabaseAddress: "tcp(db:3306)/demo"}
+	mysql, _ := GetDialect(conf.DIALECT_MYSQL)
+	assert.Equal(t, "user:p@ss@tcp(db:3306)/demo", mysql.DataSourceName(config))
+
+	config.DatabaseAddress = "db:5432/demo?sslmode=disable"
+	postgres, _ := GetDialect(conf.DIALECT_POSTGRES)
+	assert.Equal(t, "postgres://user:p%40ss@db:5432/demo?sslmode=disable", postgres.DataSourceName(config))
+}
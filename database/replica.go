@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewDBWithReplicas creates a DBManager which spreads reads outside transactions over
+// the replicas in round robin. A replica which fails a query and a ping afterwards is
+// skipped for retryInterval, reads fall back to the primary when no replica is healthy.
+func NewDBWithReplicas(primary *sql.DB, replicas []*sql.DB, retryInterval time.Duration) DBManager {
+	d := &database{primary: newNode(primary)}
+	if len(replicas) == 0 {
+		return d
+	}
+
+	d.replicas = &replicaSet{retryInterval: retryInterval, now: time.Now}
+	for _, replica := range replicas {
+		d.replicas.replicas = append(d.replicas.replicas, &replicaNode{node: newNode(replica)})
+	}
+	return d
+}
+
+type replicaSet struct {
+	replicas      []*replicaNode
+	next          uint32
+	retryInterval time.Duration
+	now           func() time.Time
+}
+
+type replicaNode struct {
+	*node
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+// query tries the healthy replicas in turn, it reports false when none of them answered
+func (r *replicaSet) query(ctx context.Context, query string, args ...interface{}) (DBRows, bool) {
+	start := int(atomic.AddUint32(&r.next, 1))
+	for i := 0; i < len(r.replicas); i++ {
+		replica := r.replicas[(start+i)%len(r.replicas)]
+		if !replica.isHealthy(r.now()) {
+			continue
+		}
+
+		rows, err := replica.QueryWithContext(ctx, query, args...)
+		if err == nil {
+			return rows, true
+		}
+
+		// a failing query is retried elsewhere, the replica is only taken out when it is unreachable
+		if pingErr := replica.db.PingContext(ctx); pingErr != nil {
+			logrus.WithError(pingErr).Warn("Read replica is down")
+			replica.markDown(r.now().Add(r.retryInterval))
+		}
+	}
+
+	return nil, false
+}
+
+func (n *replicaNode) isHealthy(now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return !now.Before(n.downUntil)
+}
+
+func (n *replicaNode) markDown(until time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.downUntil = until
+}
+
+// session is a DBManager whose reads stick to the primary once it wrote
+type session struct {
+	*database
+	wrote int32
+}
+
+func (d *database) Session() DBManager {
+	return &session{database: d}
+}
+
+// markWritten is called before the write runs, a write which failed may still have
+// reached the primary
+func (s *session) markWritten() {
+	atomic.StoreInt32(&s.wrote, 1)
+}
+
+func (s *session) BeginTx() (DBTx, error) {
+	s.markWritten()
+	return s.database.BeginTx()
+}
+
+func (s *session) ExecWithContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	s.markWritten()
+	return s.database.ExecWithContext(ctx, query, args...)
+}
+
+func (s *session) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return s.ExecWithContext(context.Background(), query, args...)
+}
+
+func (s *session) Query(query string, args ...interface{}) (DBRows, error) {
+	return s.QueryWithContext(context.Background(), query, args...)
+}
+
+func (s *session) QueryWithContext(ctx context.Context, query string, args ...interface{}) (DBRows, error) {
+	if atomic.LoadInt32(&s.wrote) == 1 {
+		return s.primary.QueryWithContext(ctx, query, args...)
+	}
+	return s.database.QueryWithContext(ctx, query, args...)
+}
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const listQuery = "SELECT id FROM wagers LIMIT ? OFFSET ?"
+
+type fakeCluster struct {
+	db       *database
+	primary  sqlmock.Sqlmock
+	replicas []sqlmock.Sqlmock
+	now      time.Time
+}
+
+func newFakeCluster(t *testing.T, replicaCount int) *fakeCluster {
+	newMock := func() (*sql.DB, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual), sqlmock.MonitorPingsOption(true))
+		require.NoError(t, err)
+		return db, mock
+	}
+
+	cluster := &fakeCluster{now: time.Unix(1642484487, 0)}
+	primaryDB, primary := newMock()
+	cluster.primary = primary
+
+	replicaDBs := []*sql.DB{}
+	for i := 0; i < replicaCount; i++ {
+		replicaDB, replica := newMock()
+		replicaDBs = append(replicaDBs, replicaDB)
+		cluster.replicas = append(cluster.replicas, replica)
+	}
+
+	cluster.db = NewDBWithReplicas(primaryDB, replicaDBs, time.Minute).(*database)
+	cluster.db.replicas.now = func() time.Time { return cluster.now }
+	return cluster
+}
+
+func expectList(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(listQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+}
+
+func (c *fakeCluster) list(t *testing.T) {
+	rows, err := c.db.Query(listQuery, 10, 0)
+	require.NoError(t, err)
+	rows.Close()
+}
+
+func (c *fakeCluster) expectationsWereMet(t *testing.T) {
+	assert.NoError(t, c.primary.ExpectationsWereMet())
+	for _, replica := range c.replicas {
+		assert.NoError(t, replica.ExpectationsWereMet())
+	}
+}
+
+func Test_Replicas_RoundRobin(t *testing.T) {
+	cluster := newFakeCluster(t, 2)
+	for _, replica := range cluster.replicas {
+		expectList(replica)
+		expectList(replica)
+	}
+
+	for i := 0; i < 4; i++ {
+		cluster.list(t)
+	}
+	cluster.expectationsWereMet(t)
+}
+
+func Test_Replicas_WritesAndTransactionsUsePrimary(t *testing.T) {
+	cluster := newFakeCluster(t, 1)
+	cluster.primary.ExpectExec("UPDATE wagers SET current_selling_price=? WHERE id=?").WillReturnResult(sqlmock.NewResult(0, 1))
+	cluster.primary.ExpectBegin()
+	expectList(cluster.primary)
+	cluster.primary.ExpectCommit()
+	expectList(cluster.primary)
+
+	_, err := cluster.db.Exec("UPDATE wagers SET current_selling_price=? WHERE id=?", 10, 1)
+	require.NoError(t, err)
+
+	err = RunInTx(cluster.db, RetryPolicy{}, func(tx DBTx) error {
+		rows, err := tx.Query(listQuery, 10, 0)
+		if err != nil {
+			return err
+		}
+		return rows.Close()
+	})
+	require.NoError(t, err)
+
+	// read after write in the same request
+	rows, err := cluster.db.Primary().Query(listQuery, 10, 0)
+	require.NoError(t, err)
+	rows.Close()
+
+	cluster.expectationsWereMet(t)
+}
+
+func Test_Replicas_Failover(t *testing.T) {
+	cluster := newFakeCluster(t, 2)
+	down, up := cluster.replicas[1], cluster.replicas[0]
+
+	// the first read goes to the second replica, which is unreachable
+	down.ExpectQuery(listQuery).WillReturnError(errors.New("connection refused"))
+	down.ExpectPing().WillReturnError(errors.New("connection refused"))
+	expectList(up)
+	cluster.list(t)
+
+	// while it is down every read goes to the other replica
+	expectList(up)
+	expectList(up)
+	cluster.list(t)
+	cluster.list(t)
+	cluster.expectationsWereMet(t)
+
+	// with no healthy replica reads go to the primary
+	up.ExpectQuery(listQuery).WillReturnError(errors.New("connection refused"))
+	up.ExpectPing().WillReturnError(errors.New("connection refused"))
+	expectList(cluster.primary)
+	expectList(cluster.primary)
+	cluster.list(t)
+	cluster.list(t)
+	cluster.expectationsWereMet(t)
+
+	// replicas are tried again after the retry interval
+	cluster.now = cluster.now.Add(time.Minute)
+	expectList(up)
+	expectList(down)
+	cluster.list(t)
+	cluster.list(t)
+	cluster.expectationsWereMet(t)
+}
+
+func Test_Replicas_QueryErrorOnHealthyReplica(t *testing.T) {
+	cluster := newFakeCluster(t, 1)
+	replica := cluster.replicas[0]
+
+	// the query is retried on the primary, the reachable replica stays in rotation
+	replica.ExpectQuery(listQuery).WillReturnError(errors.New("table is being rebuilt"))
+	replica.ExpectPing()
+	expectList(cluster.primary)
+	expectList(replica)
+
+	cluster.list(t)
+	cluster.list(t)
+	cluster.expectationsWereMet(t)
+}
+
+func Test_Replicas_SessionReadsItsWrites(t *testing.T) {
+	cluster := newFakeCluster(t, 1)
+	replica := cluster.replicas[0]
+	update := "UPDATE wagers SET current_selling_price=? WHERE id=?"
+
+	// a session reads from the replica until it writes
+	expectList(replica)
+	cluster.primary.ExpectExec(update).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectList(cluster.primary)
+	// other requests keep reading from the replica
+	expectList(replica)
+
+	session := cluster.db.Session()
+	rows, err := session.Query(listQuery, 10, 0)
+	require.NoError(t, err)
+	rows.Close()
+	_, err = session.Exec(update, 10, 1)
+	require.NoError(t, err)
+	rows, err = session.Query(listQuery, 10, 0)
+	require.NoError(t, err)
+	rows.Close()
+	cluster.list(t)
+	cluster.expectationsWereMet(t)
+
+	// a transaction sticks the session to the primary too
+	cluster.primary.ExpectBegin()
+	cluster.primary.ExpectExec(update).WillReturnResult(sqlmock.NewResult(0, 1))
+	cluster.primary.ExpectCommit()
+	expectList(cluster.primary)
+
+	session = cluster.db.Session()
+	err = RunInTx(session, RetryPolicy{}, func(tx DBTx) error {
+		_, err := tx.Exec(update, 10, 1)
+		return err
+	})
+	require.NoError(t, err)
+	rows, err = session.Query(listQuery, 10, 0)
+	require.NoError(t, err)
+	rows.Close()
+	cluster.expectationsWereMet(t)
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"errors"
+	"expvar"
+	"math/rand"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	RETRY_DEADLOCK              = "deadlock"
+	RETRY_LOCK_WAIT_TIMEOUT     = "lock_wait_timeout"
+	RETRY_SERIALIZATION_FAILURE = "serialization_failure"
+	RETRY_DATABASE_BUSY         = "database_busy"
+)
+
+// TxRetries counts retried transactions by reason, published at /debug/vars
+var TxRetries = expvar.NewMap("db_tx_retries")
+
+type RetryPolicy struct {
+	// MaxRetries is the number of times a transaction is retried after its first attempt
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// RunInTx runs fn inside a transaction which is committed when fn returns nil and
+// rolled back otherwise. Transactions failing with a retryable error, such as a
+// deadlock, are run again with jittered exponential backoff.
+func RunInTx(db DBManager, policy RetryPolicy, fn func(tx DBTx) error) error {
+	for attempt := 0; ; attempt++ {
+		err := runInTx(db, fn)
+		if err == nil {
+			return nil
+		}
+
+		reason, ok := RetryReason(err)
+		if !ok || attempt >= policy.MaxRetries {
+			return err
+		}
+
+		delay := policy.backoff(attempt)
+		TxRetries.Add(reason, 1)
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"reason":  reason,
+			"attempt": attempt + 1,
+			"delay":   delay,
+		}).Warn("Retrying transaction")
+		time.Sleep(delay)
+	}
+}
+
+func runInTx(db DBManager, fn func(tx DBTx) error) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		logrus.WithError(err).Error("cannot begin transaction")
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		db.RollbackTx(tx)
+		return err
+	}
+
+	if err := db.CommitTx(tx); err != nil {
+		db.RollbackTx(tx)
+		return err
+	}
+
+	return nil
+}
+
+// backoff picks a random delay up to BaseDelay * 2^attempt, capped at MaxDelay
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay << uint(attempt)
+	if ceiling <= 0 || (p.MaxDelay > 0 && ceiling > p.MaxDelay) {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// RetryReason tells whether err is a driver error after which the whole transaction
+// can safely run again
+func RetryReason(err error) (string, bool) {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1213:
+			return RETRY_DEADLOCK, true
+		case 1205:
+			return RETRY_LOCK_WAIT_TIMEOUT, true
+		}
+		return "", false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40P01":
+			return RETRY_DEADLOCK, true
+		case "40001":
+			return RETRY_SERIALIZATION_FAILURE, true
+		}
+		return "", false
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		if sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked {
+			return RETRY_DATABASE_BUSY, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var testRetryPolicy = RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func newTxMock(t *testing.T) (DBManager, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	return NewDB(sqlDB), mock
+}
+
+func retryCount(reason string) int64 {
+	if count, ok := TxRetries.Get(reason).(*expvar.Int); ok {
+		return count.Value()
+	}
+	return 0
+}
+
+func updateWager(tx DBTx) error {
+	_, err := tx.Exec("UPDATE wagers SET current_selling_price=? WHERE id=?", 10, 1)
+	if err != nil {
+		return fmt.Errorf("failed to update wager: %w", err)
+	}
+	return nil
+}
+
+func Test_RunInTx_RetriesRetryableErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{name: "MySQL deadlock", err: &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}, reason: RETRY_DEADLOCK},
+		{name: "MySQL lock wait timeout", err: &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, reason: RETRY_LOCK_WAIT_TIMEOUT},
+		{name: "PostgreSQL deadlock", err: &pq.Error{Code: "40P01"}, reason: RETRY_DEADLOCK},
+		{name: "PostgreSQL serialization failure", err: &pq.Error{Code: "40001"}, reason: RETRY_SERIALIZATION_FAILURE},
+	}
+
+	for _, testcase := range testCases {
+		t.Run(testcase.name, func(t *testing.T) {
+			db, mock := newTxMock(t)
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE wagers").WillReturnError(testcase.err)
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE wagers").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			before := retryCount(testcase.reason)
+			err := RunInTx(db, testRetryPolicy, updateWager)
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+			assert.Equal(t, before+1, retryCount(testcase.reason))
+		})
+	}
+}
+
+func Test_RunInTx_GivesUpAfterMaxRetries(t *testing.T) {
+	db, mock := newTxMock(t)
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+	for i := 0; i <= testRetryPolicy.MaxRetries; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE wagers").WillReturnError(deadlock)
+		mock.ExpectRollback()
+	}
+
+	err := RunInTx(db, testRetryPolicy, updateWager)
+	assert.True(t, errors.Is(err, deadlock))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_RunInTx_DoesNotRetryOtherErrors(t *testing.T) {
+	db, mock := newTxMock(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE wagers").WillReturnError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"})
+	mock.ExpectRollback()
+
+	err := RunInTx(db, testRetryPolicy, updateWager)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = RunInTx(db, testRetryPolicy, func(tx DBTx) error {
+		return errors.New("buying price must be equal or smaller than current selling price")
+	})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_RetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := policy.backoff(attempt)
+		assert.True(t, delay > 0)
+		assert.True(t, delay <= 50*time.Millisecond)
+		if attempt == 0 {
+			assert.True(t, delay <= 10*time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package errorcode
+
+// ClientError is implemented by the errors of requests which the service refused,
+// like a price above the current one, as opposed to its failures. Code is the code
+// of the error, empty for most of them.
+type ClientError interface {
+	error
+	Code() string
+}
+
+// NewClientError returns a ClientError without code, for sentinel errors
+func NewClientError(msg string) error {
+	return &clientError{msg: msg}
+}
+
+type clientError struct {
+	msg string
+}
+
+func (e *clientError) Error() string {
+	return e.msg
+}
+
+func (e *clientError) Code() string {
+	return ""
+}
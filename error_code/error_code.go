@@ -1,5 +1,14 @@
 package errorcode
 
+const (
+	// BUYER_LIMIT_EXCEEDED is the code of purchases refused by a buyer limit
+	BUYER_LIMIT_EXCEEDED = "buyer_limit_exceeded"
+	// RATE_LIMITED is the code of socket messages sent faster than allowed
+	RATE_LIMITED = "rate_limited"
+)
+
 type ErrorResponse struct {
 	Error interface{} `json:"error"`
+	// Code names the error for clients, it is only set for some errors
+	Code string `json:"code,omitempty"`
 }
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/validator"
+)
+
+func (h *Handler) HandleGetStats(w http.ResponseWriter, r *http.Request) {
+	req := model.GetStatsRequest{}
+	query := r.URL.Query()
+	if from, ok := query["from"]; ok {
+		num, err := strconv.ParseInt(from[0], 10, 64)
+		if err != nil {
+			h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse from"}, http.StatusBadRequest)
+			return
+		}
+		req.From = num
+	}
+
+	if to, ok := query["to"]; ok {
+		num, err := strconv.ParseInt(to[0], 10, 64)
+		if err != nil {
+			h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse to"}, http.StatusBadRequest)
+			return
+		}
+		req.To = num
+	}
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.statsService.GetStats(req)
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, stats, http.StatusOK)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	errorcode "wager/error_code"
+	"wager/model"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleGetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleGetStats)
+
+	tests := []struct {
+		name  string
+		query string
+		err   interface{}
+	}{
+		{name: "Invalid from", query: "from=yesterday", err: "failed to parse from"},
+		{name: "Invalid to", query: "to=1.5", err: "failed to parse to"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/admin/stats?"+test.query, nil)
+			assert.NoError(t, err)
+			mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: test.err}, http.StatusBadRequest)
+			httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+		})
+	}
+
+	t.Run("Service error", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/admin/stats?from=200&to=100", nil)
+		assert.NoError(t, err)
+		mockHandler.mockStatsService.EXPECT().GetStats(model.GetStatsRequest{From: 200, To: 100}).Return(nil, errors.New("to must not be before from"))
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "to must not be before from"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/admin/stats?from=100&to=200", nil)
+		assert.NoError(t, err)
+		stats := &model.Stats{From: 100, To: 200, FeeRevenue: []model.FeeRevenue{{Currency: "USD", Purchases: 2, Volume: 30, MakerFees: 0.3, TakerFees: 0.6, Total: 0.9}}}
+		mockHandler.mockStatsService.EXPECT().GetStats(model.GetStatsRequest{From: 100, To: 200}).Return(stats, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), stats, http.StatusOK)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
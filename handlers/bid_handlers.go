@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+	"wager/service"
+	"wager/validator"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) HandlePlaceBid(w http.ResponseWriter, r *http.Request) {
+	req := model.PlaceBidRequest{}
+	wagerId, ok := h.parseWagerRequest(w, r, &req)
+	if !ok {
+		return
+	}
+	req.WagerID = wagerId
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	bid, err := h.bidService.PlaceBid(req)
+	if err != nil {
+		h.replyBidError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, bid, http.StatusCreated)
+}
+
+func (h *Handler) HandleGetBook(w http.ResponseWriter, r *http.Request) {
+	wagerId, err := strconv.Atoi(mux.Vars(r)["wager_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		return
+	}
+
+	book, err := h.bidService.GetBook(uint(wagerId))
+	if err != nil {
+		h.replyBidError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, book, http.StatusOK)
+}
+
+func (h *Handler) HandleAcceptBid(w http.ResponseWriter, r *http.Request) {
+	bidId, ok := h.parseBidID(w, r)
+	if !ok {
+		return
+	}
+
+	req := model.AcceptBidRequest{}
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+	req.BidID = bidId
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	purchase, err := h.bidService.AcceptBid(req)
+	if err != nil {
+		h.replyBidError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, purchase, http.StatusCreated)
+}
+
+func (h *Handler) HandleCancelBid(w http.ResponseWriter, r *http.Request) {
+	bidId, ok := h.parseBidID(w, r)
+	if !ok {
+		return
+	}
+
+	req := model.CancelBidRequest{}
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+	req.BidID = bidId
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	bid, err := h.bidService.CancelBid(req)
+	if err != nil {
+		h.replyBidError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, bid, http.StatusOK)
+}
+
+func (h *Handler) HandleLowerPrice(w http.ResponseWriter, r *http.Request) {
+	req := model.LowerPriceRequest{}
+	wagerId, ok := h.parseWagerRequest(w, r, &req)
+	if !ok {
+		return
+	}
+	req.WagerID = wagerId
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.bidService.LowerPrice(req)
+	if err != nil {
+		h.replyBidError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, response, http.StatusOK)
+}
+
+// parseWagerRequest reads the wager id of the path and decodes the body into req
+func (h *Handler) parseWagerRequest(w http.ResponseWriter, r *http.Request, req interface{}) (uint, bool) {
+	wagerId, err := strconv.Atoi(mux.Vars(r)["wager_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		return 0, false
+	}
+
+	if !h.readJSON(w, r, req) {
+		return 0, false
+	}
+	return uint(wagerId), true
+}
+
+func (h *Handler) parseBidID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	bidId, err := strconv.Atoi(mux.Vars(r)["bid_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse bid id"}, http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(bidId), true
+}
+
+func (h *Handler) replyBidError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusNotFound)
+	case errors.Is(err, service.ErrBuyerLimitExceeded):
+		h.replyBuyerLimitError(w, err)
+	case errors.Is(err, service.ErrNotSeller), errors.Is(err, service.ErrNotBidder):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusForbidden)
+	case errors.Is(err, service.ErrBidNotOpen), errors.Is(err, service.ErrBidExpired), errors.Is(err, service.ErrBidNotBelowPrice), errors.Is(err, service.ErrBidTooLarge), errors.Is(err, service.ErrAskPriceNotLower),
+		errors.Is(err, service.ErrWagerNotOpen), errors.Is(err, service.ErrInvalidPurchaseSize), errors.Is(err, service.ErrTTLTooLong), errors.Is(err, service.ErrBuyerRequired),
+		errors.Is(err, service.ErrCurrencyMismatch), errors.Is(err, service.ErrInvalidAmount):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+	default:
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+	"wager/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandlePlaceBid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandlePlaceBid)
+
+	newRequest := func(wagerId string, body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, "/wagers/"+wagerId+"/bids", bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"wager_id": wagerId})
+	}
+
+	t.Run("Invalid wager id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("a", map[string]float64{"face_value": 10, "bid_price": 5}))
+	})
+
+	t.Run("Invalid bid price", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: []string{"BidPrice must be larger than 0"}}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", map[string]float64{"face_value": 10}))
+	})
+
+	t.Run("Not below the price", func(t *testing.T) {
+		req := model.PlaceBidRequest{WagerID: 1, FaceValue: 10, BidPrice: 10}
+		mockHandler.mockBidService.EXPECT().PlaceBid(req).Return(nil, service.ErrBidNotBelowPrice)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: service.ErrBidNotBelowPrice.Error()}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", map[string]float64{"face_value": 10, "bid_price": 10}))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req := model.PlaceBidRequest{WagerID: 1, Buyer: "alice", FaceValue: 10, BidPrice: 8, TTLSeconds: 60}
+		bid := &model.Bid{ID: 1, WagerID: 1, Buyer: "alice", FaceValue: 10, BidPrice: 8, Status: model.BID_STATUS_OPEN}
+		mockHandler.mockBidService.EXPECT().PlaceBid(req).Return(bid, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), bid, http.StatusCreated)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", map[string]interface{}{"buyer": "alice", "face_value": 10, "bid_price": 8, "ttl_seconds": 60}))
+	})
+}
+
+func Test_HandleBidActions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+
+	newRequest := func(bidId string, body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, "/bids/"+bidId, bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"bid_id": bidId})
+	}
+
+	t.Run("Invalid bid id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse bid id"}, http.StatusBadRequest)
+		http.HandlerFunc(handler.HandleAcceptBid).ServeHTTP(httptest.NewRecorder(), newRequest("a", map[string]string{"seller": "sam"}))
+	})
+
+	t.Run("Accept", func(t *testing.T) {
+		purchase := &model.Purchase{PurchaseID: 3, WagerID: 1, BuyingPrice: 8, FaceValue: 10}
+		mockHandler.mockBidService.EXPECT().AcceptBid(model.AcceptBidRequest{BidID: 1, Seller: "sam"}).Return(purchase, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), purchase, http.StatusCreated)
+		http.HandlerFunc(handler.HandleAcceptBid).ServeHTTP(httptest.NewRecorder(), newRequest("1", map[string]string{"seller": "sam"}))
+	})
+
+	t.Run("Accept over buyer limits", func(t *testing.T) {
+		limitErr := &service.BuyerLimitError{Limit: service.LIMIT_PURCHASES_PER_WAGER, Value: 2, Max: 1}
+		mockHandler.mockBidService.EXPECT().AcceptBid(model.AcceptBidRequest{BidID: 2, Seller: "sam"}).Return(nil, limitErr)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: limitErr.Error(), Code: errorcode.BUYER_LIMIT_EXCEEDED}, http.StatusForbidden)
+		http.HandlerFunc(handler.HandleAcceptBid).ServeHTTP(httptest.NewRecorder(), newRequest("2", map[string]string{"seller": "sam"}))
+	})
+
+	t.Run("Accept by another seller", func(t *testing.T) {
+		mockHandler.mockBidService.EXPECT().AcceptBid(model.AcceptBidRequest{BidID: 2, Seller: "bob"}).Return(nil, service.ErrNotSeller)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: service.ErrNotSeller.Error()}, http.StatusForbidden)
+		http.HandlerFunc(handler.HandleAcceptBid).ServeHTTP(httptest.NewRecorder(), newRequest("2", map[string]string{"seller": "bob"}))
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		bid := &model.Bid{ID: 1, Status: model.BID_STATUS_CANCELLED}
+		mockHandler.mockBidService.EXPECT().CancelBid(model.CancelBidRequest{BidID: 1, Buyer: "bob"}).Return(bid, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), bid, http.StatusOK)
+		http.HandlerFunc(handler.HandleCancelBid).ServeHTTP(httptest.NewRecorder(), newRequest("1", map[string]string{"buyer": "bob"}))
+	})
+
+	t.Run("Cancel errors", func(t *testing.T) {
+		tests := []struct {
+			err    error
+			status int
+		}{
+			{err: repository.ErrNotFound, status: http.StatusNotFound},
+			{err: service.ErrNotBidder, status: http.StatusForbidden},
+			{err: service.ErrBidNotOpen, status: http.StatusBadRequest},
+			{err: errors.New("custom error"), status: http.StatusInternalServerError},
+		}
+		for _, test := range tests {
+			mockHandler.mockBidService.EXPECT().CancelBid(model.CancelBidRequest{BidID: 5, Buyer: "bob"}).Return(nil, test.err)
+			mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: test.err.Error()}, test.status)
+			http.HandlerFunc(handler.HandleCancelBid).ServeHTTP(httptest.NewRecorder(), newRequest("5", map[string]string{"buyer": "bob"}))
+		}
+	})
+}
+
+func Test_HandleLowerPrice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleLowerPrice)
+
+	newRequest := func(body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPut, "/wagers/1/price", bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"wager_id": "1"})
+	}
+
+	t.Run("Not lower", func(t *testing.T) {
+		req := model.LowerPriceRequest{WagerID: 1, AskPrice: 120}
+		mockHandler.mockBidService.EXPECT().LowerPrice(req).Return(nil, service.ErrAskPriceNotLower)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: service.ErrAskPriceNotLower.Error()}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(map[string]float64{"ask_price": 120}))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req := model.LowerPriceRequest{WagerID: 1, Seller: "sam", AskPrice: 80}
+		response := &model.LowerPriceResponse{Wager: model.Wager{ID: 1, AskPrice: 80}, Purchases: []model.Purchase{{PurchaseID: 1}}}
+		mockHandler.mockBidService.EXPECT().LowerPrice(req).Return(response, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), response, http.StatusOK)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(map[string]interface{}{"seller": "sam", "ask_price": 80}))
+	})
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+	"wager/service"
+	"wager/validator"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) HandleCreateEvent(w http.ResponseWriter, r *http.Request) {
+	req := model.CreateEventRequest{}
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.eventService.CreateEvent(req)
+	if err != nil {
+		h.replyEventError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, event, http.StatusCreated)
+}
+
+func (h *Handler) HandleGetEvents(w http.ResponseWriter, r *http.Request) {
+	reqPage, reqLimit, ok := h.parsePaging(w, r)
+	if !ok {
+		return
+	}
+
+	filter := model.EventFilter{Status: r.URL.Query().Get("status")}
+	req := model.GetEventListRequest{Filter: filter, Page: reqPage, Limit: reqLimit}
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.eventService.GetEventList(req)
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, events, http.StatusOK)
+}
+
+func (h *Handler) HandleGetEvent(w http.ResponseWriter, r *http.Request) {
+	eventId, ok := h.parseEventID(w, r)
+	if !ok {
+		return
+	}
+
+	event, err := h.eventService.GetEvent(eventId)
+	if err != nil {
+		h.replyEventError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, event, http.StatusOK)
+}
+
+func (h *Handler) HandleUpdateEvent(w http.ResponseWriter, r *http.Request) {
+	eventId, ok := h.parseEventID(w, r)
+	if !ok {
+		return
+	}
+
+	req := model.UpdateEventRequest{}
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+	req.ID = eventId
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.eventService.UpdateEvent(req)
+	if err != nil {
+		h.replyEventError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, response, http.StatusOK)
+}
+
+func (h *Handler) HandleDeleteEvent(w http.ResponseWriter, r *http.Request) {
+	eventId, ok := h.parseEventID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.eventService.DeleteEvent(eventId); err != nil {
+		h.replyEventError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) HandleCreateMarket(w http.ResponseWriter, r *http.Request) {
+	eventId, ok := h.parseEventID(w, r)
+	if !ok {
+		return
+	}
+
+	req := model.CreateMarketRequest{}
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+	req.EventID = eventId
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	market, err := h.eventService.CreateMarket(req)
+	if err != nil {
+		h.replyEventError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, market, http.StatusCreated)
+}
+
+func (h *Handler) HandleGetMarkets(w http.ResponseWriter, r *http.Request) {
+	eventId, ok := h.parseEventID(w, r)
+	if !ok {
+		return
+	}
+
+	markets, err := h.eventService.GetMarkets(eventId)
+	if err != nil {
+		h.replyEventError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, markets, http.StatusOK)
+}
+
+func (h *Handler) HandleGetMarket(w http.ResponseWriter, r *http.Request) {
+	marketId, ok := h.parseMarketID(w, r)
+	if !ok {
+		return
+	}
+
+	market, err := h.eventService.GetMarket(marketId)
+	if err != nil {
+		h.replyEventError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, market, http.StatusOK)
+}
+
+func (h *Handler) HandleUpdateMarket(w http.ResponseWriter, r *http.Request) {
+	marketId, ok := h.parseMarketID(w, r)
+	if !ok {
+		return
+	}
+
+	req := model.UpdateMarketRequest{}
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+	req.ID = marketId
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	market, err := h.eventService.UpdateMarket(req)
+	if err != nil {
+		h.replyEventError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, market, http.StatusOK)
+}
+
+func (h *Handler) HandleDeleteMarket(w http.ResponseWriter, r *http.Request) {
+	marketId, ok := h.parseMarketID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.eventService.DeleteMarket(marketId); err != nil {
+		h.replyEventError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) HandleSettleMarket(w http.ResponseWriter, r *http.Request) {
+	marketId, ok := h.parseMarketID(w, r)
+	if !ok {
+		return
+	}
+
+	req := model.SettleMarketRequest{}
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+	req.MarketID = marketId
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	settlement, err := h.eventService.SettleMarket(req)
+	if err != nil {
+		h.replyEventError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, settlement, http.StatusOK)
+}
+
+func (h *Handler) parseEventID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	eventId, err := strconv.Atoi(mux.Vars(r)["event_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse event id"}, http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(eventId), true
+}
+
+func (h *Handler) parseMarketID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	marketId, err := strconv.Atoi(mux.Vars(r)["market_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse market id"}, http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(marketId), true
+}
+
+func (h *Handler) replyEventError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusNotFound)
+	case errors.Is(err, service.ErrEventHasMarkets), errors.Is(err, service.ErrMarketHasWagers):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusConflict)
+	case errors.Is(err, service.ErrEventNotOpen), errors.Is(err, service.ErrEventTransition), errors.Is(err, service.ErrMarketNotOpen), errors.Is(err, service.ErrNotInMarket):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+	default:
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+	"wager/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleCreateEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleCreateEvent)
+
+	newRequest := func(body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, "/events", bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return req
+	}
+
+	t.Run("Missing name", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: []string{"Name is required"}}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(map[string]interface{}{"starts_at": 100}))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		event := &model.Event{ID: 1, Name: "Final", StartsAt: 100, Status: model.EVENT_STATUS_SCHEDULED}
+		mockHandler.mockEventService.EXPECT().CreateEvent(model.CreateEventRequest{Name: "Final", StartsAt: 100}).Return(event, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), event, http.StatusCreated)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(map[string]interface{}{"name": "Final", "starts_at": 100}))
+	})
+}
+
+func Test_HandleGetEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleGetEvents)
+
+	t.Run("Success", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/events?status=live&page=2&limit=5", nil)
+		assert.NoError(t, err)
+		events := []model.Event{{ID: 1, Name: "Final", StartsAt: 100, Status: model.EVENT_STATUS_LIVE}}
+		request := model.GetEventListRequest{Filter: model.EventFilter{Status: model.EVENT_STATUS_LIVE}, Page: 2, Limit: 5}
+		mockHandler.mockEventService.EXPECT().GetEventList(request).Return(events, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), events, http.StatusOK)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
+
+func Test_HandleEventActions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+
+	newRequest := func(method string, eventId string, body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(method, "/events/"+eventId, bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"event_id": eventId})
+	}
+
+	t.Run("Invalid event id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse event id"}, http.StatusBadRequest)
+		http.HandlerFunc(handler.HandleGetEvent).ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodGet, "a", nil))
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockHandler.mockEventService.EXPECT().GetEvent(uint(9)).Return(nil, repository.ErrNotFound)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: repository.ErrNotFound.Error()}, http.StatusNotFound)
+		http.HandlerFunc(handler.HandleGetEvent).ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodGet, "9", nil))
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		response := &model.UpdateEventResponse{Event: model.Event{ID: 1, Name: "Final", StartsAt: 100, Status: model.EVENT_STATUS_LIVE}}
+		mockHandler.mockEventService.EXPECT().UpdateEvent(model.UpdateEventRequest{ID: 1, Name: "Final", StartsAt: 100, Status: model.EVENT_STATUS_LIVE}).Return(response, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), response, http.StatusOK)
+		http.HandlerFunc(handler.HandleUpdateEvent).ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPut, "1", map[string]interface{}{"name": "Final", "starts_at": 100, "status": "live"}))
+	})
+
+	t.Run("Invalid status change", func(t *testing.T) {
+		err := fmt.Errorf("%w from cancelled to scheduled", service.ErrEventTransition)
+		mockHandler.mockEventService.EXPECT().UpdateEvent(model.UpdateEventRequest{ID: 1, Name: "Final", StartsAt: 100, Status: model.EVENT_STATUS_SCHEDULED}).Return(nil, err)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+		http.HandlerFunc(handler.HandleUpdateEvent).ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPut, "1", map[string]interface{}{"name": "Final", "starts_at": 100, "status": "scheduled"}))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		mockHandler.mockEventService.EXPECT().DeleteEvent(uint(1)).Return(nil)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(handler.HandleDeleteEvent).ServeHTTP(rr, newRequest(http.MethodDelete, "1", nil))
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("Delete with markets", func(t *testing.T) {
+		mockHandler.mockEventService.EXPECT().DeleteEvent(uint(2)).Return(service.ErrEventHasMarkets)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: service.ErrEventHasMarkets.Error()}, http.StatusConflict)
+		http.HandlerFunc(handler.HandleDeleteEvent).ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodDelete, "2", nil))
+	})
+}
+
+func Test_HandleCreateMarket(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleCreateMarket)
+
+	newRequest := func(body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, "/events/1/markets", bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"event_id": "1"})
+	}
+
+	t.Run("One selection", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: []string{"Selections must have at least 2 items"}}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(map[string]interface{}{"name": "Winner", "selections": []string{"Home"}}))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			err    error
+			status int
+		}{
+			{err: repository.ErrNotFound, status: http.StatusNotFound},
+			{err: service.ErrEventNotOpen, status: http.StatusBadRequest},
+			{err: errors.New("custom error"), status: http.StatusInternalServerError},
+		}
+		req := model.CreateMarketRequest{EventID: 1, Name: "Winner", Selections: []string{"Home", "Away"}}
+		for _, test := range tests {
+			mockHandler.mockEventService.EXPECT().CreateMarket(req).Return(nil, test.err)
+			mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: test.err.Error()}, test.status)
+			httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(map[string]interface{}{"name": "Winner", "selections": []string{"Home", "Away"}}))
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req := model.CreateMarketRequest{EventID: 1, Name: "Winner", Selections: []string{"Home", "Away"}}
+		market := &model.Market{ID: 2, EventID: 1, Name: "Winner", Status: model.MARKET_STATUS_OPEN,
+			Selections: []model.Selection{{ID: 1, MarketID: 2, Name: "Home"}, {ID: 2, MarketID: 2, Name: "Away"}}}
+		mockHandler.mockEventService.EXPECT().CreateMarket(req).Return(market, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), market, http.StatusCreated)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(map[string]interface{}{"name": "Winner", "selections": []string{"Home", "Away"}}))
+	})
+}
+
+func Test_HandleSettleMarket(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleSettleMarket)
+
+	newRequest := func(marketId string, body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, "/markets/"+marketId+"/settle", bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"market_id": marketId})
+	}
+
+	t.Run("Invalid market id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse market id"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("a", map[string]uint{"winning_selection_id": 1}))
+	})
+
+	t.Run("Already settled", func(t *testing.T) {
+		mockHandler.mockEventService.EXPECT().SettleMarket(model.SettleMarketRequest{MarketID: 2, WinningSelectionID: 1}).Return(nil, service.ErrMarketNotOpen)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: service.ErrMarketNotOpen.Error()}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("2", map[string]uint{"winning_selection_id": 1}))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		settlement := &model.Settlement{
+			Market:  model.Market{ID: 2, Status: model.MARKET_STATUS_SETTLED, WinningSelectionID: 1},
+			Wagers:  []model.Wager{{ID: 3, Status: model.WAGER_STATUS_WON, SelectionID: 1}},
+			Payouts: []model.Payout{{WagerID: 3, PositionID: 4, Holder: "alice", Amount: 40, Currency: "USD"}},
+		}
+		mockHandler.mockEventService.EXPECT().SettleMarket(model.SettleMarketRequest{MarketID: 2, WinningSelectionID: 1}).Return(settlement, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), settlement, http.StatusOK)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("2", map[string]uint{"winning_selection_id": 1}))
+	})
+}
+
+func Test_HandleGetWagers_Market(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleGetWagers)
+
+	t.Run("Invalid market id", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/wagers?market_id=a", nil)
+		assert.NoError(t, err)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse market id"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/wagers?event_id=1&market_id=2", nil)
+		assert.NoError(t, err)
+		wagers := []model.Wager{{ID: 3, EventID: 1, MarketID: 2, SelectionID: 4}}
+		request := model.GetWagerListRequest{Filter: model.WagerFilter{EventID: 1, MarketID: 2}, Page: DEFAULT_PAGE, Limit: DEFAULT_LIMIT}
+		mockHandler.mockWagerService.EXPECT().GetWagerList(request).Return(&model.GetWagerListResponse{Wagers: wagers}, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), wagers, http.StatusOK)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
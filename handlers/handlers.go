@@ -2,12 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
+	"wager/conf"
 	errorcode "wager/error_code"
 	"wager/model"
+	"wager/odds"
+	"wager/repository"
 	"wager/service"
+	"wager/stream"
 	"wager/utils"
 	"wager/validator"
 
@@ -21,51 +27,72 @@ const (
 )
 
 type Handler struct {
-	wagerService service.WagerService
-	httpUtils    utils.HTTPUtils
+	wagerService       service.WagerService
+	purchaseService    service.PurchaseService
+	reservationService service.ReservationService
+	bidService         service.BidService
+	resaleService      service.ResaleService
+	statsService       service.StatsService
+	eventService       service.EventService
+	broker             *stream.Broker
+	httpUtils          utils.HTTPUtils
+	streamHeartbeat    time.Duration
+	socketConfig       conf.SocketConfig
 }
 
-func NewHandler(wagerSvrc service.WagerService) *Handler {
+func NewHandler(wagerSvrc service.WagerService, purchaseSvrc service.PurchaseService, reservationSvrc service.ReservationService, bidSvrc service.BidService, resaleSvrc service.ResaleService, statsSvrc service.StatsService, eventSvrc service.EventService, broker *stream.Broker, socketConfig conf.SocketConfig) *Handler {
 	return &Handler{
-		wagerService: wagerSvrc,
-		httpUtils:    utils.NewHTTPUtils(),
+		wagerService:       wagerSvrc,
+		purchaseService:    purchaseSvrc,
+		reservationService: reservationSvrc,
+		bidService:         bidSvrc,
+		resaleService:      resaleSvrc,
+		statsService:       statsSvrc,
+		eventService:       eventSvrc,
+		broker:             broker,
+		httpUtils:          utils.NewHTTPUtils(),
+		streamHeartbeat:    STREAM_HEARTBEAT,
+		socketConfig:       socketConfig,
 	}
 }
 
 func (h *Handler) HandleGetWagers(w http.ResponseWriter, r *http.Request) {
-	reqPage := DEFAULT_PAGE
-	reqLimit := DEFAULT_LIMIT
+	reqPage, reqLimit, ok := h.parsePaging(w, r)
+	if !ok {
+		return
+	}
+	oddsFormat, ok := h.parseOddsFormat(w, r)
+	if !ok {
+		return
+	}
 
-	query := r.URL.Query()
-	if page, ok := query["page"]; ok {
-		num, err := strconv.Atoi(page[0])
+	filter := model.WagerFilter{Currency: r.URL.Query().Get("currency")}
+	if eventId, ok := r.URL.Query()["event_id"]; ok {
+		num, err := strconv.ParseUint(eventId[0], 10, 0)
 		if err != nil {
-			jsonErr := errorcode.ErrorResponse{Error: "failed to parse page number"}
-			h.httpUtils.ReplyJSON(w, jsonErr, http.StatusBadRequest)
+			h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse event id"}, http.StatusBadRequest)
 			return
 		}
-		reqPage = num
+		filter.EventID = uint(num)
 	}
-
-	if limit, ok := query["limit"]; ok {
-		num, err := strconv.Atoi(limit[0])
+	if marketId, ok := r.URL.Query()["market_id"]; ok {
+		num, err := strconv.ParseUint(marketId[0], 10, 0)
 		if err != nil {
-			jsonErr := errorcode.ErrorResponse{Error: "failed to parse limit number"}
-			h.httpUtils.ReplyJSON(w, jsonErr, http.StatusBadRequest)
+			h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse market id"}, http.StatusBadRequest)
 			return
 		}
-		reqLimit = num
+		filter.MarketID = uint(num)
 	}
-
-	req := model.GetWagerListRequest{Page: reqPage, Limit: reqLimit}
+	req := model.GetWagerListRequest{Filter: filter, Page: reqPage, Limit: reqLimit}
 	if err := validator.Validate(req); err != nil {
 		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
 		return
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"page":  reqPage,
-		"limit": reqLimit,
+		"page":   reqPage,
+		"limit":  reqLimit,
+		"filter": filter,
 	}).Info("RequestQuery")
 
 	wagers, err := h.wagerService.GetWagerList(req)
@@ -74,12 +101,40 @@ func (h *Handler) HandleGetWagers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.httpUtils.ReplyJSON(w, wagers.Wagers, http.StatusOK)
+	h.httpUtils.ReplyJSON(w, renderWagers(wagers.Wagers, oddsFormat), http.StatusOK)
+}
+
+func (h *Handler) HandleGetWager(w http.ResponseWriter, r *http.Request) {
+	wagerId, err := strconv.Atoi(mux.Vars(r)["wager_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		return
+	}
+	oddsFormat, ok := h.parseOddsFormat(w, r)
+	if !ok {
+		return
+	}
+
+	wager, err := h.wagerService.GetWager(uint(wagerId))
+	if err == repository.ErrNotFound {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, renderWager(wager, oddsFormat), http.StatusOK)
 }
 
 func (h *Handler) HandlePlaceWager(w http.ResponseWriter, r *http.Request) {
 	contentType := r.Header.Get("Content-Type")
 	logrus.WithField("Type", contentType).Info("Content-Type")
+	oddsFormat, ok := h.parseOddsFormat(w, r)
+	if !ok {
+		return
+	}
 	data, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		logrus.WithError(err).Error("failed to read request body")
@@ -97,47 +152,108 @@ func (h *Handler) HandlePlaceWager(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := validator.Validate(req); err != nil {
-		logrus.WithField("error", validator.ErrorMsg(err)).Info("Validate failed")
-		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+	if msgs := validator.CreateWagerErrors(req); msgs != nil {
+		logrus.WithField("error", msgs).Info("Validate failed")
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: msgs}, http.StatusBadRequest)
 		return
 	}
 
-	if req.SellingPrice <= float64(req.TotalWagerValue*req.SellingPercentage)/100 {
-		jsonErr := errorcode.ErrorResponse{Error: []string{"SellingPrice must be larger than TotalWagerValue * SellingPercentage"}}
-		h.httpUtils.ReplyJSON(w, jsonErr, http.StatusBadRequest)
+	wager, err := h.wagerService.CreateWager(req)
+	if errors.Is(err, service.ErrSelectionNotFound) || errors.Is(err, service.ErrMarketNotOpen) {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: []string{err.Error()}}, http.StatusBadRequest)
 		return
 	}
-
-	wager, err := h.wagerService.CreateWager(req)
 	if err != nil {
 		jsonErr := errorcode.ErrorResponse{Error: []string{err.Error()}}
 		h.httpUtils.ReplyJSON(w, jsonErr, http.StatusInternalServerError)
 		return
 	}
 
-	h.httpUtils.ReplyJSON(w, wager, http.StatusCreated)
+	h.httpUtils.ReplyJSON(w, renderWager(wager, oddsFormat), http.StatusCreated)
+}
+
+func (h *Handler) HandlePlaceWagers(w http.ResponseWriter, r *http.Request) {
+	oddsFormat, ok := h.parseOddsFormat(w, r)
+	if !ok {
+		return
+	}
+	req := model.CreateWagersRequest{}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logrus.WithError(err).Error("failed to read request body")
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to read request body"}, http.StatusBadRequest)
+		return
+	}
+
+	if err := json.Unmarshal(data, &req.Wagers); err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to unmarshal request body"}, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	wagers, err := h.wagerService.CreateWagers(req)
+	if err != nil {
+		batchErr := &model.BatchError{}
+		if errors.As(err, &batchErr) {
+			h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: batchErr.Items}, http.StatusBadRequest)
+			return
+		}
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, renderWagers(wagers, oddsFormat), http.StatusCreated)
 }
 
 func (h *Handler) HandleBuyWager(w http.ResponseWriter, r *http.Request) {
-	req := model.BuyWagerRequest{}
-	vars := mux.Vars(r)
-	wagerIdStr, ok := vars["wager_id"]
+	req, ok := h.parseBuyWagerRequest(w, r)
 	if !ok {
-		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "invalid wager id"}, http.StatusBadRequest)
 		return
 	}
 
-	wagerId, err := strconv.Atoi(wagerIdStr)
+	res, err := h.wagerService.BuyWager(*req)
+	if errors.Is(err, service.ErrBuyerLimitExceeded) {
+		h.replyBuyerLimitError(w, err)
+		return
+	}
 	if err != nil {
-		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
 		return
 	}
 
-	req.WagerID = uint(wagerId)
+	h.httpUtils.ReplyJSON(w, res, http.StatusCreated)
+}
 
-	contentType := r.Header.Get("Content-Type")
-	logrus.WithField("Type", contentType).Info("Content-Type")
+// replyBuyerLimitError replies to a purchase refused by a buyer limit
+func (h *Handler) replyBuyerLimitError(w http.ResponseWriter, err error) {
+	h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error(), Code: errorcode.BUYER_LIMIT_EXCEEDED}, http.StatusForbidden)
+}
+
+func (h *Handler) HandleQuoteWager(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.parseBuyWagerRequest(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.wagerService.QuoteWager(*req)
+	if errors.Is(err, service.ErrBuyerLimitExceeded) {
+		h.replyBuyerLimitError(w, err)
+		return
+	}
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, res, http.StatusOK)
+}
+
+func (h *Handler) HandleBuyWagers(w http.ResponseWriter, r *http.Request) {
+	req := model.BatchPurchaseRequest{}
 	data, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		logrus.WithError(err).Error("failed to read request body")
@@ -145,7 +261,7 @@ func (h *Handler) HandleBuyWager(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := json.Unmarshal(data, &req); err != nil {
+	if err := json.Unmarshal(data, &req.Items); err != nil {
 		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to unmarshal request body"}, http.StatusBadRequest)
 		return
 	}
@@ -155,11 +271,143 @@ func (h *Handler) HandleBuyWager(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.wagerService.BuyWager(req)
+	res, err := h.wagerService.BuyWagers(req)
 	if err != nil {
-		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+		batchErr := &model.BatchError{}
+		if errors.As(err, &batchErr) {
+			h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: batchErr.Items}, http.StatusBadRequest)
+			return
+		}
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusInternalServerError)
 		return
 	}
 
 	h.httpUtils.ReplyJSON(w, res, http.StatusCreated)
 }
+
+// parsePaging reads the page and limit query parameters, it replies to the client
+// itself when they are invalid
+func (h *Handler) parsePaging(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	reqPage := DEFAULT_PAGE
+	reqLimit := DEFAULT_LIMIT
+
+	query := r.URL.Query()
+	if page, ok := query["page"]; ok {
+		num, err := strconv.Atoi(page[0])
+		if err != nil {
+			jsonErr := errorcode.ErrorResponse{Error: "failed to parse page number"}
+			h.httpUtils.ReplyJSON(w, jsonErr, http.StatusBadRequest)
+			return 0, 0, false
+		}
+		reqPage = num
+	}
+
+	if limit, ok := query["limit"]; ok {
+		num, err := strconv.Atoi(limit[0])
+		if err != nil {
+			jsonErr := errorcode.ErrorResponse{Error: "failed to parse limit number"}
+			h.httpUtils.ReplyJSON(w, jsonErr, http.StatusBadRequest)
+			return 0, 0, false
+		}
+		reqLimit = num
+	}
+
+	return reqPage, reqLimit, true
+}
+
+// parseOddsFormat reads the odds_format query parameter, which is empty when it is
+// not given. It replies to the client itself when the format is unknown.
+func (h *Handler) parseOddsFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("odds_format")
+	switch format {
+	case "", odds.FORMAT_DECIMAL, odds.FORMAT_FRACTIONAL, odds.FORMAT_AMERICAN:
+		return format, true
+	default:
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "odds_format " + odds.ErrUnknownFormat.Error()}, http.StatusBadRequest)
+		return "", false
+	}
+}
+
+// renderWager renders the odds of wager in format, wagers are replied as they are,
+// with decimal odds, when no format is asked for
+func renderWager(wager *model.Wager, format string) interface{} {
+	if format == "" {
+		return wager
+	}
+	return newWagerView(*wager, format)
+}
+
+func renderWagers(wagers []model.Wager, format string) interface{} {
+	if format == "" {
+		return wagers
+	}
+	views := make([]model.WagerView, 0, len(wagers))
+	for _, wager := range wagers {
+		views = append(views, newWagerView(wager, format))
+	}
+	return views
+}
+
+func newWagerView(wager model.Wager, format string) model.WagerView {
+	// the format was checked by parseOddsFormat
+	rendered, _ := wager.Odds.Render(format)
+	return model.WagerView{Wager: wager, Odds: rendered, OddsFormat: format}
+}
+
+// readJSON decodes the request body into req, it replies to the client itself when
+// the body cannot be read
+func (h *Handler) readJSON(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logrus.WithError(err).Error("failed to read request body")
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to read request body"}, http.StatusBadRequest)
+		return false
+	}
+
+	if err := json.Unmarshal(data, req); err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to unmarshal request body"}, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// parseBuyWagerRequest reads and validates the request of a buy or a quote, it
+// replies to the client itself when the request is invalid
+func (h *Handler) parseBuyWagerRequest(w http.ResponseWriter, r *http.Request) (*model.BuyWagerRequest, bool) {
+	req := model.BuyWagerRequest{}
+	vars := mux.Vars(r)
+	wagerIdStr, ok := vars["wager_id"]
+	if !ok {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "invalid wager id"}, http.StatusBadRequest)
+		return nil, false
+	}
+
+	wagerId, err := strconv.Atoi(wagerIdStr)
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		return nil, false
+	}
+
+	req.WagerID = uint(wagerId)
+
+	contentType := r.Header.Get("Content-Type")
+	logrus.WithField("Type", contentType).Info("Content-Type")
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logrus.WithError(err).Error("failed to read request body")
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to read request body"}, http.StatusBadRequest)
+		return nil, false
+	}
+
+	if err := json.Unmarshal(data, &req); err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to unmarshal request body"}, http.StatusBadRequest)
+		return nil, false
+	}
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return nil, false
+	}
+
+	return &req, true
+}
@@ -8,9 +8,13 @@ import (
 	"net/http/httptest"
 	"testing"
 	"time"
+	"wager/conf"
 	errorcode "wager/error_code"
 	"wager/mocks"
 	"wager/model"
+	"wager/odds"
+	"wager/repository"
+	"wager/service"
 	"wager/utils"
 
 	"github.com/golang/mock/gomock"
@@ -26,19 +30,39 @@ import (
 */
 
 type MockHandler struct {
-	mockWagerService *mocks.MockWagerService
-	mockHTTPUtils    *mocks.MockHTTPUtils
+	mockWagerService       *mocks.MockWagerService
+	mockPurchaseService    *mocks.MockPurchaseService
+	mockReservationService *mocks.MockReservationService
+	mockBidService         *mocks.MockBidService
+	mockResaleService      *mocks.MockResaleService
+	mockStatsService       *mocks.MockStatsService
+	mockEventService       *mocks.MockEventService
+	mockHTTPUtils          *mocks.MockHTTPUtils
 }
 
 func NewMockHandler(ctrl *gomock.Controller) (*Handler, *MockHandler) {
 	mockHandler := MockHandler{
-		mockWagerService: mocks.NewMockWagerService(ctrl),
-		mockHTTPUtils:    mocks.NewMockHTTPUtils(ctrl),
+		mockWagerService:       mocks.NewMockWagerService(ctrl),
+		mockPurchaseService:    mocks.NewMockPurchaseService(ctrl),
+		mockReservationService: mocks.NewMockReservationService(ctrl),
+		mockBidService:         mocks.NewMockBidService(ctrl),
+		mockResaleService:      mocks.NewMockResaleService(ctrl),
+		mockStatsService:       mocks.NewMockStatsService(ctrl),
+		mockEventService:       mocks.NewMockEventService(ctrl),
+		mockHTTPUtils:          mocks.NewMockHTTPUtils(ctrl),
 	}
 
 	handlers := Handler{
-		wagerService: mockHandler.mockWagerService,
-		httpUtils:    mockHandler.mockHTTPUtils,
+		wagerService:       mockHandler.mockWagerService,
+		purchaseService:    mockHandler.mockPurchaseService,
+		reservationService: mockHandler.mockReservationService,
+		bidService:         mockHandler.mockBidService,
+		resaleService:      mockHandler.mockResaleService,
+		statsService:       mockHandler.mockStatsService,
+		eventService:       mockHandler.mockEventService,
+		httpUtils:          mockHandler.mockHTTPUtils,
+		streamHeartbeat:    STREAM_HEARTBEAT,
+		socketConfig:       conf.GetDefaultConfig().Socket,
 	}
 
 	return &handlers, &mockHandler
@@ -68,6 +92,10 @@ func Test_HandleGetWagers_BadRequests(t *testing.T) {
 	req5, err := http.NewRequest("GET", "/wagers?page=0&limit=0", nil)
 	assert.NoError(t, err)
 
+	// unknown currency
+	req6, err := http.NewRequest("GET", "/wagers?currency=usd", nil)
+	assert.NoError(t, err)
+
 	testCases := []struct {
 		name          string
 		request       *http.Request
@@ -101,6 +129,11 @@ func Test_HandleGetWagers_BadRequests(t *testing.T) {
 				"Limit must be larger than 0",
 			}},
 		},
+		{
+			name:          "Unknown currency",
+			request:       req6,
+			expectedError: errorcode.ErrorResponse{Error: []string{"Currency must be an ISO 4217 currency code like USD"}},
+		},
 	}
 
 	httpHandler := http.HandlerFunc(handler.HandleGetWagers)
@@ -141,7 +174,7 @@ func Test_HandleGetWagers_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	handler, mockHandler := NewMockHandler(ctrl)
 
-	req, err := http.NewRequest("GET", "/wagers?page=2&limit=20", nil)
+	req, err := http.NewRequest("GET", "/wagers?page=2&limit=20&currency=EUR", nil)
 	assert.NoError(t, err)
 
 	httpHandler := http.HandlerFunc(handler.HandleGetWagers)
@@ -152,7 +185,7 @@ func Test_HandleGetWagers_Success(t *testing.T) {
 		{ID: 2},
 	}}
 
-	mockHandler.mockWagerService.EXPECT().GetWagerList(model.GetWagerListRequest{Page: 2, Limit: 20}).Return(
+	mockHandler.mockWagerService.EXPECT().GetWagerList(model.GetWagerListRequest{Filter: model.WagerFilter{Currency: "EUR"}, Page: 2, Limit: 20}).Return(
 		resp,
 		nil,
 	)
@@ -171,29 +204,59 @@ func Test_HandlePlaceWager_BadRequests(t *testing.T) {
 	}{
 		{
 			name:          "Invalid TotalWagerValue and Odds",
-			request:       model.CreateWagerRequest{TotalWagerValue: 0, Odds: 0, SellingPercentage: 1, SellingPrice: 1},
-			expectedError: errorcode.ErrorResponse{Error: []string{"TotalWagerValue must be larger than 0", "Odds must be larger than 0"}},
+			request:       model.CreateWagerRequest{TotalWagerValue: 0, Odds: "0", SellingPercentage: 1, SellingPrice: 1},
+			expectedError: errorcode.ErrorResponse{Error: []string{"TotalWagerValue must be larger than 0", "Odds must be decimal odds larger than 1, like 2.5"}},
 		},
 		{
 			name:          "SellingPrice has more than 2 decimals",
-			request:       model.CreateWagerRequest{TotalWagerValue: 1, Odds: 1, SellingPercentage: 1, SellingPrice: 1.111111},
-			expectedError: errorcode.ErrorResponse{Error: []string{"SellingPrice must be in monetary format with maximum 2 decimal places"}},
+			request:       model.CreateWagerRequest{TotalWagerValue: 1, Odds: "2", SellingPercentage: 1, SellingPrice: 1.111111},
+			expectedError: errorcode.ErrorResponse{Error: []string{"SellingPrice must have at most 2 decimal places in USD"}},
+		},
+		{
+			name:          "SellingPrice has decimals in yen",
+			request:       model.CreateWagerRequest{TotalWagerValue: 1000, Odds: "2", SellingPercentage: 1, SellingPrice: 500.5, Currency: "JPY"},
+			expectedError: errorcode.ErrorResponse{Error: []string{"SellingPrice must have at most 0 decimal places in JPY"}},
+		},
+		{
+			name:          "Unknown currency",
+			request:       model.CreateWagerRequest{TotalWagerValue: 1, Odds: "2", SellingPercentage: 1, SellingPrice: 1, Currency: "XYZ"},
+			expectedError: errorcode.ErrorResponse{Error: []string{"Currency must be an ISO 4217 currency code like USD"}},
 		},
 		{
 			name:          "SellingPercentage less than 1",
-			request:       model.CreateWagerRequest{TotalWagerValue: 1, Odds: 1, SellingPercentage: 0, SellingPrice: 1.11},
+			request:       model.CreateWagerRequest{TotalWagerValue: 1, Odds: "2", SellingPercentage: 0, SellingPrice: 1.11},
 			expectedError: errorcode.ErrorResponse{Error: []string{"SellingPercentage must be larger than or equal 1"}},
 		},
 		{
 			name:          "SellingPercentage larger than 100",
-			request:       model.CreateWagerRequest{TotalWagerValue: 1, Odds: 1, SellingPercentage: 101, SellingPrice: 1.11},
+			request:       model.CreateWagerRequest{TotalWagerValue: 1, Odds: "2", SellingPercentage: 101, SellingPrice: 1.11},
 			expectedError: errorcode.ErrorResponse{Error: []string{"SellingPercentage must be less than or equal 100"}},
 		},
 		{
 			name:          "SellingPrice less than TotalWagerValue * SellingPercentage",
-			request:       model.CreateWagerRequest{TotalWagerValue: 5, Odds: 1, SellingPercentage: 100, SellingPrice: 1},
+			request:       model.CreateWagerRequest{TotalWagerValue: 5, Odds: "2", SellingPercentage: 100, SellingPrice: 1},
 			expectedError: errorcode.ErrorResponse{Error: []string{"SellingPrice must be larger than TotalWagerValue * SellingPercentage"}},
 		},
+		{
+			name:          "MinPurchase negative",
+			request:       model.CreateWagerRequest{TotalWagerValue: 1, Odds: "2", SellingPercentage: 1, SellingPrice: 1.11, MinPurchase: -1},
+			expectedError: errorcode.ErrorResponse{Error: []string{"MinPurchase must be larger than or equal 0"}},
+		},
+		{
+			name:          "Unknown PriceSchedule",
+			request:       model.CreateWagerRequest{TotalWagerValue: 1, Odds: "2", SellingPercentage: 1, SellingPrice: 1.11, PriceSchedule: "exponential"},
+			expectedError: errorcode.ErrorResponse{Error: []string{"PriceSchedule must be one of linear step"}},
+		},
+		{
+			name:          "Step PriceSchedule without steps",
+			request:       model.CreateWagerRequest{TotalWagerValue: 1, Odds: "2", SellingPercentage: 1, SellingPrice: 1.11, PriceSchedule: model.PRICE_SCHEDULE_STEP, FloorPrice: 2},
+			expectedError: errorcode.ErrorResponse{Error: []string{"FloorPrice must be larger than 0 and less than SellingPrice", "DecaySeconds must be larger than 0", "DecaySteps must be larger than 0"}},
+		},
+		{
+			name:          "Purchase sizes larger than SellingPrice",
+			request:       model.CreateWagerRequest{TotalWagerValue: 1, Odds: "2", SellingPercentage: 1, SellingPrice: 1.11, MinPurchase: 2, MaxPurchase: 3},
+			expectedError: errorcode.ErrorResponse{Error: []string{"MinPurchase must be less than or equal SellingPrice", "MaxPurchase must be less than or equal SellingPrice"}},
+		},
 	}
 
 	httpHandler := http.HandlerFunc(handler.HandlePlaceWager)
@@ -218,7 +281,7 @@ func Test_HandlePlaceWager_Success(t *testing.T) {
 
 	httpHandler := http.HandlerFunc(handler.HandlePlaceWager)
 
-	requestBody := model.CreateWagerRequest{TotalWagerValue: 1, Odds: 1, SellingPercentage: 1, SellingPrice: 1}
+	requestBody := model.CreateWagerRequest{TotalWagerValue: 1, Odds: "2", SellingPercentage: 1, SellingPrice: 1}
 	bodyJson, _ := json.Marshal(requestBody)
 	req, err := http.NewRequest(http.MethodPost, "/wagers", bytes.NewReader(bodyJson))
 	assert.NoError(t, err)
@@ -226,7 +289,7 @@ func Test_HandlePlaceWager_Success(t *testing.T) {
 	expectedResp := &model.Wager{
 		ID:                  1,
 		TotalWagerValue:     1,
-		Odds:                1,
+		Odds:                2 * odds.SCALE,
 		SellingPercentage:   1,
 		SellingPrice:        1,
 		CurrentSellingPrice: 1,
@@ -241,6 +304,39 @@ func Test_HandlePlaceWager_Success(t *testing.T) {
 	httpHandler.ServeHTTP(rr, req)
 }
 
+func Test_HandlePlaceWagers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandlePlaceWagers)
+
+	newRequest := func(body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, "/wagers/batch", bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return req
+	}
+	wagers := []model.CreateWagerRequest{{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 60}}
+
+	t.Run("Empty batch", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: []string{"Wagers must have at least 1 items"}}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest([]model.CreateWagerRequest{}))
+	})
+
+	t.Run("Invalid wagers", func(t *testing.T) {
+		itemErrs := []model.BatchItemError{{Index: 0, Error: "Odds must be larger than 0"}}
+		mockHandler.mockWagerService.EXPECT().CreateWagers(model.CreateWagersRequest{Wagers: wagers}).Return(nil, &model.BatchError{Items: itemErrs})
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: itemErrs}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(wagers))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		created := []model.Wager{{ID: 1, TotalWagerValue: 100, Odds: 2 * odds.SCALE, SellingPercentage: 50, SellingPrice: 60, CurrentSellingPrice: 60}}
+		mockHandler.mockWagerService.EXPECT().CreateWagers(model.CreateWagersRequest{Wagers: wagers}).Return(created, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), created, http.StatusCreated)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(wagers))
+	})
+}
+
 func Test_BuyWager_BadRequests(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	handler, mockHandler := NewMockHandler(ctrl)
@@ -314,3 +410,223 @@ func Test_BuyWager_Success(t *testing.T) {
 	mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), expectedResp, http.StatusCreated)
 	httpHandler.ServeHTTP(rr, req)
 }
+
+func Test_BuyWager_BuyerLimitExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleBuyWager)
+
+	reqBody := model.BuyWagerRequest{WagerID: 1, Buyer: "alice", BuyingPrice: 1}
+	bodyJson, _ := json.Marshal(reqBody)
+	req, err := http.NewRequest(http.MethodPost, "buy/1", bytes.NewReader(bodyJson))
+	assert.NoError(t, err)
+	req = mux.SetURLVars(req, map[string]string{"wager_id": "1"})
+
+	limitErr := &service.BuyerLimitError{Buyer: "alice", WagerID: 1, Limit: service.LIMIT_PURCHASES_PER_WAGER, Value: 2, Max: 1}
+	mockHandler.mockWagerService.EXPECT().BuyWager(reqBody).Return(nil, limitErr)
+	mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: limitErr.Error(), Code: errorcode.BUYER_LIMIT_EXCEEDED}, http.StatusForbidden)
+	httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func Test_QuoteWager(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleQuoteWager)
+
+	newRequest := func(wagerId string, body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, "/wagers/"+wagerId+"/quote", bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"wager_id": wagerId})
+	}
+
+	t.Run("Invalid wager id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("a", map[string]float64{"buying_price": 1}))
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		reqBody := model.BuyWagerRequest{WagerID: 1, BuyingPrice: 1000}
+		mockHandler.mockWagerService.EXPECT().QuoteWager(reqBody).Return(nil, fmt.Errorf("custom error"))
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "custom error"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", reqBody))
+	})
+
+	t.Run("Buyer limit exceeded", func(t *testing.T) {
+		reqBody := model.BuyWagerRequest{WagerID: 1, Buyer: "alice", BuyingPrice: 50}
+		limitErr := &service.BuyerLimitError{Buyer: "alice", WagerID: 1, Limit: service.LIMIT_OPEN_EXPOSURE, Value: 60, Max: 50}
+		mockHandler.mockWagerService.EXPECT().QuoteWager(reqBody).Return(nil, limitErr)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: limitErr.Error(), Code: errorcode.BUYER_LIMIT_EXCEEDED}, http.StatusForbidden)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", reqBody))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		reqBody := model.BuyWagerRequest{WagerID: 1, BuyingPrice: 50}
+		quote := &model.Quote{WagerID: 1, BuyingPrice: 50, CurrentSellingPrice: 150}
+		mockHandler.mockWagerService.EXPECT().QuoteWager(reqBody).Return(quote, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), quote, http.StatusOK)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", reqBody))
+	})
+}
+
+func Test_HandleBuyWagers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleBuyWagers)
+
+	newRequest := func(body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, "/purchases/batch", bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return req
+	}
+	items := []model.BatchPurchaseItem{{WagerID: 1, BuyingPrice: 10}, {WagerID: 2, BuyingPrice: 20}}
+
+	t.Run("Invalid body", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to unmarshal request body"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(map[string]int{"wager_id": 1}))
+	})
+
+	t.Run("Empty batch", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: []string{"Items must have at least 1 items"}}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest([]model.BatchPurchaseItem{}))
+	})
+
+	t.Run("Failed items", func(t *testing.T) {
+		itemErrs := []model.BatchItemError{{Index: 1, WagerID: 2, Error: "id not found"}}
+		mockHandler.mockWagerService.EXPECT().BuyWagers(model.BatchPurchaseRequest{Items: items}).Return(nil, &model.BatchError{Items: itemErrs})
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: itemErrs}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(items))
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		mockHandler.mockWagerService.EXPECT().BuyWagers(model.BatchPurchaseRequest{Items: items}).Return(nil, fmt.Errorf("custom error"))
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "custom error"}, http.StatusInternalServerError)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(items))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		purchases := []model.Purchase{{PurchaseID: 1, WagerID: 1, BuyingPrice: 10}, {PurchaseID: 2, WagerID: 2, BuyingPrice: 20}}
+		mockHandler.mockWagerService.EXPECT().BuyWagers(model.BatchPurchaseRequest{Items: items}).Return(purchases, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), purchases, http.StatusCreated)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest(items))
+	})
+}
+
+func Test_MemoryStorage_PlaceAndBuyWager(t *testing.T) {
+	config := conf.GetDefaultConfig()
+	store := repository.NewMemoryStore()
+	handler := NewHandler(service.NewWagerService(config, store, nil), service.NewPurchaseService(config, store, nil), service.NewReservationService(config, store, nil), service.NewBidService(config, store, nil), service.NewResaleService(config, store), service.NewStatsService(config, store), service.NewEventService(config, store, nil), nil, config.Socket)
+
+	router := mux.NewRouter()
+	router.HandleFunc(config.Handlers.GetWagerList, handler.HandleGetWagers).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.CreateWager, handler.HandlePlaceWager).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.BuyWager, handler.HandleBuyWager).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.GetPurchases, handler.HandleGetPurchases).Methods(http.MethodGet)
+
+	serve := func(method string, url string, body interface{}) *httptest.ResponseRecorder {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(method, url, bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(http.MethodPost, "/wagers", model.CreateWagerRequest{TotalWagerValue: 100, Odds: "120", SellingPercentage: 1, SellingPrice: 200})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = serve(http.MethodPost, "/buy/1", map[string]interface{}{"buyer": "alice", "buying_price": 50})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = serve(http.MethodPost, "/buy/1", map[string]float64{"buying_price": 1000})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(http.MethodPost, "/buy/2", map[string]float64{"buying_price": 10})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(http.MethodPost, "/wagers?odds_format=american", map[string]interface{}{
+		"total_wager_value": 100, "odds": "3/2", "odds_format": "fractional", "selling_percentage": 1, "selling_price": 200})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	view := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &view))
+	assert.Equal(t, "+150", view["odds"])
+	assert.Equal(t, "american", view["odds_format"])
+
+	rr = serve(http.MethodPost, "/wagers", map[string]interface{}{
+		"total_wager_value": 100, "odds": 50, "odds_format": "american", "selling_percentage": 1, "selling_price": 200})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(http.MethodGet, "/wagers", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	wagers := []model.Wager{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &wagers))
+	assert.Equal(t, 2, len(wagers))
+	assert.Equal(t, 120*odds.SCALE, wagers[0].Odds)
+	assert.Equal(t, 25*odds.SCALE/10, wagers[1].Odds)
+	assert.Equal(t, float64(150), wagers[0].CurrentSellingPrice)
+	assert.Equal(t, uint(25), wagers[0].PercentageSold.Uint)
+
+	rr = serve(http.MethodGet, "/purchases?wager_id=1&buyer=alice", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	purchases := []model.Purchase{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &purchases))
+	assert.Equal(t, 1, len(purchases))
+	assert.Equal(t, float64(50), purchases[0].BuyingPrice)
+}
+
+func Test_HandleGetWager(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleGetWager)
+
+	newRequest := func(wagerId string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, "/wagers/"+wagerId, nil)
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"wager_id": wagerId})
+	}
+
+	t.Run("Invalid wager id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("a"))
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockHandler.mockWagerService.EXPECT().GetWager(uint(2)).Return(nil, repository.ErrNotFound)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "id not found"}, http.StatusNotFound)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("2"))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		wager := &model.Wager{ID: 1}
+		mockHandler.mockWagerService.EXPECT().GetWager(uint(1)).Return(wager, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), wager, http.StatusOK)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1"))
+	})
+
+	t.Run("Unknown odds format", func(t *testing.T) {
+		req := newRequest("1")
+		req.URL.RawQuery = "odds_format=malay"
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "odds_format must be one of decimal fractional american"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+
+	t.Run("Odds format", func(t *testing.T) {
+		tests := []struct {
+			format string
+			odds   interface{}
+		}{
+			{format: odds.FORMAT_DECIMAL, odds: 2.5},
+			{format: odds.FORMAT_FRACTIONAL, odds: "3/2"},
+			{format: odds.FORMAT_AMERICAN, odds: "+150"},
+		}
+		for _, test := range tests {
+			wager := &model.Wager{ID: 1, Odds: 25000}
+			req := newRequest("1")
+			req.URL.RawQuery = "odds_format=" + test.format
+			mockHandler.mockWagerService.EXPECT().GetWager(uint(1)).Return(wager, nil)
+			mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), model.WagerView{Wager: *wager, Odds: test.odds, OddsFormat: test.format}, http.StatusOK)
+			httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	})
+}
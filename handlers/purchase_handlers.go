@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+	"wager/service"
+	"wager/validator"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) HandleGetPurchases(w http.ResponseWriter, r *http.Request) {
+	reqPage, reqLimit, ok := h.parsePaging(w, r)
+	if !ok {
+		return
+	}
+
+	filter := model.PurchaseFilter{}
+	query := r.URL.Query()
+	if wagerId, ok := query["wager_id"]; ok {
+		num, err := strconv.ParseUint(wagerId[0], 10, 0)
+		if err != nil {
+			h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+			return
+		}
+		filter.WagerID = uint(num)
+	}
+
+	filter.Buyer = query.Get("buyer")
+
+	if boughtFrom, ok := query["bought_from"]; ok {
+		num, err := strconv.ParseInt(boughtFrom[0], 10, 64)
+		if err != nil {
+			h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse bought_from"}, http.StatusBadRequest)
+			return
+		}
+		filter.BoughtFrom = num
+	}
+
+	if boughtTo, ok := query["bought_to"]; ok {
+		num, err := strconv.ParseInt(boughtTo[0], 10, 64)
+		if err != nil {
+			h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse bought_to"}, http.StatusBadRequest)
+			return
+		}
+		filter.BoughtTo = num
+	}
+
+	req := model.GetPurchaseListRequest{Filter: filter, Page: reqPage, Limit: reqLimit}
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"page":   reqPage,
+		"limit":  reqLimit,
+		"filter": filter,
+	}).Info("RequestQuery")
+
+	purchases, err := h.purchaseService.GetPurchaseList(req)
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, purchases.Purchases, http.StatusOK)
+}
+
+func (h *Handler) HandleGetPurchase(w http.ResponseWriter, r *http.Request) {
+	purchaseId, err := strconv.Atoi(mux.Vars(r)["purchase_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse purchase id"}, http.StatusBadRequest)
+		return
+	}
+
+	purchase, err := h.purchaseService.GetPurchase(uint(purchaseId))
+	if err == repository.ErrNotFound {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, purchase, http.StatusOK)
+}
+
+func (h *Handler) HandleRefundPurchase(w http.ResponseWriter, r *http.Request) {
+	purchaseId, err := strconv.Atoi(mux.Vars(r)["purchase_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse purchase id"}, http.StatusBadRequest)
+		return
+	}
+
+	purchase, err := h.purchaseService.RefundPurchase(uint(purchaseId))
+	switch {
+	case err == nil:
+		h.httpUtils.ReplyJSON(w, purchase, http.StatusOK)
+	case errors.Is(err, repository.ErrNotFound):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusNotFound)
+	case errors.Is(err, service.ErrAlreadyRefunded), errors.Is(err, service.ErrRefundWindowExpired), errors.Is(err, service.ErrWagerNotOpen), errors.Is(err, service.ErrPurchaseResold):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+	default:
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusInternalServerError)
+	}
+}
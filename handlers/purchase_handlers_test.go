@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+	"wager/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleGetPurchases_BadRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleGetPurchases)
+
+	tests := []struct {
+		name  string
+		query string
+		err   interface{}
+	}{
+		{name: "Invalid page", query: "page=a", err: "failed to parse page number"},
+		{name: "Invalid wager id", query: "wager_id=-1", err: "failed to parse wager id"},
+		{name: "Invalid bought_from", query: "bought_from=yesterday", err: "failed to parse bought_from"},
+		{name: "Invalid bought_to", query: "bought_to=1.5", err: "failed to parse bought_to"},
+		{name: "Invalid limit", query: "limit=0", err: []string{"Limit must be larger than 0"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/purchases?"+test.query, nil)
+			assert.NoError(t, err)
+			mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: test.err}, http.StatusBadRequest)
+			httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+		})
+	}
+}
+
+func Test_HandleGetPurchases_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleGetPurchases)
+
+	req, err := http.NewRequest(http.MethodGet, "/purchases?wager_id=3&buyer=alice&bought_from=100&bought_to=200&page=2&limit=5", nil)
+	assert.NoError(t, err)
+
+	expectedReq := model.GetPurchaseListRequest{
+		Filter: model.PurchaseFilter{WagerID: 3, Buyer: "alice", BoughtFrom: 100, BoughtTo: 200},
+		Page:   2,
+		Limit:  5,
+	}
+	purchases := []model.Purchase{{PurchaseID: 6, WagerID: 3, Buyer: "alice", BuyingPrice: 10, BoughtAt: 150}}
+	mockHandler.mockPurchaseService.EXPECT().GetPurchaseList(expectedReq).Return(&model.GetPurchaseListResponse{Purchases: purchases}, nil)
+	mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), purchases, http.StatusOK)
+	httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func Test_HandleGetPurchase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleGetPurchase)
+
+	newRequest := func(purchaseId string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, "/purchases/"+purchaseId, nil)
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"purchase_id": purchaseId})
+	}
+
+	t.Run("Invalid purchase id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse purchase id"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("a"))
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockHandler.mockPurchaseService.EXPECT().GetPurchase(uint(2)).Return(nil, repository.ErrNotFound)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "id not found"}, http.StatusNotFound)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("2"))
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		mockHandler.mockPurchaseService.EXPECT().GetPurchase(uint(3)).Return(nil, errors.New("custom error"))
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "custom error"}, http.StatusInternalServerError)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("3"))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		purchase := &model.Purchase{PurchaseID: 1, WagerID: 1, BuyingPrice: 10}
+		mockHandler.mockPurchaseService.EXPECT().GetPurchase(uint(1)).Return(purchase, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), purchase, http.StatusOK)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1"))
+	})
+}
+
+func Test_HandleRefundPurchase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleRefundPurchase)
+
+	newRequest := func(purchaseId string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "/purchases/"+purchaseId+"/refund", nil)
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"purchase_id": purchaseId})
+	}
+
+	t.Run("Invalid purchase id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse purchase id"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("a"))
+	})
+
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{err: repository.ErrNotFound, status: http.StatusNotFound},
+		{err: service.ErrAlreadyRefunded, status: http.StatusBadRequest},
+		{err: service.ErrRefundWindowExpired, status: http.StatusBadRequest},
+		{err: service.ErrWagerNotOpen, status: http.StatusBadRequest},
+		{err: errors.New("custom error"), status: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			mockHandler.mockPurchaseService.EXPECT().RefundPurchase(uint(2)).Return(nil, test.err)
+			mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: test.err.Error()}, test.status)
+			httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("2"))
+		})
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		purchase := &model.Purchase{PurchaseID: 1, WagerID: 1, BuyingPrice: 10, RefundedAt: 1642486900}
+		mockHandler.mockPurchaseService.EXPECT().RefundPurchase(uint(1)).Return(purchase, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), purchase, http.StatusOK)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1"))
+	})
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+	"wager/service"
+	"wager/validator"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) HandleGetPositions(w http.ResponseWriter, r *http.Request) {
+	reqPage, reqLimit, ok := h.parsePaging(w, r)
+	if !ok {
+		return
+	}
+
+	filter := model.PositionFilter{Holder: r.URL.Query().Get("holder")}
+	if wagerId, ok := r.URL.Query()["wager_id"]; ok {
+		num, err := strconv.ParseUint(wagerId[0], 10, 0)
+		if err != nil {
+			h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+			return
+		}
+		filter.WagerID = uint(num)
+	}
+
+	req := model.GetPositionListRequest{Filter: filter, Page: reqPage, Limit: reqLimit}
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	positions, err := h.resaleService.GetPositionList(req)
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, positions, http.StatusOK)
+}
+
+func (h *Handler) HandleCreateListing(w http.ResponseWriter, r *http.Request) {
+	positionId, err := strconv.Atoi(mux.Vars(r)["position_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse position id"}, http.StatusBadRequest)
+		return
+	}
+
+	req := model.CreateListingRequest{}
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+	req.PositionID = uint(positionId)
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	listing, err := h.resaleService.CreateListing(req)
+	if err != nil {
+		h.replyResaleError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, listing, http.StatusCreated)
+}
+
+func (h *Handler) HandleGetListings(w http.ResponseWriter, r *http.Request) {
+	wagerId, err := strconv.Atoi(mux.Vars(r)["wager_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		return
+	}
+	reqPage, reqLimit, ok := h.parsePaging(w, r)
+	if !ok {
+		return
+	}
+
+	req := model.GetListingListRequest{WagerID: uint(wagerId), Page: reqPage, Limit: reqLimit}
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	listings, err := h.resaleService.GetListingList(req)
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, listings, http.StatusOK)
+}
+
+func (h *Handler) HandleBuyListing(w http.ResponseWriter, r *http.Request) {
+	listingId, ok := h.parseListingID(w, r)
+	if !ok {
+		return
+	}
+
+	req := model.BuyListingRequest{}
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+	req.ListingID = listingId
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := h.resaleService.BuyListing(req)
+	if err != nil {
+		h.replyResaleError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, transfer, http.StatusCreated)
+}
+
+func (h *Handler) HandleCancelListing(w http.ResponseWriter, r *http.Request) {
+	listingId, ok := h.parseListingID(w, r)
+	if !ok {
+		return
+	}
+
+	req := model.CancelListingRequest{}
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+	req.ListingID = listingId
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	listing, err := h.resaleService.CancelListing(req)
+	if err != nil {
+		h.replyResaleError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, listing, http.StatusOK)
+}
+
+func (h *Handler) HandleGetTransfers(w http.ResponseWriter, r *http.Request) {
+	purchaseId, err := strconv.Atoi(mux.Vars(r)["purchase_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse purchase id"}, http.StatusBadRequest)
+		return
+	}
+
+	transfers, err := h.resaleService.GetTransfers(uint(purchaseId))
+	if err != nil {
+		h.replyResaleError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, transfers, http.StatusOK)
+}
+
+func (h *Handler) parseListingID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	listingId, err := strconv.Atoi(mux.Vars(r)["listing_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse listing id"}, http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(listingId), true
+}
+
+func (h *Handler) replyResaleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusNotFound)
+	case errors.Is(err, service.ErrNotHolder):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusForbidden)
+	case errors.Is(err, service.ErrListingTooLarge), errors.Is(err, service.ErrListingNotOpen), errors.Is(err, service.ErrBuyerIsSeller), errors.Is(err, service.ErrWagerNotOpen),
+		errors.Is(err, service.ErrCurrencyMismatch), errors.Is(err, service.ErrInvalidAmount):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+	default:
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusInternalServerError)
+	}
+}
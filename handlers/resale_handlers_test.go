@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+	"wager/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleGetPositions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleGetPositions)
+
+	t.Run("Invalid wager id", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/positions?wager_id=a", nil)
+		assert.NoError(t, err)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/positions?holder=alice&wager_id=2&page=1&limit=5", nil)
+		assert.NoError(t, err)
+		positions := []model.Position{{ID: 1, WagerID: 2, Holder: "alice", FaceValue: 10}}
+		request := model.GetPositionListRequest{Filter: model.PositionFilter{WagerID: 2, Holder: "alice"}, Page: 1, Limit: 5}
+		mockHandler.mockResaleService.EXPECT().GetPositionList(request).Return(positions, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), positions, http.StatusOK)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
+
+func Test_HandleCreateListing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleCreateListing)
+
+	newRequest := func(positionId string, body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, "/positions/"+positionId+"/listings", bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"position_id": positionId})
+	}
+
+	t.Run("Invalid position id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse position id"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("a", map[string]interface{}{"seller": "alice", "face_value": 10, "price": 12}))
+	})
+
+	t.Run("Missing seller", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: []string{"Seller is required"}}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", map[string]interface{}{"face_value": 10, "price": 12}))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			err    error
+			status int
+		}{
+			{err: repository.ErrNotFound, status: http.StatusNotFound},
+			{err: service.ErrNotHolder, status: http.StatusForbidden},
+			{err: service.ErrListingTooLarge, status: http.StatusBadRequest},
+			{err: errors.New("custom error"), status: http.StatusInternalServerError},
+		}
+		req := model.CreateListingRequest{PositionID: 1, Seller: "alice", FaceValue: 10, Price: 12}
+		for _, test := range tests {
+			mockHandler.mockResaleService.EXPECT().CreateListing(req).Return(nil, test.err)
+			mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: test.err.Error()}, test.status)
+			httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", map[string]interface{}{"seller": "alice", "face_value": 10, "price": 12}))
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req := model.CreateListingRequest{PositionID: 1, Seller: "alice", FaceValue: 10, Price: 12}
+		listing := &model.Listing{ID: 3, PositionID: 1, Seller: "alice", FaceValue: 10, Price: 12, Status: model.LISTING_STATUS_OPEN}
+		mockHandler.mockResaleService.EXPECT().CreateListing(req).Return(listing, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), listing, http.StatusCreated)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", map[string]interface{}{"seller": "alice", "face_value": 10, "price": 12}))
+	})
+}
+
+func Test_HandleListingActions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+
+	newRequest := func(listingId string, body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, "/listings/"+listingId, bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"listing_id": listingId})
+	}
+
+	t.Run("Invalid listing id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse listing id"}, http.StatusBadRequest)
+		http.HandlerFunc(handler.HandleBuyListing).ServeHTTP(httptest.NewRecorder(), newRequest("a", map[string]string{"buyer": "bob"}))
+	})
+
+	t.Run("Buy", func(t *testing.T) {
+		transfer := &model.Transfer{ID: 1, ListingID: 3, Seller: "alice", Buyer: "bob", FaceValue: 10, Price: 12}
+		mockHandler.mockResaleService.EXPECT().BuyListing(model.BuyListingRequest{ListingID: 3, Buyer: "bob"}).Return(transfer, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), transfer, http.StatusCreated)
+		http.HandlerFunc(handler.HandleBuyListing).ServeHTTP(httptest.NewRecorder(), newRequest("3", map[string]string{"buyer": "bob"}))
+	})
+
+	t.Run("Buy own listing", func(t *testing.T) {
+		mockHandler.mockResaleService.EXPECT().BuyListing(model.BuyListingRequest{ListingID: 3, Buyer: "alice"}).Return(nil, service.ErrBuyerIsSeller)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: service.ErrBuyerIsSeller.Error()}, http.StatusBadRequest)
+		http.HandlerFunc(handler.HandleBuyListing).ServeHTTP(httptest.NewRecorder(), newRequest("3", map[string]string{"buyer": "alice"}))
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		listing := &model.Listing{ID: 3, Status: model.LISTING_STATUS_CANCELLED}
+		mockHandler.mockResaleService.EXPECT().CancelListing(model.CancelListingRequest{ListingID: 3, Seller: "alice"}).Return(listing, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), listing, http.StatusOK)
+		http.HandlerFunc(handler.HandleCancelListing).ServeHTTP(httptest.NewRecorder(), newRequest("3", map[string]string{"seller": "alice"}))
+	})
+
+	t.Run("Cancel without seller", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), gomock.Any(), http.StatusBadRequest)
+		http.HandlerFunc(handler.HandleCancelListing).ServeHTTP(httptest.NewRecorder(), newRequest("3", map[string]string{}))
+	})
+
+	t.Run("Cancel listing of another holder", func(t *testing.T) {
+		mockHandler.mockResaleService.EXPECT().CancelListing(model.CancelListingRequest{ListingID: 3, Seller: "bob"}).Return(nil, service.ErrNotHolder)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: service.ErrNotHolder.Error()}, http.StatusForbidden)
+		http.HandlerFunc(handler.HandleCancelListing).ServeHTTP(httptest.NewRecorder(), newRequest("3", map[string]string{"seller": "bob"}))
+	})
+
+	t.Run("Cancel sold listing", func(t *testing.T) {
+		mockHandler.mockResaleService.EXPECT().CancelListing(model.CancelListingRequest{ListingID: 4, Seller: "alice"}).Return(nil, service.ErrListingNotOpen)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: service.ErrListingNotOpen.Error()}, http.StatusBadRequest)
+		http.HandlerFunc(handler.HandleCancelListing).ServeHTTP(httptest.NewRecorder(), newRequest("4", map[string]string{"seller": "alice"}))
+	})
+}
+
+func Test_HandleGetTransfers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleGetTransfers)
+
+	newRequest := func(purchaseId string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, "/purchases/"+purchaseId+"/transfers", nil)
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"purchase_id": purchaseId})
+	}
+
+	t.Run("Not found", func(t *testing.T) {
+		mockHandler.mockResaleService.EXPECT().GetTransfers(uint(9)).Return(nil, repository.ErrNotFound)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: repository.ErrNotFound.Error()}, http.StatusNotFound)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("9"))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		transfers := []model.Transfer{{ID: 1, PurchaseID: 2, Seller: "alice", Buyer: "bob"}}
+		mockHandler.mockResaleService.EXPECT().GetTransfers(uint(2)).Return(transfers, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), transfers, http.StatusOK)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("2"))
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+	"wager/service"
+	"wager/validator"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) HandleReserveWager(w http.ResponseWriter, r *http.Request) {
+	wagerId, err := strconv.Atoi(mux.Vars(r)["wager_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logrus.WithError(err).Error("failed to read request body")
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to read request body"}, http.StatusBadRequest)
+		return
+	}
+
+	req := model.ReserveWagerRequest{}
+	if err := json.Unmarshal(data, &req); err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to unmarshal request body"}, http.StatusBadRequest)
+		return
+	}
+	req.WagerID = uint(wagerId)
+
+	if err := validator.Validate(req); err != nil {
+		h.httpUtils.ReplyJSON(w, validator.ErrorMsg(err), http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := h.reservationService.ReserveWager(req)
+	if err != nil {
+		h.replyReservationError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, reservation, http.StatusCreated)
+}
+
+func (h *Handler) HandleConfirmReservation(w http.ResponseWriter, r *http.Request) {
+	reservationId, ok := h.parseReservationID(w, r)
+	if !ok {
+		return
+	}
+
+	purchase, err := h.reservationService.ConfirmReservation(reservationId)
+	if err != nil {
+		h.replyReservationError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, purchase, http.StatusCreated)
+}
+
+func (h *Handler) HandleReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	reservationId, ok := h.parseReservationID(w, r)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.reservationService.ReleaseReservation(reservationId)
+	if err != nil {
+		h.replyReservationError(w, err)
+		return
+	}
+
+	h.httpUtils.ReplyJSON(w, reservation, http.StatusOK)
+}
+
+func (h *Handler) parseReservationID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	reservationId, err := strconv.Atoi(mux.Vars(r)["reservation_id"])
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse reservation id"}, http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(reservationId), true
+}
+
+func (h *Handler) replyReservationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusNotFound)
+	case errors.Is(err, service.ErrBuyerLimitExceeded):
+		h.replyBuyerLimitError(w, err)
+	case errors.Is(err, service.ErrBuyingPriceTooHigh), errors.Is(err, service.ErrInvalidPurchaseSize), errors.Is(err, service.ErrReservationNotHeld), errors.Is(err, service.ErrReservationExpired), errors.Is(err, service.ErrTTLTooLong), errors.Is(err, service.ErrBuyerRequired),
+		errors.Is(err, service.ErrCurrencyMismatch), errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrWagerNotOpen):
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+	default:
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: err.Error()}, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+	"wager/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HandleReserveWager(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	httpHandler := http.HandlerFunc(handler.HandleReserveWager)
+
+	newRequest := func(wagerId string, body interface{}) *http.Request {
+		bodyJson, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, "/wagers/"+wagerId+"/reservations", bytes.NewReader(bodyJson))
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"wager_id": wagerId})
+	}
+
+	t.Run("Invalid wager id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse wager id"}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("a", map[string]float64{"buying_price": 1}))
+	})
+
+	t.Run("Invalid buying price", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: []string{"BuyingPrice must be larger than 0"}}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", map[string]float64{"buying_price": 0}))
+	})
+
+	t.Run("Not enough unreserved capacity", func(t *testing.T) {
+		req := model.ReserveWagerRequest{WagerID: 1, BuyingPrice: 1000}
+		mockHandler.mockReservationService.EXPECT().ReserveWager(req).Return(nil, service.ErrBuyingPriceTooHigh)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: service.ErrBuyingPriceTooHigh.Error()}, http.StatusBadRequest)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", map[string]float64{"buying_price": 1000}))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req := model.ReserveWagerRequest{WagerID: 1, Buyer: "alice", BuyingPrice: 10, TTLSeconds: 60}
+		reservation := &model.Reservation{ID: 1, WagerID: 1, Buyer: "alice", BuyingPrice: 10, Status: model.RESERVATION_STATUS_HELD}
+		mockHandler.mockReservationService.EXPECT().ReserveWager(req).Return(reservation, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), reservation, http.StatusCreated)
+		httpHandler.ServeHTTP(httptest.NewRecorder(), newRequest("1", map[string]interface{}{"buyer": "alice", "buying_price": 10, "ttl_seconds": 60}))
+	})
+}
+
+func Test_HandleConfirmAndReleaseReservation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	confirm := http.HandlerFunc(handler.HandleConfirmReservation)
+	release := http.HandlerFunc(handler.HandleReleaseReservation)
+
+	newRequest := func(reservationId string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "/reservations/"+reservationId, nil)
+		assert.NoError(t, err)
+		return mux.SetURLVars(req, map[string]string{"reservation_id": reservationId})
+	}
+
+	t.Run("Invalid reservation id", func(t *testing.T) {
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: "failed to parse reservation id"}, http.StatusBadRequest).Times(2)
+		confirm.ServeHTTP(httptest.NewRecorder(), newRequest("a"))
+		release.ServeHTTP(httptest.NewRecorder(), newRequest("a"))
+	})
+
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{err: repository.ErrNotFound, status: http.StatusNotFound},
+		{err: service.ErrReservationNotHeld, status: http.StatusBadRequest},
+		{err: service.ErrReservationExpired, status: http.StatusBadRequest},
+		{err: errors.New("custom error"), status: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			mockHandler.mockReservationService.EXPECT().ConfirmReservation(uint(2)).Return(nil, test.err)
+			mockHandler.mockReservationService.EXPECT().ReleaseReservation(uint(2)).Return(nil, test.err)
+			mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: test.err.Error()}, test.status).Times(2)
+			confirm.ServeHTTP(httptest.NewRecorder(), newRequest("2"))
+			release.ServeHTTP(httptest.NewRecorder(), newRequest("2"))
+		})
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		purchase := &model.Purchase{PurchaseID: 3, WagerID: 1, BuyingPrice: 10}
+		mockHandler.mockReservationService.EXPECT().ConfirmReservation(uint(1)).Return(purchase, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), purchase, http.StatusCreated)
+		confirm.ServeHTTP(httptest.NewRecorder(), newRequest("1"))
+
+		reservation := &model.Reservation{ID: 4, WagerID: 1, Status: model.RESERVATION_STATUS_RELEASED}
+		mockHandler.mockReservationService.EXPECT().ReleaseReservation(uint(4)).Return(reservation, nil)
+		mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), reservation, http.StatusOK)
+		release.ServeHTTP(httptest.NewRecorder(), newRequest("4"))
+	})
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"wager/conf"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/service"
+	"wager/stream"
+	"wager/validator"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+var upgrader = websocket.Upgrader{}
+
+// HandleSocket upgrades the request to a WebSocket on which the client subscribes to
+// wager events and buys or quotes wagers, see model.SocketRequest
+func (h *Handler) HandleSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already replied to the client
+		logrus.WithError(err).Debug("failed to upgrade to websocket")
+		return
+	}
+
+	client := newSocketClient(h, conn, h.socketConfig)
+	go client.writeLoop()
+	client.readLoop()
+}
+
+// socketClient is a connection of /ws. The read loop handles the messages of the
+// client one at a time, everything sent to the client goes through the bounded send
+// queue of the write loop. A client which does not read its acks is not read from
+// either, and one which does not keep up with its events is disconnected.
+type socketClient struct {
+	handler *Handler
+	conn    *websocket.Conn
+	config  conf.SocketConfig
+	limiter *rate.Limiter
+	// wagerService is the session of the connection, created on its first buy or
+	// quote, so that a quote following a buy reads it from the primary
+	wagerService service.WagerService
+	send         chan model.SocketMessage
+	// done is closed when the connection is closing
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu sync.Mutex
+	// subscription follows wagerIDs, or every wager when wagerIDs is empty, it is
+	// nil while the client is not subscribed
+	subscription *stream.Subscription
+	wagerIDs     map[uint]bool
+}
+
+func newSocketClient(handler *Handler, conn *websocket.Conn, config conf.SocketConfig) *socketClient {
+	return &socketClient{
+		handler: handler,
+		conn:    conn,
+		config:  config,
+		limiter: rate.NewLimiter(rate.Limit(config.MessageRate), config.MessageBurst),
+		send:    make(chan model.SocketMessage, config.SendBufferSize),
+		done:    make(chan struct{}),
+	}
+}
+
+func (c *socketClient) readLoop() {
+	defer func() {
+		c.mu.Lock()
+		c.closeSubscription()
+		c.mu.Unlock()
+		c.close(websocket.CloseNormalClosure, "")
+	}()
+
+	c.conn.SetReadLimit(c.config.MaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
+	})
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logrus.WithError(err).Debug("websocket closed")
+			}
+			return
+		}
+
+		// messages over the limit are not even decoded, so their error has no id
+		if !c.limiter.Allow() {
+			c.replyError("", errorcode.ErrorResponse{Error: "too many messages", Code: errorcode.RATE_LIMITED})
+			continue
+		}
+		req := model.SocketRequest{}
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.replyError("", errorcode.ErrorResponse{Error: "failed to unmarshal message"})
+			continue
+		}
+		if err := validator.Validate(req); err != nil {
+			c.replyError(req.ID, validator.ErrorMsg(err))
+			continue
+		}
+		c.handle(req)
+	}
+}
+
+// writeLoop writes the send queue and pings the client until the connection closes
+func (c *socketClient) writeLoop() {
+	ping := time.NewTicker(c.config.PingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case message := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteWait))
+			if err := c.conn.WriteJSON(message); err != nil {
+				logrus.WithError(err).Debug("failed to write websocket message")
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.config.WriteWait)); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		}
+	}
+}
+
+func (c *socketClient) handle(req model.SocketRequest) {
+	switch req.Type {
+	case model.SOCKET_SUBSCRIBE:
+		c.handleSubscribe(req)
+	case model.SOCKET_UNSUBSCRIBE:
+		c.handleUnsubscribe(req)
+	case model.SOCKET_BUY:
+		c.handleBuy(req, func(buy model.BuyWagerRequest) (interface{}, error) {
+			return c.session().BuyWager(buy)
+		})
+	case model.SOCKET_QUOTE:
+		c.handleBuy(req, func(buy model.BuyWagerRequest) (interface{}, error) {
+			return c.session().QuoteWager(buy)
+		})
+	}
+}
+
+// session is only called from the read loop
+func (c *socketClient) session() service.WagerService {
+	if c.wagerService == nil {
+		c.wagerService = service.Session(c.handler.wagerService)
+	}
+	return c.wagerService
+}
+
+// handleBuy validates the purchase of req and acks what run returns for it
+func (c *socketClient) handleBuy(req model.SocketRequest, run func(model.BuyWagerRequest) (interface{}, error)) {
+	buy := req.BuyWagerRequest()
+	if err := validator.Validate(buy); err != nil {
+		c.replyError(req.ID, validator.ErrorMsg(err))
+		return
+	}
+
+	res, err := run(buy)
+	if errors.Is(err, service.ErrBuyerLimitExceeded) {
+		c.replyError(req.ID, errorcode.ErrorResponse{Error: err.Error(), Code: errorcode.BUYER_LIMIT_EXCEEDED})
+		return
+	}
+	if err != nil {
+		c.replyError(req.ID, errorcode.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.enqueue(model.SocketMessage{Type: model.SOCKET_ACK, ID: req.ID, Data: res})
+}
+
+// handleSubscribe adds the wagers of req to the subscription, a subscription to every
+// wager stays one. LastEventID only matters to a new subscription.
+func (c *socketClient) handleSubscribe(req model.SocketRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wagerIDs := map[uint]bool{}
+	if len(req.WagerIDs) > 0 && (c.subscription == nil || len(c.wagerIDs) > 0) {
+		for id := range c.wagerIDs {
+			wagerIDs[id] = true
+		}
+		for _, id := range req.WagerIDs {
+			wagerIDs[id] = true
+		}
+	}
+	if len(wagerIDs) > MAX_STREAM_WAGERS {
+		c.replyError(req.ID, errorcode.ErrorResponse{Error: fmt.Sprintf("at most %v wager ids can be streamed", MAX_STREAM_WAGERS)})
+		return
+	}
+
+	if c.subscription != nil {
+		c.wagerIDs = wagerIDs
+		c.subscription.SetWagerIDs(sortedIDs(wagerIDs))
+		c.ackSubscription(req.ID)
+		return
+	}
+	// the ack goes before the events the subscription replays
+	subscription, err := c.handler.broker.Subscribe(sortedIDs(wagerIDs), req.LastEventID)
+	if err != nil {
+		c.replyError(req.ID, errorcode.ErrorResponse{Error: "failed to parse last event id"})
+		return
+	}
+	c.wagerIDs = wagerIDs
+	c.subscription = subscription
+	c.ackSubscription(req.ID)
+	go c.forward(c.subscription)
+}
+
+// handleUnsubscribe removes the wagers of req from the subscription, or ends it when
+// req has none or none are left
+func (c *socketClient) handleUnsubscribe(req model.SocketRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.subscription == nil || len(req.WagerIDs) == 0 {
+		c.closeSubscription()
+		c.ackSubscription(req.ID)
+		return
+	}
+	if len(c.wagerIDs) == 0 {
+		c.replyError(req.ID, errorcode.ErrorResponse{Error: "wagers can not be unsubscribed from a subscription to every wager"})
+		return
+	}
+	for _, id := range req.WagerIDs {
+		delete(c.wagerIDs, id)
+	}
+	if len(c.wagerIDs) == 0 {
+		c.closeSubscription()
+	} else {
+		c.subscription.SetWagerIDs(sortedIDs(c.wagerIDs))
+	}
+	c.ackSubscription(req.ID)
+}
+
+// closeSubscription ends the subscription if there is one, c.mu must be held
+func (c *socketClient) closeSubscription() {
+	if c.subscription != nil {
+		c.subscription.Close()
+		c.subscription = nil
+		c.wagerIDs = nil
+	}
+}
+
+// ackSubscription acks the message id with the current subscription, c.mu must be
+// held
+func (c *socketClient) ackSubscription(id string) {
+	ack := model.SocketSubscription{Subscribed: c.subscription != nil, WagerIDs: sortedIDs(c.wagerIDs)}
+	c.enqueue(model.SocketMessage{Type: model.SOCKET_ACK, ID: id, Data: ack})
+}
+
+// forward sends the events of subscription to the client. While the client reads
+// slower than events are published they wait in the subscription, the broker closes
+// it once its buffer is full and the client is disconnected.
+func (c *socketClient) forward(subscription *stream.Subscription) {
+	for event := range subscription.Events() {
+		event := event
+		if !c.enqueue(model.SocketMessage{Type: model.SOCKET_EVENT, Event: &event}) {
+			return
+		}
+	}
+
+	c.mu.Lock()
+	dropped := c.subscription == subscription
+	c.mu.Unlock()
+	if dropped {
+		c.closeSlow()
+	}
+}
+
+func (c *socketClient) replyError(id string, res errorcode.ErrorResponse) {
+	c.enqueue(model.SocketMessage{Type: model.SOCKET_ERROR, ID: id, Error: res.Error, Code: res.Code})
+}
+
+// enqueue waits until message is queued, it reports false when the connection
+// closed first
+func (c *socketClient) enqueue(message model.SocketMessage) bool {
+	select {
+	case c.send <- message:
+		return true
+	case <-c.done:
+		return false
+	}
+}
+
+func (c *socketClient) closeSlow() {
+	logrus.WithField("remote_address", c.conn.RemoteAddr().String()).Warn("Closed slow websocket client")
+	c.close(websocket.CloseTryAgainLater, "too slow, reconnect and resubscribe with last_event_id")
+}
+
+// close tells the client why the connection closes, when it is still listening, and
+// closes it. The read loop then ends and with it the subscription.
+func (c *socketClient) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if code != websocket.CloseAbnormalClosure {
+			message := websocket.FormatCloseMessage(code, reason)
+			c.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(c.config.WriteWait))
+		}
+		c.conn.Close()
+	})
+}
+
+func sortedIDs(set map[uint]bool) []uint {
+	ids := make([]uint, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	errorcode "wager/error_code"
+	"wager/mocks"
+	"wager/model"
+	"wager/service"
+	"wager/stream"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// socketReply is a model.SocketMessage as the client reads it
+type socketReply struct {
+	Type  string            `json:"type"`
+	ID    string            `json:"id"`
+	Data  json.RawMessage   `json:"data"`
+	Event *model.WagerEvent `json:"event"`
+	Error interface{}       `json:"error"`
+	Code  string            `json:"code"`
+}
+
+// dialSocket serves handler.HandleSocket and connects to it
+func dialSocket(t *testing.T, handler *Handler) *websocket.Conn {
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleSocket))
+	t.Cleanup(server.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readReply(t *testing.T, conn *websocket.Conn) socketReply {
+	reply := socketReply{}
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	require.NoError(t, conn.ReadJSON(&reply))
+	return reply
+}
+
+func readSubscription(t *testing.T, conn *websocket.Conn, id string) model.SocketSubscription {
+	reply := readReply(t, conn)
+	require.Equal(t, model.SOCKET_ACK, reply.Type, reply.Error)
+	assert.Equal(t, id, reply.ID)
+	subscription := model.SocketSubscription{}
+	require.NoError(t, json.Unmarshal(reply.Data, &subscription))
+	return subscription
+}
+
+// assertClosed checks that a read failed because the server closed the connection,
+// which the client may also learn from answering a ping
+func assertClosed(t *testing.T, err error) {
+	netErr := net.Error(nil)
+	if assert.Error(t, err) && errors.As(err, &netErr) {
+		assert.False(t, netErr.Timeout(), "the connection was not closed")
+	}
+}
+
+func Test_HandleSocket_Subscribe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, _ := NewMockHandler(ctrl)
+	broker := stream.NewBroker(10, 10)
+	handler.broker = broker
+	conn := dialSocket(t, handler)
+
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "1", Type: model.SOCKET_SUBSCRIBE, WagerIDs: []uint{1}}))
+	assert.Equal(t, model.SocketSubscription{Subscribed: true, WagerIDs: []uint{1}}, readSubscription(t, conn, "1"))
+	ids := publish(t, broker,
+		model.WagerEvent{Type: model.WAGER_EVENT_PURCHASE, Wager: &model.Wager{ID: 2}},
+		model.WagerEvent{Type: model.WAGER_EVENT_PURCHASE, Wager: &model.Wager{ID: 1, CurrentSellingPrice: 75, Version: 1}},
+	)
+	reply := readReply(t, conn)
+	assert.Equal(t, model.SOCKET_EVENT, reply.Type)
+	assert.Equal(t, ids[1], reply.Event.ID)
+	assert.Equal(t, float64(75), reply.Event.Wager.CurrentSellingPrice)
+
+	// subscribing adds wagers, unsubscribing removes them
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "2", Type: model.SOCKET_SUBSCRIBE, WagerIDs: []uint{3}}))
+	assert.Equal(t, model.SocketSubscription{Subscribed: true, WagerIDs: []uint{1, 3}}, readSubscription(t, conn, "2"))
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "3", Type: model.SOCKET_UNSUBSCRIBE, WagerIDs: []uint{1}}))
+	assert.Equal(t, model.SocketSubscription{Subscribed: true, WagerIDs: []uint{3}}, readSubscription(t, conn, "3"))
+	ids = append(ids, publish(t, broker,
+		model.WagerEvent{Type: model.WAGER_EVENT_PURCHASE, Wager: &model.Wager{ID: 1, Version: 2}},
+		model.WagerEvent{Type: model.WAGER_EVENT_CREATED, Wager: &model.Wager{ID: 3}},
+	)...)
+	reply = readReply(t, conn)
+	assert.Equal(t, ids[3], reply.Event.ID)
+	assert.Equal(t, uint(3), reply.Event.Wager.ID)
+
+	// a subscription to every wager can only be ended
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "4", Type: model.SOCKET_SUBSCRIBE}))
+	assert.Equal(t, model.SocketSubscription{Subscribed: true, WagerIDs: []uint{}}, readSubscription(t, conn, "4"))
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "5", Type: model.SOCKET_UNSUBSCRIBE, WagerIDs: []uint{3}}))
+	reply = readReply(t, conn)
+	assert.Equal(t, socketReply{Type: model.SOCKET_ERROR, ID: "5", Error: "wagers can not be unsubscribed from a subscription to every wager"}, reply)
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "6", Type: model.SOCKET_UNSUBSCRIBE}))
+	assert.Equal(t, model.SocketSubscription{Subscribed: false, WagerIDs: []uint{}}, readSubscription(t, conn, "6"))
+
+	// a new subscription resumes after last_event_id
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "7", Type: model.SOCKET_SUBSCRIBE, LastEventID: ids[1]}))
+	readSubscription(t, conn, "7")
+	assert.Equal(t, ids[2], readReply(t, conn).Event.ID)
+	assert.Equal(t, ids[3], readReply(t, conn).Event.ID)
+
+	// an id which the broker did not give out is refused
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "8", Type: model.SOCKET_UNSUBSCRIBE}))
+	readSubscription(t, conn, "8")
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "9", Type: model.SOCKET_SUBSCRIBE, LastEventID: "2"}))
+	assert.Equal(t, socketReply{Type: model.SOCKET_ERROR, ID: "9", Error: "failed to parse last event id"}, readReply(t, conn))
+}
+
+// sessionWagerService counts the sessions started on a WagerService
+type sessionWagerService struct {
+	service.WagerService
+	session service.WagerService
+	count   int32
+}
+
+func (s *sessionWagerService) Session() service.WagerService {
+	atomic.AddInt32(&s.count, 1)
+	return s.session
+}
+
+func Test_HandleSocket_Buy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+
+	// the buys and quotes of a connection share one session
+	session := mocks.NewMockWagerService(ctrl)
+	sessions := &sessionWagerService{WagerService: mockHandler.mockWagerService, session: session}
+	handler.wagerService = sessions
+	conn := dialSocket(t, handler)
+
+	buy := model.BuyWagerRequest{WagerID: 1, Buyer: "bot", BuyingPrice: 10}
+	session.EXPECT().BuyWager(buy).Return(&model.Purchase{PurchaseID: 5, WagerID: 1, BuyingPrice: 10}, nil)
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "buy-1", Type: model.SOCKET_BUY, WagerID: 1, Buyer: "bot", BuyingPrice: 10}))
+	reply := readReply(t, conn)
+	assert.Equal(t, model.SOCKET_ACK, reply.Type)
+	assert.Equal(t, "buy-1", reply.ID)
+	purchase := model.Purchase{}
+	require.NoError(t, json.Unmarshal(reply.Data, &purchase))
+	assert.Equal(t, uint(5), purchase.PurchaseID)
+
+	session.EXPECT().QuoteWager(buy).Return(&model.Quote{WagerID: 1, TotalCost: 10.1}, nil)
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "quote-1", Type: model.SOCKET_QUOTE, WagerID: 1, Buyer: "bot", BuyingPrice: 10}))
+	reply = readReply(t, conn)
+	assert.Equal(t, "quote-1", reply.ID)
+	quote := model.Quote{}
+	require.NoError(t, json.Unmarshal(reply.Data, &quote))
+	assert.Equal(t, 10.1, quote.TotalCost)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sessions.count))
+
+	tests := []struct {
+		name    string
+		message string
+		err     error
+		reply   socketReply
+	}{
+		{
+			name:    "Buyer limit",
+			message: `{"id":"a","type":"buy","wager_id":1,"buyer":"bot","buying_price":10}`,
+			err:     service.ErrBuyerLimitExceeded,
+			reply:   socketReply{Type: model.SOCKET_ERROR, ID: "a", Error: "buyer limit exceeded", Code: errorcode.BUYER_LIMIT_EXCEEDED},
+		},
+		{
+			name:    "Failed purchase",
+			message: `{"id":"b","type":"buy","wager_id":1,"buyer":"bot","buying_price":10}`,
+			err:     service.ErrBuyingPriceTooHigh,
+			reply:   socketReply{Type: model.SOCKET_ERROR, ID: "b", Error: service.ErrBuyingPriceTooHigh.Error()},
+		},
+		{
+			name:    "Invalid purchase",
+			message: `{"id":"c","type":"quote","wager_id":1}`,
+			reply:   socketReply{Type: model.SOCKET_ERROR, ID: "c", Error: []interface{}{"BuyingPrice must be larger than 0"}},
+		},
+		{
+			name:    "Unknown type",
+			message: `{"id":"d","type":"sell"}`,
+			reply:   socketReply{Type: model.SOCKET_ERROR, ID: "d", Error: []interface{}{"Type must be one of subscribe unsubscribe buy quote"}},
+		},
+		{
+			name:    "Invalid message",
+			message: `{"id":`,
+			reply:   socketReply{Type: model.SOCKET_ERROR, Error: "failed to unmarshal message"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.err != nil {
+				session.EXPECT().BuyWager(buy).Return(nil, tc.err)
+			}
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(tc.message)))
+			assert.Equal(t, tc.reply, readReply(t, conn))
+		})
+	}
+}
+
+func Test_HandleSocket_RateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	handler.socketConfig.MessageRate = 0.001
+	handler.socketConfig.MessageBurst = 2
+	conn := dialSocket(t, handler)
+
+	mockHandler.mockWagerService.EXPECT().QuoteWager(gomock.Any()).Return(&model.Quote{}, nil).Times(1)
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "1", Type: model.SOCKET_QUOTE, WagerID: 1, BuyingPrice: 10}))
+	assert.Equal(t, model.SOCKET_ACK, readReply(t, conn).Type)
+
+	// malformed messages count against the limit too
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{")))
+	assert.Equal(t, socketReply{Type: model.SOCKET_ERROR, Error: "failed to unmarshal message"}, readReply(t, conn))
+	for _, message := range []string{"{", `{"id":"3","type":"quote","wager_id":1,"buying_price":10}`} {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(message)))
+		assert.Equal(t, socketReply{Type: model.SOCKET_ERROR, Error: "too many messages", Code: errorcode.RATE_LIMITED}, readReply(t, conn))
+	}
+}
+
+func Test_HandleSocket_Keepalive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, _ := NewMockHandler(ctrl)
+	handler.socketConfig.PingInterval = 20 * time.Millisecond
+	handler.socketConfig.PongWait = 60 * time.Millisecond
+
+	// reading answers the pings, the connection outlives the pong wait
+	conn := dialSocket(t, handler)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(200*time.Millisecond)))
+	_, _, err := conn.ReadMessage()
+	netErr := net.Error(nil)
+	require.True(t, errors.As(err, &netErr), err)
+	assert.True(t, netErr.Timeout())
+
+	// a client which does not answer is closed
+	conn = dialSocket(t, handler)
+	time.Sleep(200 * time.Millisecond)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, _, err = conn.ReadMessage()
+	assertClosed(t, err)
+}
+
+func Test_HandleSocket_SlowConsumer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, _ := NewMockHandler(ctrl)
+	broker := stream.NewBroker(10, 4)
+	handler.broker = broker
+	handler.socketConfig.SendBufferSize = 1
+	handler.socketConfig.WriteWait = 100 * time.Millisecond
+	conn := dialSocket(t, handler)
+
+	require.NoError(t, conn.WriteJSON(model.SocketRequest{ID: "1", Type: model.SOCKET_SUBSCRIBE}))
+	readSubscription(t, conn, "1")
+
+	// the client stops reading while many large events are published
+	wagerIDs := make([]uint, 500)
+	for i := range wagerIDs {
+		wagerIDs[i] = uint(i + 1)
+	}
+	const published = 5000
+	for i := 0; i < published; i++ {
+		broker.Publish(model.WagerEvent{Type: model.WAGER_EVENT_SETTLEMENT, Market: &model.Market{ID: 1}, WagerIDs: wagerIDs})
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	received := 0
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for {
+		reply := socketReply{}
+		if err := conn.ReadJSON(&reply); err != nil {
+			assertClosed(t, err)
+			break
+		}
+		received++
+	}
+	assert.Less(t, received, published)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	errorcode "wager/error_code"
+	"wager/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// STREAM_HEARTBEAT is how often an idle stream sends a comment, so that proxies
+	// do not close it
+	STREAM_HEARTBEAT = 15 * time.Second
+	// MAX_STREAM_WAGERS bounds the wager ids a stream can be filtered by
+	MAX_STREAM_WAGERS = 100
+)
+
+// HandleStreamWagers streams wager events as Server-Sent Events, optionally only
+// those about the comma separated ?wager_ids. A client resuming with Last-Event-ID
+// first gets the events it missed which are still buffered.
+func (h *Handler) HandleStreamWagers(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "streaming is not supported"}, http.StatusInternalServerError)
+		return
+	}
+	wagerIDs, ok := h.parseWagerIDs(w, r)
+	if !ok {
+		return
+	}
+	subscription, err := h.broker.Subscribe(wagerIDs, r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse last event id"}, http.StatusBadRequest)
+		return
+	}
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-subscription.Events():
+			// a closed subscription fell behind, the client resumes by reconnecting
+			if !ok {
+				return
+			}
+			if err := writeServerSentEvent(w, event); err != nil {
+				logrus.WithError(err).Debug("failed to write wager event")
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeServerSentEvent writes event with its id, which reset events do not have,
+// and its type
+func writeServerSentEvent(w io.Writer, event model.WagerEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %v\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %v\ndata: %s\n\n", event.Type, data)
+	return err
+}
+
+// parseWagerIDs reads the comma separated wager_ids query parameter, it replies to
+// the client itself when they are invalid
+func (h *Handler) parseWagerIDs(w http.ResponseWriter, r *http.Request) ([]uint, bool) {
+	value := r.URL.Query().Get("wager_ids")
+	if value == "" {
+		return nil, true
+	}
+
+	items := strings.Split(value, ",")
+	if len(items) > MAX_STREAM_WAGERS {
+		h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: fmt.Sprintf("at most %v wager ids can be streamed", MAX_STREAM_WAGERS)}, http.StatusBadRequest)
+		return nil, false
+	}
+	wagerIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		num, err := strconv.ParseUint(strings.TrimSpace(item), 10, 0)
+		if err != nil {
+			h.httpUtils.ReplyJSON(w, errorcode.ErrorResponse{Error: "failed to parse wager ids"}, http.StatusBadRequest)
+			return nil, false
+		}
+		wagerIDs = append(wagerIDs, uint(num))
+	}
+	return wagerIDs, true
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/stream"
+	"wager/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_HandleStreamWagers_BadRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler, mockHandler := NewMockHandler(ctrl)
+	handler.broker = stream.NewBroker(10, 10)
+	httpHandler := http.HandlerFunc(handler.HandleStreamWagers)
+
+	tests := []struct {
+		name   string
+		query  string
+		header string
+		err    string
+	}{
+		{name: "Invalid wager ids", query: "wager_ids=1,a", err: "failed to parse wager ids"},
+		{name: "Too many wager ids", query: "wager_ids=" + strings.Repeat("1,", MAX_STREAM_WAGERS) + "1", err: "at most 100 wager ids can be streamed"},
+		{name: "Invalid last event id", header: "a", err: "failed to parse last event id"},
+		{name: "Last event id without an epoch", header: "1", err: "failed to parse last event id"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/wagers/stream?"+tc.query, nil)
+			require.NoError(t, err)
+			if tc.header != "" {
+				req.Header.Set("Last-Event-ID", tc.header)
+			}
+			mockHandler.mockHTTPUtils.EXPECT().ReplyJSON(gomock.Any(), errorcode.ErrorResponse{Error: tc.err}, http.StatusBadRequest)
+			httpHandler.ServeHTTP(httptest.NewRecorder(), req)
+		})
+	}
+}
+
+// publish publishes events on broker and returns the ids they were given
+func publish(t *testing.T, broker *stream.Broker, events ...model.WagerEvent) []string {
+	subscription, err := broker.Subscribe(nil, "")
+	require.NoError(t, err)
+	defer subscription.Close()
+	broker.Publish(events...)
+	ids := []string{}
+	for range events {
+		ids = append(ids, (<-subscription.Events()).ID)
+	}
+	return ids
+}
+
+func Test_HandleStreamWagers(t *testing.T) {
+	broker := stream.NewBroker(10, 10)
+	handler := &Handler{broker: broker, httpUtils: utils.NewHTTPUtils(), streamHeartbeat: 20 * time.Millisecond}
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleStreamWagers))
+	defer server.Close()
+
+	ids := publish(t, broker,
+		model.WagerEvent{Type: model.WAGER_EVENT_CREATED, Wager: &model.Wager{ID: 1}},
+		model.WagerEvent{Type: model.WAGER_EVENT_PURCHASE, Wager: &model.Wager{ID: 1, CurrentSellingPrice: 75, Version: 1}},
+	)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"?wager_ids=1", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", ids[0])
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	// next reads the next event, skipping heartbeats
+	next := func() (string, string, model.WagerEvent) {
+		var id, eventType string
+		event := model.WagerEvent{}
+		for {
+			select {
+			case line := <-lines:
+				switch {
+				case strings.HasPrefix(line, "id: "):
+					id = strings.TrimPrefix(line, "id: ")
+				case strings.HasPrefix(line, "event: "):
+					eventType = strings.TrimPrefix(line, "event: ")
+				case strings.HasPrefix(line, "data: "):
+					assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+				case line == "" && eventType != "":
+					return id, eventType, event
+				}
+			case <-time.After(time.Second):
+				t.Fatal("no event received")
+			}
+		}
+	}
+
+	// the missed purchase is replayed, then live events of wager 1 follow
+	id, eventType, event := next()
+	assert.Equal(t, ids[1], id)
+	assert.Equal(t, model.WAGER_EVENT_PURCHASE, eventType)
+	assert.Equal(t, float64(75), event.Wager.CurrentSellingPrice)
+
+	ids = publish(t, broker,
+		model.WagerEvent{Type: model.WAGER_EVENT_PURCHASE, Wager: &model.Wager{ID: 2}},
+		model.WagerEvent{Type: model.WAGER_EVENT_STATUS, Wager: &model.Wager{ID: 1, Status: model.WAGER_STATUS_WON, Version: 2}},
+	)
+	id, eventType, event = next()
+	assert.Equal(t, ids[1], id)
+	assert.Equal(t, model.WAGER_EVENT_STATUS, eventType)
+	assert.Equal(t, model.WAGER_STATUS_WON, event.Wager.Status)
+}
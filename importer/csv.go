@@ -0,0 +1,229 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"wager/model"
+	"wager/odds"
+	"wager/service"
+	"wager/validator"
+)
+
+const (
+	COLUMN_TOTAL_WAGER_VALUE  = "total_wager_value"
+	COLUMN_ODDS               = "odds"
+	COLUMN_SELLING_PERCENTAGE = "selling_percentage"
+	COLUMN_SELLING_PRICE      = "selling_price"
+
+	// optional columns, a missing column or an empty cell leaves the bound unset
+	COLUMN_MIN_PURCHASE       = "min_purchase"
+	COLUMN_MAX_PURCHASE       = "max_purchase"
+	COLUMN_PURCHASE_INCREMENT = "purchase_increment"
+	// COLUMN_ODDS_FORMAT is the format of the odds of the row, decimal when it is
+	// missing or empty
+	COLUMN_ODDS_FORMAT = "odds_format"
+	// COLUMN_CURRENCY is the ISO 4217 currency of the row, USD when it is missing or
+	// empty
+	COLUMN_CURRENCY = "currency"
+)
+
+// ERROR_BATCH_REJECTED is the error of the rows of a batch the service rejected
+// without blaming any of them
+const ERROR_BATCH_REJECTED = "batch rejected"
+
+// FailedRow is a CSV row which was not imported, Line is its line in the file
+type FailedRow struct {
+	Line  int
+	Error string
+}
+
+type Report struct {
+	Imported int
+	Failed   []FailedRow
+}
+
+// ImportWagers creates a wager for each row of a CSV file whose header names the
+// columns of a model.CreateWagerRequest. Rows are read one at a time and valid rows
+// are created batchSize at a time, so large files are never held in memory. Invalid
+// rows are reported and skipped, the rows of a batch the service rejected for some
+// of its rows are submitted again without them. An error is only returned when the
+// file cannot be read or the wagers cannot be stored.
+func ImportWagers(r io.Reader, wagerService service.WagerService, batchSize int) (*Report, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns, err := columnIndexes(header)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	batch := model.CreateWagersRequest{}
+	lines := []int{}
+	flush := func() error {
+		if len(batch.Wagers) == 0 {
+			return nil
+		}
+
+		for len(batch.Wagers) > 0 {
+			wagers, err := wagerService.CreateWagers(batch)
+			batchErr := &model.BatchError{}
+			if !errors.As(err, &batchErr) {
+				if err != nil {
+					return err
+				}
+				report.Imported += len(wagers)
+				break
+			}
+
+			// rows are validated before they are batched, but the service has the last
+			// word. It stored none of the batch, the other rows are submitted again.
+			failed := map[int]bool{}
+			for _, item := range batchErr.Items {
+				failed[item.Index] = true
+				report.Failed = append(report.Failed, FailedRow{Line: lines[item.Index], Error: item.Error})
+			}
+			if len(failed) == 0 {
+				for _, line := range lines {
+					report.Failed = append(report.Failed, FailedRow{Line: line, Error: ERROR_BATCH_REJECTED})
+				}
+				break
+			}
+			kept := 0
+			for i := range batch.Wagers {
+				if !failed[i] {
+					batch.Wagers[kept] = batch.Wagers[i]
+					lines[kept] = lines[i]
+					kept++
+				}
+			}
+			batch.Wagers = batch.Wagers[:kept]
+			lines = lines[:kept]
+		}
+
+		batch.Wagers = batch.Wagers[:0]
+		lines = lines[:0]
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		parseErr := &csv.ParseError{}
+		if errors.As(err, &parseErr) {
+			report.Failed = append(report.Failed, FailedRow{Line: parseErr.Line, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		req, err := parseWager(record, columns)
+		if err != nil {
+			report.Failed = append(report.Failed, FailedRow{Line: line, Error: err.Error()})
+			continue
+		}
+		if msgs := validator.CreateWagerErrors(*req); msgs != nil {
+			report.Failed = append(report.Failed, FailedRow{Line: line, Error: strings.Join(msgs, ", ")})
+			continue
+		}
+
+		batch.Wagers = append(batch.Wagers, *req)
+		lines = append(lines, line)
+		if len(batch.Wagers) == batchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// columnIndexes maps each column of a wager to its position in the header
+func columnIndexes(header []string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	for _, name := range []string{COLUMN_TOTAL_WAGER_VALUE, COLUMN_ODDS, COLUMN_SELLING_PERCENTAGE, COLUMN_SELLING_PRICE} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+	return columns, nil
+}
+
+func parseWager(record []string, columns map[string]int) (*model.CreateWagerRequest, error) {
+	parseUint := func(name string) (uint, error) {
+		value, err := strconv.ParseUint(record[columns[name]], 10, 0)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %v %q", name, record[columns[name]])
+		}
+		return uint(value), nil
+	}
+
+	totalWagerValue, err := parseUint(COLUMN_TOTAL_WAGER_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	sellingPercentage, err := parseUint(COLUMN_SELLING_PERCENTAGE)
+	if err != nil {
+		return nil, err
+	}
+	sellingPrice, err := strconv.ParseFloat(record[columns[COLUMN_SELLING_PRICE]], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v %q", COLUMN_SELLING_PRICE, record[columns[COLUMN_SELLING_PRICE]])
+	}
+
+	req := &model.CreateWagerRequest{
+		TotalWagerValue:   totalWagerValue,
+		Odds:              odds.Value(record[columns[COLUMN_ODDS]]),
+		SellingPercentage: sellingPercentage,
+		SellingPrice:      sellingPrice,
+	}
+	optional := []struct {
+		name  string
+		field *float64
+	}{
+		{COLUMN_MIN_PURCHASE, &req.MinPurchase},
+		{COLUMN_MAX_PURCHASE, &req.MaxPurchase},
+		{COLUMN_PURCHASE_INCREMENT, &req.PurchaseIncrement},
+	}
+	if i, ok := columns[COLUMN_ODDS_FORMAT]; ok {
+		req.OddsFormat = record[i]
+	}
+	if i, ok := columns[COLUMN_CURRENCY]; ok {
+		req.Currency = record[i]
+	}
+	for _, column := range optional {
+		name := column.name
+		i, ok := columns[name]
+		if !ok || record[i] == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(record[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v %q", name, record[i])
+		}
+		*column.field = value
+	}
+
+	return req, nil
+}
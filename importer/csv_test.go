@@ -0,0 +1,150 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"wager/conf"
+	"wager/mocks"
+	"wager/model"
+	"wager/odds"
+	"wager/repository"
+	"wager/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ImportWagers(t *testing.T) {
+	wagerService := service.NewWagerService(conf.GetDefaultConfig(), repository.NewMemoryStore(), nil)
+	file := strings.Join([]string{
+		"odds,total_wager_value,selling_percentage,selling_price",
+		"2,100,50,60",
+		"2,100,50,40",
+		"2,abc,50,60",
+		"3,200,10,30.5",
+		"2,100,50",
+		"4,300,20,70",
+		"",
+	}, "\n")
+
+	report, err := ImportWagers(strings.NewReader(file), wagerService, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Imported)
+	assert.Equal(t, []FailedRow{
+		{Line: 3, Error: "SellingPrice must be larger than TotalWagerValue * SellingPercentage"},
+		{Line: 4, Error: `invalid total_wager_value "abc"`},
+		{Line: 6, Error: "wrong number of fields"},
+	}, report.Failed)
+
+	list, err := wagerService.GetWagerList(model.GetWagerListRequest{Page: 1, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(list.Wagers))
+	assert.Equal(t, 3*odds.SCALE, list.Wagers[1].Odds)
+	assert.Equal(t, 30.5, list.Wagers[1].SellingPrice)
+}
+
+func Test_ImportWagers_PurchaseSize(t *testing.T) {
+	wagerService := service.NewWagerService(conf.GetDefaultConfig(), repository.NewMemoryStore(), nil)
+	file := strings.Join([]string{
+		"total_wager_value,odds,selling_percentage,selling_price,min_purchase,purchase_increment",
+		"100,2,50,60,5,",
+		"100,2,50,60,,x",
+		"100,2,50,60,70,1",
+		"",
+	}, "\n")
+
+	report, err := ImportWagers(strings.NewReader(file), wagerService, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, []FailedRow{
+		{Line: 3, Error: `invalid purchase_increment "x"`},
+		{Line: 4, Error: "MinPurchase must be less than or equal SellingPrice"},
+	}, report.Failed)
+
+	wager, err := wagerService.GetWager(1)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(5), wager.MinPurchase)
+	assert.Equal(t, float64(0), wager.PurchaseIncrement)
+}
+
+func Test_ImportWagers_Currency(t *testing.T) {
+	wagerService := service.NewWagerService(conf.GetDefaultConfig(), repository.NewMemoryStore(), nil)
+	file := strings.Join([]string{
+		"total_wager_value,odds,selling_percentage,selling_price,currency",
+		"100,2,50,60,",
+		"10000,2,50,6000,JPY",
+		"10000,2,50,6000.5,JPY",
+		"100,2,50,60,dollars",
+		"",
+	}, "\n")
+
+	report, err := ImportWagers(strings.NewReader(file), wagerService, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, []FailedRow{
+		{Line: 4, Error: "SellingPrice must have at most 0 decimal places in JPY"},
+		{Line: 5, Error: "Currency must be an ISO 4217 currency code like USD"},
+	}, report.Failed)
+
+	list, err := wagerService.GetWagerList(model.GetWagerListRequest{Page: 1, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", list.Wagers[0].Currency)
+	assert.Equal(t, "JPY", list.Wagers[1].Currency)
+}
+
+func Test_ImportWagers_MissingColumn(t *testing.T) {
+	report, err := ImportWagers(strings.NewReader("odds,total_wager_value,selling_price\n2,100,60\n"), nil, 10)
+	assert.Nil(t, report)
+	assert.EqualError(t, err, `missing column "selling_percentage"`)
+}
+
+func Test_ImportWagers_StoreError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	wagerService := mocks.NewMockWagerService(ctrl)
+	wagerService.EXPECT().CreateWagers(gomock.Any()).Return(nil, errors.New("custom error"))
+
+	report, err := ImportWagers(strings.NewReader("odds,total_wager_value,selling_percentage,selling_price\n2,100,50,60\n"), wagerService, 10)
+	assert.Nil(t, report)
+	assert.EqualError(t, err, "custom error")
+}
+
+func Test_ImportWagers_RejectedBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	wagerService := mocks.NewMockWagerService(ctrl)
+	file := strings.Join([]string{
+		"odds,total_wager_value,selling_percentage,selling_price",
+		"2,100,50,60",
+		"2,200,50,120",
+		"2,300,50,180",
+		"",
+	}, "\n")
+
+	// the service rejects the second row, the batch is stored without it
+	gomock.InOrder(
+		wagerService.EXPECT().CreateWagers(gomock.Any()).DoAndReturn(func(request model.CreateWagersRequest) ([]model.Wager, error) {
+			assert.Len(t, request.Wagers, 3)
+			return nil, &model.BatchError{Items: []model.BatchItemError{{Index: 1, Error: "custom error"}}}
+		}),
+		wagerService.EXPECT().CreateWagers(gomock.Any()).DoAndReturn(func(request model.CreateWagersRequest) ([]model.Wager, error) {
+			assert.Equal(t, []uint{100, 300}, []uint{request.Wagers[0].TotalWagerValue, request.Wagers[1].TotalWagerValue})
+			return []model.Wager{{ID: 1}, {ID: 2}}, nil
+		}),
+	)
+
+	report, err := ImportWagers(strings.NewReader(file), wagerService, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, []FailedRow{{Line: 3, Error: "custom error"}}, report.Failed)
+}
+
+func Test_ImportWagers_RejectedBatchWithoutItems(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	wagerService := mocks.NewMockWagerService(ctrl)
+	wagerService.EXPECT().CreateWagers(gomock.Any()).Return(nil, &model.BatchError{})
+
+	report, err := ImportWagers(strings.NewReader("odds,total_wager_value,selling_percentage,selling_price\n2,100,50,60\n2,200,50,120\n"), wagerService, 10)
+	assert.NoError(t, err)
+	assert.Zero(t, report.Imported)
+	assert.Equal(t, []FailedRow{{Line: 2, Error: ERROR_BATCH_REJECTED}, {Line: 3, Error: ERROR_BATCH_REJECTED}}, report.Failed)
+}
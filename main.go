@@ -1,24 +1,40 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"expvar"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"time"
+	"wager/cache"
 	"wager/conf"
 	"wager/database"
 	"wager/handlers"
+	"wager/importer"
 	"wager/middleware"
+	"wager/repository"
 	"wager/service"
+	sqlmigration "wager/sql_migration"
+	"wager/stream"
 
+	"github.com/go-redis/redis/v8"
 	_ "github.com/go-sql-driver/mysql"
-	//_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/gorilla/mux"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	MYSQL_DRIVER = "mysql"
+	DB_CONNECT_ATTEMPTS = 10
+	DB_CONNECT_INTERVAL = 3 * time.Second
+
+	IMPORT_BATCH_SIZE = 500
 )
 
 func main() {
@@ -29,59 +45,252 @@ func main() {
 		logrus.Fatal("Failed to load config")
 	}
 
-	dsn := fmt.Sprintf("%v:%v@%v", config.SQL.Username, config.SQL.Password, config.SQL.DatabaseAddress)
+	flag.StringVar(&config.Storage, "storage", config.Storage, "storage backend: sql or memory")
+	flag.StringVar(&config.SQL.Dialect, "sql-dialect", config.SQL.Dialect, "sql dialect: mysql, postgres or sqlite")
+	flag.StringVar(&config.SQL.DatabaseAddress, "sql-address", config.SQL.DatabaseAddress, "database address, or file path for sqlite")
+	flag.StringVar(&config.Cache.Backend, "cache", config.Cache.Backend, "wager cache: none, memory or redis")
+	flag.StringVar(&config.Cache.RedisAddress, "redis-address", config.Cache.RedisAddress, "redis address for the redis cache")
+	flag.DurationVar(&config.Purchase.RefundWindow, "refund-window", config.Purchase.RefundWindow, "how long after a purchase it can be refunded")
+	flag.DurationVar(&config.Reservation.TTL, "reservation-ttl", config.Reservation.TTL, "how long a reservation is held when the request gives no ttl")
+	flag.Float64Var(&config.BuyerLimit.MaxWagerPercentage, "max-wager-percentage", config.BuyerLimit.MaxWagerPercentage, "largest percentage of a wager's selling price one buyer can buy, 0 for no limit")
+	flag.Float64Var(&config.BuyerLimit.MaxOpenExposure, "max-open-exposure", config.BuyerLimit.MaxOpenExposure, "largest amount one buyer can hold across open wagers, 0 for no limit")
+	flag.IntVar(&config.BuyerLimit.MaxPurchasesPerWager, "max-purchases-per-wager", config.BuyerLimit.MaxPurchasesPerWager, "most purchases one buyer can make on a wager, 0 for no limit")
+	flag.Float64Var(&config.Fee.Maker.Percentage, "maker-fee", config.Fee.Maker.Percentage, "percentage of the buying price charged to the seller of a wager")
+	flag.Float64Var(&config.Fee.Maker.Min, "maker-fee-min", config.Fee.Maker.Min, "smallest maker fee, 0 for no minimum")
+	flag.Float64Var(&config.Fee.Maker.Max, "maker-fee-max", config.Fee.Maker.Max, "largest maker fee, 0 for no maximum")
+	flag.Float64Var(&config.Fee.Taker.Percentage, "taker-fee", config.Fee.Taker.Percentage, "percentage of the buying price charged to the buyer")
+	flag.Float64Var(&config.Fee.Taker.Min, "taker-fee-min", config.Fee.Taker.Min, "smallest taker fee, 0 for no minimum")
+	flag.Float64Var(&config.Fee.Taker.Max, "taker-fee-max", config.Fee.Taker.Max, "largest taker fee, 0 for no maximum")
+	flag.IntVar(&config.Stream.ReplaySize, "stream-replay-size", config.Stream.ReplaySize, "how many of the last wager events a reconnecting stream client can resume from")
+	flag.Float64Var(&config.Socket.MessageRate, "ws-message-rate", config.Socket.MessageRate, "how many messages per second a websocket connection can send")
+	flag.IntVar(&config.Socket.MessageBurst, "ws-message-burst", config.Socket.MessageBurst, "how many messages a websocket connection can send at once")
+	feeTiers := flag.String("fee-tiers", "", "comma separated volume:maker:taker fee percentages of users who traded at least volume")
+	replicas := flag.String("sql-replicas", "", "comma separated read replica addresses")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %v [flags] [import-wagers file.csv]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *replicas != "" {
+		config.SQL.ReplicaAddresses = strings.Split(*replicas, ",")
+	}
 
-	/*
-		if err := migrateDatabase(dsn); err != nil {
-			logrus.WithError(err).Fatal("cannot migrate database")
+	if *feeTiers != "" {
+		tiers, err := conf.ParseFeeTiers(*feeTiers)
+		if err != nil {
+			logrus.Fatalf("Invalid fee tiers: %v", err)
 		}
-	*/
+		config.Fee.Tiers = tiers
+	}
+
+	if err := config.SQL.Validate(); err != nil {
+		logrus.Fatalf("Invalid config: %v", err)
+	}
+
+	store, err := initStore(config)
+	if err != nil {
+		logrus.Fatalf("Failed to init storage: %v", err)
+	}
+
+	switch args := flag.Args(); {
+	case len(args) == 0:
+		startHTTPServer(config, store)
+	case args[0] == "import-wagers" && len(args) == 2:
+		importWagers(config, store, args[1])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
 
-	db, err := initDatabase(dsn)
+func initStore(config *conf.Config) (repository.Store, error) {
+	if config.Storage == conf.STORAGE_MEMORY {
+		logrus.Info("Using in-memory storage")
+		return repository.NewMemoryStore(), nil
+	}
+
+	if config.Storage != conf.STORAGE_SQL {
+		return nil, fmt.Errorf("unknown storage %q", config.Storage)
+	}
+
+	dialect, err := database.GetDialect(config.SQL.Dialect)
 	if err != nil {
-		logrus.Fatalf("Failed to init database: %v", err)
+		return nil, err
 	}
 
-	logrus.Info("Initialize database successfully")
+	db, err := initDatabase(dialect, config.SQL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init database: %v", err)
+	}
 
-	startHTTPServer(config, db)
+	if err := sqlmigration.Migrate(db, dialect); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %v", err)
+	}
+
+	logrus.WithField("dialect", dialect.Name()).Info("Initialize database successfully")
+	return repository.NewSQLStore(config.SQL, dialect, db)
 }
 
-func initDatabase(dataSourceName string) (database.DBManager, error) {
-	db, err := sql.Open(MYSQL_DRIVER, dataSourceName)
+func initDatabase(dialect database.Dialect, config conf.SQLConfig) (database.DBManager, error) {
+	primary, err := openDatabase(dialect, config)
 	if err != nil {
 		return nil, err
 	}
 
-	return database.NewDB(db), nil
+	// the database container may still be starting up
+	for i := 1; ; i++ {
+		err = primary.Ping()
+		if err == nil || i == DB_CONNECT_ATTEMPTS {
+			break
+		}
+		logrus.WithError(err).Warn("Database is not ready, retrying")
+		time.Sleep(DB_CONNECT_INTERVAL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// unreachable replicas are skipped at query time, so they are not waited for
+	replicas := []*sql.DB{}
+	for _, address := range config.ReplicaAddresses {
+		replicaConfig := config
+		replicaConfig.DatabaseAddress = address
+		replica, err := openDatabase(dialect, replicaConfig)
+		if err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, replica)
+	}
+
+	return database.NewDBWithReplicas(primary, replicas, config.ReplicaRetryInterval), nil
 }
 
-/*
-func migrateDatabase(dsn string) error {
-	db, _ := sql.Open("mysql", dsn)
-	driver, _ := mysql.WithInstance(db, &mysql.Config{})
-	m, _ := migrate.NewWithDatabaseInstance(
-		"file:///migrations",
-		"mysql",
-		driver,
-	)
-
-	m.Steps(2)
+func openDatabase(dialect database.Dialect, config conf.SQLConfig) (*sql.DB, error) {
+	db, err := sql.Open(dialect.DriverName(), dialect.DataSourceName(config))
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	return db, nil
+}
+
+func initCache(config conf.CacheConfig) cache.Cache {
+	switch config.Backend {
+	case conf.CACHE_MEMORY:
+		return cache.NewLRU(config.Capacity)
+	case conf.CACHE_REDIS:
+		return cache.NewRedis(redis.NewClient(&redis.Options{Addr: config.RedisAddress}))
+	default:
+		logrus.WithField("backend", config.Backend).Info("Wager cache is disabled")
+		return nil
+	}
+}
+
+func initServices(config *conf.Config, store repository.Store, publisher service.WagerPublisher) (service.WagerService, service.PurchaseService, service.ReservationService, service.BidService, service.ResaleService, service.StatsService, service.EventService) {
+	wagerService := service.NewWagerService(config, store, publisher)
+	purchaseService := service.NewPurchaseService(config, store, publisher)
+	reservationService := service.NewReservationService(config, store, publisher)
+	bidService := service.NewBidService(config, store, publisher)
+	resaleService := service.NewResaleService(config, store)
+	statsService := service.NewStatsService(config, store)
+	eventService := service.NewEventService(config, store, publisher)
+	if wagerCache := initCache(config.Cache); wagerCache != nil {
+		// single wagers are filled from the primary, a lagging replica would cache the
+		// wager a write just deleted
+		primaryWagerService := service.NewWagerService(config, store.UsePrimary(), publisher)
+		wagerService = service.NewCachedWagerService(wagerService, primaryWagerService, wagerCache, config.Cache.TTL)
+		purchaseService = service.NewCachedPurchaseService(purchaseService, wagerCache)
+		reservationService = service.NewCachedReservationService(reservationService, wagerCache)
+		bidService = service.NewCachedBidService(bidService, wagerCache)
+		eventService = service.NewCachedEventService(eventService, wagerCache)
+	}
+	return wagerService, purchaseService, reservationService, bidService, resaleService, statsService, eventService
+}
+
+// importWagers creates the wagers of a CSV file, it goes through the cache so that
+// a running server does not keep serving stale lists
+func importWagers(config *conf.Config, store repository.Store, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		logrus.Fatalf("Failed to open %v: %v", path, err)
+	}
+	defer file.Close()
+
+	wagerService, _, _, _, _, _, _ := initServices(config, store, nil)
+	report, err := importer.ImportWagers(file, wagerService, IMPORT_BATCH_SIZE)
+	if err != nil {
+		logrus.Fatalf("Failed to import wagers: %v", err)
+	}
+
+	for _, row := range report.Failed {
+		logrus.WithField("line", row.Line).Error(row.Error)
+	}
+	logrus.WithFields(logrus.Fields{
+		"imported": report.Imported,
+		"failed":   len(report.Failed),
+	}).Info("Imported wagers")
+	if len(report.Failed) > 0 {
+		os.Exit(1)
+	}
 }
-*/
 
-func startHTTPServer(config *conf.Config, db database.DBManager) {
-	if config == nil || db == nil {
+func startHTTPServer(config *conf.Config, store repository.Store) {
+	if config == nil || store == nil {
 		log.Fatal("Invalid intializer objects")
 	}
 
-	wagerService := service.NewWagerService(config, db)
-	handler := handlers.NewHandler(wagerService)
+	broker := stream.NewBroker(config.Stream.ReplaySize, config.Stream.BufferSize)
+	wagerService, purchaseService, reservationService, bidService, resaleService, statsService, eventService := initServices(config, store, broker)
+	go service.SweepReservations(context.Background(), reservationService, config.Reservation.SweepInterval)
+	go service.SweepBids(context.Background(), bidService, config.Bid.SweepInterval)
+	handler := handlers.NewHandler(wagerService, purchaseService, reservationService, bidService, resaleService, statsService, eventService, broker, config.Socket)
 
 	router := mux.NewRouter()
 	router.HandleFunc(config.Handlers.GetWagerList, handler.HandleGetWagers).Methods(http.MethodGet)
+	// the stream is routed before the wager ids it would match
+	router.HandleFunc(config.Handlers.StreamWagers, handler.HandleStreamWagers).Methods(http.MethodGet)
 	router.HandleFunc(config.Handlers.CreateWager, handler.HandlePlaceWager).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.CreateWagers, handler.HandlePlaceWagers).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.GetWager, handler.HandleGetWager).Methods(http.MethodGet)
 	router.HandleFunc(config.Handlers.BuyWager, handler.HandleBuyWager).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.QuoteWager, handler.HandleQuoteWager).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.BuyWagers, handler.HandleBuyWagers).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.GetPurchases, handler.HandleGetPurchases).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.GetPurchase, handler.HandleGetPurchase).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.Refund, handler.HandleRefundPurchase).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.ReserveWager, handler.HandleReserveWager).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.ConfirmReservation, handler.HandleConfirmReservation).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.ReleaseReservation, handler.HandleReleaseReservation).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.PlaceBid, handler.HandlePlaceBid).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.GetBook, handler.HandleGetBook).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.AcceptBid, handler.HandleAcceptBid).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.CancelBid, handler.HandleCancelBid).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.LowerPrice, handler.HandleLowerPrice).Methods(http.MethodPut)
+	router.HandleFunc(config.Handlers.GetPositions, handler.HandleGetPositions).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.CreateListing, handler.HandleCreateListing).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.GetListings, handler.HandleGetListings).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.BuyListing, handler.HandleBuyListing).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.CancelListing, handler.HandleCancelListing).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.GetTransfers, handler.HandleGetTransfers).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.GetStats, handler.HandleGetStats).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.CreateEvent, handler.HandleCreateEvent).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.GetEvents, handler.HandleGetEvents).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.GetEvent, handler.HandleGetEvent).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.UpdateEvent, handler.HandleUpdateEvent).Methods(http.MethodPut)
+	router.HandleFunc(config.Handlers.DeleteEvent, handler.HandleDeleteEvent).Methods(http.MethodDelete)
+	router.HandleFunc(config.Handlers.CreateMarket, handler.HandleCreateMarket).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.GetMarkets, handler.HandleGetMarkets).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.GetMarket, handler.HandleGetMarket).Methods(http.MethodGet)
+	router.HandleFunc(config.Handlers.UpdateMarket, handler.HandleUpdateMarket).Methods(http.MethodPut)
+	router.HandleFunc(config.Handlers.DeleteMarket, handler.HandleDeleteMarket).Methods(http.MethodDelete)
+	router.HandleFunc(config.Handlers.SettleMarket, handler.HandleSettleMarket).Methods(http.MethodPost)
+	router.HandleFunc(config.Handlers.Socket, handler.HandleSocket).Methods(http.MethodGet)
+	router.Handle("/debug/vars", expvar.Handler()).Methods(http.MethodGet)
 
 	router.Use(middleware.LoggingMiddleware)
 
@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/bid_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	model "wager/model"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockBidService is a mock of BidService interface.
+type MockBidService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBidServiceMockRecorder
+}
+
+// MockBidServiceMockRecorder is the mock recorder for MockBidService.
+type MockBidServiceMockRecorder struct {
+	mock *MockBidService
+}
+
+// NewMockBidService creates a new mock instance.
+func NewMockBidService(ctrl *gomock.Controller) *MockBidService {
+	mock := &MockBidService{ctrl: ctrl}
+	mock.recorder = &MockBidServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBidService) EXPECT() *MockBidServiceMockRecorder {
+	return m.recorder
+}
+
+// AcceptBid mocks base method.
+func (m *MockBidService) AcceptBid(request model.AcceptBidRequest) (*model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptBid", request)
+	ret0, _ := ret[0].(*model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptBid indicates an expected call of AcceptBid.
+func (mr *MockBidServiceMockRecorder) AcceptBid(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptBid", reflect.TypeOf((*MockBidService)(nil).AcceptBid), request)
+}
+
+// CancelBid mocks base method.
+func (m *MockBidService) CancelBid(request model.CancelBidRequest) (*model.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelBid", request)
+	ret0, _ := ret[0].(*model.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelBid indicates an expected call of CancelBid.
+func (mr *MockBidServiceMockRecorder) CancelBid(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelBid", reflect.TypeOf((*MockBidService)(nil).CancelBid), request)
+}
+
+// ExpireBids mocks base method.
+func (m *MockBidService) ExpireBids() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireBids")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireBids indicates an expected call of ExpireBids.
+func (mr *MockBidServiceMockRecorder) ExpireBids() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireBids", reflect.TypeOf((*MockBidService)(nil).ExpireBids))
+}
+
+// GetBook mocks base method.
+func (m *MockBidService) GetBook(wagerID uint) (*model.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBook", wagerID)
+	ret0, _ := ret[0].(*model.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBook indicates an expected call of GetBook.
+func (mr *MockBidServiceMockRecorder) GetBook(wagerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBook", reflect.TypeOf((*MockBidService)(nil).GetBook), wagerID)
+}
+
+// LowerPrice mocks base method.
+func (m *MockBidService) LowerPrice(request model.LowerPriceRequest) (*model.LowerPriceResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LowerPrice", request)
+	ret0, _ := ret[0].(*model.LowerPriceResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LowerPrice indicates an expected call of LowerPrice.
+func (mr *MockBidServiceMockRecorder) LowerPrice(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LowerPrice", reflect.TypeOf((*MockBidService)(nil).LowerPrice), request)
+}
+
+// MatchScheduledBids mocks base method.
+func (m *MockBidService) MatchScheduledBids() ([]model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MatchScheduledBids")
+	ret0, _ := ret[0].([]model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MatchScheduledBids indicates an expected call of MatchScheduledBids.
+func (mr *MockBidServiceMockRecorder) MatchScheduledBids() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MatchScheduledBids", reflect.TypeOf((*MockBidService)(nil).MatchScheduledBids))
+}
+
+// PlaceBid mocks base method.
+func (m *MockBidService) PlaceBid(request model.PlaceBidRequest) (*model.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlaceBid", request)
+	ret0, _ := ret[0].(*model.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlaceBid indicates an expected call of PlaceBid.
+func (mr *MockBidServiceMockRecorder) PlaceBid(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlaceBid", reflect.TypeOf((*MockBidService)(nil).PlaceBid), request)
+}
@@ -50,6 +50,20 @@ func (mr *MockDBRowsMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockDBRows)(nil).Close))
 }
 
+// Err mocks base method.
+func (m *MockDBRows) Err() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Err")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Err indicates an expected call of Err.
+func (mr *MockDBRowsMockRecorder) Err() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Err", reflect.TypeOf((*MockDBRows)(nil).Err))
+}
+
 // Next mocks base method.
 func (m *MockDBRows) Next() bool {
 	m.ctrl.T.Helper()
@@ -82,6 +96,240 @@ func (mr *MockDBRowsMockRecorder) Scan(arg0 ...interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockDBRows)(nil).Scan), arg0...)
 }
 
+// MockExecutor is a mock of Executor interface.
+type MockExecutor struct {
+	ctrl     *gomock.Controller
+	recorder *MockExecutorMockRecorder
+}
+
+// MockExecutorMockRecorder is the mock recorder for MockExecutor.
+type MockExecutorMockRecorder struct {
+	mock *MockExecutor
+}
+
+// NewMockExecutor creates a new mock instance.
+func NewMockExecutor(ctrl *gomock.Controller) *MockExecutor {
+	mock := &MockExecutor{ctrl: ctrl}
+	mock.recorder = &MockExecutorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExecutor) EXPECT() *MockExecutorMockRecorder {
+	return m.recorder
+}
+
+// Exec mocks base method.
+func (m *MockExecutor) Exec(query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Exec", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exec indicates an expected call of Exec.
+func (mr *MockExecutorMockRecorder) Exec(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockExecutor)(nil).Exec), varargs...)
+}
+
+// ExecWithContext mocks base method.
+func (m *MockExecutor) ExecWithContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecWithContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecWithContext indicates an expected call of ExecWithContext.
+func (mr *MockExecutorMockRecorder) ExecWithContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecWithContext", reflect.TypeOf((*MockExecutor)(nil).ExecWithContext), varargs...)
+}
+
+// Query mocks base method.
+func (m *MockExecutor) Query(query string, args ...interface{}) (database.DBRows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Query", varargs...)
+	ret0, _ := ret[0].(database.DBRows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockExecutorMockRecorder) Query(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockExecutor)(nil).Query), varargs...)
+}
+
+// QueryWithContext mocks base method.
+func (m *MockExecutor) QueryWithContext(ctx context.Context, query string, args ...interface{}) (database.DBRows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryWithContext", varargs...)
+	ret0, _ := ret[0].(database.DBRows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryWithContext indicates an expected call of QueryWithContext.
+func (mr *MockExecutorMockRecorder) QueryWithContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryWithContext", reflect.TypeOf((*MockExecutor)(nil).QueryWithContext), varargs...)
+}
+
+// MockDBTx is a mock of DBTx interface.
+type MockDBTx struct {
+	ctrl     *gomock.Controller
+	recorder *MockDBTxMockRecorder
+}
+
+// MockDBTxMockRecorder is the mock recorder for MockDBTx.
+type MockDBTxMockRecorder struct {
+	mock *MockDBTx
+}
+
+// NewMockDBTx creates a new mock instance.
+func NewMockDBTx(ctrl *gomock.Controller) *MockDBTx {
+	mock := &MockDBTx{ctrl: ctrl}
+	mock.recorder = &MockDBTxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDBTx) EXPECT() *MockDBTxMockRecorder {
+	return m.recorder
+}
+
+// Commit mocks base method.
+func (m *MockDBTx) Commit() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockDBTxMockRecorder) Commit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockDBTx)(nil).Commit))
+}
+
+// Exec mocks base method.
+func (m *MockDBTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Exec", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exec indicates an expected call of Exec.
+func (mr *MockDBTxMockRecorder) Exec(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockDBTx)(nil).Exec), varargs...)
+}
+
+// ExecWithContext mocks base method.
+func (m *MockDBTx) ExecWithContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecWithContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecWithContext indicates an expected call of ExecWithContext.
+func (mr *MockDBTxMockRecorder) ExecWithContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecWithContext", reflect.TypeOf((*MockDBTx)(nil).ExecWithContext), varargs...)
+}
+
+// Query mocks base method.
+func (m *MockDBTx) Query(query string, args ...interface{}) (database.DBRows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Query", varargs...)
+	ret0, _ := ret[0].(database.DBRows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockDBTxMockRecorder) Query(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockDBTx)(nil).Query), varargs...)
+}
+
+// QueryWithContext mocks base method.
+func (m *MockDBTx) QueryWithContext(ctx context.Context, query string, args ...interface{}) (database.DBRows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryWithContext", varargs...)
+	ret0, _ := ret[0].(database.DBRows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryWithContext indicates an expected call of QueryWithContext.
+func (mr *MockDBTxMockRecorder) QueryWithContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryWithContext", reflect.TypeOf((*MockDBTx)(nil).QueryWithContext), varargs...)
+}
+
+// Rollback mocks base method.
+func (m *MockDBTx) Rollback() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockDBTxMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockDBTx)(nil).Rollback))
+}
+
 // MockDBManager is a mock of DBManager interface.
 type MockDBManager struct {
 	ctrl     *gomock.Controller
@@ -106,10 +354,10 @@ func (m *MockDBManager) EXPECT() *MockDBManagerMockRecorder {
 }
 
 // BeginTx mocks base method.
-func (m *MockDBManager) BeginTx() (*sql.Tx, error) {
+func (m *MockDBManager) BeginTx() (database.DBTx, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "BeginTx")
-	ret0, _ := ret[0].(*sql.Tx)
+	ret0, _ := ret[0].(database.DBTx)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -120,8 +368,24 @@ func (mr *MockDBManagerMockRecorder) BeginTx() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockDBManager)(nil).BeginTx))
 }
 
+// CacheStatements mocks base method.
+func (m *MockDBManager) CacheStatements(queries ...string) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range queries {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "CacheStatements", varargs...)
+}
+
+// CacheStatements indicates an expected call of CacheStatements.
+func (mr *MockDBManagerMockRecorder) CacheStatements(queries ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheStatements", reflect.TypeOf((*MockDBManager)(nil).CacheStatements), queries...)
+}
+
 // CommitTx mocks base method.
-func (m *MockDBManager) CommitTx(tx *sql.Tx) error {
+func (m *MockDBManager) CommitTx(tx database.DBTx) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CommitTx", tx)
 	ret0, _ := ret[0].(error)
@@ -174,6 +438,20 @@ func (mr *MockDBManagerMockRecorder) ExecWithContext(ctx, query interface{}, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecWithContext", reflect.TypeOf((*MockDBManager)(nil).ExecWithContext), varargs...)
 }
 
+// Primary mocks base method.
+func (m *MockDBManager) Primary() database.Executor {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Primary")
+	ret0, _ := ret[0].(database.Executor)
+	return ret0
+}
+
+// Primary indicates an expected call of Primary.
+func (mr *MockDBManagerMockRecorder) Primary() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Primary", reflect.TypeOf((*MockDBManager)(nil).Primary))
+}
+
 // Query mocks base method.
 func (m *MockDBManager) Query(query string, args ...interface{}) (database.DBRows, error) {
 	m.ctrl.T.Helper()
@@ -215,7 +493,7 @@ func (mr *MockDBManagerMockRecorder) QueryWithContext(ctx, query interface{}, ar
 }
 
 // RollbackTx mocks base method.
-func (m *MockDBManager) RollbackTx(tx *sql.Tx) error {
+func (m *MockDBManager) RollbackTx(tx database.DBTx) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "RollbackTx", tx)
 	ret0, _ := ret[0].(error)
@@ -227,3 +505,17 @@ func (mr *MockDBManagerMockRecorder) RollbackTx(tx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackTx", reflect.TypeOf((*MockDBManager)(nil).RollbackTx), tx)
 }
+
+// Session mocks base method.
+func (m *MockDBManager) Session() database.DBManager {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Session")
+	ret0, _ := ret[0].(database.DBManager)
+	return ret0
+}
+
+// Session indicates an expected call of Session.
+func (mr *MockDBManagerMockRecorder) Session() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Session", reflect.TypeOf((*MockDBManager)(nil).Session))
+}
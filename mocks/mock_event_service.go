@@ -0,0 +1,198 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/event_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	model "wager/model"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockEventService is a mock of EventService interface.
+type MockEventService struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventServiceMockRecorder
+}
+
+// MockEventServiceMockRecorder is the mock recorder for MockEventService.
+type MockEventServiceMockRecorder struct {
+	mock *MockEventService
+}
+
+// NewMockEventService creates a new mock instance.
+func NewMockEventService(ctrl *gomock.Controller) *MockEventService {
+	mock := &MockEventService{ctrl: ctrl}
+	mock.recorder = &MockEventServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventService) EXPECT() *MockEventServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateEvent mocks base method.
+func (m *MockEventService) CreateEvent(request model.CreateEventRequest) (*model.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEvent", request)
+	ret0, _ := ret[0].(*model.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEvent indicates an expected call of CreateEvent.
+func (mr *MockEventServiceMockRecorder) CreateEvent(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEvent", reflect.TypeOf((*MockEventService)(nil).CreateEvent), request)
+}
+
+// CreateMarket mocks base method.
+func (m *MockEventService) CreateMarket(request model.CreateMarketRequest) (*model.Market, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMarket", request)
+	ret0, _ := ret[0].(*model.Market)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMarket indicates an expected call of CreateMarket.
+func (mr *MockEventServiceMockRecorder) CreateMarket(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMarket", reflect.TypeOf((*MockEventService)(nil).CreateMarket), request)
+}
+
+// DeleteEvent mocks base method.
+func (m *MockEventService) DeleteEvent(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEvent", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEvent indicates an expected call of DeleteEvent.
+func (mr *MockEventServiceMockRecorder) DeleteEvent(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEvent", reflect.TypeOf((*MockEventService)(nil).DeleteEvent), id)
+}
+
+// DeleteMarket mocks base method.
+func (m *MockEventService) DeleteMarket(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMarket", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMarket indicates an expected call of DeleteMarket.
+func (mr *MockEventServiceMockRecorder) DeleteMarket(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMarket", reflect.TypeOf((*MockEventService)(nil).DeleteMarket), id)
+}
+
+// GetEvent mocks base method.
+func (m *MockEventService) GetEvent(id uint) (*model.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvent", id)
+	ret0, _ := ret[0].(*model.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvent indicates an expected call of GetEvent.
+func (mr *MockEventServiceMockRecorder) GetEvent(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvent", reflect.TypeOf((*MockEventService)(nil).GetEvent), id)
+}
+
+// GetEventList mocks base method.
+func (m *MockEventService) GetEventList(request model.GetEventListRequest) ([]model.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEventList", request)
+	ret0, _ := ret[0].([]model.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEventList indicates an expected call of GetEventList.
+func (mr *MockEventServiceMockRecorder) GetEventList(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventList", reflect.TypeOf((*MockEventService)(nil).GetEventList), request)
+}
+
+// GetMarket mocks base method.
+func (m *MockEventService) GetMarket(id uint) (*model.Market, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMarket", id)
+	ret0, _ := ret[0].(*model.Market)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMarket indicates an expected call of GetMarket.
+func (mr *MockEventServiceMockRecorder) GetMarket(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMarket", reflect.TypeOf((*MockEventService)(nil).GetMarket), id)
+}
+
+// GetMarkets mocks base method.
+func (m *MockEventService) GetMarkets(eventID uint) ([]model.Market, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMarkets", eventID)
+	ret0, _ := ret[0].([]model.Market)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMarkets indicates an expected call of GetMarkets.
+func (mr *MockEventServiceMockRecorder) GetMarkets(eventID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMarkets", reflect.TypeOf((*MockEventService)(nil).GetMarkets), eventID)
+}
+
+// SettleMarket mocks base method.
+func (m *MockEventService) SettleMarket(request model.SettleMarketRequest) (*model.Settlement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SettleMarket", request)
+	ret0, _ := ret[0].(*model.Settlement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SettleMarket indicates an expected call of SettleMarket.
+func (mr *MockEventServiceMockRecorder) SettleMarket(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SettleMarket", reflect.TypeOf((*MockEventService)(nil).SettleMarket), request)
+}
+
+// UpdateEvent mocks base method.
+func (m *MockEventService) UpdateEvent(request model.UpdateEventRequest) (*model.UpdateEventResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEvent", request)
+	ret0, _ := ret[0].(*model.UpdateEventResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateEvent indicates an expected call of UpdateEvent.
+func (mr *MockEventServiceMockRecorder) UpdateEvent(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEvent", reflect.TypeOf((*MockEventService)(nil).UpdateEvent), request)
+}
+
+// UpdateMarket mocks base method.
+func (m *MockEventService) UpdateMarket(request model.UpdateMarketRequest) (*model.Market, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMarket", request)
+	ret0, _ := ret[0].(*model.Market)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMarket indicates an expected call of UpdateMarket.
+func (mr *MockEventServiceMockRecorder) UpdateMarket(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMarket", reflect.TypeOf((*MockEventService)(nil).UpdateMarket), request)
+}
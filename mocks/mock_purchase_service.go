@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/purchase_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	model "wager/model"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPurchaseService is a mock of PurchaseService interface.
+type MockPurchaseService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPurchaseServiceMockRecorder
+}
+
+// MockPurchaseServiceMockRecorder is the mock recorder for MockPurchaseService.
+type MockPurchaseServiceMockRecorder struct {
+	mock *MockPurchaseService
+}
+
+// NewMockPurchaseService creates a new mock instance.
+func NewMockPurchaseService(ctrl *gomock.Controller) *MockPurchaseService {
+	mock := &MockPurchaseService{ctrl: ctrl}
+	mock.recorder = &MockPurchaseServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPurchaseService) EXPECT() *MockPurchaseServiceMockRecorder {
+	return m.recorder
+}
+
+// GetPurchase mocks base method.
+func (m *MockPurchaseService) GetPurchase(id uint) (*model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchase", id)
+	ret0, _ := ret[0].(*model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchase indicates an expected call of GetPurchase.
+func (mr *MockPurchaseServiceMockRecorder) GetPurchase(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchase", reflect.TypeOf((*MockPurchaseService)(nil).GetPurchase), id)
+}
+
+// GetPurchaseList mocks base method.
+func (m *MockPurchaseService) GetPurchaseList(request model.GetPurchaseListRequest) (*model.GetPurchaseListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchaseList", request)
+	ret0, _ := ret[0].(*model.GetPurchaseListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchaseList indicates an expected call of GetPurchaseList.
+func (mr *MockPurchaseServiceMockRecorder) GetPurchaseList(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchaseList", reflect.TypeOf((*MockPurchaseService)(nil).GetPurchaseList), request)
+}
+
+// RefundPurchase mocks base method.
+func (m *MockPurchaseService) RefundPurchase(id uint) (*model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundPurchase", id)
+	ret0, _ := ret[0].(*model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefundPurchase indicates an expected call of RefundPurchase.
+func (mr *MockPurchaseServiceMockRecorder) RefundPurchase(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundPurchase", reflect.TypeOf((*MockPurchaseService)(nil).RefundPurchase), id)
+}
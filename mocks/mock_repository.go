@@ -0,0 +1,1280 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	model "wager/model"
+	repository "wager/repository"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWagerRepository is a mock of WagerRepository interface.
+type MockWagerRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWagerRepositoryMockRecorder
+}
+
+// MockWagerRepositoryMockRecorder is the mock recorder for MockWagerRepository.
+type MockWagerRepositoryMockRecorder struct {
+	mock *MockWagerRepository
+}
+
+// NewMockWagerRepository creates a new mock instance.
+func NewMockWagerRepository(ctrl *gomock.Controller) *MockWagerRepository {
+	mock := &MockWagerRepository{ctrl: ctrl}
+	mock.recorder = &MockWagerRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWagerRepository) EXPECT() *MockWagerRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWagerRepository) Create(wager *model.Wager) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", wager)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWagerRepositoryMockRecorder) Create(wager interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWagerRepository)(nil).Create), wager)
+}
+
+// CreateMany mocks base method.
+func (m *MockWagerRepository) CreateMany(wagers []model.Wager) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMany", wagers)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMany indicates an expected call of CreateMany.
+func (mr *MockWagerRepositoryMockRecorder) CreateMany(wagers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMany", reflect.TypeOf((*MockWagerRepository)(nil).CreateMany), wagers)
+}
+
+// GetByID mocks base method.
+func (m *MockWagerRepository) GetByID(id uint) (*model.Wager, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", id)
+	ret0, _ := ret[0].(*model.Wager)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockWagerRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockWagerRepository)(nil).GetByID), id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockWagerRepository) GetByIDForUpdate(id uint) (*model.Wager, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", id)
+	ret0, _ := ret[0].(*model.Wager)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockWagerRepositoryMockRecorder) GetByIDForUpdate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockWagerRepository)(nil).GetByIDForUpdate), id)
+}
+
+// List mocks base method.
+func (m *MockWagerRepository) List(filter model.WagerFilter, offset, limit int) ([]model.Wager, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", filter, offset, limit)
+	ret0, _ := ret[0].([]model.Wager)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockWagerRepositoryMockRecorder) List(filter, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockWagerRepository)(nil).List), filter, offset, limit)
+}
+
+// UpdateAskPrice mocks base method.
+func (m *MockWagerRepository) UpdateAskPrice(wager *model.Wager) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAskPrice", wager)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAskPrice indicates an expected call of UpdateAskPrice.
+func (mr *MockWagerRepositoryMockRecorder) UpdateAskPrice(wager interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAskPrice", reflect.TypeOf((*MockWagerRepository)(nil).UpdateAskPrice), wager)
+}
+
+// UpdateSale mocks base method.
+func (m *MockWagerRepository) UpdateSale(wager *model.Wager) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSale", wager)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSale indicates an expected call of UpdateSale.
+func (mr *MockWagerRepositoryMockRecorder) UpdateSale(wager interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSale", reflect.TypeOf((*MockWagerRepository)(nil).UpdateSale), wager)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockWagerRepository) UpdateStatus(wager *model.Wager) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", wager)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockWagerRepositoryMockRecorder) UpdateStatus(wager interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockWagerRepository)(nil).UpdateStatus), wager)
+}
+
+// MockPurchaseRepository is a mock of PurchaseRepository interface.
+type MockPurchaseRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPurchaseRepositoryMockRecorder
+}
+
+// MockPurchaseRepositoryMockRecorder is the mock recorder for MockPurchaseRepository.
+type MockPurchaseRepositoryMockRecorder struct {
+	mock *MockPurchaseRepository
+}
+
+// NewMockPurchaseRepository creates a new mock instance.
+func NewMockPurchaseRepository(ctrl *gomock.Controller) *MockPurchaseRepository {
+	mock := &MockPurchaseRepository{ctrl: ctrl}
+	mock.recorder = &MockPurchaseRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPurchaseRepository) EXPECT() *MockPurchaseRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPurchaseRepository) Create(purchase *model.Purchase) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", purchase)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPurchaseRepositoryMockRecorder) Create(purchase interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPurchaseRepository)(nil).Create), purchase)
+}
+
+// FeeRevenue mocks base method.
+func (m *MockPurchaseRepository) FeeRevenue(from, to int64) ([]model.FeeRevenue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FeeRevenue", from, to)
+	ret0, _ := ret[0].([]model.FeeRevenue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FeeRevenue indicates an expected call of FeeRevenue.
+func (mr *MockPurchaseRepositoryMockRecorder) FeeRevenue(from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FeeRevenue", reflect.TypeOf((*MockPurchaseRepository)(nil).FeeRevenue), from, to)
+}
+
+// GetByID mocks base method.
+func (m *MockPurchaseRepository) GetByID(id uint) (*model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", id)
+	ret0, _ := ret[0].(*model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockPurchaseRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPurchaseRepository)(nil).GetByID), id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockPurchaseRepository) GetByIDForUpdate(id uint) (*model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", id)
+	ret0, _ := ret[0].(*model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockPurchaseRepositoryMockRecorder) GetByIDForUpdate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockPurchaseRepository)(nil).GetByIDForUpdate), id)
+}
+
+// List mocks base method.
+func (m *MockPurchaseRepository) List(filter model.PurchaseFilter, offset, limit int) ([]model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", filter, offset, limit)
+	ret0, _ := ret[0].([]model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockPurchaseRepositoryMockRecorder) List(filter, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPurchaseRepository)(nil).List), filter, offset, limit)
+}
+
+// Refund mocks base method.
+func (m *MockPurchaseRepository) Refund(purchase *model.Purchase) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", purchase)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockPurchaseRepositoryMockRecorder) Refund(purchase interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockPurchaseRepository)(nil).Refund), purchase)
+}
+
+// TradingVolume mocks base method.
+func (m *MockPurchaseRepository) TradingVolume(buyer, seller, currency string) (*model.TradingVolume, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TradingVolume", buyer, seller, currency)
+	ret0, _ := ret[0].(*model.TradingVolume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TradingVolume indicates an expected call of TradingVolume.
+func (mr *MockPurchaseRepositoryMockRecorder) TradingVolume(buyer, seller, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TradingVolume", reflect.TypeOf((*MockPurchaseRepository)(nil).TradingVolume), buyer, seller, currency)
+}
+
+// MockBidRepository is a mock of BidRepository interface.
+type MockBidRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBidRepositoryMockRecorder
+}
+
+// MockBidRepositoryMockRecorder is the mock recorder for MockBidRepository.
+type MockBidRepositoryMockRecorder struct {
+	mock *MockBidRepository
+}
+
+// NewMockBidRepository creates a new mock instance.
+func NewMockBidRepository(ctrl *gomock.Controller) *MockBidRepository {
+	mock := &MockBidRepository{ctrl: ctrl}
+	mock.recorder = &MockBidRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBidRepository) EXPECT() *MockBidRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockBidRepository) Create(bid *model.Bid) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", bid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockBidRepositoryMockRecorder) Create(bid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockBidRepository)(nil).Create), bid)
+}
+
+// GetByID mocks base method.
+func (m *MockBidRepository) GetByID(id uint) (*model.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", id)
+	ret0, _ := ret[0].(*model.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockBidRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockBidRepository)(nil).GetByID), id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockBidRepository) GetByIDForUpdate(id uint) (*model.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", id)
+	ret0, _ := ret[0].(*model.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockBidRepositoryMockRecorder) GetByIDForUpdate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockBidRepository)(nil).GetByIDForUpdate), id)
+}
+
+// ListExpired mocks base method.
+func (m *MockBidRepository) ListExpired(now int64, limit int) ([]model.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpired", now, limit)
+	ret0, _ := ret[0].([]model.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpired indicates an expected call of ListExpired.
+func (mr *MockBidRepositoryMockRecorder) ListExpired(now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpired", reflect.TypeOf((*MockBidRepository)(nil).ListExpired), now, limit)
+}
+
+// ListOpen mocks base method.
+func (m *MockBidRepository) ListOpen(wagerID uint) ([]model.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpen", wagerID)
+	ret0, _ := ret[0].([]model.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpen indicates an expected call of ListOpen.
+func (mr *MockBidRepositoryMockRecorder) ListOpen(wagerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpen", reflect.TypeOf((*MockBidRepository)(nil).ListOpen), wagerID)
+}
+
+// ListWagerIDs mocks base method.
+func (m *MockBidRepository) ListWagerIDs(afterID uint, limit int) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWagerIDs", afterID, limit)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWagerIDs indicates an expected call of ListWagerIDs.
+func (mr *MockBidRepositoryMockRecorder) ListWagerIDs(afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWagerIDs", reflect.TypeOf((*MockBidRepository)(nil).ListWagerIDs), afterID, limit)
+}
+
+// Update mocks base method.
+func (m *MockBidRepository) Update(bid *model.Bid) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", bid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockBidRepositoryMockRecorder) Update(bid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockBidRepository)(nil).Update), bid)
+}
+
+// MockPositionRepository is a mock of PositionRepository interface.
+type MockPositionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPositionRepositoryMockRecorder
+}
+
+// MockPositionRepositoryMockRecorder is the mock recorder for MockPositionRepository.
+type MockPositionRepositoryMockRecorder struct {
+	mock *MockPositionRepository
+}
+
+// NewMockPositionRepository creates a new mock instance.
+func NewMockPositionRepository(ctrl *gomock.Controller) *MockPositionRepository {
+	mock := &MockPositionRepository{ctrl: ctrl}
+	mock.recorder = &MockPositionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPositionRepository) EXPECT() *MockPositionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// BuyerExposure mocks base method.
+func (m *MockPositionRepository) BuyerExposure(holder string, wagerID uint, currency string) (*model.BuyerExposure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuyerExposure", holder, wagerID, currency)
+	ret0, _ := ret[0].(*model.BuyerExposure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuyerExposure indicates an expected call of BuyerExposure.
+func (mr *MockPositionRepositoryMockRecorder) BuyerExposure(holder, wagerID, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyerExposure", reflect.TypeOf((*MockPositionRepository)(nil).BuyerExposure), holder, wagerID, currency)
+}
+
+// Create mocks base method.
+func (m *MockPositionRepository) Create(position *model.Position) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", position)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPositionRepositoryMockRecorder) Create(position interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPositionRepository)(nil).Create), position)
+}
+
+// GetByID mocks base method.
+func (m *MockPositionRepository) GetByID(id uint) (*model.Position, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", id)
+	ret0, _ := ret[0].(*model.Position)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockPositionRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPositionRepository)(nil).GetByID), id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockPositionRepository) GetByIDForUpdate(id uint) (*model.Position, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", id)
+	ret0, _ := ret[0].(*model.Position)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockPositionRepositoryMockRecorder) GetByIDForUpdate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockPositionRepository)(nil).GetByIDForUpdate), id)
+}
+
+// List mocks base method.
+func (m *MockPositionRepository) List(filter model.PositionFilter, offset, limit int) ([]model.Position, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", filter, offset, limit)
+	ret0, _ := ret[0].([]model.Position)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockPositionRepositoryMockRecorder) List(filter, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPositionRepository)(nil).List), filter, offset, limit)
+}
+
+// ListByPurchase mocks base method.
+func (m *MockPositionRepository) ListByPurchase(purchaseID uint) ([]model.Position, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByPurchase", purchaseID)
+	ret0, _ := ret[0].([]model.Position)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByPurchase indicates an expected call of ListByPurchase.
+func (mr *MockPositionRepositoryMockRecorder) ListByPurchase(purchaseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByPurchase", reflect.TypeOf((*MockPositionRepository)(nil).ListByPurchase), purchaseID)
+}
+
+// Update mocks base method.
+func (m *MockPositionRepository) Update(position *model.Position) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", position)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockPositionRepositoryMockRecorder) Update(position interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPositionRepository)(nil).Update), position)
+}
+
+// MockListingRepository is a mock of ListingRepository interface.
+type MockListingRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockListingRepositoryMockRecorder
+}
+
+// MockListingRepositoryMockRecorder is the mock recorder for MockListingRepository.
+type MockListingRepositoryMockRecorder struct {
+	mock *MockListingRepository
+}
+
+// NewMockListingRepository creates a new mock instance.
+func NewMockListingRepository(ctrl *gomock.Controller) *MockListingRepository {
+	mock := &MockListingRepository{ctrl: ctrl}
+	mock.recorder = &MockListingRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockListingRepository) EXPECT() *MockListingRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockListingRepository) Create(listing *model.Listing) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", listing)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockListingRepositoryMockRecorder) Create(listing interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockListingRepository)(nil).Create), listing)
+}
+
+// GetByID mocks base method.
+func (m *MockListingRepository) GetByID(id uint) (*model.Listing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", id)
+	ret0, _ := ret[0].(*model.Listing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockListingRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockListingRepository)(nil).GetByID), id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockListingRepository) GetByIDForUpdate(id uint) (*model.Listing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", id)
+	ret0, _ := ret[0].(*model.Listing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockListingRepositoryMockRecorder) GetByIDForUpdate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockListingRepository)(nil).GetByIDForUpdate), id)
+}
+
+// ListOpen mocks base method.
+func (m *MockListingRepository) ListOpen(wagerID uint, offset, limit int) ([]model.Listing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpen", wagerID, offset, limit)
+	ret0, _ := ret[0].([]model.Listing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpen indicates an expected call of ListOpen.
+func (mr *MockListingRepositoryMockRecorder) ListOpen(wagerID, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpen", reflect.TypeOf((*MockListingRepository)(nil).ListOpen), wagerID, offset, limit)
+}
+
+// Update mocks base method.
+func (m *MockListingRepository) Update(listing *model.Listing) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", listing)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockListingRepositoryMockRecorder) Update(listing interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockListingRepository)(nil).Update), listing)
+}
+
+// MockTransferRepository is a mock of TransferRepository interface.
+type MockTransferRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransferRepositoryMockRecorder
+}
+
+// MockTransferRepositoryMockRecorder is the mock recorder for MockTransferRepository.
+type MockTransferRepositoryMockRecorder struct {
+	mock *MockTransferRepository
+}
+
+// NewMockTransferRepository creates a new mock instance.
+func NewMockTransferRepository(ctrl *gomock.Controller) *MockTransferRepository {
+	mock := &MockTransferRepository{ctrl: ctrl}
+	mock.recorder = &MockTransferRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransferRepository) EXPECT() *MockTransferRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockTransferRepository) Create(transfer *model.Transfer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", transfer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockTransferRepositoryMockRecorder) Create(transfer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTransferRepository)(nil).Create), transfer)
+}
+
+// FeeRevenue mocks base method.
+func (m *MockTransferRepository) FeeRevenue(from, to int64) ([]model.FeeRevenue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FeeRevenue", from, to)
+	ret0, _ := ret[0].([]model.FeeRevenue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FeeRevenue indicates an expected call of FeeRevenue.
+func (mr *MockTransferRepositoryMockRecorder) FeeRevenue(from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FeeRevenue", reflect.TypeOf((*MockTransferRepository)(nil).FeeRevenue), from, to)
+}
+
+// ListByPurchase mocks base method.
+func (m *MockTransferRepository) ListByPurchase(purchaseID uint) ([]model.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByPurchase", purchaseID)
+	ret0, _ := ret[0].([]model.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByPurchase indicates an expected call of ListByPurchase.
+func (mr *MockTransferRepositoryMockRecorder) ListByPurchase(purchaseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByPurchase", reflect.TypeOf((*MockTransferRepository)(nil).ListByPurchase), purchaseID)
+}
+
+// MockEventRepository is a mock of EventRepository interface.
+type MockEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventRepositoryMockRecorder
+}
+
+// MockEventRepositoryMockRecorder is the mock recorder for MockEventRepository.
+type MockEventRepositoryMockRecorder struct {
+	mock *MockEventRepository
+}
+
+// NewMockEventRepository creates a new mock instance.
+func NewMockEventRepository(ctrl *gomock.Controller) *MockEventRepository {
+	mock := &MockEventRepository{ctrl: ctrl}
+	mock.recorder = &MockEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventRepository) EXPECT() *MockEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockEventRepository) Create(event *model.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockEventRepositoryMockRecorder) Create(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockEventRepository)(nil).Create), event)
+}
+
+// Delete mocks base method.
+func (m *MockEventRepository) Delete(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockEventRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockEventRepository)(nil).Delete), id)
+}
+
+// GetByID mocks base method.
+func (m *MockEventRepository) GetByID(id uint) (*model.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", id)
+	ret0, _ := ret[0].(*model.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockEventRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockEventRepository)(nil).GetByID), id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockEventRepository) GetByIDForUpdate(id uint) (*model.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", id)
+	ret0, _ := ret[0].(*model.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockEventRepositoryMockRecorder) GetByIDForUpdate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockEventRepository)(nil).GetByIDForUpdate), id)
+}
+
+// List mocks base method.
+func (m *MockEventRepository) List(filter model.EventFilter, offset, limit int) ([]model.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", filter, offset, limit)
+	ret0, _ := ret[0].([]model.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockEventRepositoryMockRecorder) List(filter, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockEventRepository)(nil).List), filter, offset, limit)
+}
+
+// Update mocks base method.
+func (m *MockEventRepository) Update(event *model.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockEventRepositoryMockRecorder) Update(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockEventRepository)(nil).Update), event)
+}
+
+// MockMarketRepository is a mock of MarketRepository interface.
+type MockMarketRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMarketRepositoryMockRecorder
+}
+
+// MockMarketRepositoryMockRecorder is the mock recorder for MockMarketRepository.
+type MockMarketRepositoryMockRecorder struct {
+	mock *MockMarketRepository
+}
+
+// NewMockMarketRepository creates a new mock instance.
+func NewMockMarketRepository(ctrl *gomock.Controller) *MockMarketRepository {
+	mock := &MockMarketRepository{ctrl: ctrl}
+	mock.recorder = &MockMarketRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMarketRepository) EXPECT() *MockMarketRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockMarketRepository) Create(market *model.Market) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", market)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockMarketRepositoryMockRecorder) Create(market interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMarketRepository)(nil).Create), market)
+}
+
+// Delete mocks base method.
+func (m *MockMarketRepository) Delete(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockMarketRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMarketRepository)(nil).Delete), id)
+}
+
+// GetByID mocks base method.
+func (m *MockMarketRepository) GetByID(id uint) (*model.Market, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", id)
+	ret0, _ := ret[0].(*model.Market)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockMarketRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockMarketRepository)(nil).GetByID), id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockMarketRepository) GetByIDForUpdate(id uint) (*model.Market, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", id)
+	ret0, _ := ret[0].(*model.Market)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockMarketRepositoryMockRecorder) GetByIDForUpdate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockMarketRepository)(nil).GetByIDForUpdate), id)
+}
+
+// GetSelection mocks base method.
+func (m *MockMarketRepository) GetSelection(id uint) (*model.Selection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSelection", id)
+	ret0, _ := ret[0].(*model.Selection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSelection indicates an expected call of GetSelection.
+func (mr *MockMarketRepositoryMockRecorder) GetSelection(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSelection", reflect.TypeOf((*MockMarketRepository)(nil).GetSelection), id)
+}
+
+// ListByEvent mocks base method.
+func (m *MockMarketRepository) ListByEvent(eventID uint) ([]model.Market, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByEvent", eventID)
+	ret0, _ := ret[0].([]model.Market)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByEvent indicates an expected call of ListByEvent.
+func (mr *MockMarketRepositoryMockRecorder) ListByEvent(eventID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByEvent", reflect.TypeOf((*MockMarketRepository)(nil).ListByEvent), eventID)
+}
+
+// Update mocks base method.
+func (m *MockMarketRepository) Update(market *model.Market) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", market)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockMarketRepositoryMockRecorder) Update(market interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockMarketRepository)(nil).Update), market)
+}
+
+// MockReservationRepository is a mock of ReservationRepository interface.
+type MockReservationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReservationRepositoryMockRecorder
+}
+
+// MockReservationRepositoryMockRecorder is the mock recorder for MockReservationRepository.
+type MockReservationRepositoryMockRecorder struct {
+	mock *MockReservationRepository
+}
+
+// NewMockReservationRepository creates a new mock instance.
+func NewMockReservationRepository(ctrl *gomock.Controller) *MockReservationRepository {
+	mock := &MockReservationRepository{ctrl: ctrl}
+	mock.recorder = &MockReservationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReservationRepository) EXPECT() *MockReservationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockReservationRepository) Create(reservation *model.Reservation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", reservation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockReservationRepositoryMockRecorder) Create(reservation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockReservationRepository)(nil).Create), reservation)
+}
+
+// GetByID mocks base method.
+func (m *MockReservationRepository) GetByID(id uint) (*model.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", id)
+	ret0, _ := ret[0].(*model.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockReservationRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockReservationRepository)(nil).GetByID), id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockReservationRepository) GetByIDForUpdate(id uint) (*model.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", id)
+	ret0, _ := ret[0].(*model.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockReservationRepositoryMockRecorder) GetByIDForUpdate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockReservationRepository)(nil).GetByIDForUpdate), id)
+}
+
+// ListExpired mocks base method.
+func (m *MockReservationRepository) ListExpired(now int64, limit int) ([]model.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpired", now, limit)
+	ret0, _ := ret[0].([]model.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpired indicates an expected call of ListExpired.
+func (mr *MockReservationRepositoryMockRecorder) ListExpired(now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpired", reflect.TypeOf((*MockReservationRepository)(nil).ListExpired), now, limit)
+}
+
+// ListHeld mocks base method.
+func (m *MockReservationRepository) ListHeld(wagerID uint, limit int) ([]model.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHeld", wagerID, limit)
+	ret0, _ := ret[0].([]model.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHeld indicates an expected call of ListHeld.
+func (mr *MockReservationRepositoryMockRecorder) ListHeld(wagerID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHeld", reflect.TypeOf((*MockReservationRepository)(nil).ListHeld), wagerID, limit)
+}
+
+// Update mocks base method.
+func (m *MockReservationRepository) Update(reservation *model.Reservation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", reservation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockReservationRepositoryMockRecorder) Update(reservation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockReservationRepository)(nil).Update), reservation)
+}
+
+// MockBuyerRepository is a mock of BuyerRepository interface.
+type MockBuyerRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBuyerRepositoryMockRecorder
+}
+
+// MockBuyerRepositoryMockRecorder is the mock recorder for MockBuyerRepository.
+type MockBuyerRepositoryMockRecorder struct {
+	mock *MockBuyerRepository
+}
+
+// NewMockBuyerRepository creates a new mock instance.
+func NewMockBuyerRepository(ctrl *gomock.Controller) *MockBuyerRepository {
+	mock := &MockBuyerRepository{ctrl: ctrl}
+	mock.recorder = &MockBuyerRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBuyerRepository) EXPECT() *MockBuyerRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Lock mocks base method.
+func (m *MockBuyerRepository) Lock(buyer string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", buyer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockBuyerRepositoryMockRecorder) Lock(buyer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockBuyerRepository)(nil).Lock), buyer)
+}
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// Bids mocks base method.
+func (m *MockStore) Bids() repository.BidRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Bids")
+	ret0, _ := ret[0].(repository.BidRepository)
+	return ret0
+}
+
+// Bids indicates an expected call of Bids.
+func (mr *MockStoreMockRecorder) Bids() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Bids", reflect.TypeOf((*MockStore)(nil).Bids))
+}
+
+// Buyers mocks base method.
+func (m *MockStore) Buyers() repository.BuyerRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Buyers")
+	ret0, _ := ret[0].(repository.BuyerRepository)
+	return ret0
+}
+
+// Buyers indicates an expected call of Buyers.
+func (mr *MockStoreMockRecorder) Buyers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Buyers", reflect.TypeOf((*MockStore)(nil).Buyers))
+}
+
+// Events mocks base method.
+func (m *MockStore) Events() repository.EventRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Events")
+	ret0, _ := ret[0].(repository.EventRepository)
+	return ret0
+}
+
+// Events indicates an expected call of Events.
+func (mr *MockStoreMockRecorder) Events() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockStore)(nil).Events))
+}
+
+// Listings mocks base method.
+func (m *MockStore) Listings() repository.ListingRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Listings")
+	ret0, _ := ret[0].(repository.ListingRepository)
+	return ret0
+}
+
+// Listings indicates an expected call of Listings.
+func (mr *MockStoreMockRecorder) Listings() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Listings", reflect.TypeOf((*MockStore)(nil).Listings))
+}
+
+// Markets mocks base method.
+func (m *MockStore) Markets() repository.MarketRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Markets")
+	ret0, _ := ret[0].(repository.MarketRepository)
+	return ret0
+}
+
+// Markets indicates an expected call of Markets.
+func (mr *MockStoreMockRecorder) Markets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Markets", reflect.TypeOf((*MockStore)(nil).Markets))
+}
+
+// Positions mocks base method.
+func (m *MockStore) Positions() repository.PositionRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Positions")
+	ret0, _ := ret[0].(repository.PositionRepository)
+	return ret0
+}
+
+// Positions indicates an expected call of Positions.
+func (mr *MockStoreMockRecorder) Positions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Positions", reflect.TypeOf((*MockStore)(nil).Positions))
+}
+
+// Purchases mocks base method.
+func (m *MockStore) Purchases() repository.PurchaseRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purchases")
+	ret0, _ := ret[0].(repository.PurchaseRepository)
+	return ret0
+}
+
+// Purchases indicates an expected call of Purchases.
+func (mr *MockStoreMockRecorder) Purchases() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purchases", reflect.TypeOf((*MockStore)(nil).Purchases))
+}
+
+// Reservations mocks base method.
+func (m *MockStore) Reservations() repository.ReservationRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reservations")
+	ret0, _ := ret[0].(repository.ReservationRepository)
+	return ret0
+}
+
+// Reservations indicates an expected call of Reservations.
+func (mr *MockStoreMockRecorder) Reservations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reservations", reflect.TypeOf((*MockStore)(nil).Reservations))
+}
+
+// RunInTx mocks base method.
+func (m *MockStore) RunInTx(fn func(repository.Store) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunInTx", fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunInTx indicates an expected call of RunInTx.
+func (mr *MockStoreMockRecorder) RunInTx(fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunInTx", reflect.TypeOf((*MockStore)(nil).RunInTx), fn)
+}
+
+// Session mocks base method.
+func (m *MockStore) Session() repository.Store {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Session")
+	ret0, _ := ret[0].(repository.Store)
+	return ret0
+}
+
+// Session indicates an expected call of Session.
+func (mr *MockStoreMockRecorder) Session() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Session", reflect.TypeOf((*MockStore)(nil).Session))
+}
+
+// Transfers mocks base method.
+func (m *MockStore) Transfers() repository.TransferRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfers")
+	ret0, _ := ret[0].(repository.TransferRepository)
+	return ret0
+}
+
+// Transfers indicates an expected call of Transfers.
+func (mr *MockStoreMockRecorder) Transfers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfers", reflect.TypeOf((*MockStore)(nil).Transfers))
+}
+
+// UsePrimary mocks base method.
+func (m *MockStore) UsePrimary() repository.Store {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UsePrimary")
+	ret0, _ := ret[0].(repository.Store)
+	return ret0
+}
+
+// UsePrimary indicates an expected call of UsePrimary.
+func (mr *MockStoreMockRecorder) UsePrimary() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsePrimary", reflect.TypeOf((*MockStore)(nil).UsePrimary))
+}
+
+// Wagers mocks base method.
+func (m *MockStore) Wagers() repository.WagerRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Wagers")
+	ret0, _ := ret[0].(repository.WagerRepository)
+	return ret0
+}
+
+// Wagers indicates an expected call of Wagers.
+func (mr *MockStoreMockRecorder) Wagers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Wagers", reflect.TypeOf((*MockStore)(nil).Wagers))
+}
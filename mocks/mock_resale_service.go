@@ -0,0 +1,125 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/resale_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	model "wager/model"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockResaleService is a mock of ResaleService interface.
+type MockResaleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockResaleServiceMockRecorder
+}
+
+// MockResaleServiceMockRecorder is the mock recorder for MockResaleService.
+type MockResaleServiceMockRecorder struct {
+	mock *MockResaleService
+}
+
+// NewMockResaleService creates a new mock instance.
+func NewMockResaleService(ctrl *gomock.Controller) *MockResaleService {
+	mock := &MockResaleService{ctrl: ctrl}
+	mock.recorder = &MockResaleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResaleService) EXPECT() *MockResaleServiceMockRecorder {
+	return m.recorder
+}
+
+// BuyListing mocks base method.
+func (m *MockResaleService) BuyListing(request model.BuyListingRequest) (*model.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuyListing", request)
+	ret0, _ := ret[0].(*model.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuyListing indicates an expected call of BuyListing.
+func (mr *MockResaleServiceMockRecorder) BuyListing(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyListing", reflect.TypeOf((*MockResaleService)(nil).BuyListing), request)
+}
+
+// CancelListing mocks base method.
+func (m *MockResaleService) CancelListing(request model.CancelListingRequest) (*model.Listing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelListing", request)
+	ret0, _ := ret[0].(*model.Listing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelListing indicates an expected call of CancelListing.
+func (mr *MockResaleServiceMockRecorder) CancelListing(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelListing", reflect.TypeOf((*MockResaleService)(nil).CancelListing), request)
+}
+
+// CreateListing mocks base method.
+func (m *MockResaleService) CreateListing(request model.CreateListingRequest) (*model.Listing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateListing", request)
+	ret0, _ := ret[0].(*model.Listing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateListing indicates an expected call of CreateListing.
+func (mr *MockResaleServiceMockRecorder) CreateListing(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateListing", reflect.TypeOf((*MockResaleService)(nil).CreateListing), request)
+}
+
+// GetListingList mocks base method.
+func (m *MockResaleService) GetListingList(request model.GetListingListRequest) ([]model.Listing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListingList", request)
+	ret0, _ := ret[0].([]model.Listing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetListingList indicates an expected call of GetListingList.
+func (mr *MockResaleServiceMockRecorder) GetListingList(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListingList", reflect.TypeOf((*MockResaleService)(nil).GetListingList), request)
+}
+
+// GetPositionList mocks base method.
+func (m *MockResaleService) GetPositionList(request model.GetPositionListRequest) ([]model.Position, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPositionList", request)
+	ret0, _ := ret[0].([]model.Position)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPositionList indicates an expected call of GetPositionList.
+func (mr *MockResaleServiceMockRecorder) GetPositionList(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPositionList", reflect.TypeOf((*MockResaleService)(nil).GetPositionList), request)
+}
+
+// GetTransfers mocks base method.
+func (m *MockResaleService) GetTransfers(purchaseID uint) ([]model.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfers", purchaseID)
+	ret0, _ := ret[0].([]model.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfers indicates an expected call of GetTransfers.
+func (mr *MockResaleServiceMockRecorder) GetTransfers(purchaseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfers", reflect.TypeOf((*MockResaleService)(nil).GetTransfers), purchaseID)
+}
@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/reservation_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	model "wager/model"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockReservationService is a mock of ReservationService interface.
+type MockReservationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockReservationServiceMockRecorder
+}
+
+// MockReservationServiceMockRecorder is the mock recorder for MockReservationService.
+type MockReservationServiceMockRecorder struct {
+	mock *MockReservationService
+}
+
+// NewMockReservationService creates a new mock instance.
+func NewMockReservationService(ctrl *gomock.Controller) *MockReservationService {
+	mock := &MockReservationService{ctrl: ctrl}
+	mock.recorder = &MockReservationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReservationService) EXPECT() *MockReservationServiceMockRecorder {
+	return m.recorder
+}
+
+// ConfirmReservation mocks base method.
+func (m *MockReservationService) ConfirmReservation(id uint) (*model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmReservation", id)
+	ret0, _ := ret[0].(*model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmReservation indicates an expected call of ConfirmReservation.
+func (mr *MockReservationServiceMockRecorder) ConfirmReservation(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmReservation", reflect.TypeOf((*MockReservationService)(nil).ConfirmReservation), id)
+}
+
+// ReleaseExpired mocks base method.
+func (m *MockReservationService) ReleaseExpired() ([]model.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseExpired")
+	ret0, _ := ret[0].([]model.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseExpired indicates an expected call of ReleaseExpired.
+func (mr *MockReservationServiceMockRecorder) ReleaseExpired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseExpired", reflect.TypeOf((*MockReservationService)(nil).ReleaseExpired))
+}
+
+// ReleaseReservation mocks base method.
+func (m *MockReservationService) ReleaseReservation(id uint) (*model.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseReservation", id)
+	ret0, _ := ret[0].(*model.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseReservation indicates an expected call of ReleaseReservation.
+func (mr *MockReservationServiceMockRecorder) ReleaseReservation(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseReservation", reflect.TypeOf((*MockReservationService)(nil).ReleaseReservation), id)
+}
+
+// ReserveWager mocks base method.
+func (m *MockReservationService) ReserveWager(request model.ReserveWagerRequest) (*model.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveWager", request)
+	ret0, _ := ret[0].(*model.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveWager indicates an expected call of ReserveWager.
+func (mr *MockReservationServiceMockRecorder) ReserveWager(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveWager", reflect.TypeOf((*MockReservationService)(nil).ReserveWager), request)
+}
@@ -49,6 +49,21 @@ func (mr *MockWagerServiceMockRecorder) BuyWager(request interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyWager", reflect.TypeOf((*MockWagerService)(nil).BuyWager), request)
 }
 
+// BuyWagers mocks base method.
+func (m *MockWagerService) BuyWagers(request model.BatchPurchaseRequest) ([]model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuyWagers", request)
+	ret0, _ := ret[0].([]model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuyWagers indicates an expected call of BuyWagers.
+func (mr *MockWagerServiceMockRecorder) BuyWagers(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyWagers", reflect.TypeOf((*MockWagerService)(nil).BuyWagers), request)
+}
+
 // CreateWager mocks base method.
 func (m *MockWagerService) CreateWager(request model.CreateWagerRequest) (*model.Wager, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +79,36 @@ func (mr *MockWagerServiceMockRecorder) CreateWager(request interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWager", reflect.TypeOf((*MockWagerService)(nil).CreateWager), request)
 }
 
+// CreateWagers mocks base method.
+func (m *MockWagerService) CreateWagers(request model.CreateWagersRequest) ([]model.Wager, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWagers", request)
+	ret0, _ := ret[0].([]model.Wager)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWagers indicates an expected call of CreateWagers.
+func (mr *MockWagerServiceMockRecorder) CreateWagers(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWagers", reflect.TypeOf((*MockWagerService)(nil).CreateWagers), request)
+}
+
+// GetWager mocks base method.
+func (m *MockWagerService) GetWager(id uint) (*model.Wager, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWager", id)
+	ret0, _ := ret[0].(*model.Wager)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWager indicates an expected call of GetWager.
+func (mr *MockWagerServiceMockRecorder) GetWager(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWager", reflect.TypeOf((*MockWagerService)(nil).GetWager), id)
+}
+
 // GetWagerList mocks base method.
 func (m *MockWagerService) GetWagerList(request model.GetWagerListRequest) (*model.GetWagerListResponse, error) {
 	m.ctrl.T.Helper()
@@ -78,3 +123,18 @@ func (mr *MockWagerServiceMockRecorder) GetWagerList(request interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWagerList", reflect.TypeOf((*MockWagerService)(nil).GetWagerList), request)
 }
+
+// QuoteWager mocks base method.
+func (m *MockWagerService) QuoteWager(request model.BuyWagerRequest) (*model.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuoteWager", request)
+	ret0, _ := ret[0].(*model.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QuoteWager indicates an expected call of QuoteWager.
+func (mr *MockWagerServiceMockRecorder) QuoteWager(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuoteWager", reflect.TypeOf((*MockWagerService)(nil).QuoteWager), request)
+}
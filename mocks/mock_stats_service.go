@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/stats_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	model "wager/model"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStatsService is a mock of StatsService interface.
+type MockStatsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatsServiceMockRecorder
+}
+
+// MockStatsServiceMockRecorder is the mock recorder for MockStatsService.
+type MockStatsServiceMockRecorder struct {
+	mock *MockStatsService
+}
+
+// NewMockStatsService creates a new mock instance.
+func NewMockStatsService(ctrl *gomock.Controller) *MockStatsService {
+	mock := &MockStatsService{ctrl: ctrl}
+	mock.recorder = &MockStatsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatsService) EXPECT() *MockStatsServiceMockRecorder {
+	return m.recorder
+}
+
+// GetStats mocks base method.
+func (m *MockStatsService) GetStats(request model.GetStatsRequest) (*model.Stats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", request)
+	ret0, _ := ret[0].(*model.Stats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockStatsServiceMockRecorder) GetStats(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockStatsService)(nil).GetStats), request)
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+type CreateWagersRequest struct {
+	Wagers []CreateWagerRequest `validate:"min=1,max=1000"`
+}
+
+type BatchPurchaseItem struct {
+	WagerID     uint    `json:"wager_id" validate:"gt=0"`
+	Buyer       string  `json:"buyer" validate:"max=64"`
+	BuyingPrice float64 `json:"buying_price" validate:"gt=0,monetary"`
+	Currency    string  `json:"currency" validate:"omitempty,currency"`
+}
+
+type BatchPurchaseRequest struct {
+	Items []BatchPurchaseItem `validate:"min=1,max=100,dive"`
+}
+
+// BatchItemError reports why the item at Index of a batch failed
+type BatchItemError struct {
+	Index   int    `json:"index"`
+	WagerID uint   `json:"wager_id,omitempty"`
+	Error   string `json:"error"`
+	// Code is set for errors with an error code, see package errorcode
+	Code string `json:"code,omitempty"`
+}
+
+// BatchError is returned when some items of a batch failed, in which case none of
+// the items were applied
+type BatchError struct {
+	Items []BatchItemError
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		msgs = append(msgs, fmt.Sprintf("item %v: %v", item.Index, item.Error))
+	}
+	return "batch failed: " + strings.Join(msgs, ", ")
+}
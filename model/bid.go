@@ -0,0 +1,81 @@
+package model
+
+const (
+	BID_STATUS_OPEN      = "open"
+	BID_STATUS_FILLED    = "filled"
+	BID_STATUS_CANCELLED = "cancelled"
+	BID_STATUS_EXPIRED   = "expired"
+)
+
+// Bid offers BidPrice for FaceValue of a wager, see model.Purchase. A bid rests in
+// the book of the wager until it is filled whole, cancelled or expires.
+type Bid struct {
+	ID        uint    `json:"id"`
+	WagerID   uint    `json:"wager_id"`
+	Buyer     string  `json:"buyer"`
+	FaceValue float64 `json:"face_value"`
+	BidPrice  float64 `json:"bid_price"`
+	Status    string  `json:"status"`
+	CreatedAt int64   `json:"created_at"`
+	ExpiresAt int64   `json:"expires_at"`
+	// PurchaseID is set once the bid is filled
+	PurchaseID uint `json:"purchase_id"`
+}
+
+// UnitPrice is what the bid pays for each unit of face value, bids paying more
+// per unit are filled first
+func (b *Bid) UnitPrice() float64 {
+	return b.BidPrice / b.FaceValue
+}
+
+type PlaceBidRequest struct {
+	WagerID   uint    `json:"id" validate:"gt=0"`
+	Buyer     string  `json:"buyer" validate:"max=64"`
+	FaceValue float64 `json:"face_value" validate:"gt=0,monetary"`
+	BidPrice  float64 `json:"bid_price" validate:"gt=0,monetary"`
+	Currency  string  `json:"currency" validate:"omitempty,currency"`
+	// TTLSeconds defaults to the configured bid TTL
+	TTLSeconds int `json:"ttl_seconds" validate:"gte=0"`
+}
+
+// BookEntry is a resting bid as shown in the book, without its buyer
+type BookEntry struct {
+	BidID     uint    `json:"bid_id"`
+	FaceValue float64 `json:"face_value"`
+	BidPrice  float64 `json:"bid_price"`
+	CreatedAt int64   `json:"created_at"`
+	ExpiresAt int64   `json:"expires_at"`
+}
+
+// Book lists the resting bids of a wager in the order they would be filled
+type Book struct {
+	WagerID             uint        `json:"wager_id"`
+	CurrentSellingPrice float64     `json:"current_selling_price"`
+	Bids                []BookEntry `json:"bids"`
+}
+
+// AcceptBidRequest is made by the Seller of the wager of the bid
+type AcceptBidRequest struct {
+	BidID  uint   `json:"id" validate:"gt=0"`
+	Seller string `json:"seller" validate:"max=64"`
+}
+
+// CancelBidRequest is made by the Buyer who placed the bid
+type CancelBidRequest struct {
+	BidID uint   `json:"id" validate:"gt=0"`
+	Buyer string `json:"buyer" validate:"max=64"`
+}
+
+// LowerPriceRequest is made by the Seller of the wager
+type LowerPriceRequest struct {
+	WagerID uint   `json:"id" validate:"gt=0"`
+	Seller  string `json:"seller" validate:"max=64"`
+	// AskPrice is the new price of the whole selling price of the wager
+	AskPrice float64 `json:"ask_price" validate:"gt=0,monetary"`
+}
+
+// LowerPriceResponse is the repriced wager and the purchases of the bids it filled
+type LowerPriceResponse struct {
+	Wager     Wager      `json:"wager"`
+	Purchases []Purchase `json:"purchases"`
+}
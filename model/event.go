@@ -0,0 +1,118 @@
+package model
+
+const (
+	EVENT_STATUS_SCHEDULED = "scheduled"
+	EVENT_STATUS_LIVE      = "live"
+	// EVENT_STATUS_FINISHED and EVENT_STATUS_CANCELLED events take no new markets
+	EVENT_STATUS_FINISHED  = "finished"
+	EVENT_STATUS_CANCELLED = "cancelled"
+
+	// MARKET_STATUS_OPEN markets take new wagers, MARKET_STATUS_SETTLED markets have
+	// a winning selection and their wagers were settled with them
+	MARKET_STATUS_OPEN    = "open"
+	MARKET_STATUS_SETTLED = "settled"
+	// MARKET_STATUS_VOID markets were open when their event was cancelled, their
+	// wagers were voided with them
+	MARKET_STATUS_VOID = "void"
+)
+
+// Event is a sporting event, like a match, which wagers are placed on through the
+// selections of its markets
+type Event struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	StartsAt int64  `json:"starts_at"`
+	Status   string `json:"status"`
+}
+
+type CreateEventRequest struct {
+	Name     string `json:"name" validate:"required,max=128"`
+	StartsAt int64  `json:"starts_at" validate:"gt=0"`
+	// Status defaults to EVENT_STATUS_SCHEDULED
+	Status string `json:"status" validate:"omitempty,oneof=scheduled live finished cancelled"`
+}
+
+// UpdateEventRequest can move a scheduled event to any status and a live one to
+// finished or cancelled, finished and cancelled events keep their status
+type UpdateEventRequest struct {
+	ID       uint   `json:"id" validate:"gt=0"`
+	Name     string `json:"name" validate:"required,max=128"`
+	StartsAt int64  `json:"starts_at" validate:"gt=0"`
+	Status   string `json:"status" validate:"required,oneof=scheduled live finished cancelled"`
+}
+
+// EventFilter selects events, zero fields match any event
+type EventFilter struct {
+	Status string `validate:"omitempty,oneof=scheduled live finished cancelled"`
+}
+
+type GetEventListRequest struct {
+	Filter EventFilter
+	Page   int `validate:"gt=0"`
+	Limit  int `validate:"gt=0"`
+}
+
+// Market is something to bet on in an event, like the winner of a match, with the
+// selections it can be settled with
+type Market struct {
+	ID         uint        `json:"id"`
+	EventID    uint        `json:"event_id"`
+	Name       string      `json:"name"`
+	Status     string      `json:"status"`
+	Selections []Selection `json:"selections"`
+	// WinningSelectionID and SettledAt are set once the market is settled, a void
+	// market only has SettledAt
+	WinningSelectionID uint  `json:"winning_selection_id"`
+	SettledAt          int64 `json:"settled_at"`
+}
+
+// Selection is a possible outcome of a market, wagers back one selection
+type Selection struct {
+	ID       uint   `json:"id"`
+	MarketID uint   `json:"market_id"`
+	Name     string `json:"name"`
+}
+
+type CreateMarketRequest struct {
+	EventID uint   `json:"event_id" validate:"gt=0"`
+	Name    string `json:"name" validate:"required,max=128"`
+	// Selections are the names of the selections in display order
+	Selections []string `json:"selections" validate:"min=2,max=100,dive,required,max=128"`
+}
+
+// UpdateMarketRequest renames a market, its selections can not change once wagers
+// may reference them
+type UpdateMarketRequest struct {
+	ID   uint   `json:"id" validate:"gt=0"`
+	Name string `json:"name" validate:"required,max=128"`
+}
+
+type SettleMarketRequest struct {
+	MarketID           uint `json:"id" validate:"gt=0"`
+	WinningSelectionID uint `json:"winning_selection_id" validate:"gt=0"`
+}
+
+// Payout is what a position of a settled wager pays its holder, nothing when the
+// wager lost
+type Payout struct {
+	WagerID    uint    `json:"wager_id"`
+	PositionID uint    `json:"position_id"`
+	Holder     string  `json:"holder"`
+	Amount     float64 `json:"amount"`
+	Currency   string  `json:"currency"`
+}
+
+// UpdateEventResponse is the updated event and, when it was cancelled, the settlements
+// of the markets it voided
+type UpdateEventResponse struct {
+	Event
+	Settlements []Settlement `json:"settlements,omitempty"`
+}
+
+// Settlement is the outcome of settling or voiding a market: the market, the wagers
+// it settled and the payouts of their positions
+type Settlement struct {
+	Market  Market   `json:"market"`
+	Wagers  []Wager  `json:"wagers"`
+	Payouts []Payout `json:"payouts"`
+}
@@ -0,0 +1,108 @@
+package model
+
+const (
+	LISTING_STATUS_OPEN      = "open"
+	LISTING_STATUS_SOLD      = "sold"
+	LISTING_STATUS_CANCELLED = "cancelled"
+)
+
+// Position is what a holder owns of a purchase. Every purchase opens a position for
+// its buyer, reselling part of a position opens a new one for the buyer of that part
+// under the same original purchase. Settlement pays the holders of the positions.
+type Position struct {
+	ID         uint   `json:"id"`
+	PurchaseID uint   `json:"purchase_id"`
+	WagerID    uint   `json:"wager_id"`
+	Holder     string `json:"holder"`
+	// FaceValue is the part of the wager's selling price held, see Purchase
+	FaceValue float64 `json:"face_value"`
+	// ListedFaceValue is the part of FaceValue offered in open listings
+	ListedFaceValue float64 `json:"listed_face_value"`
+	AcquiredAt      int64   `json:"acquired_at"`
+	// Payout is what the position was paid when its wager was settled
+	Payout float64 `json:"payout"`
+}
+
+// PositionFilter selects positions, zero fields match any position. Positions whose
+// face value was sold or refunded entirely are left out.
+type PositionFilter struct {
+	WagerID uint
+	Holder  string
+}
+
+// BuyerExposure is the face value a buyer holds in its positions, whether it bought
+// it from the wager or from a listing. Positions sold or refunded entirely are left
+// out.
+type BuyerExposure struct {
+	// WagerFaceValue is held in WagerPositions positions of one wager
+	WagerFaceValue float64
+	WagerPositions int
+	// OpenFaceValue is held on all open wagers in the currency of that wager,
+	// including it
+	OpenFaceValue float64
+}
+
+type GetPositionListRequest struct {
+	Filter PositionFilter
+	Page   int `validate:"gt=0"`
+	Limit  int `validate:"gt=0"`
+}
+
+// Listing offers FaceValue of a position for Price until it is sold whole or
+// cancelled
+type Listing struct {
+	ID         uint    `json:"id"`
+	PositionID uint    `json:"position_id"`
+	PurchaseID uint    `json:"purchase_id"`
+	WagerID    uint    `json:"wager_id"`
+	Seller     string  `json:"seller"`
+	FaceValue  float64 `json:"face_value"`
+	Price      float64 `json:"price"`
+	Status     string  `json:"status"`
+	CreatedAt  int64   `json:"created_at"`
+	// TransferID is set once the listing is sold
+	TransferID uint `json:"transfer_id"`
+}
+
+type CreateListingRequest struct {
+	PositionID uint    `json:"id" validate:"gt=0"`
+	Seller     string  `json:"seller" validate:"required,max=64"`
+	FaceValue  float64 `json:"face_value" validate:"gt=0,monetary"`
+	Price      float64 `json:"price" validate:"gt=0,monetary"`
+}
+
+// CancelListingRequest is made by the Seller of the listing, who must still hold its
+// position
+type CancelListingRequest struct {
+	ListingID uint   `json:"id" validate:"gt=0"`
+	Seller    string `json:"seller" validate:"required,max=64"`
+}
+
+type BuyListingRequest struct {
+	ListingID uint   `json:"id" validate:"gt=0"`
+	Buyer     string `json:"buyer" validate:"required,max=64"`
+	Currency  string `json:"currency" validate:"omitempty,currency"`
+}
+
+// Transfer records a listing sold from one position to a new one, the transfers of
+// an original purchase are its ownership history. The seller of the listing pays the
+// maker fee and its buyer the taker fee, see Purchase.
+type Transfer struct {
+	ID             uint    `json:"id"`
+	PurchaseID     uint    `json:"purchase_id"`
+	ListingID      uint    `json:"listing_id"`
+	FromPositionID uint    `json:"from_position_id"`
+	ToPositionID   uint    `json:"to_position_id"`
+	Seller         string  `json:"seller"`
+	Buyer          string  `json:"buyer"`
+	FaceValue      float64 `json:"face_value"`
+	Price          float64 `json:"price"`
+	Fees           Fees    `json:"fees"`
+	TransferredAt  int64   `json:"transferred_at"`
+}
+
+type GetListingListRequest struct {
+	WagerID uint `validate:"gt=0"`
+	Page    int  `validate:"gt=0"`
+	Limit   int  `validate:"gt=0"`
+}
@@ -3,6 +3,49 @@ package model
 type Purchase struct {
 	PurchaseID  uint    `json:"id"`
 	WagerID     uint    `json:"wager_id"`
+	Buyer       string  `json:"buyer"`
 	BuyingPrice float64 `json:"buying_price"`
-	BoughtAt    int64   `json:"bought_at"`
+	// FaceValue is the part of the wager's selling price bought, it is BuyingPrice
+	// unless the price of the wager had decayed
+	FaceValue float64 `json:"face_value"`
+	BoughtAt  int64   `json:"bought_at"`
+	// RefundedAt is 0 until the purchase is refunded
+	RefundedAt int64 `json:"refunded_at"`
+	Fees       Fees  `json:"fees"`
+}
+
+// Fees is the fee breakdown of a purchase. The buyer pays BuyingPrice plus TakerFee
+// and the seller receives BuyingPrice less MakerFee, the rates are the percentages
+// the fees were charged at.
+type Fees struct {
+	MakerFee  float64 `json:"maker_fee"`
+	MakerRate float64 `json:"maker_rate"`
+	TakerFee  float64 `json:"taker_fee"`
+	TakerRate float64 `json:"taker_rate"`
+}
+
+// TradingVolume is what a buyer bought and what was bought from a seller in one
+// currency, refunded purchases are left out
+type TradingVolume struct {
+	Bought float64
+	Sold   float64
+}
+
+// PurchaseFilter selects purchases, zero fields match any purchase. The bought_at
+// range includes both ends.
+type PurchaseFilter struct {
+	WagerID    uint
+	Buyer      string
+	BoughtFrom int64
+	BoughtTo   int64
+}
+
+type GetPurchaseListRequest struct {
+	Filter PurchaseFilter
+	Page   int `validate:"gt=0"`
+	Limit  int `validate:"gt=0"`
+}
+
+type GetPurchaseListResponse struct {
+	Purchases []Purchase
 }
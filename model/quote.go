@@ -0,0 +1,20 @@
+package model
+
+import "wager/utils"
+
+// Quote previews a purchase: the state of the wager after it, the part of the wager
+// it buys and what it pays out if the wager wins
+type Quote struct {
+	WagerID             uint              `json:"wager_id"`
+	BuyingPrice         float64           `json:"buying_price"`
+	FaceValue           float64           `json:"face_value"`
+	CurrentSellingPrice float64           `json:"current_selling_price"`
+	PercentageSold      utils.NullUint    `json:"percentage_sold"`
+	AmountSold          utils.NullFloat64 `json:"amount_sold"`
+	// SharePercentage is the percentage of the whole wager bought by the purchase
+	SharePercentage float64 `json:"share_percentage"`
+	PotentialPayout float64 `json:"potential_payout"`
+	Fees            Fees    `json:"fees"`
+	// TotalCost is what the buyer pays, the buying price and the taker fee
+	TotalCost float64 `json:"total_cost"`
+}
@@ -0,0 +1,34 @@
+package model
+
+const (
+	RESERVATION_STATUS_HELD      = "held"
+	RESERVATION_STATUS_CONFIRMED = "confirmed"
+	RESERVATION_STATUS_RELEASED  = "released"
+	// RESERVATION_STATUS_EXPIRED reservations were released by the sweeper
+	RESERVATION_STATUS_EXPIRED = "expired"
+)
+
+// Reservation holds BuyingPrice of a wager for a buyer until it is confirmed into a
+// purchase, released, or expires
+type Reservation struct {
+	ID          uint    `json:"id"`
+	WagerID     uint    `json:"wager_id"`
+	Buyer       string  `json:"buyer"`
+	BuyingPrice float64 `json:"buying_price"`
+	// FaceValue is held off the wager's selling price, see model.Purchase
+	FaceValue float64 `json:"face_value"`
+	Status    string  `json:"status"`
+	CreatedAt int64   `json:"created_at"`
+	ExpiresAt int64   `json:"expires_at"`
+	// PurchaseID is set once the reservation is confirmed
+	PurchaseID uint `json:"purchase_id"`
+}
+
+type ReserveWagerRequest struct {
+	WagerID     uint    `json:"id" validate:"gt=0"`
+	Buyer       string  `json:"buyer" validate:"max=64"`
+	BuyingPrice float64 `json:"buying_price" validate:"gt=0,monetary"`
+	Currency    string  `json:"currency" validate:"omitempty,currency"`
+	// TTLSeconds defaults to the configured reservation TTL
+	TTLSeconds int `json:"ttl_seconds" validate:"gte=0"`
+}
@@ -0,0 +1,56 @@
+package model
+
+const (
+	SOCKET_SUBSCRIBE   = "subscribe"
+	SOCKET_UNSUBSCRIBE = "unsubscribe"
+	SOCKET_BUY         = "buy"
+	SOCKET_QUOTE       = "quote"
+
+	SOCKET_ACK   = "ack"
+	SOCKET_ERROR = "error"
+	SOCKET_EVENT = "event"
+)
+
+// SocketRequest is a message sent by a client of /ws. ID is chosen by the client and
+// is returned in the ack or error answering the message.
+type SocketRequest struct {
+	ID   string `json:"id" validate:"max=64"`
+	Type string `json:"type" validate:"oneof=subscribe unsubscribe buy quote"`
+	// WagerIDs are the wagers to subscribe to or unsubscribe from, all wagers when
+	// it is empty. LastEventID resumes a new subscription like Last-Event-ID does
+	// for the wager stream.
+	WagerIDs    []uint `json:"wager_ids" validate:"max=100"`
+	LastEventID string `json:"last_event_id" validate:"max=64"`
+	// The wager to buy or quote and the purchase
+	WagerID     uint    `json:"wager_id"`
+	Buyer       string  `json:"buyer"`
+	BuyingPrice float64 `json:"buying_price"`
+	Currency    string  `json:"currency"`
+}
+
+func (r SocketRequest) BuyWagerRequest() BuyWagerRequest {
+	return BuyWagerRequest{
+		WagerID:     r.WagerID,
+		Buyer:       r.Buyer,
+		BuyingPrice: r.BuyingPrice,
+		Currency:    r.Currency,
+	}
+}
+
+// SocketMessage is a message sent to a client of /ws: the ack or error answering
+// the client message ID, or an event of its subscription
+type SocketMessage struct {
+	Type  string      `json:"type"`
+	ID    string      `json:"id,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+	Event *WagerEvent `json:"event,omitempty"`
+	Error interface{} `json:"error,omitempty"`
+	Code  string      `json:"code,omitempty"`
+}
+
+// SocketSubscription is the ack of subscribe and unsubscribe, a subscription with no
+// wager ids follows every wager
+type SocketSubscription struct {
+	Subscribed bool   `json:"subscribed"`
+	WagerIDs   []uint `json:"wager_ids"`
+}
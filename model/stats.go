@@ -0,0 +1,27 @@
+package model
+
+// FeeRevenue sums the fees of the purchases in Currency bought in a period and of the
+// listings resold in it, refunded purchases are left out
+type FeeRevenue struct {
+	Currency  string  `json:"currency"`
+	Purchases int     `json:"purchases"`
+	Transfers int     `json:"transfers"`
+	Volume    float64 `json:"volume"`
+	MakerFees float64 `json:"maker_fees"`
+	TakerFees float64 `json:"taker_fees"`
+	Total     float64 `json:"total"`
+}
+
+type Stats struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+	// FeeRevenue has the revenue of each currency with purchases, by currency code
+	FeeRevenue []FeeRevenue `json:"fee_revenue"`
+}
+
+// GetStatsRequest selects the purchases bought between From and To, both included,
+// a zero bound leaves the period open on that side
+type GetStatsRequest struct {
+	From int64 `validate:"gte=0"`
+	To   int64 `validate:"gte=0"`
+}
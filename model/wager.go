@@ -1,31 +1,107 @@
 package model
 
 import (
+	"wager/odds"
 	"wager/utils"
 )
 
+const (
+	// WAGER_STATUS_OPEN wagers can be bought and their purchases refunded
+	WAGER_STATUS_OPEN = "open"
+	// WAGER_STATUS_WON and WAGER_STATUS_LOST wagers were settled with their market
+	WAGER_STATUS_WON  = "won"
+	WAGER_STATUS_LOST = "lost"
+	// WAGER_STATUS_VOID wagers were voided with their market, their positions are
+	// paid back what their holders paid for them
+	WAGER_STATUS_VOID = "void"
+
+	// PRICE_SCHEDULE_LINEAR prices decay evenly from SellingPrice to FloorPrice over
+	// DecaySeconds, PRICE_SCHEDULE_STEP prices drop in DecaySteps equal steps
+	PRICE_SCHEDULE_LINEAR = "linear"
+	PRICE_SCHEDULE_STEP   = "step"
+)
+
 type Wager struct {
 	ID                  uint              `json:"id"`
 	TotalWagerValue     uint              `json:"total_wager_value"`
-	Odds                uint              `json:"odds"`
+	Odds                odds.Odds         `json:"odds"`
 	SellingPercentage   uint              `json:"selling_percentage"`
 	SellingPrice        float64           `json:"selling_price"`
 	CurrentSellingPrice float64           `json:"current_selling_price"`
 	PercentageSold      utils.NullUint    `json:"percentage_sold"`
 	AmountSold          utils.NullFloat64 `json:"amount_sold"`
 	PlaceAt             int64             `json:"place_at"`
+	Status              string            `json:"status"`
+	// ReservedAmount is held by reservations and already taken off CurrentSellingPrice
+	ReservedAmount float64 `json:"reserved_amount"`
+	// MinPurchase, MaxPurchase and PurchaseIncrement bound the buying price of a
+	// purchase, 0 leaves it unbounded
+	MinPurchase       float64 `json:"min_purchase"`
+	MaxPurchase       float64 `json:"max_purchase"`
+	PurchaseIncrement float64 `json:"purchase_increment"`
+	// PriceSchedule is empty for a fixed price. With a schedule CurrentSellingPrice is
+	// stored at the undecayed price and decayed when the wager is read or bought.
+	PriceSchedule string  `json:"price_schedule"`
+	FloorPrice    float64 `json:"floor_price"`
+	DecaySeconds  int64   `json:"decay_seconds"`
+	DecaySteps    int     `json:"decay_steps"`
+	// AskPrice is set when the seller lowers the price, it caps the price of the
+	// whole SellingPrice like a price schedule does
+	AskPrice float64 `json:"ask_price"`
+	// Seller is charged the maker fees of the purchases, it may be empty
+	Seller string `json:"seller"`
+	// Currency is the ISO 4217 code of all the amounts of the wager and its purchases
+	Currency string `json:"currency"`
+	// SelectionID is the market selection the wager backs, 0 for a wager which is not
+	// on a market. EventID and MarketID are those of the selection.
+	EventID     uint `json:"event_id"`
+	MarketID    uint `json:"market_id"`
+	SelectionID uint `json:"selection_id"`
+	// Version is incremented by every update of the wager, the events of later
+	// changes carry higher versions
+	Version uint64 `json:"version"`
 }
 
 type CreateWagerRequest struct {
-	TotalWagerValue   uint    `json:"total_wager_value" validate:"gt=0"`
-	Odds              uint    `json:"odds" validate:"gt=0"`
-	SellingPercentage uint    `json:"selling_percentage" validate:"gte=1,lte=100"`
-	SellingPrice      float64 `json:"selling_price" validate:"gt=0,monetary-format"`
+	TotalWagerValue uint `json:"total_wager_value" validate:"gt=0"`
+	// Odds are read in OddsFormat, decimal when it is empty
+	Odds              odds.Value `json:"odds" validate:"required"`
+	OddsFormat        string     `json:"odds_format" validate:"omitempty,oneof=decimal fractional american"`
+	SellingPercentage uint       `json:"selling_percentage" validate:"gte=1,lte=100"`
+	SellingPrice      float64    `json:"selling_price" validate:"gt=0"`
+	MinPurchase       float64    `json:"min_purchase" validate:"gte=0"`
+	MaxPurchase       float64    `json:"max_purchase" validate:"gte=0"`
+	PurchaseIncrement float64    `json:"purchase_increment" validate:"gte=0"`
+	PriceSchedule     string     `json:"price_schedule" validate:"omitempty,oneof=linear step"`
+	FloorPrice        float64    `json:"floor_price" validate:"gte=0"`
+	DecaySeconds      int64      `json:"decay_seconds" validate:"gte=0"`
+	DecaySteps        int        `json:"decay_steps" validate:"gte=0"`
+	Seller            string     `json:"seller" validate:"max=64"`
+	// Currency defaults to currency.DEFAULT, the amounts above must not have more
+	// decimal places than it
+	Currency string `json:"currency" validate:"omitempty,currency"`
+	// SelectionID optionally places the wager on a selection of an open market
+	SelectionID uint `json:"selection_id"`
+}
+
+// WagerView renders a wager with its odds in the format asked by the client
+type WagerView struct {
+	Wager
+	Odds       interface{} `json:"odds"`
+	OddsFormat string      `json:"odds_format"`
+}
+
+// WagerFilter selects wagers, zero fields match any wager
+type WagerFilter struct {
+	Currency string `validate:"omitempty,currency"`
+	EventID  uint
+	MarketID uint
 }
 
 type GetWagerListRequest struct {
-	Page  int `validate:"gt=0"`
-	Limit int `validate:"gt=0"`
+	Filter WagerFilter
+	Page   int `validate:"gt=0"`
+	Limit  int `validate:"gt=0"`
 }
 
 type GetWagerListResponse struct {
@@ -34,5 +110,8 @@ type GetWagerListResponse struct {
 
 type BuyWagerRequest struct {
 	WagerID     uint    `json:"id" validate:"gt=0"`
-	BuyingPrice float64 `json:"buying_price" validate:"gt=0"`
+	Buyer       string  `json:"buyer" validate:"max=64"`
+	BuyingPrice float64 `json:"buying_price" validate:"gt=0,monetary"`
+	// Currency must be the currency of the wager when it is given
+	Currency string `json:"currency" validate:"omitempty,currency"`
 }
@@ -0,0 +1,51 @@
+package model
+
+const (
+	WAGER_EVENT_CREATED  = "created"
+	WAGER_EVENT_PURCHASE = "purchase"
+	// WAGER_EVENT_RESERVATION reports a wager with part of it reserved or released
+	WAGER_EVENT_RESERVATION = "reservation"
+	WAGER_EVENT_REFUND      = "refund"
+	// WAGER_EVENT_PRICE reports a wager whose ask price was lowered without filling
+	// any bid
+	WAGER_EVENT_PRICE = "price"
+	// WAGER_EVENT_STATUS reports a wager whose status changed, like a wager settled
+	// won or lost
+	WAGER_EVENT_STATUS = "status"
+	// WAGER_EVENT_SETTLEMENT reports a settled or void market with the wagers it settled
+	WAGER_EVENT_SETTLEMENT = "settlement"
+	// WAGER_EVENT_RESET tells a resuming subscriber that events were missed, the
+	// wagers it follows must be read again
+	WAGER_EVENT_RESET = "reset"
+)
+
+// WagerEvent is a committed change of wagers, pushed to the subscribers of the wager
+// stream. IDs are given by the broker, reset events have none.
+type WagerEvent struct {
+	ID   string `json:"id,omitempty"`
+	Type string `json:"type"`
+	At   int64  `json:"at"`
+	// Wager is the wager as the change left it, for every type but settlement. Its
+	// Version orders the events of the wager.
+	Wager *Wager `json:"wager,omitempty"`
+	// Market and WagerIDs are the settled market and its wagers, for settlements
+	Market   *Market `json:"market,omitempty"`
+	WagerIDs []uint  `json:"wager_ids,omitempty"`
+}
+
+// Concerns reports whether the event is about one of wagerIDs, every event concerns
+// an empty set
+func (e WagerEvent) Concerns(wagerIDs map[uint]bool) bool {
+	if len(wagerIDs) == 0 {
+		return true
+	}
+	if e.Wager != nil && wagerIDs[e.Wager.ID] {
+		return true
+	}
+	for _, id := range e.WagerIDs {
+		if wagerIDs[id] {
+			return true
+		}
+	}
+	return false
+}
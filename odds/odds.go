@@ -0,0 +1,236 @@
+// Package odds converts odds between the formats clients use and the internal unit
+// wagers are stored in.
+//
+// Odds are stored as decimal odds, the total paid per unit staked on a win, in
+// ten-thousandths: decimal 2.5, fractional 3/2 and American +150 are all stored as
+// 25000. Converting to the internal unit rounds half up to the nearest
+// ten-thousandth, rendering fractional odds picks the fraction with the smallest
+// denominator up to MAX_DENOMINATOR that converts back to the same odds, and
+// American odds are rendered rounded half up to a whole number.
+package odds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	FORMAT_DECIMAL    = "decimal"
+	FORMAT_FRACTIONAL = "fractional"
+	FORMAT_AMERICAN   = "american"
+
+	// SCALE is the internal unit of decimal odds 1
+	SCALE Odds = 10000
+	// MAX_DECIMAL bounds the decimal odds of a wager
+	MAX_DECIMAL = 10000
+	// MAX_DENOMINATOR bounds the denominators tried when rendering fractional odds
+	MAX_DENOMINATOR = 100
+)
+
+var (
+	ErrInvalidDecimal    = errors.New("must be decimal odds larger than 1, like 2.5")
+	ErrInvalidFractional = errors.New("must be fractional odds larger than 0, like 3/2")
+	ErrInvalidAmerican   = errors.New("must be American odds of at least +100 or at most -100, like +150")
+	ErrTooLarge          = fmt.Errorf("must be at most %v in decimal odds", MAX_DECIMAL)
+	ErrUnknownFormat     = fmt.Errorf("must be one of %v %v %v", FORMAT_DECIMAL, FORMAT_FRACTIONAL, FORMAT_AMERICAN)
+)
+
+var decimalPattern = regexp.MustCompile(`^[0-9]{1,9}(\.[0-9]{1,9})?$`)
+
+// Odds are decimal odds in ten-thousandths, see the package documentation
+type Odds uint
+
+// Parse converts value given in format to odds, an empty format is decimal
+func Parse(value string, format string) (Odds, error) {
+	value = strings.TrimSpace(value)
+	var decimal *big.Rat
+	var err error
+	switch format {
+	case "", FORMAT_DECIMAL:
+		decimal, err = parseDecimal(value)
+	case FORMAT_FRACTIONAL:
+		decimal, err = parseFractional(value)
+	case FORMAT_AMERICAN:
+		decimal, err = parseAmerican(value)
+	default:
+		return 0, ErrUnknownFormat
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if decimal.Cmp(big.NewRat(MAX_DECIMAL, 1)) > 0 {
+		return 0, ErrTooLarge
+	}
+	odds := Odds(roundHalfUp(new(big.Rat).Mul(decimal, big.NewRat(int64(SCALE), 1))))
+	// odds rounding down to 1 pay nothing on a win
+	if odds <= SCALE {
+		return 0, invalidError(format)
+	}
+	return odds, nil
+}
+
+func parseDecimal(value string) (*big.Rat, error) {
+	// big.Rat also reads fractions and exponents, which are not decimal odds
+	if !decimalPattern.MatchString(value) {
+		return nil, ErrInvalidDecimal
+	}
+	decimal, ok := new(big.Rat).SetString(value)
+	if !ok || decimal.Cmp(big.NewRat(1, 1)) <= 0 {
+		return nil, ErrInvalidDecimal
+	}
+	return decimal, nil
+}
+
+func parseFractional(value string) (*big.Rat, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return nil, ErrInvalidFractional
+	}
+	numerator, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil || numerator == 0 {
+		return nil, ErrInvalidFractional
+	}
+	denominator, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil || denominator == 0 {
+		return nil, ErrInvalidFractional
+	}
+	fraction := big.NewRat(int64(numerator), int64(denominator))
+	return fraction.Add(fraction, big.NewRat(1, 1)), nil
+}
+
+func parseAmerican(value string) (*big.Rat, error) {
+	american, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || (american > -100 && american < 100) {
+		return nil, ErrInvalidAmerican
+	}
+	// +150 wins 150 per 100 staked, -200 stakes 200 to win 100
+	if american > 0 {
+		return big.NewRat(american+100, 100), nil
+	}
+	return big.NewRat(-american+100, -american), nil
+}
+
+func invalidError(format string) error {
+	switch format {
+	case FORMAT_FRACTIONAL:
+		return ErrInvalidFractional
+	case FORMAT_AMERICAN:
+		return ErrInvalidAmerican
+	default:
+		return ErrInvalidDecimal
+	}
+}
+
+// roundHalfUp rounds a positive rational to the nearest integer, halves up
+func roundHalfUp(value *big.Rat) int64 {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if new(big.Int).Mul(remainder, big.NewInt(2)).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient.Int64()
+}
+
+// Decimal returns the decimal odds, the payout multiplier of a win
+func (o Odds) Decimal() float64 {
+	return float64(o) / float64(SCALE)
+}
+
+// Fractional returns the odds as the profit per stake, like 3/2
+func (o Odds) Fractional() string {
+	profit := int64(o) - int64(SCALE)
+	if profit <= 0 {
+		return "0/1"
+	}
+	for denominator := int64(1); denominator <= MAX_DENOMINATOR; denominator++ {
+		numerator := roundHalfUp(big.NewRat(profit*denominator, int64(SCALE)))
+		if numerator > 0 && roundHalfUp(big.NewRat(numerator*int64(SCALE), denominator)) == profit {
+			return fmt.Sprintf("%d/%d", numerator, denominator)
+		}
+	}
+
+	fraction := big.NewRat(profit, int64(SCALE))
+	return fraction.String()
+}
+
+// American returns the odds as the profit per 100 staked, like +150, or the stake
+// winning 100, like -200. Even odds are +100.
+func (o Odds) American() string {
+	profit := int64(o) - int64(SCALE)
+	// odds of 1 or less only come from wagers placed without odds
+	if profit <= 0 {
+		return "0"
+	}
+	if profit >= int64(SCALE) {
+		return fmt.Sprintf("+%d", roundHalfUp(big.NewRat(profit*100, int64(SCALE))))
+	}
+	return fmt.Sprintf("-%d", roundHalfUp(big.NewRat(int64(SCALE)*100, profit)))
+}
+
+// Render returns the odds in format for a JSON response, decimal odds are a number
+// and the other formats strings
+func (o Odds) Render(format string) (interface{}, error) {
+	switch format {
+	case "", FORMAT_DECIMAL:
+		return o.Decimal(), nil
+	case FORMAT_FRACTIONAL:
+		return o.Fractional(), nil
+	case FORMAT_AMERICAN:
+		return o.American(), nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// MarshalJSON renders the odds in decimal, so wagers keep their odds in JSON
+func (o Odds) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Decimal())
+}
+
+// UnmarshalJSON reads decimal odds as written by MarshalJSON
+func (o *Odds) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var decimal float64
+	if err := json.Unmarshal(data, &decimal); err != nil {
+		return err
+	}
+	if decimal < 0 {
+		return ErrInvalidDecimal
+	}
+	*o = Odds(math.Round(decimal * float64(SCALE)))
+	return nil
+}
+
+// Value is odds as given by a client, a JSON number or string in any format
+type Value string
+
+func (v *Value) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*v = Value(text)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*v = Value(number)
+	return nil
+}
+
+// Parse converts the value given in format to odds
+func (v Value) Parse(format string) (Odds, error) {
+	return Parse(string(v), format)
+}
@@ -0,0 +1,131 @@
+package odds
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		format string
+		odds   Odds
+		err    error
+	}{
+		{name: "Decimal", value: "2.5", format: FORMAT_DECIMAL, odds: 25000},
+		{name: "Decimal is the default format", value: "2.50", odds: 25000},
+		{name: "Whole decimal", value: "120", odds: 1200000},
+		{name: "Decimal rounds half up", value: "1.00005", odds: 10001},
+		{name: "Decimal rounds down", value: "1.33334", odds: 13333},
+		{name: "Decimal of 1", value: "1", err: ErrInvalidDecimal},
+		{name: "Decimal rounding to 1", value: "1.00004", err: ErrInvalidDecimal},
+		{name: "Decimal with exponent", value: "2e1", err: ErrInvalidDecimal},
+		{name: "Decimal fraction", value: "3/2", err: ErrInvalidDecimal},
+		{name: "Negative decimal", value: "-2", err: ErrInvalidDecimal},
+		{name: "Decimal too large", value: "10000.01", err: ErrTooLarge},
+		{name: "Largest decimal", value: "10000", odds: 100000000},
+		{name: "Fractional", value: "3/2", format: FORMAT_FRACTIONAL, odds: 25000},
+		{name: "Fractional evens", value: "1/1", format: FORMAT_FRACTIONAL, odds: 20000},
+		{name: "Fractional odds on", value: "1/3", format: FORMAT_FRACTIONAL, odds: 13333},
+		{name: "Fractional rounds half up", value: "1/16", format: FORMAT_FRACTIONAL, odds: 10625},
+		{name: "Fractional rounds up", value: "2/3", format: FORMAT_FRACTIONAL, odds: 16667},
+		{name: "Fractional with spaces", value: " 5/4 ", format: FORMAT_FRACTIONAL, odds: 22500},
+		{name: "Fractional of 0", value: "0/1", format: FORMAT_FRACTIONAL, err: ErrInvalidFractional},
+		{name: "Fractional by 0", value: "1/0", format: FORMAT_FRACTIONAL, err: ErrInvalidFractional},
+		{name: "Fractional decimal", value: "2.5", format: FORMAT_FRACTIONAL, err: ErrInvalidFractional},
+		{name: "Fractional rounding to 1", value: "1/100000", format: FORMAT_FRACTIONAL, err: ErrInvalidFractional},
+		{name: "American underdog", value: "+150", format: FORMAT_AMERICAN, odds: 25000},
+		{name: "American without sign", value: "150", format: FORMAT_AMERICAN, odds: 25000},
+		{name: "American favourite", value: "-200", format: FORMAT_AMERICAN, odds: 15000},
+		{name: "American evens", value: "+100", format: FORMAT_AMERICAN, odds: 20000},
+		{name: "American evens favourite", value: "-100", format: FORMAT_AMERICAN, odds: 20000},
+		{name: "American rounds", value: "-110", format: FORMAT_AMERICAN, odds: 19091},
+		{name: "American between -100 and +100", value: "+50", format: FORMAT_AMERICAN, err: ErrInvalidAmerican},
+		{name: "American decimal", value: "+150.5", format: FORMAT_AMERICAN, err: ErrInvalidAmerican},
+		{name: "American too large", value: "+1000000", format: FORMAT_AMERICAN, err: ErrTooLarge},
+		{name: "Unknown format", value: "2", format: "malay", err: ErrUnknownFormat},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			odds, err := Parse(tc.value, tc.format)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.odds, odds)
+		})
+	}
+}
+
+func Test_Render(t *testing.T) {
+	tests := []struct {
+		odds       Odds
+		decimal    float64
+		fractional string
+		american   string
+	}{
+		{odds: 25000, decimal: 2.5, fractional: "3/2", american: "+150"},
+		{odds: 20000, decimal: 2, fractional: "1/1", american: "+100"},
+		{odds: 15000, decimal: 1.5, fractional: "1/2", american: "-200"},
+		{odds: 13333, decimal: 1.3333, fractional: "1/3", american: "-300"},
+		{odds: 19091, decimal: 1.9091, fractional: "10/11", american: "-110"},
+		{odds: 1200000, decimal: 120, fractional: "119/1", american: "+11900"},
+		// no fraction with a denominator up to MAX_DENOMINATOR converts back
+		{odds: 10001, decimal: 1.0001, fractional: "1/10000", american: "-1000000"},
+		{odds: 12345, decimal: 1.2345, fractional: "469/2000", american: "-426"},
+		// American odds round half up
+		{odds: 10625, decimal: 1.0625, fractional: "1/16", american: "-1600"},
+		{odds: 20050, decimal: 2.005, fractional: "201/200", american: "+101"},
+		{odds: 0, decimal: 0, fractional: "0/1", american: "0"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.decimal, tc.odds.Decimal())
+		assert.Equal(t, tc.fractional, tc.odds.Fractional())
+		assert.Equal(t, tc.american, tc.odds.American())
+	}
+
+	rendered, err := Odds(25000).Render(FORMAT_AMERICAN)
+	assert.NoError(t, err)
+	assert.Equal(t, "+150", rendered)
+	_, err = Odds(25000).Render("malay")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+// every odds a client can give in a format renders back to what was given
+func Test_RoundTrip(t *testing.T) {
+	tests := []struct {
+		format string
+		values []string
+	}{
+		{format: FORMAT_FRACTIONAL, values: []string{"1/1", "3/2", "1/3", "10/11", "5/4", "100/1", "1/100"}},
+		{format: FORMAT_AMERICAN, values: []string{"+100", "+150", "-200", "-110", "+250", "-125"}},
+	}
+	for _, tc := range tests {
+		for _, value := range tc.values {
+			odds, err := Parse(value, tc.format)
+			assert.NoError(t, err)
+			rendered, err := odds.Render(tc.format)
+			assert.NoError(t, err)
+			assert.Equal(t, value, rendered)
+		}
+	}
+}
+
+func Test_JSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Odds Odds `json:"odds"`
+	}{Odds: 19091})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"odds": 1.9091}`, string(data))
+
+	var read struct {
+		Odds Odds `json:"odds"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &read))
+	assert.Equal(t, Odds(19091), read.Odds)
+
+	var values []Value
+	assert.NoError(t, json.Unmarshal([]byte(`[2.50, "3/2", -200, "+150"]`), &values))
+	assert.Equal(t, []Value{"2.50", "3/2", "-200", "+150"}, values)
+	assert.Error(t, json.Unmarshal([]byte(`[true]`), &values))
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"fmt"
+	"wager/database"
+	"wager/model"
+)
+
+const bidColumns = "id, wager_id, buyer, face_value, bid_price, status, created_at, expires_at, purchase_id"
+
+type bidQueries struct {
+	insert           string
+	getByID          string
+	getByIDForUpdate string
+	listOpen         string
+	listExpired      string
+	listWagerIDs     string
+	update           string
+}
+
+func newBidQueries(dialect database.Dialect, table string) *bidQueries {
+	return &bidQueries{
+		insert:           insertStatement(dialect, fmt.Sprintf("INSERT INTO %v (wager_id, buyer, face_value, bid_price, status, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)", table)),
+		getByID:          dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?", bidColumns, table)),
+		getByIDForUpdate: dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?%v", bidColumns, table, dialect.LockClause())),
+		listOpen:         dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE wager_id=? AND status=? ORDER BY bid_price / face_value DESC, id", bidColumns, table)),
+		listExpired:      dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE status=? AND expires_at<=? ORDER BY id LIMIT ?", bidColumns, table)),
+		listWagerIDs:     dialect.Rebind(fmt.Sprintf("SELECT DISTINCT wager_id FROM %v WHERE status=? AND wager_id>? ORDER BY wager_id LIMIT ?", table)),
+		update:           dialect.Rebind(fmt.Sprintf("UPDATE %v SET status=?, purchase_id=? WHERE id=?", table)),
+	}
+}
+
+type bidRepository struct {
+	queries *bidQueries
+	dialect database.Dialect
+	db      database.Executor
+}
+
+func newBidRepository(queries *bidQueries, dialect database.Dialect, db database.Executor) *bidRepository {
+	return &bidRepository{queries: queries, dialect: dialect, db: db}
+}
+
+func (r *bidRepository) Create(bid *model.Bid) error {
+	id, err := insertReturningID(r.db, r.dialect, r.queries.insert, bid.WagerID, bid.Buyer, bid.FaceValue, bid.BidPrice, bid.Status, bid.CreatedAt, bid.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create bid: %w", err)
+	}
+
+	bid.ID = uint(id)
+	return nil
+}
+
+func (r *bidRepository) GetByID(id uint) (*model.Bid, error) {
+	return r.getOne(r.queries.getByID, id)
+}
+
+func (r *bidRepository) GetByIDForUpdate(id uint) (*model.Bid, error) {
+	return r.getOne(r.queries.getByIDForUpdate, id)
+}
+
+func (r *bidRepository) ListOpen(wagerID uint) ([]model.Bid, error) {
+	return r.list(r.queries.listOpen, wagerID, model.BID_STATUS_OPEN)
+}
+
+func (r *bidRepository) ListExpired(now int64, limit int) ([]model.Bid, error) {
+	return r.list(r.queries.listExpired, model.BID_STATUS_OPEN, now, limit)
+}
+
+func (r *bidRepository) ListWagerIDs(afterID uint, limit int) ([]uint, error) {
+	rows, err := r.db.Query(r.queries.listWagerIDs, model.BID_STATUS_OPEN, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bid wager ids: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]uint, 0)
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan bid wager id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate bid wager ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *bidRepository) Update(bid *model.Bid) error {
+	if _, err := r.db.Exec(r.queries.update, bid.Status, bid.PurchaseID, bid.ID); err != nil {
+		return fmt.Errorf("failed to update bid: %w", err)
+	}
+	return nil
+}
+
+func (r *bidRepository) list(query string, args ...interface{}) ([]model.Bid, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bids: %w", err)
+	}
+	defer rows.Close()
+
+	bids := make([]model.Bid, 0)
+	for rows.Next() {
+		bid, err := scanBid(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bid: %w", err)
+		}
+		bids = append(bids, *bid)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate bids: %w", err)
+	}
+
+	return bids, nil
+}
+
+func (r *bidRepository) getOne(query string, args ...interface{}) (*model.Bid, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bid: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get bid: %w", err)
+		}
+		return nil, ErrNotFound
+	}
+
+	bid, err := scanBid(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan bid: %w", err)
+	}
+
+	return bid, nil
+}
+
+// scanBid reads a row selected with bidColumns
+func scanBid(rows database.DBRows) (*model.Bid, error) {
+	bid := model.Bid{}
+	err := rows.Scan(&bid.ID,
+		&bid.WagerID,
+		&bid.Buyer,
+		&bid.FaceValue,
+		&bid.BidPrice,
+		&bid.Status,
+		&bid.CreatedAt,
+		&bid.ExpiresAt,
+		&bid.PurchaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bid, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"fmt"
+	"wager/database"
+)
+
+type buyerQueries struct {
+	insert string
+	lock   string
+}
+
+func newBuyerQueries(dialect database.Dialect, table string) *buyerQueries {
+	return &buyerQueries{
+		insert: dialect.Rebind(dialect.InsertIgnore(fmt.Sprintf("INSERT INTO %v (name) VALUES (?)", table))),
+		lock:   dialect.Rebind(fmt.Sprintf("SELECT name FROM %v WHERE name=?", table) + dialect.LockClause()),
+	}
+}
+
+type buyerRepository struct {
+	queries *buyerQueries
+	db      database.Executor
+}
+
+func newBuyerRepository(queries *buyerQueries, db database.Executor) *buyerRepository {
+	return &buyerRepository{queries: queries, db: db}
+}
+
+// Lock locks the row of the buyer, and creates it the first time the buyer is seen.
+// The row is locked before it is inserted, since an insert skipping an existing row
+// only takes a shared lock on it with MySQL.
+func (r *buyerRepository) Lock(name string) error {
+	locked, err := r.lock(name)
+	if err != nil || locked {
+		return err
+	}
+
+	// a buyer inserted by a running transaction is waited for
+	if _, err := r.db.Exec(r.queries.insert, name); err != nil {
+		return fmt.Errorf("failed to create buyer: %w", err)
+	}
+	locked, err = r.lock(name)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return fmt.Errorf("failed to lock buyer %q", name)
+	}
+	return nil
+}
+
+func (r *buyerRepository) lock(name string) (bool, error) {
+	rows, err := r.db.Query(r.queries.lock, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock buyer: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return true, nil
+	}
+	return false, rows.Err()
+}
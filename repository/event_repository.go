@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"wager/database"
+	"wager/model"
+)
+
+const eventColumns = "id, name, starts_at, status"
+
+type eventQueries struct {
+	insert           string
+	list             string
+	getByID          string
+	getByIDForUpdate string
+	update           string
+	delete           string
+}
+
+func newEventQueries(dialect database.Dialect, table string) *eventQueries {
+	return &eventQueries{
+		insert: insertStatement(dialect, fmt.Sprintf("INSERT INTO %v (name, starts_at, status) VALUES (?, ?, ?)", table)),
+		// list is completed by List with the conditions of the filter
+		list:             fmt.Sprintf("SELECT %v FROM %v WHERE 1=1", eventColumns, table),
+		getByID:          dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?", eventColumns, table)),
+		getByIDForUpdate: dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?%v", eventColumns, table, dialect.LockClause())),
+		update:           dialect.Rebind(fmt.Sprintf("UPDATE %v SET name=?, starts_at=?, status=? WHERE id=?", table)),
+		delete:           dialect.Rebind(fmt.Sprintf("DELETE FROM %v WHERE id=?", table)),
+	}
+}
+
+type eventRepository struct {
+	queries *eventQueries
+	dialect database.Dialect
+	db      database.Executor
+}
+
+func newEventRepository(queries *eventQueries, dialect database.Dialect, db database.Executor) *eventRepository {
+	return &eventRepository{queries: queries, dialect: dialect, db: db}
+}
+
+func (r *eventRepository) Create(event *model.Event) error {
+	id, err := insertReturningID(r.db, r.dialect, r.queries.insert, event.Name, event.StartsAt, event.Status)
+	if err != nil {
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+
+	event.ID = uint(id)
+	return nil
+}
+
+func (r *eventRepository) List(filter model.EventFilter, offset int, limit int) ([]model.Event, error) {
+	query := strings.Builder{}
+	query.WriteString(r.queries.list)
+	args := []interface{}{}
+	if filter.Status != "" {
+		query.WriteString(" AND status=?")
+		args = append(args, filter.Status)
+	}
+	query.WriteString(" ORDER BY starts_at, id LIMIT ? OFFSET ?")
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(r.dialect.Rebind(query.String()), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]model.Event, 0)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, *event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) GetByID(id uint) (*model.Event, error) {
+	return r.getOne(r.queries.getByID, id)
+}
+
+func (r *eventRepository) GetByIDForUpdate(id uint) (*model.Event, error) {
+	return r.getOne(r.queries.getByIDForUpdate, id)
+}
+
+func (r *eventRepository) Update(event *model.Event) error {
+	if _, err := r.db.Exec(r.queries.update, event.Name, event.StartsAt, event.Status, event.ID); err != nil {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+	return nil
+}
+
+func (r *eventRepository) Delete(id uint) error {
+	res, err := r.db.Exec(r.queries.delete, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+	return checkDeleted(res)
+}
+
+func (r *eventRepository) getOne(query string, args ...interface{}) (*model.Event, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get event: %w", err)
+		}
+		return nil, ErrNotFound
+	}
+
+	event, err := scanEvent(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan event: %w", err)
+	}
+
+	return event, nil
+}
+
+// scanEvent reads a row selected with eventColumns
+func scanEvent(rows database.DBRows) (*model.Event, error) {
+	event := model.Event{}
+	err := rows.Scan(&event.ID,
+		&event.Name,
+		&event.StartsAt,
+		&event.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"fmt"
+	"wager/database"
+	"wager/model"
+)
+
+const listingColumns = "id, position_id, purchase_id, wager_id, seller, face_value, price, status, created_at, transfer_id"
+
+type listingQueries struct {
+	insert           string
+	getByID          string
+	getByIDForUpdate string
+	listOpen         string
+	update           string
+}
+
+func newListingQueries(dialect database.Dialect, table string) *listingQueries {
+	return &listingQueries{
+		insert:           insertStatement(dialect, fmt.Sprintf("INSERT INTO %v (position_id, purchase_id, wager_id, seller, face_value, price, status, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", table)),
+		getByID:          dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?", listingColumns, table)),
+		getByIDForUpdate: dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?%v", listingColumns, table, dialect.LockClause())),
+		listOpen:         dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE wager_id=? AND status=? ORDER BY price / face_value, id LIMIT ? OFFSET ?", listingColumns, table)),
+		update:           dialect.Rebind(fmt.Sprintf("UPDATE %v SET status=?, transfer_id=? WHERE id=?", table)),
+	}
+}
+
+type listingRepository struct {
+	queries *listingQueries
+	dialect database.Dialect
+	db      database.Executor
+}
+
+func newListingRepository(queries *listingQueries, dialect database.Dialect, db database.Executor) *listingRepository {
+	return &listingRepository{queries: queries, dialect: dialect, db: db}
+}
+
+func (r *listingRepository) Create(listing *model.Listing) error {
+	id, err := insertReturningID(r.db, r.dialect, r.queries.insert, listing.PositionID, listing.PurchaseID, listing.WagerID, listing.Seller, listing.FaceValue, listing.Price, listing.Status, listing.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create listing: %w", err)
+	}
+
+	listing.ID = uint(id)
+	return nil
+}
+
+func (r *listingRepository) GetByID(id uint) (*model.Listing, error) {
+	return r.getOne(r.queries.getByID, id)
+}
+
+func (r *listingRepository) GetByIDForUpdate(id uint) (*model.Listing, error) {
+	return r.getOne(r.queries.getByIDForUpdate, id)
+}
+
+func (r *listingRepository) ListOpen(wagerID uint, offset int, limit int) ([]model.Listing, error) {
+	rows, err := r.db.Query(r.queries.listOpen, wagerID, model.LISTING_STATUS_OPEN, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listings: %w", err)
+	}
+	defer rows.Close()
+
+	listings := make([]model.Listing, 0)
+	for rows.Next() {
+		listing, err := scanListing(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan listing: %w", err)
+		}
+		listings = append(listings, *listing)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate listings: %w", err)
+	}
+
+	return listings, nil
+}
+
+func (r *listingRepository) Update(listing *model.Listing) error {
+	if _, err := r.db.Exec(r.queries.update, listing.Status, listing.TransferID, listing.ID); err != nil {
+		return fmt.Errorf("failed to update listing: %w", err)
+	}
+	return nil
+}
+
+func (r *listingRepository) getOne(query string, args ...interface{}) (*model.Listing, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get listing: %w", err)
+		}
+		return nil, ErrNotFound
+	}
+
+	listing, err := scanListing(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan listing: %w", err)
+	}
+
+	return listing, nil
+}
+
+// scanListing reads a row selected with listingColumns
+func scanListing(rows database.DBRows) (*model.Listing, error) {
+	listing := model.Listing{}
+	err := rows.Scan(&listing.ID,
+		&listing.PositionID,
+		&listing.PurchaseID,
+		&listing.WagerID,
+		&listing.Seller,
+		&listing.FaceValue,
+		&listing.Price,
+		&listing.Status,
+		&listing.CreatedAt,
+		&listing.TransferID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listing, nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"fmt"
+	"wager/database"
+	"wager/model"
+)
+
+const (
+	marketColumns    = "id, event_id, name, status, winning_selection_id, settled_at"
+	selectionColumns = "id, market_id, name"
+)
+
+type marketQueries struct {
+	insert              string
+	insertSelection     string
+	getByID             string
+	getByIDForUpdate    string
+	listByEvent         string
+	getSelection        string
+	listSelections      string
+	listEventSelections string
+	update              string
+	delete              string
+	deleteSelections    string
+}
+
+func newMarketQueries(dialect database.Dialect, table string, selectionTable string) *marketQueries {
+	return &marketQueries{
+		insert:              insertStatement(dialect, fmt.Sprintf("INSERT INTO %v (event_id, name, status, winning_selection_id, settled_at) VALUES (?, ?, ?, ?, ?)", table)),
+		insertSelection:     insertStatement(dialect, fmt.Sprintf("INSERT INTO %v (market_id, name) VALUES (?, ?)", selectionTable)),
+		getByID:             dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?", marketColumns, table)),
+		getByIDForUpdate:    dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?%v", marketColumns, table, dialect.LockClause())),
+		listByEvent:         dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE event_id=? ORDER BY id", marketColumns, table)),
+		getSelection:        dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?", selectionColumns, selectionTable)),
+		listSelections:      dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE market_id=? ORDER BY id", selectionColumns, selectionTable)),
+		listEventSelections: dialect.Rebind(fmt.Sprintf("SELECT s.id, s.market_id, s.name FROM %v s JOIN %v m ON m.id=s.market_id WHERE m.event_id=? ORDER BY s.id", selectionTable, table)),
+		update:              dialect.Rebind(fmt.Sprintf("UPDATE %v SET name=?, status=?, winning_selection_id=?, settled_at=? WHERE id=?", table)),
+		delete:              dialect.Rebind(fmt.Sprintf("DELETE FROM %v WHERE id=?", table)),
+		deleteSelections:    dialect.Rebind(fmt.Sprintf("DELETE FROM %v WHERE market_id=?", selectionTable)),
+	}
+}
+
+type marketRepository struct {
+	queries *marketQueries
+	dialect database.Dialect
+	db      database.Executor
+}
+
+func newMarketRepository(queries *marketQueries, dialect database.Dialect, db database.Executor) *marketRepository {
+	return &marketRepository{queries: queries, dialect: dialect, db: db}
+}
+
+func (r *marketRepository) Create(market *model.Market) error {
+	id, err := insertReturningID(r.db, r.dialect, r.queries.insert, market.EventID, market.Name, market.Status, market.WinningSelectionID, market.SettledAt)
+	if err != nil {
+		return fmt.Errorf("failed to create market: %w", err)
+	}
+	market.ID = uint(id)
+
+	for i := range market.Selections {
+		selection := &market.Selections[i]
+		selection.MarketID = market.ID
+		id, err := insertReturningID(r.db, r.dialect, r.queries.insertSelection, selection.MarketID, selection.Name)
+		if err != nil {
+			return fmt.Errorf("failed to create selection: %w", err)
+		}
+		selection.ID = uint(id)
+	}
+	return nil
+}
+
+func (r *marketRepository) GetByID(id uint) (*model.Market, error) {
+	return r.getOne(r.queries.getByID, id)
+}
+
+func (r *marketRepository) GetByIDForUpdate(id uint) (*model.Market, error) {
+	return r.getOne(r.queries.getByIDForUpdate, id)
+}
+
+func (r *marketRepository) ListByEvent(eventID uint) ([]model.Market, error) {
+	markets, err := r.list(r.queries.listByEvent, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	selections, err := r.listSelections(r.queries.listEventSelections, eventID)
+	if err != nil {
+		return nil, err
+	}
+	byMarket := make(map[uint]int, len(markets))
+	for i := range markets {
+		byMarket[markets[i].ID] = i
+	}
+	for _, selection := range selections {
+		market := &markets[byMarket[selection.MarketID]]
+		market.Selections = append(market.Selections, selection)
+	}
+
+	return markets, nil
+}
+
+func (r *marketRepository) GetSelection(id uint) (*model.Selection, error) {
+	selections, err := r.listSelections(r.queries.getSelection, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(selections) == 0 {
+		return nil, ErrNotFound
+	}
+	return &selections[0], nil
+}
+
+func (r *marketRepository) Update(market *model.Market) error {
+	if _, err := r.db.Exec(r.queries.update, market.Name, market.Status, market.WinningSelectionID, market.SettledAt, market.ID); err != nil {
+		return fmt.Errorf("failed to update market: %w", err)
+	}
+	return nil
+}
+
+func (r *marketRepository) Delete(id uint) error {
+	if _, err := r.db.Exec(r.queries.deleteSelections, id); err != nil {
+		return fmt.Errorf("failed to delete selections: %w", err)
+	}
+
+	res, err := r.db.Exec(r.queries.delete, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete market: %w", err)
+	}
+	return checkDeleted(res)
+}
+
+func (r *marketRepository) getOne(query string, id uint) (*model.Market, error) {
+	markets, err := r.list(query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(markets) == 0 {
+		return nil, ErrNotFound
+	}
+
+	market := &markets[0]
+	market.Selections, err = r.listSelections(r.queries.listSelections, market.ID)
+	if err != nil {
+		return nil, err
+	}
+	return market, nil
+}
+
+// list reads markets without their selections
+func (r *marketRepository) list(query string, args ...interface{}) ([]model.Market, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get markets: %w", err)
+	}
+	defer rows.Close()
+
+	markets := make([]model.Market, 0)
+	for rows.Next() {
+		market := model.Market{Selections: []model.Selection{}}
+		err := rows.Scan(&market.ID,
+			&market.EventID,
+			&market.Name,
+			&market.Status,
+			&market.WinningSelectionID,
+			&market.SettledAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan market: %w", err)
+		}
+		markets = append(markets, market)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate markets: %w", err)
+	}
+
+	return markets, nil
+}
+
+func (r *marketRepository) listSelections(query string, args ...interface{}) ([]model.Selection, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get selections: %w", err)
+	}
+	defer rows.Close()
+
+	selections := make([]model.Selection, 0)
+	for rows.Next() {
+		selection := model.Selection{}
+		if err := rows.Scan(&selection.ID, &selection.MarketID, &selection.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan selection: %w", err)
+		}
+		selections = append(selections, selection)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate selections: %w", err)
+	}
+
+	return selections, nil
+}
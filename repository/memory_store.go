@@ -0,0 +1,1083 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"wager/model"
+)
+
+var ErrInvalidSellingPrice = errors.New("current selling price must not be negative")
+
+type memoryData struct {
+	wagers            map[uint]model.Wager
+	wagerIDs          []uint
+	purchases         map[uint]model.Purchase
+	reservations      map[uint]model.Reservation
+	bids              map[uint]model.Bid
+	positions         map[uint]model.Position
+	listings          map[uint]model.Listing
+	transfers         map[uint]model.Transfer
+	events            map[uint]model.Event
+	markets           map[uint]model.Market
+	selections        map[uint]model.Selection
+	nextWagerID       uint
+	nextPurchaseID    uint
+	nextReservationID uint
+	nextBidID         uint
+	nextPositionID    uint
+	nextListingID     uint
+	nextTransferID    uint
+	nextEventID       uint
+	nextMarketID      uint
+	nextSelectionID   uint
+}
+
+func (d *memoryData) clone() *memoryData {
+	c := &memoryData{
+		wagers:            make(map[uint]model.Wager, len(d.wagers)),
+		wagerIDs:          append([]uint(nil), d.wagerIDs...),
+		purchases:         make(map[uint]model.Purchase, len(d.purchases)),
+		reservations:      make(map[uint]model.Reservation, len(d.reservations)),
+		bids:              make(map[uint]model.Bid, len(d.bids)),
+		positions:         make(map[uint]model.Position, len(d.positions)),
+		listings:          make(map[uint]model.Listing, len(d.listings)),
+		transfers:         make(map[uint]model.Transfer, len(d.transfers)),
+		events:            make(map[uint]model.Event, len(d.events)),
+		markets:           make(map[uint]model.Market, len(d.markets)),
+		selections:        make(map[uint]model.Selection, len(d.selections)),
+		nextWagerID:       d.nextWagerID,
+		nextPurchaseID:    d.nextPurchaseID,
+		nextReservationID: d.nextReservationID,
+		nextBidID:         d.nextBidID,
+		nextPositionID:    d.nextPositionID,
+		nextListingID:     d.nextListingID,
+		nextTransferID:    d.nextTransferID,
+		nextEventID:       d.nextEventID,
+		nextMarketID:      d.nextMarketID,
+		nextSelectionID:   d.nextSelectionID,
+	}
+	for id, w := range d.wagers {
+		c.wagers[id] = w
+	}
+	for id, p := range d.purchases {
+		c.purchases[id] = p
+	}
+	for id, r := range d.reservations {
+		c.reservations[id] = r
+	}
+	for id, b := range d.bids {
+		c.bids[id] = b
+	}
+	for id, p := range d.positions {
+		c.positions[id] = p
+	}
+	for id, l := range d.listings {
+		c.listings[id] = l
+	}
+	for id, t := range d.transfers {
+		c.transfers[id] = t
+	}
+	for id, e := range d.events {
+		c.events[id] = e
+	}
+	// the selections of a market never change, the clones can share them
+	for id, m := range d.markets {
+		c.markets[id] = m
+	}
+	for id, s := range d.selections {
+		c.selections[id] = s
+	}
+	return c
+}
+
+// memoryStore keeps everything in process memory. Transactions are serialized by a
+// single lock and work on a copy of the data which replaces the original on commit.
+type memoryStore struct {
+	db *memoryDB
+	// data is only set for a store bound to a running transaction
+	data *memoryData
+}
+
+type memoryDB struct {
+	mu   sync.RWMutex
+	data *memoryData
+}
+
+func NewMemoryStore() Store {
+	data := &memoryData{
+		wagers:            make(map[uint]model.Wager),
+		purchases:         make(map[uint]model.Purchase),
+		reservations:      make(map[uint]model.Reservation),
+		bids:              make(map[uint]model.Bid),
+		positions:         make(map[uint]model.Position),
+		listings:          make(map[uint]model.Listing),
+		transfers:         make(map[uint]model.Transfer),
+		events:            make(map[uint]model.Event),
+		markets:           make(map[uint]model.Market),
+		selections:        make(map[uint]model.Selection),
+		nextWagerID:       1,
+		nextPurchaseID:    1,
+		nextReservationID: 1,
+		nextBidID:         1,
+		nextPositionID:    1,
+		nextListingID:     1,
+		nextTransferID:    1,
+		nextEventID:       1,
+		nextMarketID:      1,
+		nextSelectionID:   1,
+	}
+	return &memoryStore{db: &memoryDB{data: data}}
+}
+
+func (s *memoryStore) Wagers() WagerRepository {
+	return &memoryWagerRepository{store: s}
+}
+
+func (s *memoryStore) Purchases() PurchaseRepository {
+	return &memoryPurchaseRepository{store: s}
+}
+
+func (s *memoryStore) Reservations() ReservationRepository {
+	return &memoryReservationRepository{store: s}
+}
+
+func (s *memoryStore) Bids() BidRepository {
+	return &memoryBidRepository{store: s}
+}
+
+func (s *memoryStore) Positions() PositionRepository {
+	return &memoryPositionRepository{store: s}
+}
+
+func (s *memoryStore) Listings() ListingRepository {
+	return &memoryListingRepository{store: s}
+}
+
+func (s *memoryStore) Transfers() TransferRepository {
+	return &memoryTransferRepository{store: s}
+}
+
+func (s *memoryStore) Events() EventRepository {
+	return &memoryEventRepository{store: s}
+}
+
+func (s *memoryStore) Markets() MarketRepository {
+	return &memoryMarketRepository{store: s}
+}
+
+func (s *memoryStore) Buyers() BuyerRepository {
+	return memoryBuyerRepository{}
+}
+
+func (s *memoryStore) UsePrimary() Store {
+	return s
+}
+
+func (s *memoryStore) Session() Store {
+	return s
+}
+
+func (s *memoryStore) RunInTx(fn func(store Store) error) error {
+	if s.data != nil {
+		return fn(s)
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	txStore := &memoryStore{db: s.db, data: s.db.data.clone()}
+	if err := fn(txStore); err != nil {
+		return err
+	}
+
+	s.db.data = txStore.data
+	return nil
+}
+
+func (s *memoryStore) read(fn func(data *memoryData) error) error {
+	if s.data != nil {
+		return fn(s.data)
+	}
+
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	return fn(s.db.data)
+}
+
+// write runs a single statement as its own transaction unless one is already running
+func (s *memoryStore) write(fn func(data *memoryData) error) error {
+	return s.RunInTx(func(store Store) error {
+		return fn(store.(*memoryStore).data)
+	})
+}
+
+type memoryWagerRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryWagerRepository) Create(wager *model.Wager) error {
+	return r.store.write(func(data *memoryData) error {
+		wager.ID = data.nextWagerID
+		data.nextWagerID++
+		data.wagers[wager.ID] = *wager
+		data.wagerIDs = append(data.wagerIDs, wager.ID)
+		return nil
+	})
+}
+
+func (r *memoryWagerRepository) CreateMany(wagers []model.Wager) error {
+	return r.store.write(func(data *memoryData) error {
+		for i := range wagers {
+			wagers[i].ID = data.nextWagerID
+			data.nextWagerID++
+			data.wagers[wagers[i].ID] = wagers[i]
+			data.wagerIDs = append(data.wagerIDs, wagers[i].ID)
+		}
+		return nil
+	})
+}
+
+func (r *memoryWagerRepository) List(filter model.WagerFilter, offset int, limit int) ([]model.Wager, error) {
+	wagers := make([]model.Wager, 0)
+	err := r.store.read(func(data *memoryData) error {
+		for _, id := range data.wagerIDs {
+			if len(wagers) == limit {
+				break
+			}
+			wager := data.wagers[id]
+			if (filter.Currency != "" && wager.Currency != filter.Currency) ||
+				(filter.EventID != 0 && wager.EventID != filter.EventID) ||
+				(filter.MarketID != 0 && wager.MarketID != filter.MarketID) {
+				continue
+			}
+			if offset > 0 {
+				offset--
+				continue
+			}
+			wagers = append(wagers, wager)
+		}
+		return nil
+	})
+	return wagers, err
+}
+
+func (r *memoryWagerRepository) GetByID(id uint) (*model.Wager, error) {
+	var wager *model.Wager
+	err := r.store.read(func(data *memoryData) error {
+		w, ok := data.wagers[id]
+		if !ok {
+			return ErrNotFound
+		}
+		wager = &w
+		return nil
+	})
+	return wager, err
+}
+
+func (r *memoryWagerRepository) GetByIDForUpdate(id uint) (*model.Wager, error) {
+	// the whole transaction already holds the store lock
+	return r.GetByID(id)
+}
+
+func (r *memoryWagerRepository) UpdateSale(wager *model.Wager) error {
+	return r.store.write(func(data *memoryData) error {
+		w, ok := data.wagers[wager.ID]
+		if !ok {
+			return ErrNotFound
+		}
+		if wager.CurrentSellingPrice < 0 {
+			return ErrInvalidSellingPrice
+		}
+
+		w.CurrentSellingPrice = wager.CurrentSellingPrice
+		w.PercentageSold = wager.PercentageSold
+		w.AmountSold = wager.AmountSold
+		w.ReservedAmount = wager.ReservedAmount
+		w.Version++
+		wager.Version = w.Version
+		data.wagers[wager.ID] = w
+		return nil
+	})
+}
+
+func (r *memoryWagerRepository) UpdateAskPrice(wager *model.Wager) error {
+	return r.store.write(func(data *memoryData) error {
+		w, ok := data.wagers[wager.ID]
+		if !ok {
+			return ErrNotFound
+		}
+
+		w.AskPrice = wager.AskPrice
+		w.Version++
+		wager.Version = w.Version
+		data.wagers[wager.ID] = w
+		return nil
+	})
+}
+
+func (r *memoryWagerRepository) UpdateStatus(wager *model.Wager) error {
+	return r.store.write(func(data *memoryData) error {
+		w, ok := data.wagers[wager.ID]
+		if !ok {
+			return ErrNotFound
+		}
+
+		w.Status = wager.Status
+		w.Version++
+		wager.Version = w.Version
+		data.wagers[wager.ID] = w
+		return nil
+	})
+}
+
+type memoryPurchaseRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryPurchaseRepository) Create(purchase *model.Purchase) error {
+	return r.store.write(func(data *memoryData) error {
+		if _, ok := data.wagers[purchase.WagerID]; !ok {
+			return fmt.Errorf("failed to create purchase: wager %v does not exist", purchase.WagerID)
+		}
+
+		purchase.PurchaseID = data.nextPurchaseID
+		data.nextPurchaseID++
+		data.purchases[purchase.PurchaseID] = *purchase
+		return nil
+	})
+}
+
+func (r *memoryPurchaseRepository) List(filter model.PurchaseFilter, offset int, limit int) ([]model.Purchase, error) {
+	purchases := make([]model.Purchase, 0)
+	err := r.store.read(func(data *memoryData) error {
+		// ids are handed out in order and purchases are never deleted
+		skipped := 0
+		for id := uint(1); id < data.nextPurchaseID && len(purchases) < limit; id++ {
+			purchase, ok := data.purchases[id]
+			if !ok || !matchPurchase(filter, purchase) {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			purchases = append(purchases, purchase)
+		}
+		return nil
+	})
+	return purchases, err
+}
+
+func (r *memoryPurchaseRepository) GetByID(id uint) (*model.Purchase, error) {
+	var purchase *model.Purchase
+	err := r.store.read(func(data *memoryData) error {
+		p, ok := data.purchases[id]
+		if !ok {
+			return ErrNotFound
+		}
+		purchase = &p
+		return nil
+	})
+	return purchase, err
+}
+
+func (r *memoryPurchaseRepository) GetByIDForUpdate(id uint) (*model.Purchase, error) {
+	// the whole transaction already holds the store lock
+	return r.GetByID(id)
+}
+
+func (r *memoryPurchaseRepository) Refund(purchase *model.Purchase) error {
+	return r.store.write(func(data *memoryData) error {
+		p, ok := data.purchases[purchase.PurchaseID]
+		if !ok {
+			return ErrNotFound
+		}
+
+		p.RefundedAt = purchase.RefundedAt
+		data.purchases[purchase.PurchaseID] = p
+		return nil
+	})
+}
+
+func (r *memoryPurchaseRepository) TradingVolume(buyer string, seller string, currency string) (*model.TradingVolume, error) {
+	volume := model.TradingVolume{}
+	err := r.store.read(func(data *memoryData) error {
+		for _, purchase := range data.purchases {
+			wager := data.wagers[purchase.WagerID]
+			if purchase.RefundedAt != 0 || wager.Currency != currency {
+				continue
+			}
+			if purchase.Buyer == buyer {
+				volume.Bought += purchase.BuyingPrice
+			}
+			if wager.Seller == seller {
+				volume.Sold += purchase.BuyingPrice
+			}
+		}
+		return nil
+	})
+	return &volume, err
+}
+
+func (r *memoryPurchaseRepository) FeeRevenue(from int64, to int64) ([]model.FeeRevenue, error) {
+	byCurrency := map[string]*model.FeeRevenue{}
+	filter := model.PurchaseFilter{BoughtFrom: from, BoughtTo: to}
+	err := r.store.read(func(data *memoryData) error {
+		for _, purchase := range data.purchases {
+			if purchase.RefundedAt != 0 || !matchPurchase(filter, purchase) {
+				continue
+			}
+			currency := data.wagers[purchase.WagerID].Currency
+			revenue, ok := byCurrency[currency]
+			if !ok {
+				revenue = &model.FeeRevenue{Currency: currency}
+				byCurrency[currency] = revenue
+			}
+			revenue.Purchases++
+			revenue.Volume += purchase.BuyingPrice
+			revenue.MakerFees += purchase.Fees.MakerFee
+			revenue.TakerFees += purchase.Fees.TakerFee
+		}
+		return nil
+	})
+
+	revenues := make([]model.FeeRevenue, 0, len(byCurrency))
+	for _, revenue := range byCurrency {
+		revenue.Total = revenue.MakerFees + revenue.TakerFees
+		revenues = append(revenues, *revenue)
+	}
+	sort.Slice(revenues, func(i, j int) bool {
+		return revenues[i].Currency < revenues[j].Currency
+	})
+	return revenues, err
+}
+
+func matchPurchase(filter model.PurchaseFilter, purchase model.Purchase) bool {
+	return (filter.WagerID == 0 || purchase.WagerID == filter.WagerID) &&
+		(filter.Buyer == "" || purchase.Buyer == filter.Buyer) &&
+		(filter.BoughtFrom == 0 || purchase.BoughtAt >= filter.BoughtFrom) &&
+		(filter.BoughtTo == 0 || purchase.BoughtAt <= filter.BoughtTo)
+}
+
+type memoryReservationRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryReservationRepository) Create(reservation *model.Reservation) error {
+	return r.store.write(func(data *memoryData) error {
+		if _, ok := data.wagers[reservation.WagerID]; !ok {
+			return fmt.Errorf("failed to create reservation: wager %v does not exist", reservation.WagerID)
+		}
+
+		reservation.ID = data.nextReservationID
+		data.nextReservationID++
+		data.reservations[reservation.ID] = *reservation
+		return nil
+	})
+}
+
+func (r *memoryReservationRepository) GetByID(id uint) (*model.Reservation, error) {
+	var reservation *model.Reservation
+	err := r.store.read(func(data *memoryData) error {
+		res, ok := data.reservations[id]
+		if !ok {
+			return ErrNotFound
+		}
+		reservation = &res
+		return nil
+	})
+	return reservation, err
+}
+
+func (r *memoryReservationRepository) GetByIDForUpdate(id uint) (*model.Reservation, error) {
+	// the whole transaction already holds the store lock
+	return r.GetByID(id)
+}
+
+func (r *memoryReservationRepository) ListExpired(now int64, limit int) ([]model.Reservation, error) {
+	reservations := make([]model.Reservation, 0)
+	err := r.store.read(func(data *memoryData) error {
+		for id := uint(1); id < data.nextReservationID && len(reservations) < limit; id++ {
+			res, ok := data.reservations[id]
+			if ok && res.Status == model.RESERVATION_STATUS_HELD && res.ExpiresAt <= now {
+				reservations = append(reservations, res)
+			}
+		}
+		return nil
+	})
+	return reservations, err
+}
+
+func (r *memoryReservationRepository) ListHeld(wagerID uint, limit int) ([]model.Reservation, error) {
+	reservations := make([]model.Reservation, 0)
+	err := r.store.read(func(data *memoryData) error {
+		for id := uint(1); id < data.nextReservationID && len(reservations) < limit; id++ {
+			res, ok := data.reservations[id]
+			if ok && res.WagerID == wagerID && res.Status == model.RESERVATION_STATUS_HELD {
+				reservations = append(reservations, res)
+			}
+		}
+		return nil
+	})
+	return reservations, err
+}
+
+func (r *memoryReservationRepository) Update(reservation *model.Reservation) error {
+	return r.store.write(func(data *memoryData) error {
+		res, ok := data.reservations[reservation.ID]
+		if !ok {
+			return ErrNotFound
+		}
+
+		res.Status = reservation.Status
+		res.PurchaseID = reservation.PurchaseID
+		data.reservations[reservation.ID] = res
+		return nil
+	})
+}
+
+type memoryBidRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryBidRepository) Create(bid *model.Bid) error {
+	return r.store.write(func(data *memoryData) error {
+		if _, ok := data.wagers[bid.WagerID]; !ok {
+			return fmt.Errorf("failed to create bid: wager %v does not exist", bid.WagerID)
+		}
+
+		bid.ID = data.nextBidID
+		data.nextBidID++
+		data.bids[bid.ID] = *bid
+		return nil
+	})
+}
+
+func (r *memoryBidRepository) GetByID(id uint) (*model.Bid, error) {
+	var bid *model.Bid
+	err := r.store.read(func(data *memoryData) error {
+		b, ok := data.bids[id]
+		if !ok {
+			return ErrNotFound
+		}
+		bid = &b
+		return nil
+	})
+	return bid, err
+}
+
+func (r *memoryBidRepository) GetByIDForUpdate(id uint) (*model.Bid, error) {
+	// the whole transaction already holds the store lock
+	return r.GetByID(id)
+}
+
+func (r *memoryBidRepository) ListOpen(wagerID uint) ([]model.Bid, error) {
+	bids := make([]model.Bid, 0)
+	err := r.store.read(func(data *memoryData) error {
+		for id := uint(1); id < data.nextBidID; id++ {
+			bid, ok := data.bids[id]
+			if ok && bid.WagerID == wagerID && bid.Status == model.BID_STATUS_OPEN {
+				bids = append(bids, bid)
+			}
+		}
+		return nil
+	})
+	// bids are in id order, which breaks ties of the stable sort
+	sort.SliceStable(bids, func(i, j int) bool {
+		return bids[i].UnitPrice() > bids[j].UnitPrice()
+	})
+	return bids, err
+}
+
+func (r *memoryBidRepository) ListExpired(now int64, limit int) ([]model.Bid, error) {
+	bids := make([]model.Bid, 0)
+	err := r.store.read(func(data *memoryData) error {
+		for id := uint(1); id < data.nextBidID && len(bids) < limit; id++ {
+			bid, ok := data.bids[id]
+			if ok && bid.Status == model.BID_STATUS_OPEN && bid.ExpiresAt <= now {
+				bids = append(bids, bid)
+			}
+		}
+		return nil
+	})
+	return bids, err
+}
+
+func (r *memoryBidRepository) ListWagerIDs(afterID uint, limit int) ([]uint, error) {
+	ids := make([]uint, 0)
+	err := r.store.read(func(data *memoryData) error {
+		open := make(map[uint]bool)
+		for _, bid := range data.bids {
+			if bid.Status == model.BID_STATUS_OPEN && bid.WagerID > afterID {
+				open[bid.WagerID] = true
+			}
+		}
+		for _, id := range data.wagerIDs {
+			if open[id] && len(ids) < limit {
+				ids = append(ids, id)
+			}
+		}
+		return nil
+	})
+	return ids, err
+}
+
+func (r *memoryBidRepository) Update(bid *model.Bid) error {
+	return r.store.write(func(data *memoryData) error {
+		b, ok := data.bids[bid.ID]
+		if !ok {
+			return ErrNotFound
+		}
+
+		b.Status = bid.Status
+		b.PurchaseID = bid.PurchaseID
+		data.bids[bid.ID] = b
+		return nil
+	})
+}
+
+type memoryPositionRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryPositionRepository) Create(position *model.Position) error {
+	return r.store.write(func(data *memoryData) error {
+		if _, ok := data.purchases[position.PurchaseID]; !ok {
+			return fmt.Errorf("failed to create position: purchase %v does not exist", position.PurchaseID)
+		}
+
+		position.ID = data.nextPositionID
+		data.nextPositionID++
+		data.positions[position.ID] = *position
+		return nil
+	})
+}
+
+func (r *memoryPositionRepository) List(filter model.PositionFilter, offset int, limit int) ([]model.Position, error) {
+	positions := make([]model.Position, 0)
+	err := r.store.read(func(data *memoryData) error {
+		skipped := 0
+		for id := uint(1); id < data.nextPositionID && len(positions) < limit; id++ {
+			position, ok := data.positions[id]
+			if !ok || position.FaceValue <= 0 ||
+				(filter.WagerID != 0 && position.WagerID != filter.WagerID) ||
+				(filter.Holder != "" && position.Holder != filter.Holder) {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			positions = append(positions, position)
+		}
+		return nil
+	})
+	return positions, err
+}
+
+func (r *memoryPositionRepository) BuyerExposure(holder string, wagerID uint, currency string) (*model.BuyerExposure, error) {
+	exposure := model.BuyerExposure{}
+	err := r.store.read(func(data *memoryData) error {
+		for _, position := range data.positions {
+			wager := data.wagers[position.WagerID]
+			if position.Holder != holder || position.FaceValue <= 0 || wager.Status != model.WAGER_STATUS_OPEN || wager.Currency != currency {
+				continue
+			}
+			if position.WagerID == wagerID {
+				exposure.WagerFaceValue += position.FaceValue
+				exposure.WagerPositions++
+			}
+			exposure.OpenFaceValue += position.FaceValue
+		}
+		return nil
+	})
+	return &exposure, err
+}
+
+func (r *memoryPositionRepository) GetByID(id uint) (*model.Position, error) {
+	var position *model.Position
+	err := r.store.read(func(data *memoryData) error {
+		p, ok := data.positions[id]
+		if !ok {
+			return ErrNotFound
+		}
+		position = &p
+		return nil
+	})
+	return position, err
+}
+
+func (r *memoryPositionRepository) GetByIDForUpdate(id uint) (*model.Position, error) {
+	// the whole transaction already holds the store lock
+	return r.GetByID(id)
+}
+
+func (r *memoryPositionRepository) ListByPurchase(purchaseID uint) ([]model.Position, error) {
+	positions := make([]model.Position, 0)
+	err := r.store.read(func(data *memoryData) error {
+		for id := uint(1); id < data.nextPositionID; id++ {
+			position, ok := data.positions[id]
+			if ok && position.PurchaseID == purchaseID {
+				positions = append(positions, position)
+			}
+		}
+		return nil
+	})
+	return positions, err
+}
+
+func (r *memoryPositionRepository) Update(position *model.Position) error {
+	return r.store.write(func(data *memoryData) error {
+		p, ok := data.positions[position.ID]
+		if !ok {
+			return ErrNotFound
+		}
+
+		p.FaceValue = position.FaceValue
+		p.ListedFaceValue = position.ListedFaceValue
+		p.Payout = position.Payout
+		data.positions[position.ID] = p
+		return nil
+	})
+}
+
+type memoryListingRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryListingRepository) Create(listing *model.Listing) error {
+	return r.store.write(func(data *memoryData) error {
+		if _, ok := data.positions[listing.PositionID]; !ok {
+			return fmt.Errorf("failed to create listing: position %v does not exist", listing.PositionID)
+		}
+
+		listing.ID = data.nextListingID
+		data.nextListingID++
+		data.listings[listing.ID] = *listing
+		return nil
+	})
+}
+
+func (r *memoryListingRepository) GetByID(id uint) (*model.Listing, error) {
+	var listing *model.Listing
+	err := r.store.read(func(data *memoryData) error {
+		l, ok := data.listings[id]
+		if !ok {
+			return ErrNotFound
+		}
+		listing = &l
+		return nil
+	})
+	return listing, err
+}
+
+func (r *memoryListingRepository) GetByIDForUpdate(id uint) (*model.Listing, error) {
+	// the whole transaction already holds the store lock
+	return r.GetByID(id)
+}
+
+func (r *memoryListingRepository) ListOpen(wagerID uint, offset int, limit int) ([]model.Listing, error) {
+	listings := make([]model.Listing, 0)
+	err := r.store.read(func(data *memoryData) error {
+		for id := uint(1); id < data.nextListingID; id++ {
+			listing, ok := data.listings[id]
+			if ok && listing.WagerID == wagerID && listing.Status == model.LISTING_STATUS_OPEN {
+				listings = append(listings, listing)
+			}
+		}
+		return nil
+	})
+	// listings are in id order, which breaks ties of the stable sort
+	sort.SliceStable(listings, func(i, j int) bool {
+		return listings[i].Price/listings[i].FaceValue < listings[j].Price/listings[j].FaceValue
+	})
+	if offset >= len(listings) {
+		return []model.Listing{}, err
+	}
+	if offset+limit < len(listings) {
+		return listings[offset : offset+limit], err
+	}
+	return listings[offset:], err
+}
+
+func (r *memoryListingRepository) Update(listing *model.Listing) error {
+	return r.store.write(func(data *memoryData) error {
+		l, ok := data.listings[listing.ID]
+		if !ok {
+			return ErrNotFound
+		}
+
+		l.Status = listing.Status
+		l.TransferID = listing.TransferID
+		data.listings[listing.ID] = l
+		return nil
+	})
+}
+
+type memoryTransferRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryTransferRepository) Create(transfer *model.Transfer) error {
+	return r.store.write(func(data *memoryData) error {
+		if _, ok := data.purchases[transfer.PurchaseID]; !ok {
+			return fmt.Errorf("failed to create transfer: purchase %v does not exist", transfer.PurchaseID)
+		}
+
+		transfer.ID = data.nextTransferID
+		data.nextTransferID++
+		data.transfers[transfer.ID] = *transfer
+		return nil
+	})
+}
+
+func (r *memoryTransferRepository) ListByPurchase(purchaseID uint) ([]model.Transfer, error) {
+	transfers := make([]model.Transfer, 0)
+	err := r.store.read(func(data *memoryData) error {
+		for id := uint(1); id < data.nextTransferID; id++ {
+			transfer, ok := data.transfers[id]
+			if ok && transfer.PurchaseID == purchaseID {
+				transfers = append(transfers, transfer)
+			}
+		}
+		return nil
+	})
+	return transfers, err
+}
+
+func (r *memoryTransferRepository) FeeRevenue(from int64, to int64) ([]model.FeeRevenue, error) {
+	byCurrency := map[string]*model.FeeRevenue{}
+	err := r.store.read(func(data *memoryData) error {
+		for _, transfer := range data.transfers {
+			if (from != 0 && transfer.TransferredAt < from) || (to != 0 && transfer.TransferredAt > to) {
+				continue
+			}
+			currency := data.wagers[data.purchases[transfer.PurchaseID].WagerID].Currency
+			revenue, ok := byCurrency[currency]
+			if !ok {
+				revenue = &model.FeeRevenue{Currency: currency}
+				byCurrency[currency] = revenue
+			}
+			revenue.Transfers++
+			revenue.Volume += transfer.Price
+			revenue.MakerFees += transfer.Fees.MakerFee
+			revenue.TakerFees += transfer.Fees.TakerFee
+		}
+		return nil
+	})
+
+	revenues := make([]model.FeeRevenue, 0, len(byCurrency))
+	for _, revenue := range byCurrency {
+		revenue.Total = revenue.MakerFees + revenue.TakerFees
+		revenues = append(revenues, *revenue)
+	}
+	sort.Slice(revenues, func(i, j int) bool {
+		return revenues[i].Currency < revenues[j].Currency
+	})
+	return revenues, err
+}
+
+type memoryEventRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryEventRepository) Create(event *model.Event) error {
+	return r.store.write(func(data *memoryData) error {
+		event.ID = data.nextEventID
+		data.nextEventID++
+		data.events[event.ID] = *event
+		return nil
+	})
+}
+
+func (r *memoryEventRepository) List(filter model.EventFilter, offset int, limit int) ([]model.Event, error) {
+	events := make([]model.Event, 0)
+	err := r.store.read(func(data *memoryData) error {
+		for _, event := range data.events {
+			if filter.Status == "" || event.Status == filter.Status {
+				events = append(events, event)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].StartsAt != events[j].StartsAt {
+			return events[i].StartsAt < events[j].StartsAt
+		}
+		return events[i].ID < events[j].ID
+	})
+	if offset >= len(events) {
+		return make([]model.Event, 0), nil
+	}
+	events = events[offset:]
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *memoryEventRepository) GetByID(id uint) (*model.Event, error) {
+	var event *model.Event
+	err := r.store.read(func(data *memoryData) error {
+		e, ok := data.events[id]
+		if !ok {
+			return ErrNotFound
+		}
+		event = &e
+		return nil
+	})
+	return event, err
+}
+
+func (r *memoryEventRepository) GetByIDForUpdate(id uint) (*model.Event, error) {
+	// the whole transaction already holds the store lock
+	return r.GetByID(id)
+}
+
+func (r *memoryEventRepository) Update(event *model.Event) error {
+	return r.store.write(func(data *memoryData) error {
+		e, ok := data.events[event.ID]
+		if !ok {
+			return ErrNotFound
+		}
+
+		e.Name = event.Name
+		e.StartsAt = event.StartsAt
+		e.Status = event.Status
+		data.events[event.ID] = e
+		return nil
+	})
+}
+
+func (r *memoryEventRepository) Delete(id uint) error {
+	return r.store.write(func(data *memoryData) error {
+		if _, ok := data.events[id]; !ok {
+			return ErrNotFound
+		}
+
+		delete(data.events, id)
+		return nil
+	})
+}
+
+type memoryMarketRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryMarketRepository) Create(market *model.Market) error {
+	return r.store.write(func(data *memoryData) error {
+		if _, ok := data.events[market.EventID]; !ok {
+			return fmt.Errorf("failed to create market: event %v does not exist", market.EventID)
+		}
+
+		market.ID = data.nextMarketID
+		data.nextMarketID++
+		for i := range market.Selections {
+			selection := &market.Selections[i]
+			selection.ID = data.nextSelectionID
+			selection.MarketID = market.ID
+			data.nextSelectionID++
+			data.selections[selection.ID] = *selection
+		}
+		stored := *market
+		stored.Selections = append([]model.Selection(nil), market.Selections...)
+		data.markets[market.ID] = stored
+		return nil
+	})
+}
+
+func (r *memoryMarketRepository) GetByID(id uint) (*model.Market, error) {
+	var market *model.Market
+	err := r.store.read(func(data *memoryData) error {
+		m, ok := data.markets[id]
+		if !ok {
+			return ErrNotFound
+		}
+		market = copyMarket(m)
+		return nil
+	})
+	return market, err
+}
+
+func (r *memoryMarketRepository) GetByIDForUpdate(id uint) (*model.Market, error) {
+	// the whole transaction already holds the store lock
+	return r.GetByID(id)
+}
+
+func (r *memoryMarketRepository) ListByEvent(eventID uint) ([]model.Market, error) {
+	markets := make([]model.Market, 0)
+	err := r.store.read(func(data *memoryData) error {
+		for id := uint(1); id < data.nextMarketID; id++ {
+			market, ok := data.markets[id]
+			if ok && market.EventID == eventID {
+				markets = append(markets, *copyMarket(market))
+			}
+		}
+		return nil
+	})
+	return markets, err
+}
+
+func (r *memoryMarketRepository) GetSelection(id uint) (*model.Selection, error) {
+	var selection *model.Selection
+	err := r.store.read(func(data *memoryData) error {
+		s, ok := data.selections[id]
+		if !ok {
+			return ErrNotFound
+		}
+		selection = &s
+		return nil
+	})
+	return selection, err
+}
+
+func (r *memoryMarketRepository) Update(market *model.Market) error {
+	return r.store.write(func(data *memoryData) error {
+		m, ok := data.markets[market.ID]
+		if !ok {
+			return ErrNotFound
+		}
+
+		m.Name = market.Name
+		m.Status = market.Status
+		m.WinningSelectionID = market.WinningSelectionID
+		m.SettledAt = market.SettledAt
+		data.markets[market.ID] = m
+		return nil
+	})
+}
+
+func (r *memoryMarketRepository) Delete(id uint) error {
+	return r.store.write(func(data *memoryData) error {
+		m, ok := data.markets[id]
+		if !ok {
+			return ErrNotFound
+		}
+
+		for _, selection := range m.Selections {
+			delete(data.selections, selection.ID)
+		}
+		delete(data.markets, id)
+		return nil
+	})
+}
+
+// copyMarket copies a stored market so that callers can not change its selections
+func copyMarket(market model.Market) *model.Market {
+	market.Selections = append(make([]model.Selection, 0, len(market.Selections)), market.Selections...)
+	return &market
+}
+
+// memoryBuyerRepository has nothing to lock, the transactions of the memory store
+// already run one at a time
+type memoryBuyerRepository struct{}
+
+func (memoryBuyerRepository) Lock(buyer string) error {
+	return nil
+}
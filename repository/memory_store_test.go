@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"wager/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryStore_Wagers(t *testing.T) {
+	store := NewMemoryStore()
+
+	for i := 0; i < 3; i++ {
+		wager := &model.Wager{TotalWagerValue: 100, SellingPrice: 20, CurrentSellingPrice: 20}
+		assert.NoError(t, store.Wagers().Create(wager))
+		assert.Equal(t, uint(i+1), wager.ID)
+	}
+
+	wagers, err := store.Wagers().List(model.WagerFilter{}, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(wagers))
+	assert.Equal(t, uint(2), wagers[0].ID)
+
+	_, err = store.Wagers().GetByID(10)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func Test_MemoryStore_Invariants(t *testing.T) {
+	store := NewMemoryStore()
+	wager := &model.Wager{TotalWagerValue: 100, SellingPrice: 20, CurrentSellingPrice: 20}
+	assert.NoError(t, store.Wagers().Create(wager))
+
+	wager.CurrentSellingPrice = -1
+	assert.Equal(t, ErrInvalidSellingPrice, store.Wagers().UpdateSale(wager))
+
+	err := store.Purchases().Create(&model.Purchase{WagerID: 2, BuyingPrice: 1})
+	assert.Error(t, err)
+}
+
+func Test_MemoryStore_RunInTx_Rollback(t *testing.T) {
+	store := NewMemoryStore()
+	wager := &model.Wager{TotalWagerValue: 100, SellingPrice: 20, CurrentSellingPrice: 20}
+	assert.NoError(t, store.Wagers().Create(wager))
+
+	err := store.RunInTx(func(tx Store) error {
+		w, err := tx.Wagers().GetByIDForUpdate(wager.ID)
+		assert.NoError(t, err)
+
+		w.CurrentSellingPrice = 5
+		assert.NoError(t, tx.Wagers().UpdateSale(w))
+		assert.NoError(t, tx.Purchases().Create(&model.Purchase{WagerID: w.ID, BuyingPrice: 15}))
+		return errors.New("custom error")
+	})
+	assert.Error(t, err)
+
+	w, err := store.Wagers().GetByID(wager.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(20), w.CurrentSellingPrice)
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"wager/database"
+	"wager/model"
+)
+
+const positionColumns = "id, purchase_id, wager_id, holder, face_value, listed_face_value, acquired_at, payout"
+
+type positionQueries struct {
+	insert           string
+	list             string
+	getByID          string
+	getByIDForUpdate string
+	listByPurchase   string
+	update           string
+	buyerExposure    string
+}
+
+func newPositionQueries(dialect database.Dialect, table string, wagerTable string) *positionQueries {
+	return &positionQueries{
+		insert: insertStatement(dialect, fmt.Sprintf("INSERT INTO %v (purchase_id, wager_id, holder, face_value, listed_face_value, acquired_at) VALUES (?, ?, ?, ?, ?, ?)", table)),
+		// list is completed by List with the conditions of the filter
+		list:             fmt.Sprintf("SELECT %v FROM %v WHERE face_value>0", positionColumns, table),
+		getByID:          dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?", positionColumns, table)),
+		getByIDForUpdate: dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?%v", positionColumns, table, dialect.LockClause())),
+		listByPurchase:   dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE purchase_id=? ORDER BY id", positionColumns, table)),
+		update:           dialect.Rebind(fmt.Sprintf("UPDATE %v SET face_value=?, listed_face_value=?, payout=? WHERE id=?", table)),
+		buyerExposure: dialect.Rebind(fmt.Sprintf("SELECT COALESCE(SUM(CASE WHEN p.wager_id=? THEN p.face_value ELSE 0 END), 0), "+
+			"COALESCE(SUM(CASE WHEN p.wager_id=? THEN 1 ELSE 0 END), 0), COALESCE(SUM(p.face_value), 0) "+
+			"FROM %v p JOIN %v w ON w.id=p.wager_id WHERE p.holder=? AND p.face_value>0 AND w.status=? AND w.currency=?", table, wagerTable)),
+	}
+}
+
+type positionRepository struct {
+	queries *positionQueries
+	dialect database.Dialect
+	db      database.Executor
+}
+
+func newPositionRepository(queries *positionQueries, dialect database.Dialect, db database.Executor) *positionRepository {
+	return &positionRepository{queries: queries, dialect: dialect, db: db}
+}
+
+func (r *positionRepository) Create(position *model.Position) error {
+	id, err := insertReturningID(r.db, r.dialect, r.queries.insert, position.PurchaseID, position.WagerID, position.Holder, position.FaceValue, position.ListedFaceValue, position.AcquiredAt)
+	if err != nil {
+		return fmt.Errorf("failed to create position: %w", err)
+	}
+
+	position.ID = uint(id)
+	return nil
+}
+
+func (r *positionRepository) List(filter model.PositionFilter, offset int, limit int) ([]model.Position, error) {
+	query := strings.Builder{}
+	query.WriteString(r.queries.list)
+	args := []interface{}{}
+	if filter.WagerID != 0 {
+		query.WriteString(" AND wager_id=?")
+		args = append(args, filter.WagerID)
+	}
+	if filter.Holder != "" {
+		query.WriteString(" AND holder=?")
+		args = append(args, filter.Holder)
+	}
+	query.WriteString(" ORDER BY id LIMIT ? OFFSET ?")
+	args = append(args, limit, offset)
+
+	return r.list(r.dialect.Rebind(query.String()), args...)
+}
+
+func (r *positionRepository) GetByID(id uint) (*model.Position, error) {
+	return r.getOne(r.queries.getByID, id)
+}
+
+func (r *positionRepository) GetByIDForUpdate(id uint) (*model.Position, error) {
+	return r.getOne(r.queries.getByIDForUpdate, id)
+}
+
+func (r *positionRepository) ListByPurchase(purchaseID uint) ([]model.Position, error) {
+	return r.list(r.queries.listByPurchase, purchaseID)
+}
+
+func (r *positionRepository) Update(position *model.Position) error {
+	if _, err := r.db.Exec(r.queries.update, position.FaceValue, position.ListedFaceValue, position.Payout, position.ID); err != nil {
+		return fmt.Errorf("failed to update position: %w", err)
+	}
+	return nil
+}
+
+func (r *positionRepository) BuyerExposure(holder string, wagerID uint, currency string) (*model.BuyerExposure, error) {
+	exposure := model.BuyerExposure{}
+	rows, err := r.db.Query(r.queries.buyerExposure, wagerID, wagerID, holder, model.WAGER_STATUS_OPEN, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get buyer exposure: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get buyer exposure: %w", err)
+		}
+		return &exposure, nil
+	}
+	if err := rows.Scan(&exposure.WagerFaceValue, &exposure.WagerPositions, &exposure.OpenFaceValue); err != nil {
+		return nil, fmt.Errorf("failed to scan buyer exposure: %w", err)
+	}
+
+	return &exposure, nil
+}
+
+func (r *positionRepository) list(query string, args ...interface{}) ([]model.Position, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+	defer rows.Close()
+
+	positions := make([]model.Position, 0)
+	for rows.Next() {
+		position, err := scanPosition(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan position: %w", err)
+		}
+		positions = append(positions, *position)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate positions: %w", err)
+	}
+
+	return positions, nil
+}
+
+func (r *positionRepository) getOne(query string, args ...interface{}) (*model.Position, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get position: %w", err)
+		}
+		return nil, ErrNotFound
+	}
+
+	position, err := scanPosition(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan position: %w", err)
+	}
+
+	return position, nil
+}
+
+// scanPosition reads a row selected with positionColumns
+func scanPosition(rows database.DBRows) (*model.Position, error) {
+	position := model.Position{}
+	err := rows.Scan(&position.ID,
+		&position.PurchaseID,
+		&position.WagerID,
+		&position.Holder,
+		&position.FaceValue,
+		&position.ListedFaceValue,
+		&position.AcquiredAt,
+		&position.Payout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &position, nil
+}
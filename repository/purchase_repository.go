@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"wager/database"
+	"wager/model"
+)
+
+const purchaseColumns = "id, wager_id, buyer, buying_price, face_value, bought_at, refunded_at, maker_fee, maker_rate, taker_fee, taker_rate"
+
+type purchaseQueries struct {
+	insert           string
+	list             string
+	getByID          string
+	getByIDForUpdate string
+	refund           string
+	tradingVolume    string
+	feeRevenue       string
+}
+
+func newPurchaseQueries(dialect database.Dialect, table string, wagerTable string) *purchaseQueries {
+	return &purchaseQueries{
+		insert: insertStatement(dialect, fmt.Sprintf("INSERT INTO %v (wager_id, buyer, buying_price, face_value, bought_at, maker_fee, maker_rate, taker_fee, taker_rate) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", table)),
+		// list is completed by List with the conditions of the filter
+		list:             fmt.Sprintf("SELECT %v FROM %v WHERE 1=1", purchaseColumns, table),
+		getByID:          dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?", purchaseColumns, table)),
+		getByIDForUpdate: dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?%v", purchaseColumns, table, dialect.LockClause())),
+		refund:           dialect.Rebind(fmt.Sprintf("UPDATE %v SET refunded_at=? WHERE id=?", table)),
+		tradingVolume: dialect.Rebind(fmt.Sprintf("SELECT COALESCE(SUM(CASE WHEN p.buyer=? THEN p.buying_price ELSE 0 END), 0), "+
+			"COALESCE(SUM(CASE WHEN w.seller=? THEN p.buying_price ELSE 0 END), 0) "+
+			"FROM %v p JOIN %v w ON w.id=p.wager_id WHERE p.refunded_at=0 AND w.currency=? AND (p.buyer=? OR w.seller=?)", table, wagerTable)),
+		// feeRevenue is completed by FeeRevenue with the bounds of the period
+		feeRevenue: fmt.Sprintf("SELECT w.currency, COUNT(*), SUM(p.buying_price), SUM(p.maker_fee), SUM(p.taker_fee) "+
+			"FROM %v p JOIN %v w ON w.id=p.wager_id WHERE p.refunded_at=0", table, wagerTable),
+	}
+}
+
+type purchaseRepository struct {
+	queries *purchaseQueries
+	dialect database.Dialect
+	db      database.Executor
+}
+
+func newPurchaseRepository(queries *purchaseQueries, dialect database.Dialect, db database.Executor) *purchaseRepository {
+	return &purchaseRepository{queries: queries, dialect: dialect, db: db}
+}
+
+func (r *purchaseRepository) Create(purchase *model.Purchase) error {
+	id, err := insertReturningID(r.db, r.dialect, r.queries.insert, purchase.WagerID, purchase.Buyer, purchase.BuyingPrice, purchase.FaceValue, purchase.BoughtAt,
+		purchase.Fees.MakerFee, purchase.Fees.MakerRate, purchase.Fees.TakerFee, purchase.Fees.TakerRate)
+	if err != nil {
+		return fmt.Errorf("failed to create purchase: %w", err)
+	}
+
+	purchase.PurchaseID = uint(id)
+	return nil
+}
+
+func (r *purchaseRepository) List(filter model.PurchaseFilter, offset int, limit int) ([]model.Purchase, error) {
+	query := strings.Builder{}
+	query.WriteString(r.queries.list)
+	args := []interface{}{}
+	if filter.WagerID != 0 {
+		query.WriteString(" AND wager_id=?")
+		args = append(args, filter.WagerID)
+	}
+	if filter.Buyer != "" {
+		query.WriteString(" AND buyer=?")
+		args = append(args, filter.Buyer)
+	}
+	if filter.BoughtFrom != 0 {
+		query.WriteString(" AND bought_at>=?")
+		args = append(args, filter.BoughtFrom)
+	}
+	if filter.BoughtTo != 0 {
+		query.WriteString(" AND bought_at<=?")
+		args = append(args, filter.BoughtTo)
+	}
+	query.WriteString(" ORDER BY id LIMIT ? OFFSET ?")
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(r.dialect.Rebind(query.String()), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchases: %w", err)
+	}
+	defer rows.Close()
+
+	purchases := make([]model.Purchase, 0)
+	for rows.Next() {
+		purchase, err := scanPurchase(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan purchase: %w", err)
+		}
+		purchases = append(purchases, *purchase)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate purchases: %w", err)
+	}
+
+	return purchases, nil
+}
+
+func (r *purchaseRepository) GetByID(id uint) (*model.Purchase, error) {
+	return r.getOne(r.queries.getByID, id)
+}
+
+func (r *purchaseRepository) GetByIDForUpdate(id uint) (*model.Purchase, error) {
+	return r.getOne(r.queries.getByIDForUpdate, id)
+}
+
+func (r *purchaseRepository) Refund(purchase *model.Purchase) error {
+	if _, err := r.db.Exec(r.queries.refund, purchase.RefundedAt, purchase.PurchaseID); err != nil {
+		return fmt.Errorf("failed to refund purchase: %w", err)
+	}
+	return nil
+}
+
+func (r *purchaseRepository) TradingVolume(buyer string, seller string, currency string) (*model.TradingVolume, error) {
+	volume := model.TradingVolume{}
+	rows, err := r.db.Query(r.queries.tradingVolume, buyer, seller, currency, buyer, seller)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trading volume: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get trading volume: %w", err)
+		}
+		return &volume, nil
+	}
+	if err := rows.Scan(&volume.Bought, &volume.Sold); err != nil {
+		return nil, fmt.Errorf("failed to scan trading volume: %w", err)
+	}
+
+	return &volume, nil
+}
+
+func (r *purchaseRepository) FeeRevenue(from int64, to int64) ([]model.FeeRevenue, error) {
+	query := strings.Builder{}
+	query.WriteString(r.queries.feeRevenue)
+	args := []interface{}{}
+	if from != 0 {
+		query.WriteString(" AND p.bought_at>=?")
+		args = append(args, from)
+	}
+	if to != 0 {
+		query.WriteString(" AND p.bought_at<=?")
+		args = append(args, to)
+	}
+	query.WriteString(" GROUP BY w.currency ORDER BY w.currency")
+
+	rows, err := r.db.Query(r.dialect.Rebind(query.String()), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee revenue: %w", err)
+	}
+	defer rows.Close()
+
+	revenues := make([]model.FeeRevenue, 0)
+	for rows.Next() {
+		revenue := model.FeeRevenue{}
+		if err := rows.Scan(&revenue.Currency, &revenue.Purchases, &revenue.Volume, &revenue.MakerFees, &revenue.TakerFees); err != nil {
+			return nil, fmt.Errorf("failed to scan fee revenue: %w", err)
+		}
+		revenue.Total = revenue.MakerFees + revenue.TakerFees
+		revenues = append(revenues, revenue)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate fee revenue: %w", err)
+	}
+
+	return revenues, nil
+}
+
+func (r *purchaseRepository) getOne(query string, args ...interface{}) (*model.Purchase, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchase: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get purchase: %w", err)
+		}
+		return nil, ErrNotFound
+	}
+
+	purchase, err := scanPurchase(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan purchase: %w", err)
+	}
+
+	return purchase, nil
+}
+
+// scanPurchase reads a row selected with purchaseColumns
+func scanPurchase(rows database.DBRows) (*model.Purchase, error) {
+	purchase := model.Purchase{}
+	err := rows.Scan(&purchase.PurchaseID,
+		&purchase.WagerID,
+		&purchase.Buyer,
+		&purchase.BuyingPrice,
+		&purchase.FaceValue,
+		&purchase.BoughtAt,
+		&purchase.RefundedAt,
+		&purchase.Fees.MakerFee,
+		&purchase.Fees.MakerRate,
+		&purchase.Fees.TakerFee,
+		&purchase.Fees.TakerRate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &purchase, nil
+}
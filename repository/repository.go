@@ -0,0 +1,166 @@
+package repository
+
+import (
+	errorcode "wager/error_code"
+	"wager/model"
+)
+
+var ErrNotFound = errorcode.NewClientError("id not found")
+
+type WagerRepository interface {
+	Create(wager *model.Wager) error
+	// CreateMany inserts all the wagers, setting their ids, with as few statements as possible
+	CreateMany(wagers []model.Wager) error
+	// List returns the wagers matching filter in id order
+	List(filter model.WagerFilter, offset int, limit int) ([]model.Wager, error)
+	GetByID(id uint) (*model.Wager, error)
+	// GetByIDForUpdate reads a wager and locks it until the surrounding transaction ends
+	GetByIDForUpdate(id uint) (*model.Wager, error)
+	UpdateSale(wager *model.Wager) error
+	// UpdateAskPrice stores the AskPrice of the wager
+	UpdateAskPrice(wager *model.Wager) error
+	// UpdateStatus stores the Status of the wager
+	UpdateStatus(wager *model.Wager) error
+}
+
+type PurchaseRepository interface {
+	Create(purchase *model.Purchase) error
+	// List returns the purchases matching filter in id order
+	List(filter model.PurchaseFilter, offset int, limit int) ([]model.Purchase, error)
+	GetByID(id uint) (*model.Purchase, error)
+	// GetByIDForUpdate locks the purchase until the end of the transaction
+	GetByIDForUpdate(id uint) (*model.Purchase, error)
+	// Refund stores the RefundedAt of the purchase
+	Refund(purchase *model.Purchase) error
+	// TradingVolume sums what buyer bought and what was bought from the wagers of
+	// seller in currency, refunded purchases are left out
+	TradingVolume(buyer string, seller string, currency string) (*model.TradingVolume, error)
+	// FeeRevenue sums the purchases bought from from to to, both included and 0 for
+	// no bound, for each currency in code order. Refunded purchases are left out.
+	FeeRevenue(from int64, to int64) ([]model.FeeRevenue, error)
+}
+
+type BidRepository interface {
+	Create(bid *model.Bid) error
+	GetByID(id uint) (*model.Bid, error)
+	// GetByIDForUpdate locks the bid until the end of the transaction
+	GetByIDForUpdate(id uint) (*model.Bid, error)
+	// ListOpen returns the open bids of a wager, the highest unit price first and
+	// then in id order, including expired bids which were not swept yet
+	ListOpen(wagerID uint) ([]model.Bid, error)
+	// ListExpired returns up to limit open bids which expired at or before now
+	ListExpired(now int64, limit int) ([]model.Bid, error)
+	// ListWagerIDs returns up to limit ids, above afterID and in order, of the wagers
+	// with open bids
+	ListWagerIDs(afterID uint, limit int) ([]uint, error)
+	// Update stores the Status and PurchaseID of the bid
+	Update(bid *model.Bid) error
+}
+
+type PositionRepository interface {
+	Create(position *model.Position) error
+	// List returns the positions matching filter in id order
+	List(filter model.PositionFilter, offset int, limit int) ([]model.Position, error)
+	GetByID(id uint) (*model.Position, error)
+	// GetByIDForUpdate locks the position until the end of the transaction
+	GetByIDForUpdate(id uint) (*model.Position, error)
+	// ListByPurchase returns every position of an original purchase in id order, the
+	// first one is the position of its buyer
+	ListByPurchase(purchaseID uint) ([]model.Position, error)
+	// Update stores the FaceValue, ListedFaceValue and Payout of the position
+	Update(position *model.Position) error
+	// BuyerExposure sums the positions of holder on wagerID and across all open
+	// wagers in currency, the currency of wagerID
+	BuyerExposure(holder string, wagerID uint, currency string) (*model.BuyerExposure, error)
+}
+
+type ListingRepository interface {
+	Create(listing *model.Listing) error
+	GetByID(id uint) (*model.Listing, error)
+	// GetByIDForUpdate locks the listing until the end of the transaction
+	GetByIDForUpdate(id uint) (*model.Listing, error)
+	// ListOpen returns the open listings of a wager, the lowest price per unit of face
+	// value first and then in id order
+	ListOpen(wagerID uint, offset int, limit int) ([]model.Listing, error)
+	// Update stores the Status and TransferID of the listing
+	Update(listing *model.Listing) error
+}
+
+type TransferRepository interface {
+	Create(transfer *model.Transfer) error
+	// ListByPurchase returns the transfers of an original purchase in id order
+	ListByPurchase(purchaseID uint) ([]model.Transfer, error)
+	// FeeRevenue sums the transfers made from from to to like PurchaseRepository's,
+	// with Transfers counted instead of Purchases
+	FeeRevenue(from int64, to int64) ([]model.FeeRevenue, error)
+}
+
+type EventRepository interface {
+	Create(event *model.Event) error
+	// List returns the events matching filter in start time and then id order
+	List(filter model.EventFilter, offset int, limit int) ([]model.Event, error)
+	GetByID(id uint) (*model.Event, error)
+	// GetByIDForUpdate locks the event until the end of the transaction
+	GetByIDForUpdate(id uint) (*model.Event, error)
+	// Update stores the Name, StartsAt and Status of the event
+	Update(event *model.Event) error
+	Delete(id uint) error
+}
+
+// MarketRepository stores markets with their selections, which are created and
+// deleted with them
+type MarketRepository interface {
+	// Create inserts the market and its selections, setting their ids
+	Create(market *model.Market) error
+	GetByID(id uint) (*model.Market, error)
+	// GetByIDForUpdate locks the market until the end of the transaction
+	GetByIDForUpdate(id uint) (*model.Market, error)
+	// ListByEvent returns the markets of an event in id order
+	ListByEvent(eventID uint) ([]model.Market, error)
+	GetSelection(id uint) (*model.Selection, error)
+	// Update stores the Name, Status, WinningSelectionID and SettledAt of the market
+	Update(market *model.Market) error
+	Delete(id uint) error
+}
+
+type ReservationRepository interface {
+	Create(reservation *model.Reservation) error
+	GetByID(id uint) (*model.Reservation, error)
+	// GetByIDForUpdate locks the reservation until the end of the transaction
+	GetByIDForUpdate(id uint) (*model.Reservation, error)
+	// ListExpired returns up to limit held reservations which expired at or before now
+	ListExpired(now int64, limit int) ([]model.Reservation, error)
+	// ListHeld returns up to limit held reservations of a wager in id order
+	ListHeld(wagerID uint, limit int) ([]model.Reservation, error)
+	// Update stores the Status and PurchaseID of the reservation
+	Update(reservation *model.Reservation) error
+}
+
+type BuyerRepository interface {
+	// Lock locks buyer until the end of the transaction, so that the purchases of a
+	// buyer are checked against its limits one at a time
+	Lock(buyer string) error
+}
+
+// Store gives access to the repositories. Repositories returned by the Store passed
+// to RunInTx's callback share one transaction, which is committed when the callback
+// returns nil and rolled back otherwise.
+type Store interface {
+	Wagers() WagerRepository
+	Purchases() PurchaseRepository
+	Reservations() ReservationRepository
+	Bids() BidRepository
+	Positions() PositionRepository
+	Listings() ListingRepository
+	Transfers() TransferRepository
+	Events() EventRepository
+	Markets() MarketRepository
+	Buyers() BuyerRepository
+	RunInTx(fn func(store Store) error) error
+	// UsePrimary returns a Store whose reads never go to a read replica, for reads
+	// which must see writes made earlier in the same request
+	UsePrimary() Store
+	// Session returns a Store for the calls of one request. Its reads go to a read
+	// replica until it writes, from then on they stay on the primary.
+	Session() Store
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"fmt"
+	"wager/database"
+	"wager/model"
+)
+
+const reservationColumns = "id, wager_id, buyer, buying_price, face_value, status, created_at, expires_at, purchase_id"
+
+type reservationQueries struct {
+	insert           string
+	getByID          string
+	getByIDForUpdate string
+	listExpired      string
+	listHeld         string
+	update           string
+}
+
+func newReservationQueries(dialect database.Dialect, table string) *reservationQueries {
+	return &reservationQueries{
+		insert:           insertStatement(dialect, fmt.Sprintf("INSERT INTO %v (wager_id, buyer, buying_price, face_value, status, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)", table)),
+		getByID:          dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?", reservationColumns, table)),
+		getByIDForUpdate: dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?%v", reservationColumns, table, dialect.LockClause())),
+		listExpired:      dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE status=? AND expires_at<=? ORDER BY id LIMIT ?", reservationColumns, table)),
+		listHeld:         dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE wager_id=? AND status=? ORDER BY id LIMIT ?", reservationColumns, table)),
+		update:           dialect.Rebind(fmt.Sprintf("UPDATE %v SET status=?, purchase_id=? WHERE id=?", table)),
+	}
+}
+
+type reservationRepository struct {
+	queries *reservationQueries
+	dialect database.Dialect
+	db      database.Executor
+}
+
+func newReservationRepository(queries *reservationQueries, dialect database.Dialect, db database.Executor) *reservationRepository {
+	return &reservationRepository{queries: queries, dialect: dialect, db: db}
+}
+
+func (r *reservationRepository) Create(reservation *model.Reservation) error {
+	id, err := insertReturningID(r.db, r.dialect, r.queries.insert, reservation.WagerID, reservation.Buyer, reservation.BuyingPrice, reservation.FaceValue, reservation.Status, reservation.CreatedAt, reservation.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reservation: %w", err)
+	}
+
+	reservation.ID = uint(id)
+	return nil
+}
+
+func (r *reservationRepository) GetByID(id uint) (*model.Reservation, error) {
+	return r.getOne(r.queries.getByID, id)
+}
+
+func (r *reservationRepository) GetByIDForUpdate(id uint) (*model.Reservation, error) {
+	return r.getOne(r.queries.getByIDForUpdate, id)
+}
+
+func (r *reservationRepository) ListExpired(now int64, limit int) ([]model.Reservation, error) {
+	return r.list(r.queries.listExpired, model.RESERVATION_STATUS_HELD, now, limit)
+}
+
+func (r *reservationRepository) ListHeld(wagerID uint, limit int) ([]model.Reservation, error) {
+	return r.list(r.queries.listHeld, wagerID, model.RESERVATION_STATUS_HELD, limit)
+}
+
+func (r *reservationRepository) list(query string, args ...interface{}) ([]model.Reservation, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservations: %w", err)
+	}
+	defer rows.Close()
+
+	reservations := make([]model.Reservation, 0)
+	for rows.Next() {
+		reservation, err := scanReservation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		reservations = append(reservations, *reservation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+func (r *reservationRepository) Update(reservation *model.Reservation) error {
+	if _, err := r.db.Exec(r.queries.update, reservation.Status, reservation.PurchaseID, reservation.ID); err != nil {
+		return fmt.Errorf("failed to update reservation: %w", err)
+	}
+	return nil
+}
+
+func (r *reservationRepository) getOne(query string, args ...interface{}) (*model.Reservation, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get reservation: %w", err)
+		}
+		return nil, ErrNotFound
+	}
+
+	reservation, err := scanReservation(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan reservation: %w", err)
+	}
+
+	return reservation, nil
+}
+
+// scanReservation reads a row selected with reservationColumns
+func scanReservation(rows database.DBRows) (*model.Reservation, error) {
+	reservation := model.Reservation{}
+	err := rows.Scan(&reservation.ID,
+		&reservation.WagerID,
+		&reservation.Buyer,
+		&reservation.BuyingPrice,
+		&reservation.FaceValue,
+		&reservation.Status,
+		&reservation.CreatedAt,
+		&reservation.ExpiresAt,
+		&reservation.PurchaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
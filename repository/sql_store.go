@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"wager/conf"
+	"wager/database"
+)
+
+type sqlStore struct {
+	config             conf.SQLConfig
+	dialect            database.Dialect
+	db                 database.DBManager
+	wagerQueries       *wagerQueries
+	purchaseQueries    *purchaseQueries
+	reservationQueries *reservationQueries
+	bidQueries         *bidQueries
+	positionQueries    *positionQueries
+	listingQueries     *listingQueries
+	transferQueries    *transferQueries
+	eventQueries       *eventQueries
+	marketQueries      *marketQueries
+	buyerQueries       *buyerQueries
+	wagers             *wagerRepository
+	purchases          *purchaseRepository
+	reservations       *reservationRepository
+	bids               *bidRepository
+	positions          *positionRepository
+	listings           *listingRepository
+	transfers          *transferRepository
+	events             *eventRepository
+	markets            *marketRepository
+	buyers             *buyerRepository
+	inTx               bool
+}
+
+// NewSQLStore validates the configured table names and renders every SQL statement
+// once, transactions reuse the same statements.
+func NewSQLStore(config conf.SQLConfig, dialect database.Dialect, db database.DBManager) (Store, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	store := &sqlStore{
+		config:             config,
+		dialect:            dialect,
+		db:                 db,
+		wagerQueries:       newWagerQueries(dialect, tableName(dialect, config, config.WagerTable)),
+		purchaseQueries:    newPurchaseQueries(dialect, tableName(dialect, config, config.PurchaseTable), tableName(dialect, config, config.WagerTable)),
+		reservationQueries: newReservationQueries(dialect, tableName(dialect, config, config.ReservationTable)),
+		bidQueries:         newBidQueries(dialect, tableName(dialect, config, config.BidTable)),
+		positionQueries:    newPositionQueries(dialect, tableName(dialect, config, config.PositionTable), tableName(dialect, config, config.WagerTable)),
+		listingQueries:     newListingQueries(dialect, tableName(dialect, config, config.ListingTable)),
+		transferQueries:    newTransferQueries(dialect, tableName(dialect, config, config.TransferTable), tableName(dialect, config, config.PurchaseTable), tableName(dialect, config, config.WagerTable)),
+		eventQueries:       newEventQueries(dialect, tableName(dialect, config, config.EventTable)),
+		marketQueries:      newMarketQueries(dialect, tableName(dialect, config, config.MarketTable), tableName(dialect, config, config.SelectionTable)),
+		buyerQueries:       newBuyerQueries(dialect, tableName(dialect, config, config.BuyerTable)),
+	}
+	store.bind(db)
+
+	if config.PrepareStatements {
+		db.CacheStatements(
+			store.wagerQueries.list,
+			store.wagerQueries.getByID,
+			store.wagerQueries.getByIDForUpdate,
+			store.wagerQueries.updateSale,
+			store.purchaseQueries.insert,
+			store.purchaseQueries.getByID,
+		)
+	}
+	return store, nil
+}
+
+// bind points the repositories at the database or at a transaction
+func (s *sqlStore) bind(exec database.Executor) {
+	s.wagers = newWagerRepository(s.wagerQueries, s.dialect, exec)
+	s.purchases = newPurchaseRepository(s.purchaseQueries, s.dialect, exec)
+	s.reservations = newReservationRepository(s.reservationQueries, s.dialect, exec)
+	s.bids = newBidRepository(s.bidQueries, s.dialect, exec)
+	s.positions = newPositionRepository(s.positionQueries, s.dialect, exec)
+	s.listings = newListingRepository(s.listingQueries, s.dialect, exec)
+	s.transfers = newTransferRepository(s.transferQueries, s.dialect, exec)
+	s.events = newEventRepository(s.eventQueries, s.dialect, exec)
+	s.markets = newMarketRepository(s.marketQueries, s.dialect, exec)
+	s.buyers = newBuyerRepository(s.buyerQueries, exec)
+}
+
+func (s *sqlStore) Wagers() WagerRepository {
+	return s.wagers
+}
+
+func (s *sqlStore) Purchases() PurchaseRepository {
+	return s.purchases
+}
+
+func (s *sqlStore) Reservations() ReservationRepository {
+	return s.reservations
+}
+
+func (s *sqlStore) Bids() BidRepository {
+	return s.bids
+}
+
+func (s *sqlStore) Positions() PositionRepository {
+	return s.positions
+}
+
+func (s *sqlStore) Listings() ListingRepository {
+	return s.listings
+}
+
+func (s *sqlStore) Transfers() TransferRepository {
+	return s.transfers
+}
+
+func (s *sqlStore) Events() EventRepository {
+	return s.events
+}
+
+func (s *sqlStore) Markets() MarketRepository {
+	return s.markets
+}
+
+func (s *sqlStore) Buyers() BuyerRepository {
+	return s.buyers
+}
+
+func (s *sqlStore) UsePrimary() Store {
+	// transactions already run on the primary
+	if s.inTx {
+		return s
+	}
+
+	primaryStore := *s
+	primaryStore.bind(s.db.Primary())
+	return &primaryStore
+}
+
+func (s *sqlStore) Session() Store {
+	if s.inTx {
+		return s
+	}
+
+	sessionStore := *s
+	sessionStore.db = s.db.Session()
+	sessionStore.bind(sessionStore.db)
+	return &sessionStore
+}
+
+func (s *sqlStore) RunInTx(fn func(store Store) error) error {
+	// nested calls join the transaction that is already running
+	if s.inTx {
+		return fn(s)
+	}
+
+	policy := database.RetryPolicy{
+		MaxRetries: s.config.TxMaxRetries,
+		BaseDelay:  s.config.TxRetryBaseDelay,
+		MaxDelay:   s.config.TxRetryMaxDelay,
+	}
+	return database.RunInTx(s.db, policy, func(tx database.DBTx) error {
+		txStore := *s
+		txStore.inTx = true
+		txStore.bind(tx)
+		return fn(&txStore)
+	})
+}
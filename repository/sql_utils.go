@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"wager/conf"
+	"wager/database"
+)
+
+// tableName returns the quoted, optionally schema qualified, name of a table
+func tableName(dialect database.Dialect, config conf.SQLConfig, table string) string {
+	if config.Schema == "" {
+		return dialect.QuoteIdentifier(table)
+	}
+	return dialect.QuoteIdentifier(config.Schema) + "." + dialect.QuoteIdentifier(table)
+}
+
+// insertStatement prepares an INSERT so that insertReturningID can read the generated id
+func insertStatement(dialect database.Dialect, query string) string {
+	if dialect.InsertReturningID() {
+		query += " RETURNING id"
+	}
+	return dialect.Rebind(query)
+}
+
+// insertReturningID runs a statement built by insertStatement and returns the id
+// generated for the new row
+func insertReturningID(db database.Executor, dialect database.Dialect, query string, args ...interface{}) (int64, error) {
+	if !dialect.InsertReturningID() {
+		res, err := db.Exec(query, args...)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, errors.New("no id returned")
+	}
+
+	var id int64
+	if err := rows.Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// checkDeleted returns ErrNotFound when a DELETE matched no row
+func checkDeleted(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// valuesList returns the VALUES list of a multi-row INSERT of rows rows of columns columns
+func valuesList(rows int, columns int) string {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", columns), ", ") + ")"
+	return strings.TrimSuffix(strings.Repeat(row+", ", rows), ", ")
+}
+
+// insertManyReturningIDs runs a multi-row statement built by insertStatement with
+// RETURNING and returns the ids generated for the rows rows, in insertion order
+func insertManyReturningIDs(db database.Executor, query string, rows int, args ...interface{}) ([]int64, error) {
+	result, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	ids := make([]int64, 0, rows)
+	for result.Next() {
+		var id int64
+		if err := result.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) != rows {
+		return nil, fmt.Errorf("%v ids returned for %v rows", len(ids), rows)
+	}
+	return ids, nil
+}
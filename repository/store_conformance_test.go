@@ -0,0 +1,870 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"wager/conf"
+	"wager/database"
+	"wager/model"
+	"wager/odds"
+	sqlmigration "wager/sql_migration"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Every Store implementation must pass the conformance suite. MySQL and PostgreSQL
+// run only when a test database is provided through WAGER_TEST_MYSQL_ADDRESS or
+// WAGER_TEST_POSTGRES_ADDRESS, the credentials are read from WAGER_TEST_SQL_USER and
+// WAGER_TEST_SQL_PASSWORD. The suite expects an empty database.
+
+func Test_Conformance_Memory(t *testing.T) {
+	runStoreConformance(t, func(t *testing.T) Store {
+		return NewMemoryStore()
+	})
+}
+
+func Test_Conformance_SQLite(t *testing.T) {
+	runStoreConformance(t, func(t *testing.T) Store {
+		config := conf.GetDefaultConfig().SQL
+		config.Dialect = conf.DIALECT_SQLITE
+		config.DatabaseAddress = filepath.Join(t.TempDir(), "wager.db")
+		return newConformanceSQLStore(t, config)
+	})
+}
+
+func Test_Conformance_MySQL(t *testing.T) {
+	runSQLConformance(t, conf.DIALECT_MYSQL, "WAGER_TEST_MYSQL_ADDRESS")
+}
+
+func Test_Conformance_Postgres(t *testing.T) {
+	runSQLConformance(t, conf.DIALECT_POSTGRES, "WAGER_TEST_POSTGRES_ADDRESS")
+}
+
+func runSQLConformance(t *testing.T, dialect string, addressEnv string) {
+	address := os.Getenv(addressEnv)
+	if address == "" {
+		t.Skipf("%v is not set", addressEnv)
+	}
+
+	runStoreConformance(t, func(t *testing.T) Store {
+		config := conf.GetDefaultConfig().SQL
+		config.Dialect = dialect
+		config.DatabaseAddress = address
+		config.Username = os.Getenv("WAGER_TEST_SQL_USER")
+		config.Password = os.Getenv("WAGER_TEST_SQL_PASSWORD")
+		store := newConformanceSQLStore(t, config)
+		truncateTables(t, store.(*sqlStore))
+		return store
+	})
+}
+
+func newConformanceSQLStore(t *testing.T, config conf.SQLConfig) Store {
+	dialect, err := database.GetDialect(config.Dialect)
+	require.NoError(t, err)
+
+	sqlDB, err := sql.Open(dialect.DriverName(), dialect.DataSourceName(config))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db := database.NewDB(sqlDB)
+	require.NoError(t, sqlmigration.Migrate(db, dialect))
+	store, err := NewSQLStore(config, dialect, db)
+	require.NoError(t, err)
+	return store
+}
+
+// truncateTables empties a shared test database between subtests
+func truncateTables(t *testing.T, store *sqlStore) {
+	for _, table := range []string{store.config.BuyerTable, store.config.SelectionTable, store.config.MarketTable, store.config.EventTable, store.config.TransferTable, store.config.ListingTable, store.config.PositionTable, store.config.BidTable, store.config.ReservationTable, store.config.PurchaseTable, store.config.WagerTable} {
+		_, err := store.db.Exec("DELETE FROM " + table)
+		require.NoError(t, err)
+	}
+}
+
+func newConformanceWager(t *testing.T, store Store) *model.Wager {
+	wager := &model.Wager{
+		TotalWagerValue:     100,
+		Odds:                2 * odds.SCALE,
+		SellingPercentage:   50,
+		SellingPrice:        100,
+		CurrentSellingPrice: 100,
+		PlaceAt:             1642484487,
+		Status:              model.WAGER_STATUS_OPEN,
+		MinPurchase:         5,
+		MaxPurchase:         50,
+		PurchaseIncrement:   0.5,
+		PriceSchedule:       model.PRICE_SCHEDULE_STEP,
+		FloorPrice:          40,
+		DecaySeconds:        600,
+		DecaySteps:          3,
+		Seller:              "bookie",
+		Currency:            "USD",
+	}
+	require.NoError(t, store.Wagers().Create(wager))
+	return wager
+}
+
+func runStoreConformance(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Run("Create and get wager", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		assert.NotZero(t, wager.ID)
+
+		got, err := store.Wagers().GetByID(wager.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *wager, *got)
+		assert.False(t, got.PercentageSold.Valid)
+		assert.False(t, got.AmountSold.Valid)
+	})
+
+	t.Run("Create many wagers", func(t *testing.T) {
+		store := newStore(t)
+		first := newConformanceWager(t, store)
+
+		// more than one statement worth of rows
+		wagers := make([]model.Wager, MAX_INSERT_ROWS+2)
+		for i := range wagers {
+			wagers[i] = model.Wager{TotalWagerValue: uint(i + 1), Odds: 2 * odds.SCALE, SellingPercentage: 50, SellingPrice: 100, CurrentSellingPrice: 100, PlaceAt: 1642484487}
+		}
+		require.NoError(t, store.Wagers().CreateMany(wagers))
+
+		for _, i := range []int{0, MAX_INSERT_ROWS - 1, MAX_INSERT_ROWS, MAX_INSERT_ROWS + 1} {
+			assert.Greater(t, wagers[i].ID, first.ID)
+			got, err := store.Wagers().GetByID(wagers[i].ID)
+			require.NoError(t, err)
+			assert.Equal(t, wagers[i], *got)
+		}
+	})
+
+	t.Run("Read from primary", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+
+		got, err := store.UsePrimary().Wagers().GetByID(wager.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *wager, *got)
+	})
+
+	t.Run("Get unknown wager", func(t *testing.T) {
+		store := newStore(t)
+		_, err := store.Wagers().GetByID(1000)
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("List wagers in id order", func(t *testing.T) {
+		store := newStore(t)
+		ids := []uint{}
+		for i := 0; i < 5; i++ {
+			ids = append(ids, newConformanceWager(t, store).ID)
+		}
+
+		wagers, err := store.Wagers().List(model.WagerFilter{}, 1, 3)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(wagers))
+		for i, wager := range wagers {
+			assert.Equal(t, ids[i+1], wager.ID)
+		}
+
+		wagers, err = store.Wagers().List(model.WagerFilter{}, 10, 3)
+		require.NoError(t, err)
+		assert.Equal(t, 0, len(wagers))
+	})
+
+	t.Run("List wagers in a currency", func(t *testing.T) {
+		store := newStore(t)
+		newConformanceWager(t, store)
+		yen := &model.Wager{TotalWagerValue: 10000, Odds: 2 * odds.SCALE, SellingPercentage: 50, SellingPrice: 5000, CurrentSellingPrice: 5000, PlaceAt: 1642484487, Status: "open", Currency: "JPY"}
+		require.NoError(t, store.Wagers().Create(yen))
+		newConformanceWager(t, store)
+
+		wagers, err := store.Wagers().List(model.WagerFilter{Currency: "JPY"}, 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(wagers))
+		assert.Equal(t, yen.ID, wagers[0].ID)
+		assert.Equal(t, "JPY", wagers[0].Currency)
+
+		wagers, err = store.Wagers().List(model.WagerFilter{Currency: "USD"}, 1, 10)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(wagers))
+		assert.Equal(t, "USD", wagers[0].Currency)
+	})
+
+	t.Run("Update sale", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		wager.CurrentSellingPrice = 74.5
+		wager.AmountSold.Float64, wager.AmountSold.Valid = 25.5, true
+		wager.PercentageSold.Uint, wager.PercentageSold.Valid = 25, true
+		wager.ReservedAmount = 10.5
+		require.NoError(t, store.Wagers().UpdateSale(wager))
+
+		got, err := store.Wagers().GetByID(wager.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *wager, *got)
+	})
+
+	t.Run("Update ask price", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		wager.AskPrice = 80.5
+		require.NoError(t, store.Wagers().UpdateAskPrice(wager))
+
+		got, err := store.Wagers().GetByID(wager.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *wager, *got)
+	})
+
+	t.Run("Updates increment the version", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		assert.Equal(t, uint64(0), wager.Version)
+
+		require.NoError(t, store.Wagers().UpdateSale(wager))
+		require.NoError(t, store.Wagers().UpdateAskPrice(wager))
+		wager.Status = model.WAGER_STATUS_WON
+		require.NoError(t, store.Wagers().UpdateStatus(wager))
+		assert.Equal(t, uint64(3), wager.Version)
+
+		got, err := store.Wagers().GetByID(wager.ID)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), got.Version)
+	})
+
+	t.Run("Negative selling price is rejected", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		wager.CurrentSellingPrice = -1
+		assert.Error(t, store.Wagers().UpdateSale(wager))
+	})
+
+	t.Run("Purchase of unknown wager is rejected", func(t *testing.T) {
+		store := newStore(t)
+		err := store.Purchases().Create(&model.Purchase{WagerID: 1000, BuyingPrice: 1, BoughtAt: 1642484487})
+		assert.Error(t, err)
+	})
+
+	t.Run("List and get purchases", func(t *testing.T) {
+		store := newStore(t)
+		first := newConformanceWager(t, store)
+		second := newConformanceWager(t, store)
+		purchases := []*model.Purchase{
+			{WagerID: first.ID, Buyer: "alice", BuyingPrice: 10, BoughtAt: 100},
+			{WagerID: first.ID, Buyer: "bob", BuyingPrice: 20, FaceValue: 25.5, BoughtAt: 200},
+			{WagerID: second.ID, Buyer: "alice", BuyingPrice: 30, BoughtAt: 300},
+			{WagerID: first.ID, Buyer: "alice", BuyingPrice: 40, BoughtAt: 400},
+		}
+		for _, purchase := range purchases {
+			require.NoError(t, store.Purchases().Create(purchase))
+		}
+
+		got, err := store.Purchases().GetByID(purchases[1].PurchaseID)
+		require.NoError(t, err)
+		assert.Equal(t, *purchases[1], *got)
+
+		_, err = store.Purchases().GetByID(1000)
+		assert.Equal(t, ErrNotFound, err)
+
+		tests := []struct {
+			filter   model.PurchaseFilter
+			offset   int
+			limit    int
+			expected []int
+		}{
+			{filter: model.PurchaseFilter{}, offset: 0, limit: 10, expected: []int{0, 1, 2, 3}},
+			{filter: model.PurchaseFilter{}, offset: 1, limit: 2, expected: []int{1, 2}},
+			{filter: model.PurchaseFilter{WagerID: first.ID}, offset: 0, limit: 10, expected: []int{0, 1, 3}},
+			{filter: model.PurchaseFilter{Buyer: "alice"}, offset: 1, limit: 10, expected: []int{2, 3}},
+			{filter: model.PurchaseFilter{BoughtFrom: 200, BoughtTo: 300}, offset: 0, limit: 10, expected: []int{1, 2}},
+			{filter: model.PurchaseFilter{WagerID: first.ID, Buyer: "alice", BoughtFrom: 101}, offset: 0, limit: 10, expected: []int{3}},
+			{filter: model.PurchaseFilter{Buyer: "carol"}, offset: 0, limit: 10, expected: []int{}},
+		}
+		for _, test := range tests {
+			list, err := store.Purchases().List(test.filter, test.offset, test.limit)
+			require.NoError(t, err)
+			require.Equal(t, len(test.expected), len(list), "filter %+v", test.filter)
+			for i, index := range test.expected {
+				assert.Equal(t, *purchases[index], list[i])
+			}
+		}
+	})
+
+	t.Run("Refund purchase", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		purchase := &model.Purchase{WagerID: wager.ID, BuyingPrice: 10, BoughtAt: 100}
+		require.NoError(t, store.Purchases().Create(purchase))
+
+		err := store.RunInTx(func(tx Store) error {
+			locked, err := tx.Purchases().GetByIDForUpdate(purchase.PurchaseID)
+			if err != nil {
+				return err
+			}
+			locked.RefundedAt = 200
+			return tx.Purchases().Refund(locked)
+		})
+		require.NoError(t, err)
+
+		got, err := store.Purchases().GetByID(purchase.PurchaseID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(200), got.RefundedAt)
+	})
+
+	t.Run("Buyer exposure", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		other := newConformanceWager(t, store)
+		closed := &model.Wager{TotalWagerValue: 100, Odds: 2 * odds.SCALE, SellingPercentage: 50, SellingPrice: 100, CurrentSellingPrice: 100, PlaceAt: 1642484487, Status: "closed", Currency: "USD"}
+		require.NoError(t, store.Wagers().Create(closed))
+		euro := &model.Wager{TotalWagerValue: 100, Odds: 2 * odds.SCALE, SellingPercentage: 50, SellingPrice: 100, CurrentSellingPrice: 100, PlaceAt: 1642484487, Status: "open", Currency: "EUR"}
+		require.NoError(t, store.Wagers().Create(euro))
+
+		// bob sold all of his position on wager to alice, the last position was refunded
+		positions := []struct {
+			wagerID uint
+			buyer   string
+			holder  string
+			value   float64
+		}{
+			{wager.ID, "alice", "alice", 10.5},
+			{wager.ID, "alice", "alice", 20},
+			{wager.ID, "bob", "bob", 0},
+			{wager.ID, "bob", "alice", 5},
+			{other.ID, "alice", "alice", 7},
+			{closed.ID, "alice", "alice", 9},
+			{euro.ID, "alice", "alice", 11},
+			{wager.ID, "alice", "alice", 0},
+		}
+		for _, p := range positions {
+			purchase := &model.Purchase{WagerID: p.wagerID, Buyer: p.buyer, BuyingPrice: 5, FaceValue: 5, BoughtAt: 100}
+			require.NoError(t, store.Purchases().Create(purchase))
+			require.NoError(t, store.Positions().Create(&model.Position{PurchaseID: purchase.PurchaseID, WagerID: p.wagerID, Holder: p.holder, FaceValue: p.value, AcquiredAt: 100}))
+		}
+
+		exposure, err := store.Positions().BuyerExposure("alice", wager.ID, "USD")
+		require.NoError(t, err)
+		assert.Equal(t, model.BuyerExposure{WagerFaceValue: 35.5, WagerPositions: 3, OpenFaceValue: 42.5}, *exposure)
+
+		exposure, err = store.Positions().BuyerExposure("alice", euro.ID, "EUR")
+		require.NoError(t, err)
+		assert.Equal(t, model.BuyerExposure{WagerFaceValue: 11, WagerPositions: 1, OpenFaceValue: 11}, *exposure)
+
+		exposure, err = store.Positions().BuyerExposure("bob", wager.ID, "USD")
+		require.NoError(t, err)
+		assert.Equal(t, model.BuyerExposure{}, *exposure)
+	})
+
+	t.Run("Trading volume and fee revenue", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		aliceWager := &model.Wager{TotalWagerValue: 100, Odds: 2 * odds.SCALE, SellingPercentage: 50, SellingPrice: 100, CurrentSellingPrice: 100, PlaceAt: 1642484487, Status: "open", Seller: "alice", Currency: "USD"}
+		require.NoError(t, store.Wagers().Create(aliceWager))
+		euro := &model.Wager{TotalWagerValue: 100, Odds: 2 * odds.SCALE, SellingPercentage: 50, SellingPrice: 100, CurrentSellingPrice: 100, PlaceAt: 1642484487, Status: "open", Seller: "alice", Currency: "EUR"}
+		require.NoError(t, store.Wagers().Create(euro))
+
+		purchases := []*model.Purchase{
+			{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 10.5, BoughtAt: 100, Fees: model.Fees{MakerFee: 0.11, MakerRate: 1, TakerFee: 0.21, TakerRate: 2}},
+			{WagerID: wager.ID, Buyer: "bob", BuyingPrice: 20, BoughtAt: 200, Fees: model.Fees{MakerFee: 0.2, MakerRate: 1, TakerFee: 0.4, TakerRate: 2}},
+			{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 30, BoughtAt: 300, Fees: model.Fees{MakerFee: 0.3, MakerRate: 1, TakerFee: 0.6, TakerRate: 2}},
+			{WagerID: aliceWager.ID, Buyer: "bookie", BuyingPrice: 5, BoughtAt: 300},
+			{WagerID: euro.ID, Buyer: "bookie", BuyingPrice: 8, BoughtAt: 300, Fees: model.Fees{MakerFee: 0.08, MakerRate: 1}},
+		}
+		for _, purchase := range purchases {
+			require.NoError(t, store.Purchases().Create(purchase))
+		}
+		got, err := store.Purchases().GetByID(purchases[0].PurchaseID)
+		require.NoError(t, err)
+		assert.Equal(t, purchases[0].Fees, got.Fees)
+
+		purchases[2].RefundedAt = 400
+		require.NoError(t, store.Purchases().Refund(purchases[2]))
+
+		volume, err := store.Purchases().TradingVolume("alice", "alice", "USD")
+		require.NoError(t, err)
+		assert.Equal(t, model.TradingVolume{Bought: 10.5, Sold: 5}, *volume)
+		volume, err = store.Purchases().TradingVolume("bookie", "bookie", "USD")
+		require.NoError(t, err)
+		assert.Equal(t, model.TradingVolume{Bought: 5, Sold: 30.5}, *volume)
+		volume, err = store.Purchases().TradingVolume("alice", "alice", "EUR")
+		require.NoError(t, err)
+		assert.Equal(t, model.TradingVolume{Sold: 8}, *volume)
+		volume, err = store.Purchases().TradingVolume("carol", "", "USD")
+		require.NoError(t, err)
+		assert.Equal(t, model.TradingVolume{}, *volume)
+
+		revenues, err := store.Purchases().FeeRevenue(0, 0)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(revenues))
+		assert.Equal(t, "EUR", revenues[0].Currency)
+		assert.Equal(t, 1, revenues[0].Purchases)
+		assert.InDelta(t, 0.08, revenues[0].Total, 0.001)
+		assert.Equal(t, "USD", revenues[1].Currency)
+		assert.Equal(t, 3, revenues[1].Purchases)
+		assert.InDelta(t, 35.5, revenues[1].Volume, 0.001)
+		assert.InDelta(t, 0.31, revenues[1].MakerFees, 0.001)
+		assert.InDelta(t, 0.61, revenues[1].TakerFees, 0.001)
+		assert.InDelta(t, 0.92, revenues[1].Total, 0.001)
+
+		revenues, err = store.Purchases().FeeRevenue(150, 250)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(revenues))
+		assert.Equal(t, 1, revenues[0].Purchases)
+		assert.InDelta(t, 20, revenues[0].Volume, 0.001)
+		assert.InDelta(t, 0.6, revenues[0].Total, 0.001)
+	})
+
+	t.Run("Reservations", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		reservations := []*model.Reservation{
+			{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 10, FaceValue: 12.5, Status: model.RESERVATION_STATUS_HELD, CreatedAt: 100, ExpiresAt: 200},
+			{WagerID: wager.ID, Buyer: "bob", BuyingPrice: 20, FaceValue: 22.5, Status: model.RESERVATION_STATUS_HELD, CreatedAt: 100, ExpiresAt: 300},
+			{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 30, Status: model.RESERVATION_STATUS_HELD, CreatedAt: 100, ExpiresAt: 150},
+		}
+		for _, reservation := range reservations {
+			require.NoError(t, store.Reservations().Create(reservation))
+		}
+
+		got, err := store.Reservations().GetByID(reservations[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, *reservations[1], *got)
+		_, err = store.Reservations().GetByID(1000)
+		assert.Equal(t, ErrNotFound, err)
+
+		reservations[2].Status = model.RESERVATION_STATUS_CONFIRMED
+		reservations[2].PurchaseID = 7
+		err = store.RunInTx(func(tx Store) error {
+			if _, err := tx.Reservations().GetByIDForUpdate(reservations[2].ID); err != nil {
+				return err
+			}
+			return tx.Reservations().Update(reservations[2])
+		})
+		require.NoError(t, err)
+		got, err = store.Reservations().GetByID(reservations[2].ID)
+		require.NoError(t, err)
+		assert.Equal(t, *reservations[2], *got)
+
+		expired, err := store.Reservations().ListExpired(200, 10)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(expired))
+		assert.Equal(t, reservations[0].ID, expired[0].ID)
+
+		expired, err = store.Reservations().ListExpired(300, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(expired))
+
+		held, err := store.Reservations().ListHeld(wager.ID, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Reservation{*reservations[0], *reservations[1]}, held)
+		held, err = store.Reservations().ListHeld(wager.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Reservation{*reservations[0]}, held)
+		held, err = store.Reservations().ListHeld(wager.ID+1, 10)
+		require.NoError(t, err)
+		assert.Empty(t, held)
+	})
+
+	t.Run("Bids", func(t *testing.T) {
+		store := newStore(t)
+		wagers := []*model.Wager{newConformanceWager(t, store), newConformanceWager(t, store), newConformanceWager(t, store)}
+		bids := []*model.Bid{
+			{WagerID: wagers[0].ID, Buyer: "alice", FaceValue: 20, BidPrice: 10, Status: model.BID_STATUS_OPEN, CreatedAt: 100, ExpiresAt: 200},
+			{WagerID: wagers[0].ID, Buyer: "bob", FaceValue: 10, BidPrice: 8, Status: model.BID_STATUS_OPEN, CreatedAt: 100, ExpiresAt: 300},
+			{WagerID: wagers[0].ID, Buyer: "carol", FaceValue: 10, BidPrice: 5, Status: model.BID_STATUS_OPEN, CreatedAt: 100, ExpiresAt: 150},
+			{WagerID: wagers[0].ID, Buyer: "dave", FaceValue: 5, BidPrice: 4, Status: model.BID_STATUS_OPEN, CreatedAt: 100, ExpiresAt: 300},
+			{WagerID: wagers[2].ID, Buyer: "alice", FaceValue: 10, BidPrice: 5, Status: model.BID_STATUS_OPEN, CreatedAt: 100, ExpiresAt: 300},
+		}
+		for _, bid := range bids {
+			require.NoError(t, store.Bids().Create(bid))
+		}
+
+		got, err := store.Bids().GetByID(bids[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, *bids[1], *got)
+		_, err = store.Bids().GetByID(1000)
+		assert.Equal(t, ErrNotFound, err)
+
+		bids[3].Status = model.BID_STATUS_FILLED
+		bids[3].PurchaseID = 7
+		err = store.RunInTx(func(tx Store) error {
+			if _, err := tx.Bids().GetByIDForUpdate(bids[3].ID); err != nil {
+				return err
+			}
+			return tx.Bids().Update(bids[3])
+		})
+		require.NoError(t, err)
+		got, err = store.Bids().GetByID(bids[3].ID)
+		require.NoError(t, err)
+		assert.Equal(t, *bids[3], *got)
+
+		// the best unit price first, ties in id order
+		open, err := store.Bids().ListOpen(wagers[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(open))
+		assert.Equal(t, []uint{bids[1].ID, bids[0].ID, bids[2].ID}, []uint{open[0].ID, open[1].ID, open[2].ID})
+
+		expired, err := store.Bids().ListExpired(200, 10)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(expired))
+		assert.Equal(t, bids[0].ID, expired[0].ID)
+		assert.Equal(t, bids[2].ID, expired[1].ID)
+
+		ids, err := store.Bids().ListWagerIDs(0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{wagers[0].ID, wagers[2].ID}, ids)
+		ids, err = store.Bids().ListWagerIDs(wagers[0].ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{wagers[2].ID}, ids)
+	})
+
+	t.Run("Positions, listings and transfers", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		purchase := &model.Purchase{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 20, FaceValue: 20, BoughtAt: 100}
+		require.NoError(t, store.Purchases().Create(purchase))
+
+		sold := &model.Position{PurchaseID: purchase.PurchaseID, WagerID: wager.ID, Holder: "alice", FaceValue: 20, AcquiredAt: 100}
+		bought := &model.Position{PurchaseID: purchase.PurchaseID, WagerID: wager.ID, Holder: "bob", FaceValue: 7.5, AcquiredAt: 200}
+		require.NoError(t, store.Positions().Create(sold))
+		require.NoError(t, store.Positions().Create(bought))
+
+		got, err := store.Positions().GetByID(bought.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *bought, *got)
+		_, err = store.Positions().GetByID(1000)
+		assert.Equal(t, ErrNotFound, err)
+
+		listings := []*model.Listing{
+			{PositionID: sold.ID, PurchaseID: purchase.PurchaseID, WagerID: wager.ID, Seller: "alice", FaceValue: 10, Price: 12, Status: model.LISTING_STATUS_OPEN, CreatedAt: 150},
+			{PositionID: sold.ID, PurchaseID: purchase.PurchaseID, WagerID: wager.ID, Seller: "alice", FaceValue: 7.5, Price: 6, Status: model.LISTING_STATUS_OPEN, CreatedAt: 150},
+		}
+		for _, listing := range listings {
+			require.NoError(t, store.Listings().Create(listing))
+		}
+
+		transfer := &model.Transfer{PurchaseID: purchase.PurchaseID, ListingID: listings[1].ID, FromPositionID: sold.ID, ToPositionID: bought.ID,
+			Seller: "alice", Buyer: "bob", FaceValue: 7.5, Price: 6, Fees: model.Fees{MakerFee: 0.06, MakerRate: 1, TakerFee: 0.12, TakerRate: 2}, TransferredAt: 200}
+		err = store.RunInTx(func(tx Store) error {
+			if _, err := tx.Positions().GetByIDForUpdate(sold.ID); err != nil {
+				return err
+			}
+			sold.FaceValue = 12.5
+			sold.ListedFaceValue = 10
+			if err := tx.Positions().Update(sold); err != nil {
+				return err
+			}
+			if err := tx.Transfers().Create(transfer); err != nil {
+				return err
+			}
+			if _, err := tx.Listings().GetByIDForUpdate(listings[1].ID); err != nil {
+				return err
+			}
+			listings[1].Status = model.LISTING_STATUS_SOLD
+			listings[1].TransferID = transfer.ID
+			return tx.Listings().Update(listings[1])
+		})
+		require.NoError(t, err)
+
+		gotListing, err := store.Listings().GetByID(listings[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, *listings[1], *gotListing)
+		_, err = store.Listings().GetByID(1000)
+		assert.Equal(t, ErrNotFound, err)
+
+		open, err := store.Listings().ListOpen(wager.ID, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Listing{*listings[0]}, open)
+
+		positions, err := store.Positions().ListByPurchase(purchase.PurchaseID)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Position{*sold, *bought}, positions)
+
+		positions, err = store.Positions().List(model.PositionFilter{Holder: "bob"}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Position{*bought}, positions)
+
+		// positions sold entirely are left out of the list
+		bought.FaceValue = 0
+		require.NoError(t, store.Positions().Update(bought))
+		positions, err = store.Positions().List(model.PositionFilter{WagerID: wager.ID}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Position{*sold}, positions)
+
+		transfers, err := store.Transfers().ListByPurchase(purchase.PurchaseID)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Transfer{*transfer}, transfers)
+
+		revenues, err := store.Transfers().FeeRevenue(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []model.FeeRevenue{{Currency: wager.Currency, Transfers: 1, Volume: 6, MakerFees: 0.06, TakerFees: 0.12, Total: 0.18}}, revenues)
+		revenues, err = store.Transfers().FeeRevenue(201, 0)
+		require.NoError(t, err)
+		assert.Empty(t, revenues)
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		store := newStore(t)
+		events := []*model.Event{
+			{Name: "Final", StartsAt: 300, Status: model.EVENT_STATUS_SCHEDULED},
+			{Name: "Semi final", StartsAt: 200, Status: model.EVENT_STATUS_FINISHED},
+			{Name: "Other semi final", StartsAt: 200, Status: model.EVENT_STATUS_LIVE},
+		}
+		for _, event := range events {
+			require.NoError(t, store.Events().Create(event))
+			assert.NotZero(t, event.ID)
+		}
+
+		got, err := store.Events().GetByID(events[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, *events[0], *got)
+		_, err = store.Events().GetByID(1000)
+		assert.Equal(t, ErrNotFound, err)
+
+		// events are listed by start time, then id
+		list, err := store.Events().List(model.EventFilter{}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Event{*events[1], *events[2], *events[0]}, list)
+		list, err = store.Events().List(model.EventFilter{}, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Event{*events[2]}, list)
+		list, err = store.Events().List(model.EventFilter{Status: model.EVENT_STATUS_LIVE}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Event{*events[2]}, list)
+
+		err = store.RunInTx(func(tx Store) error {
+			event, err := tx.Events().GetByIDForUpdate(events[0].ID)
+			if err != nil {
+				return err
+			}
+			event.Name = "Grand final"
+			event.StartsAt = 400
+			event.Status = model.EVENT_STATUS_LIVE
+			return tx.Events().Update(event)
+		})
+		require.NoError(t, err)
+		got, err = store.Events().GetByID(events[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.Event{ID: events[0].ID, Name: "Grand final", StartsAt: 400, Status: model.EVENT_STATUS_LIVE}, *got)
+
+		require.NoError(t, store.Events().Delete(events[1].ID))
+		_, err = store.Events().GetByID(events[1].ID)
+		assert.Equal(t, ErrNotFound, err)
+		assert.Equal(t, ErrNotFound, store.Events().Delete(events[1].ID))
+	})
+
+	t.Run("Markets and selections", func(t *testing.T) {
+		store := newStore(t)
+		event := &model.Event{Name: "Final", StartsAt: 300, Status: model.EVENT_STATUS_SCHEDULED}
+		require.NoError(t, store.Events().Create(event))
+		winner := &model.Market{EventID: event.ID, Name: "Winner", Status: model.MARKET_STATUS_OPEN,
+			Selections: []model.Selection{{Name: "Home"}, {Name: "Away"}}}
+		goals := &model.Market{EventID: event.ID, Name: "Goals", Status: model.MARKET_STATUS_OPEN,
+			Selections: []model.Selection{{Name: "Under 2.5"}, {Name: "Over 2.5"}}}
+		require.NoError(t, store.Markets().Create(winner))
+		require.NoError(t, store.Markets().Create(goals))
+		assert.NotZero(t, winner.ID)
+		assert.Equal(t, winner.ID, winner.Selections[1].MarketID)
+		assert.NotZero(t, winner.Selections[1].ID)
+
+		got, err := store.Markets().GetByID(winner.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *winner, *got)
+		_, err = store.Markets().GetByID(1000)
+		assert.Equal(t, ErrNotFound, err)
+
+		markets, err := store.Markets().ListByEvent(event.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Market{*winner, *goals}, markets)
+		markets, err = store.Markets().ListByEvent(1000)
+		require.NoError(t, err)
+		assert.Empty(t, markets)
+
+		selection, err := store.Markets().GetSelection(goals.Selections[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, goals.Selections[1], *selection)
+		_, err = store.Markets().GetSelection(1000)
+		assert.Equal(t, ErrNotFound, err)
+
+		err = store.RunInTx(func(tx Store) error {
+			market, err := tx.Markets().GetByIDForUpdate(winner.ID)
+			if err != nil {
+				return err
+			}
+			market.Status = model.MARKET_STATUS_SETTLED
+			market.WinningSelectionID = market.Selections[0].ID
+			market.SettledAt = 500
+			*winner = *market
+			return tx.Markets().Update(market)
+		})
+		require.NoError(t, err)
+		got, err = store.Markets().GetByID(winner.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *winner, *got)
+
+		require.NoError(t, store.Markets().Delete(goals.ID))
+		_, err = store.Markets().GetByID(goals.ID)
+		assert.Equal(t, ErrNotFound, err)
+		_, err = store.Markets().GetSelection(goals.Selections[0].ID)
+		assert.Equal(t, ErrNotFound, err)
+		assert.Equal(t, ErrNotFound, store.Markets().Delete(goals.ID))
+	})
+
+	t.Run("Wagers on markets", func(t *testing.T) {
+		store := newStore(t)
+		newConformanceWager(t, store)
+		onMarket := func(eventID uint, marketID uint) *model.Wager {
+			wager := &model.Wager{TotalWagerValue: 100, Odds: 2 * odds.SCALE, SellingPercentage: 50, SellingPrice: 100, CurrentSellingPrice: 100, PlaceAt: 1642484487,
+				Status: model.WAGER_STATUS_OPEN, Currency: "USD", EventID: eventID, MarketID: marketID, SelectionID: marketID*10 + 1}
+			require.NoError(t, store.Wagers().Create(wager))
+			return wager
+		}
+		first := onMarket(1, 1)
+		second := onMarket(1, 2)
+		other := onMarket(2, 3)
+
+		got, err := store.Wagers().GetByID(first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *first, *got)
+
+		wagers, err := store.Wagers().List(model.WagerFilter{EventID: 1}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Wager{*first, *second}, wagers)
+		wagers, err = store.Wagers().List(model.WagerFilter{MarketID: 3}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Wager{*other}, wagers)
+
+		first.Status = model.WAGER_STATUS_WON
+		require.NoError(t, store.Wagers().UpdateStatus(first))
+		got, err = store.Wagers().GetByID(first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *first, *got)
+	})
+
+	t.Run("Position payout", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		purchase := &model.Purchase{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 20, FaceValue: 20, BoughtAt: 100}
+		require.NoError(t, store.Purchases().Create(purchase))
+		position := &model.Position{PurchaseID: purchase.PurchaseID, WagerID: wager.ID, Holder: "alice", FaceValue: 20, AcquiredAt: 100}
+		require.NoError(t, store.Positions().Create(position))
+
+		position.Payout = 40.25
+		require.NoError(t, store.Positions().Update(position))
+		got, err := store.Positions().GetByID(position.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *position, *got)
+	})
+
+	t.Run("Transaction commit", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+		purchase := &model.Purchase{WagerID: wager.ID, BuyingPrice: 10, BoughtAt: 1642484487}
+
+		err := store.RunInTx(func(tx Store) error {
+			w, err := tx.Wagers().GetByIDForUpdate(wager.ID)
+			if err != nil {
+				return err
+			}
+			w.CurrentSellingPrice -= purchase.BuyingPrice
+			if err := tx.Wagers().UpdateSale(w); err != nil {
+				return err
+			}
+			return tx.Purchases().Create(purchase)
+		})
+		require.NoError(t, err)
+		assert.NotZero(t, purchase.PurchaseID)
+
+		got, err := store.Wagers().GetByID(wager.ID)
+		require.NoError(t, err)
+		assert.Equal(t, float64(90), got.CurrentSellingPrice)
+	})
+
+	t.Run("Transaction rollback", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+
+		err := store.RunInTx(func(tx Store) error {
+			w, err := tx.Wagers().GetByIDForUpdate(wager.ID)
+			if err != nil {
+				return err
+			}
+			w.CurrentSellingPrice = 0
+			if err := tx.Wagers().UpdateSale(w); err != nil {
+				return err
+			}
+			return errors.New("custom error")
+		})
+		assert.Error(t, err)
+
+		got, err := store.Wagers().GetByID(wager.ID)
+		require.NoError(t, err)
+		assert.Equal(t, float64(100), got.CurrentSellingPrice)
+	})
+
+	t.Run("Concurrent transactions are serialized", func(t *testing.T) {
+		store := newStore(t)
+		wager := newConformanceWager(t, store)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := store.RunInTx(func(tx Store) error {
+					w, err := tx.Wagers().GetByIDForUpdate(wager.ID)
+					if err != nil {
+						return err
+					}
+					w.CurrentSellingPrice -= 10
+					return tx.Wagers().UpdateSale(w)
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		got, err := store.Wagers().GetByID(wager.ID)
+		require.NoError(t, err)
+		assert.Equal(t, float64(0), got.CurrentSellingPrice)
+	})
+
+	t.Run("Buyer lock serializes purchases of different wagers", func(t *testing.T) {
+		store := newStore(t)
+
+		// each transaction buys another wager while alice holds fewer than 3 purchases
+		var wg sync.WaitGroup
+		for i := 0; i < 6; i++ {
+			wager := newConformanceWager(t, store)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := store.RunInTx(func(tx Store) error {
+					if _, err := tx.Wagers().GetByIDForUpdate(wager.ID); err != nil {
+						return err
+					}
+					if err := tx.Buyers().Lock("alice"); err != nil {
+						return err
+					}
+					purchases, err := tx.Purchases().List(model.PurchaseFilter{Buyer: "alice"}, 0, 10)
+					if err != nil || len(purchases) >= 3 {
+						return err
+					}
+					return tx.Purchases().Create(&model.Purchase{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 10, BoughtAt: 100})
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		purchases, err := store.Purchases().List(model.PurchaseFilter{Buyer: "alice"}, 0, 10)
+		require.NoError(t, err)
+		assert.Len(t, purchases, 3)
+	})
+}
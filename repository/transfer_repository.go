@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"wager/database"
+	"wager/model"
+)
+
+const transferColumns = "id, purchase_id, listing_id, from_position_id, to_position_id, seller, buyer, face_value, price, maker_fee, maker_rate, taker_fee, taker_rate, transferred_at"
+
+type transferQueries struct {
+	insert         string
+	listByPurchase string
+	feeRevenue     string
+}
+
+func newTransferQueries(dialect database.Dialect, table string, purchaseTable string, wagerTable string) *transferQueries {
+	return &transferQueries{
+		insert: insertStatement(dialect, fmt.Sprintf("INSERT INTO %v (purchase_id, listing_id, from_position_id, to_position_id, seller, buyer, face_value, price, "+
+			"maker_fee, maker_rate, taker_fee, taker_rate, transferred_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", table)),
+		listByPurchase: dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE purchase_id=? ORDER BY id", transferColumns, table)),
+		// feeRevenue is completed by FeeRevenue with the bounds of the period
+		feeRevenue: fmt.Sprintf("SELECT w.currency, COUNT(*), SUM(t.price), SUM(t.maker_fee), SUM(t.taker_fee) "+
+			"FROM %v t JOIN %v p ON p.id=t.purchase_id JOIN %v w ON w.id=p.wager_id WHERE 1=1", table, purchaseTable, wagerTable),
+	}
+}
+
+type transferRepository struct {
+	queries *transferQueries
+	dialect database.Dialect
+	db      database.Executor
+}
+
+func newTransferRepository(queries *transferQueries, dialect database.Dialect, db database.Executor) *transferRepository {
+	return &transferRepository{queries: queries, dialect: dialect, db: db}
+}
+
+func (r *transferRepository) Create(transfer *model.Transfer) error {
+	id, err := insertReturningID(r.db, r.dialect, r.queries.insert, transfer.PurchaseID, transfer.ListingID, transfer.FromPositionID, transfer.ToPositionID,
+		transfer.Seller, transfer.Buyer, transfer.FaceValue, transfer.Price,
+		transfer.Fees.MakerFee, transfer.Fees.MakerRate, transfer.Fees.TakerFee, transfer.Fees.TakerRate, transfer.TransferredAt)
+	if err != nil {
+		return fmt.Errorf("failed to create transfer: %w", err)
+	}
+
+	transfer.ID = uint(id)
+	return nil
+}
+
+func (r *transferRepository) ListByPurchase(purchaseID uint) ([]model.Transfer, error) {
+	rows, err := r.db.Query(r.queries.listByPurchase, purchaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := make([]model.Transfer, 0)
+	for rows.Next() {
+		transfer := model.Transfer{}
+		err := rows.Scan(&transfer.ID,
+			&transfer.PurchaseID,
+			&transfer.ListingID,
+			&transfer.FromPositionID,
+			&transfer.ToPositionID,
+			&transfer.Seller,
+			&transfer.Buyer,
+			&transfer.FaceValue,
+			&transfer.Price,
+			&transfer.Fees.MakerFee,
+			&transfer.Fees.MakerRate,
+			&transfer.Fees.TakerFee,
+			&transfer.Fees.TakerRate,
+			&transfer.TransferredAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transfer: %w", err)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transfers: %w", err)
+	}
+
+	return transfers, nil
+}
+
+func (r *transferRepository) FeeRevenue(from int64, to int64) ([]model.FeeRevenue, error) {
+	query := strings.Builder{}
+	query.WriteString(r.queries.feeRevenue)
+	args := []interface{}{}
+	if from != 0 {
+		query.WriteString(" AND t.transferred_at>=?")
+		args = append(args, from)
+	}
+	if to != 0 {
+		query.WriteString(" AND t.transferred_at<=?")
+		args = append(args, to)
+	}
+	query.WriteString(" GROUP BY w.currency ORDER BY w.currency")
+
+	rows, err := r.db.Query(r.dialect.Rebind(query.String()), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer fee revenue: %w", err)
+	}
+	defer rows.Close()
+
+	revenues := make([]model.FeeRevenue, 0)
+	for rows.Next() {
+		revenue := model.FeeRevenue{}
+		if err := rows.Scan(&revenue.Currency, &revenue.Transfers, &revenue.Volume, &revenue.MakerFees, &revenue.TakerFees); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer fee revenue: %w", err)
+		}
+		revenue.Total = revenue.MakerFees + revenue.TakerFees
+		revenues = append(revenues, revenue)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transfer fee revenue: %w", err)
+	}
+
+	return revenues, nil
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"wager/database"
+	"wager/model"
+)
+
+const (
+	wagerColumns = "id, total_wager_value, odds, selling_percentage, selling_price, current_selling_price, percentage_sold, amount_sold, place_at, status, reserved_amount, min_purchase, max_purchase, purchase_increment, price_schedule, floor_price, decay_seconds, decay_steps, ask_price, seller, currency, event_id, market_id, selection_id, version"
+
+	// wagerInsertColumns are the columns set when a wager is created, in the order of wagerInsertArgs
+	wagerInsertColumns     = "total_wager_value, odds, selling_percentage, selling_price, current_selling_price, place_at, status, min_purchase, max_purchase, purchase_increment, price_schedule, floor_price, decay_seconds, decay_steps, seller, currency, event_id, market_id, selection_id"
+	wagerInsertColumnCount = 19
+
+	// MAX_INSERT_ROWS bounds a multi-row INSERT well below the placeholder limits of the databases
+	MAX_INSERT_ROWS = 500
+)
+
+type wagerQueries struct {
+	table            string
+	insert           string
+	list             string
+	getByID          string
+	getByIDForUpdate string
+	updateSale       string
+	updateAskPrice   string
+	updateStatus     string
+}
+
+func newWagerQueries(dialect database.Dialect, table string) *wagerQueries {
+	return &wagerQueries{
+		table:  table,
+		insert: insertStatement(dialect, fmt.Sprintf("INSERT INTO %v (%v) VALUES %v", table, wagerInsertColumns, valuesList(1, wagerInsertColumnCount))),
+		// list is completed by List with the conditions of the filter
+		list:             fmt.Sprintf("SELECT %v FROM %v WHERE 1=1", wagerColumns, table),
+		getByID:          dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?", wagerColumns, table)),
+		getByIDForUpdate: dialect.Rebind(fmt.Sprintf("SELECT %v FROM %v WHERE id=?%v", wagerColumns, table, dialect.LockClause())),
+		updateSale:       dialect.Rebind(fmt.Sprintf("UPDATE %v SET current_selling_price=?, percentage_sold=?, amount_sold=?, reserved_amount=?, version=version+1 WHERE id=?", table)),
+		updateAskPrice:   dialect.Rebind(fmt.Sprintf("UPDATE %v SET ask_price=?, version=version+1 WHERE id=?", table)),
+		updateStatus:     dialect.Rebind(fmt.Sprintf("UPDATE %v SET status=?, version=version+1 WHERE id=?", table)),
+	}
+}
+
+type wagerRepository struct {
+	queries *wagerQueries
+	dialect database.Dialect
+	db      database.Executor
+}
+
+func newWagerRepository(queries *wagerQueries, dialect database.Dialect, db database.Executor) *wagerRepository {
+	return &wagerRepository{queries: queries, dialect: dialect, db: db}
+}
+
+func (r *wagerRepository) Create(wager *model.Wager) error {
+	id, err := insertReturningID(r.db, r.dialect, r.queries.insert, wagerInsertArgs(wager)...)
+	if err != nil {
+		return fmt.Errorf("failed to add wager: %w", err)
+	}
+
+	wager.ID = uint(id)
+	return nil
+}
+
+func (r *wagerRepository) CreateMany(wagers []model.Wager) error {
+	if !r.dialect.InsertReturningID() {
+		// LastInsertId only tells the id of one row and the ids of a multi-row INSERT
+		// are not always consecutive, so the wagers are inserted one at a time
+		for i := range wagers {
+			if err := r.Create(&wagers[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for start := 0; start < len(wagers); start += MAX_INSERT_ROWS {
+		end := start + MAX_INSERT_ROWS
+		if end > len(wagers) {
+			end = len(wagers)
+		}
+		if err := r.insertMany(wagers[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertMany inserts wagers with a single statement reading their ids with RETURNING,
+// which is only built for batches since the number of rows varies
+func (r *wagerRepository) insertMany(wagers []model.Wager) error {
+	query := insertStatement(r.dialect, fmt.Sprintf("INSERT INTO %v (%v) VALUES %v", r.queries.table, wagerInsertColumns, valuesList(len(wagers), wagerInsertColumnCount)))
+	args := make([]interface{}, 0, len(wagers)*wagerInsertColumnCount)
+	for i := range wagers {
+		args = append(args, wagerInsertArgs(&wagers[i])...)
+	}
+
+	ids, err := insertManyReturningIDs(r.db, query, len(wagers), args...)
+	if err != nil {
+		return fmt.Errorf("failed to add wagers: %w", err)
+	}
+
+	for i := range wagers {
+		wagers[i].ID = uint(ids[i])
+	}
+	return nil
+}
+
+func (r *wagerRepository) List(filter model.WagerFilter, offset int, limit int) ([]model.Wager, error) {
+	query := strings.Builder{}
+	query.WriteString(r.queries.list)
+	args := []interface{}{}
+	if filter.Currency != "" {
+		query.WriteString(" AND currency=?")
+		args = append(args, filter.Currency)
+	}
+	if filter.EventID != 0 {
+		query.WriteString(" AND event_id=?")
+		args = append(args, filter.EventID)
+	}
+	if filter.MarketID != 0 {
+		query.WriteString(" AND market_id=?")
+		args = append(args, filter.MarketID)
+	}
+	query.WriteString(" ORDER BY id LIMIT ? OFFSET ?")
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(r.dialect.Rebind(query.String()), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wagers: %w", err)
+	}
+	defer rows.Close()
+
+	wagers := make([]model.Wager, 0)
+	for rows.Next() {
+		wager, err := scanWager(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wager: %w", err)
+		}
+		wagers = append(wagers, *wager)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate wagers: %w", err)
+	}
+
+	return wagers, nil
+}
+
+func (r *wagerRepository) GetByID(id uint) (*model.Wager, error) {
+	return r.getOne(r.queries.getByID, id)
+}
+
+func (r *wagerRepository) GetByIDForUpdate(id uint) (*model.Wager, error) {
+	return r.getOne(r.queries.getByIDForUpdate, id)
+}
+
+func (r *wagerRepository) getOne(query string, args ...interface{}) (*model.Wager, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wager: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get wager: %w", err)
+		}
+		return nil, ErrNotFound
+	}
+
+	wager, err := scanWager(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan wager: %w", err)
+	}
+
+	return wager, nil
+}
+
+func (r *wagerRepository) UpdateSale(wager *model.Wager) error {
+	if _, err := r.db.Exec(r.queries.updateSale, wager.CurrentSellingPrice, wager.PercentageSold, wager.AmountSold, wager.ReservedAmount, wager.ID); err != nil {
+		return fmt.Errorf("failed to update wager: %w", err)
+	}
+	wager.Version++
+	return nil
+}
+
+func (r *wagerRepository) UpdateAskPrice(wager *model.Wager) error {
+	if _, err := r.db.Exec(r.queries.updateAskPrice, wager.AskPrice, wager.ID); err != nil {
+		return fmt.Errorf("failed to update wager: %w", err)
+	}
+	wager.Version++
+	return nil
+}
+
+func (r *wagerRepository) UpdateStatus(wager *model.Wager) error {
+	if _, err := r.db.Exec(r.queries.updateStatus, wager.Status, wager.ID); err != nil {
+		return fmt.Errorf("failed to update wager: %w", err)
+	}
+	wager.Version++
+	return nil
+}
+
+func wagerInsertArgs(wager *model.Wager) []interface{} {
+	return []interface{}{wager.TotalWagerValue, wager.Odds, wager.SellingPercentage, wager.SellingPrice, wager.CurrentSellingPrice, wager.PlaceAt, wager.Status, wager.MinPurchase, wager.MaxPurchase, wager.PurchaseIncrement,
+		wager.PriceSchedule, wager.FloorPrice, wager.DecaySeconds, wager.DecaySteps, wager.Seller, wager.Currency,
+		wager.EventID, wager.MarketID, wager.SelectionID}
+}
+
+// scanWager reads a row selected with wagerColumns
+func scanWager(rows database.DBRows) (*model.Wager, error) {
+	wager := model.Wager{}
+	err := rows.Scan(&wager.ID,
+		&wager.TotalWagerValue,
+		&wager.Odds,
+		&wager.SellingPercentage,
+		&wager.SellingPrice,
+		&wager.CurrentSellingPrice,
+		&wager.PercentageSold,
+		&wager.AmountSold,
+		&wager.PlaceAt,
+		&wager.Status,
+		&wager.ReservedAmount,
+		&wager.MinPurchase,
+		&wager.MaxPurchase,
+		&wager.PurchaseIncrement,
+		&wager.PriceSchedule,
+		&wager.FloorPrice,
+		&wager.DecaySeconds,
+		&wager.DecaySteps,
+		&wager.AskPrice,
+		&wager.Seller,
+		&wager.Currency,
+		&wager.EventID,
+		&wager.MarketID,
+		&wager.SelectionID,
+		&wager.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wager, nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+	"wager/conf"
+	"wager/database"
+	"wager/model"
+	"wager/odds"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func NewDBMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock
+}
+
+func newMockStore() (Store, sqlmock.Sqlmock) {
+	db, mock := NewDBMock()
+	config := conf.GetDefaultConfig().SQL
+	dialect, _ := database.GetDialect(config.Dialect)
+	store, _ := NewSQLStore(config, dialect, database.NewDB(db))
+	return store, mock
+}
+
+var wagerRowColumns = []string{"id", "total_wager_value", "odds", "selling_percentage", "selling_price", "current_selling_price", "percentage_sold", "amount_sold", "place_at", "status", "reserved_amount", "min_purchase", "max_purchase", "purchase_increment", "price_schedule", "floor_price", "decay_seconds", "decay_steps", "ask_price", "seller", "currency", "event_id", "market_id", "selection_id", "version"}
+
+func Test_WagerRepository_List(t *testing.T) {
+	store, mock := newMockStore()
+
+	rows := sqlmock.NewRows(wagerRowColumns).
+		AddRow(1, 100, 2, 10, 20, 20, nil, nil, 1642484487, "open", 0, 0, 0, 0, "", 0, 0, 0, 0, "", "USD", 0, 0, 0, 0).
+		AddRow(2, 100, 2, 10, 20, 15, 25, 5, 1642484488, "open", 0, 0, 0, 0, "", 0, 0, 0, 0, "", "USD", 1, 2, 3, 4)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT "+wagerColumns+" FROM `wagers` WHERE 1=1 AND currency=? ORDER BY id LIMIT ? OFFSET ?")).
+		WithArgs("USD", 2, 0).
+		WillReturnRows(rows)
+
+	wagers, err := store.Wagers().List(model.WagerFilter{Currency: "USD"}, 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(wagers))
+	assert.False(t, wagers[0].PercentageSold.Valid)
+	assert.Equal(t, uint(25), wagers[1].PercentageSold.Uint)
+	assert.Equal(t, float64(5), wagers[1].AmountSold.Float64)
+	assert.Equal(t, uint(3), wagers[1].SelectionID)
+	assert.Equal(t, uint64(4), wagers[1].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_WagerRepository_List_Errors(t *testing.T) {
+	t.Run("Scan error", func(t *testing.T) {
+		store, mock := newMockStore()
+		rows := sqlmock.NewRows(wagerRowColumns).AddRow("abc", 100, 2, 10, 20, 20, nil, nil, 1642484487, "open", 0, 0, 0, 0, "", 0, 0, 0, 0, "", "USD", 0, 0, 0, 0)
+		mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+		wagers, err := store.Wagers().List(model.WagerFilter{}, 0, 10)
+		assert.Nil(t, wagers)
+		assert.Error(t, err)
+	})
+
+	t.Run("Row iteration error", func(t *testing.T) {
+		store, mock := newMockStore()
+		rows := sqlmock.NewRows(wagerRowColumns).
+			AddRow(1, 100, 2, 10, 20, 20, nil, nil, 1642484487, "open", 0, 0, 0, 0, "", 0, 0, 0, 0, "", "USD", 0, 0, 0, 0).
+			RowError(0, errors.New("connection reset"))
+		mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+		wagers, err := store.Wagers().List(model.WagerFilter{}, 0, 10)
+		assert.Nil(t, wagers)
+		assert.Contains(t, err.Error(), "connection reset")
+	})
+}
+
+func Test_WagerRepository_GetByID_NotFound(t *testing.T) {
+	store, mock := newMockStore()
+	mock.ExpectPrepare(regexp.QuoteMeta("SELECT " + wagerColumns + " FROM `wagers` WHERE id=?")).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(wagerRowColumns))
+
+	wager, err := store.Wagers().GetByID(1)
+	assert.Nil(t, wager)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func Test_SQLStore_RunInTx(t *testing.T) {
+	t.Run("Commit", func(t *testing.T) {
+		store, mock := newMockStore()
+		insertQuery := regexp.QuoteMeta("INSERT INTO `purchase` (wager_id, buyer, buying_price, face_value, bought_at, maker_fee, maker_rate, taker_fee, taker_rate) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		mock.ExpectBegin()
+		// cached statements are prepared on the database, then again on the connection of the transaction
+		mock.ExpectPrepare(insertQuery)
+		mock.ExpectPrepare(insertQuery).
+			ExpectExec().
+			WithArgs(1, "alice", float64(10), float64(10), 1642484487, 0.1, 1.0, 0.0, 0.0).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectCommit()
+
+		purchase := &model.Purchase{WagerID: 1, Buyer: "alice", BuyingPrice: 10, FaceValue: 10, BoughtAt: 1642484487, Fees: model.Fees{MakerFee: 0.1, MakerRate: 1}}
+		err := store.RunInTx(func(store Store) error {
+			return store.Purchases().Create(purchase)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), purchase.PurchaseID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		store, mock := newMockStore()
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := store.RunInTx(func(store Store) error {
+			return errors.New("custom error")
+		})
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_SQLStore_Session(t *testing.T) {
+	primaryDB, primary := NewDBMock()
+	replicaDB, replica := NewDBMock()
+	config := conf.GetDefaultConfig().SQL
+	dialect, _ := database.GetDialect(config.Dialect)
+	store, err := NewSQLStore(config, dialect, database.NewDBWithReplicas(primaryDB, []*sql.DB{replicaDB}, time.Minute))
+	assert.NoError(t, err)
+
+	getQuery := regexp.QuoteMeta("SELECT " + wagerColumns + " FROM `wagers` WHERE id=?")
+	updateQuery := regexp.QuoteMeta("UPDATE `wagers` SET ")
+	replica.ExpectPrepare(getQuery).ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows(wagerRowColumns))
+	primary.ExpectPrepare(updateQuery).ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	primary.ExpectPrepare(getQuery).ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows(wagerRowColumns))
+
+	// the read after the write of the session goes to the primary
+	session := store.Session()
+	_, err = session.Wagers().GetByID(1)
+	assert.Equal(t, ErrNotFound, err)
+	assert.NoError(t, session.Wagers().UpdateSale(&model.Wager{ID: 1, CurrentSellingPrice: 10}))
+	_, err = session.Wagers().GetByID(1)
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.NoError(t, primary.ExpectationsWereMet())
+	assert.NoError(t, replica.ExpectationsWereMet())
+}
+
+func Test_BuyerRepository_Lock(t *testing.T) {
+	store, mock := newMockStore()
+	lockQuery := regexp.QuoteMeta("SELECT name FROM `buyer` WHERE name=? FOR UPDATE")
+
+	// a known buyer is locked right away
+	mock.ExpectQuery(lockQuery).WithArgs("alice").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("alice"))
+	assert.NoError(t, store.Buyers().Lock("alice"))
+
+	// a new one is inserted first
+	mock.ExpectQuery(lockQuery).WithArgs("bob").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO `buyer` (name) VALUES (?)")).WithArgs("bob").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(lockQuery).WithArgs("bob").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("bob"))
+	assert.NoError(t, store.Buyers().Lock("bob"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_NewSQLStore_TableNames(t *testing.T) {
+	db, mock := NewDBMock()
+	config := conf.GetDefaultConfig().SQL
+	dialect, _ := database.GetDialect(conf.DIALECT_POSTGRES)
+
+	config.WagerTable = "wagers; DROP TABLE purchase"
+	_, err := NewSQLStore(config, dialect, database.NewDB(db))
+	assert.Error(t, err)
+
+	config.WagerTable = "wagers"
+	config.Schema = "trading"
+	store, err := NewSQLStore(config, dialect, database.NewDB(db))
+	assert.NoError(t, err)
+
+	mock.ExpectPrepare(regexp.QuoteMeta(`SELECT ` + wagerColumns + ` FROM "trading"."wagers" WHERE id=$1`)).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(wagerRowColumns))
+	_, err = store.Wagers().GetByID(1)
+	assert.Equal(t, ErrNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_WagerRepository_CreateMany_Returning(t *testing.T) {
+	db, mock := NewDBMock()
+	config := conf.GetDefaultConfig().SQL
+	config.Dialect = conf.DIALECT_POSTGRES
+	dialect, _ := database.GetDialect(config.Dialect)
+	store, err := NewSQLStore(config, dialect, database.NewDB(db))
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(dialect.Rebind(`INSERT INTO "wagers" (`+wagerInsertColumns+`) VALUES `+valuesList(2, wagerInsertColumnCount)+` RETURNING id`))).
+		WithArgs(100, 20000, 10, 20.0, 20.0, 1642484487, "open", 5.0, 0.0, 0.0, "", 0.0, 0, 0, "bookie", "USD", 0, 0, 0,
+			200, 30000, 10, 30.0, 30.0, 1642484487, "open", 0.0, 10.0, 0.5, model.PRICE_SCHEDULE_LINEAR, 15.0, 3600, 0, "", "EUR", 1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(5))
+
+	wagers := []model.Wager{
+		{TotalWagerValue: 100, Odds: 2 * odds.SCALE, SellingPercentage: 10, SellingPrice: 20, CurrentSellingPrice: 20, PlaceAt: 1642484487, Status: "open", MinPurchase: 5, Seller: "bookie", Currency: "USD"},
+		{TotalWagerValue: 200, Odds: 3 * odds.SCALE, SellingPercentage: 10, SellingPrice: 30, CurrentSellingPrice: 30, PlaceAt: 1642484487, Status: "open", MaxPurchase: 10, PurchaseIncrement: 0.5,
+			PriceSchedule: model.PRICE_SCHEDULE_LINEAR, FloorPrice: 15, DecaySeconds: 3600, Currency: "EUR",
+			EventID: 1, MarketID: 2, SelectionID: 3},
+	}
+	assert.NoError(t, store.Wagers().CreateMany(wagers))
+	assert.Equal(t, uint(4), wagers[0].ID)
+	assert.Equal(t, uint(5), wagers[1].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_WagerRepository_CreateMany_PerRow(t *testing.T) {
+	store, mock := newMockStore()
+	insert := regexp.QuoteMeta("INSERT INTO `wagers` (" + wagerInsertColumns + ") VALUES " + valuesList(1, wagerInsertColumnCount))
+
+	// the ids of the rows need not be consecutive
+	mock.ExpectExec(insert).WithArgs(100, 20000, 10, 20.0, 20.0, 1642484487, "open", 5.0, 0.0, 0.0, "", 0.0, 0, 0, "bookie", "USD", 0, 0, 0).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec(insert).WithArgs(200, 30000, 10, 30.0, 30.0, 1642484487, "open", 0.0, 0.0, 0.0, "", 0.0, 0, 0, "bookie", "EUR", 0, 0, 0).
+		WillReturnResult(sqlmock.NewResult(9, 1))
+
+	wagers := []model.Wager{
+		{TotalWagerValue: 100, Odds: 2 * odds.SCALE, SellingPercentage: 10, SellingPrice: 20, CurrentSellingPrice: 20, PlaceAt: 1642484487, Status: "open", MinPurchase: 5, Seller: "bookie", Currency: "USD"},
+		{TotalWagerValue: 200, Odds: 3 * odds.SCALE, SellingPercentage: 10, SellingPrice: 30, CurrentSellingPrice: 30, PlaceAt: 1642484487, Status: "open", Seller: "bookie", Currency: "EUR"},
+	}
+	assert.NoError(t, store.Wagers().CreateMany(wagers))
+	assert.Equal(t, uint(4), wagers[0].ID)
+	assert.Equal(t, uint(9), wagers[1].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
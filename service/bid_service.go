@@ -0,0 +1,491 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"wager/conf"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrBidNotOpen       = errorcode.NewClientError("bid is not open")
+	ErrBidExpired       = errorcode.NewClientError("bid has expired")
+	ErrBidNotBelowPrice = errorcode.NewClientError("bid price must be smaller than the current price of its face value, buy the wager instead")
+	ErrBidTooLarge      = errorcode.NewClientError("bid face value must be equal or smaller than current selling price")
+	ErrAskPriceNotLower = errorcode.NewClientError("ask price must be smaller than the current price of the wager")
+	ErrNotSeller        = errorcode.NewClientError("seller does not sell the wager")
+	ErrNotBidder        = errorcode.NewClientError("buyer did not place the bid")
+)
+
+// BidService keeps a book of limit bids per wager. Bids rest below the price of the
+// wager and are filled whole, the best unit price first, once the price comes down
+// to them or the seller accepts them. Every change of a book runs in the transaction
+// which locked its wager.
+type BidService interface {
+	PlaceBid(request model.PlaceBidRequest) (*model.Bid, error)
+	GetBook(wagerID uint) (*model.Book, error)
+	// AcceptBid fills a bid at its own price, it is refused with ErrNotSeller unless
+	// the seller of request sells the wager
+	AcceptBid(request model.AcceptBidRequest) (*model.Purchase, error)
+	// CancelBid is refused with ErrNotBidder unless the buyer of request placed the bid
+	CancelBid(request model.CancelBidRequest) (*model.Bid, error)
+	// LowerPrice sets the ask price of a wager and fills the bids it reaches, it is
+	// refused with ErrNotSeller unless the seller of request sells the wager
+	LowerPrice(request model.LowerPriceRequest) (*model.LowerPriceResponse, error)
+	// ExpireBids expires the open bids past their expiry and returns how many expired
+	ExpireBids() (int, error)
+	// MatchScheduledBids fills the bids reached by the decaying price of wagers with a
+	// price schedule and returns the purchases of the filled bids
+	MatchScheduledBids() ([]model.Purchase, error)
+}
+
+type bidService struct {
+	config *conf.Config
+	store  repository.Store
+	// publisher receives the wagers of filled bids and lowered prices, it may be nil
+	publisher WagerPublisher
+	now       func() time.Time
+}
+
+func NewBidService(config *conf.Config, store repository.Store, publisher WagerPublisher) BidService {
+	return &bidService{
+		config:    config,
+		store:     store,
+		publisher: publisher,
+		now:       time.Now,
+	}
+}
+
+func (bs *bidService) PlaceBid(request model.PlaceBidRequest) (*model.Bid, error) {
+	ttl := bs.config.Bid.TTL
+	if request.TTLSeconds > 0 {
+		ttl = time.Duration(request.TTLSeconds) * time.Second
+	}
+	if ttl > bs.config.Bid.MaxTTL {
+		return nil, fmt.Errorf("%w, it must be at most %v seconds", ErrTTLTooLong, int(bs.config.Bid.MaxTTL.Seconds()))
+	}
+
+	now := bs.now().UTC()
+	bid := &model.Bid{
+		WagerID:   request.WagerID,
+		Buyer:     request.Buyer,
+		FaceValue: request.FaceValue,
+		BidPrice:  request.BidPrice,
+		Status:    model.BID_STATUS_OPEN,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+
+	var events []model.WagerEvent
+	err := bs.store.RunInTx(func(store repository.Store) error {
+		events = nil
+		wager, err := store.Wagers().GetByIDForUpdate(request.WagerID)
+		if err != nil {
+			return err
+		}
+		if wager.Status != model.WAGER_STATUS_OPEN {
+			return ErrWagerNotOpen
+		}
+		if err := checkCurrency(wager, request.Currency, request.FaceValue, request.BidPrice); err != nil {
+			return err
+		}
+
+		// the price may have decayed onto resting bids since the last sweep, they go
+		// before the new bid
+		filled, err := bs.matchBids(store, wager, bid.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if len(filled) > 0 {
+			events = append(events, wagerEvent(model.WAGER_EVENT_PURCHASE, *wager, bid.CreatedAt))
+		}
+
+		if toMinor(wager, bid.FaceValue) > toMinor(wager, wager.CurrentSellingPrice) {
+			return ErrBidTooLarge
+		}
+		if toMinor(wager, bid.BidPrice) >= toMinor(wager, faceValuePriceAt(wager, bid.FaceValue, bid.CreatedAt)) {
+			return ErrBidNotBelowPrice
+		}
+		// a bid for all that is left is allowed below the minimum, like buying the remainder
+		currentPrice := currentSellingPriceAt(wager, bid.CreatedAt)
+		if isRemainder(wager, wager.CurrentSellingPrice, bid.FaceValue) {
+			currentPrice = bid.BidPrice
+		}
+		if err := checkPurchaseSize(wager, currentPrice, bid.BidPrice); err != nil {
+			return err
+		}
+
+		return store.Bids().Create(bid)
+	})
+	if err != nil {
+		// bids filled before a refused bid are rolled back with it
+		logrus.WithError(err).Error("cannot place bid")
+		return nil, err
+	}
+
+	publish(bs.publisher, events...)
+	return bid, nil
+}
+
+func (bs *bidService) GetBook(wagerID uint) (*model.Book, error) {
+	now := bs.now().UTC().Unix()
+	wager, err := bs.store.Wagers().GetByID(wagerID)
+	if err != nil {
+		return nil, err
+	}
+	bids, err := bs.store.Bids().ListOpen(wagerID)
+	if err != nil {
+		return nil, err
+	}
+
+	book := &model.Book{
+		WagerID:             wager.ID,
+		CurrentSellingPrice: currentSellingPriceAt(wager, now),
+		Bids:                make([]model.BookEntry, 0, len(bids)),
+	}
+	for _, bid := range bids {
+		if bid.ExpiresAt <= now {
+			continue
+		}
+		book.Bids = append(book.Bids, model.BookEntry{
+			BidID:     bid.ID,
+			FaceValue: bid.FaceValue,
+			BidPrice:  bid.BidPrice,
+			CreatedAt: bid.CreatedAt,
+			ExpiresAt: bid.ExpiresAt,
+		})
+	}
+	return book, nil
+}
+
+func (bs *bidService) AcceptBid(request model.AcceptBidRequest) (*model.Purchase, error) {
+	var purchase *model.Purchase
+	var event model.WagerEvent
+	err := bs.store.RunInTx(func(store repository.Store) error {
+		now := bs.now().UTC().Unix()
+		wager, bid, err := lockBid(store, request.BidID)
+		if err != nil {
+			return err
+		}
+		if wager.Seller != request.Seller {
+			return ErrNotSeller
+		}
+		if bid.ExpiresAt <= now {
+			return ErrBidExpired
+		}
+		if wager.Status != model.WAGER_STATUS_OPEN {
+			return ErrWagerNotOpen
+		}
+		if toMinor(wager, bid.FaceValue) > toMinor(wager, wager.CurrentSellingPrice) {
+			return ErrBidTooLarge
+		}
+		if err := checkBuyerLimits(bs.config.BuyerLimit, store, wager, bid.Buyer, bid.FaceValue); err != nil {
+			return err
+		}
+
+		pur, err := fillBid(store, bs.config.Fee, wager, bid, now)
+		if err != nil {
+			return err
+		}
+		purchase = pur
+		if err := store.Wagers().UpdateSale(wager); err != nil {
+			return err
+		}
+		event = wagerEvent(model.WAGER_EVENT_PURCHASE, *wager, now)
+		return nil
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("bid_id", request.BidID).Error("cannot accept bid")
+		return nil, err
+	}
+
+	publish(bs.publisher, event)
+	return purchase, nil
+}
+
+func (bs *bidService) CancelBid(request model.CancelBidRequest) (*model.Bid, error) {
+	var bid *model.Bid
+	err := bs.store.RunInTx(func(store repository.Store) error {
+		_, b, err := lockBid(store, request.BidID)
+		if err != nil {
+			return err
+		}
+		if b.Buyer != request.Buyer {
+			return ErrNotBidder
+		}
+
+		b.Status = model.BID_STATUS_CANCELLED
+		bid = b
+		return store.Bids().Update(bid)
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("bid_id", request.BidID).Error("cannot cancel bid")
+		return nil, err
+	}
+
+	return bid, nil
+}
+
+func (bs *bidService) LowerPrice(request model.LowerPriceRequest) (*model.LowerPriceResponse, error) {
+	response := &model.LowerPriceResponse{}
+	var event model.WagerEvent
+	err := bs.store.RunInTx(func(store repository.Store) error {
+		now := bs.now().UTC().Unix()
+		wager, err := store.Wagers().GetByIDForUpdate(request.WagerID)
+		if err != nil {
+			return err
+		}
+		if wager.Seller != request.Seller {
+			return ErrNotSeller
+		}
+		if wager.Status != model.WAGER_STATUS_OPEN {
+			return ErrWagerNotOpen
+		}
+		if err := checkCurrency(wager, "", request.AskPrice); err != nil {
+			return err
+		}
+		if toMinor(wager, request.AskPrice) >= toMinor(wager, sellingPriceAt(wager, now)) {
+			return ErrAskPriceNotLower
+		}
+
+		wager.AskPrice = request.AskPrice
+		if err := store.Wagers().UpdateAskPrice(wager); err != nil {
+			return err
+		}
+
+		purchases, err := bs.matchBids(store, wager, now)
+		if err != nil {
+			return err
+		}
+		event = wagerEvent(model.WAGER_EVENT_PRICE, *wager, now)
+		if len(purchases) > 0 {
+			event = wagerEvent(model.WAGER_EVENT_PURCHASE, *wager, now)
+		}
+
+		response.Wager = *wager
+		response.Wager.CurrentSellingPrice = currentSellingPriceAt(wager, now)
+		response.Purchases = purchases
+		return nil
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("wager_id", request.WagerID).Error("cannot lower price")
+		return nil, err
+	}
+
+	publish(bs.publisher, event)
+	return response, nil
+}
+
+func (bs *bidService) ExpireBids() (int, error) {
+	expired := 0
+	for {
+		now := bs.now().UTC().Unix()
+		bids, err := bs.store.UsePrimary().Bids().ListExpired(now, bs.config.Bid.SweepBatchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, bid := range bids {
+			err := bs.store.RunInTx(func(store repository.Store) error {
+				_, b, err := lockBid(store, bid.ID)
+				if err != nil {
+					return err
+				}
+				b.Status = model.BID_STATUS_EXPIRED
+				return store.Bids().Update(b)
+			})
+			// the bid may have been filled or cancelled since it was listed
+			if errors.Is(err, ErrBidNotOpen) {
+				continue
+			}
+			if err != nil {
+				return expired, err
+			}
+			expired++
+		}
+
+		if len(bids) < bs.config.Bid.SweepBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+func (bs *bidService) MatchScheduledBids() ([]model.Purchase, error) {
+	purchases := make([]model.Purchase, 0)
+	afterID := uint(0)
+	for {
+		ids, err := bs.store.UsePrimary().Bids().ListWagerIDs(afterID, bs.config.Bid.SweepBatchSize)
+		if err != nil {
+			return purchases, err
+		}
+
+		for _, id := range ids {
+			afterID = id
+			wager, err := bs.store.UsePrimary().Wagers().GetByID(id)
+			if err != nil {
+				return purchases, err
+			}
+			// the price of other wagers only changes with LowerPrice, which matches itself
+			if wager.PriceSchedule == "" || wager.Status != model.WAGER_STATUS_OPEN {
+				continue
+			}
+
+			var filled []model.Purchase
+			var event model.WagerEvent
+			err = bs.store.RunInTx(func(store repository.Store) error {
+				wager, err := store.Wagers().GetByIDForUpdate(id)
+				if err != nil {
+					return err
+				}
+				at := bs.now().UTC().Unix()
+				filled, err = bs.matchBids(store, wager, at)
+				if err != nil {
+					return err
+				}
+				event = wagerEvent(model.WAGER_EVENT_PURCHASE, *wager, at)
+				return nil
+			})
+			if err != nil {
+				return purchases, err
+			}
+			if len(filled) > 0 {
+				purchases = append(purchases, filled...)
+				publish(bs.publisher, event)
+			}
+		}
+
+		if len(ids) < bs.config.Bid.SweepBatchSize {
+			return purchases, nil
+		}
+	}
+}
+
+// matchBids fills the open bids of the locked wager which pay at least the price of
+// their face value at at, the best unit price first. Bids larger than what is left
+// or over the limits of their buyer are skipped and keep resting.
+func (bs *bidService) matchBids(store repository.Store, wager *model.Wager, at int64) ([]model.Purchase, error) {
+	purchases := make([]model.Purchase, 0)
+	if wager.Status != model.WAGER_STATUS_OPEN {
+		return purchases, nil
+	}
+
+	bids, err := store.Bids().ListOpen(wager.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range bids {
+		bid := &bids[i]
+		if bid.ExpiresAt <= at || toMinor(wager, bid.FaceValue) > toMinor(wager, wager.CurrentSellingPrice) {
+			continue
+		}
+		if toMinor(wager, bid.BidPrice) < toMinor(wager, faceValuePriceAt(wager, bid.FaceValue, at)) {
+			continue
+		}
+		err := checkBuyerLimits(bs.config.BuyerLimit, store, wager, bid.Buyer, bid.FaceValue)
+		if errors.Is(err, ErrBuyerLimitExceeded) || errors.Is(err, ErrBuyerRequired) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		purchase, err := fillBid(store, bs.config.Fee, wager, bid, at)
+		if err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, *purchase)
+	}
+
+	if len(purchases) == 0 {
+		return purchases, nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"wager_id": wager.ID,
+		"filled":   len(purchases),
+	}).Info("Matched bids")
+	if err := store.Wagers().UpdateSale(wager); err != nil {
+		return nil, err
+	}
+	return purchases, nil
+}
+
+// fillBid buys the face value of bid at its price. The sale is applied to wager in
+// memory, the caller persists it.
+func fillBid(store repository.Store, fees conf.FeeConfig, wager *model.Wager, bid *model.Bid, at int64) (*model.Purchase, error) {
+	wager.CurrentSellingPrice -= bid.FaceValue
+	addAmountSold(wager, bid.BidPrice)
+
+	purchase := &model.Purchase{
+		WagerID:     bid.WagerID,
+		Buyer:       bid.Buyer,
+		BuyingPrice: bid.BidPrice,
+		FaceValue:   bid.FaceValue,
+		BoughtAt:    at,
+	}
+	if err := createPurchase(store, fees, wager, purchase); err != nil {
+		return nil, err
+	}
+
+	bid.Status = model.BID_STATUS_FILLED
+	bid.PurchaseID = purchase.PurchaseID
+	if err := store.Bids().Update(bid); err != nil {
+		return nil, err
+	}
+	return purchase, nil
+}
+
+// lockBid locks the wager of an open bid, then the bid, in the same order as
+// BuyWager locks the wager before writing the purchase
+func lockBid(store repository.Store, id uint) (*model.Wager, *model.Bid, error) {
+	bid, err := store.Bids().GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	wager, err := store.Wagers().GetByIDForUpdate(bid.WagerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	bid, err = store.Bids().GetByIDForUpdate(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if bid.Status != model.BID_STATUS_OPEN {
+		return nil, nil, ErrBidNotOpen
+	}
+	return wager, bid, nil
+}
+
+// SweepBids expires bids and matches the bids of decaying wagers every interval
+// until ctx is done
+func SweepBids(ctx context.Context, bs BidService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := bs.ExpireBids()
+			if err != nil {
+				logrus.WithError(err).Error("failed to expire bids")
+			}
+			if expired > 0 {
+				logrus.WithField("expired", expired).Info("Expired bids")
+			}
+
+			if _, err := bs.MatchScheduledBids(); err != nil {
+				logrus.WithError(err).Error("failed to match bids")
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"wager/conf"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bidTest struct {
+	wagers *wagerService
+	bids   *bidService
+	now    time.Time
+}
+
+func newBidTest(t *testing.T, config *conf.Config, request model.CreateWagerRequest) (*bidTest, *model.Wager) {
+	config.Bid.SweepBatchSize = 1
+	store := repository.NewMemoryStore()
+	bt := &bidTest{
+		wagers: NewWagerService(config, store, nil).(*wagerService),
+		bids:   NewBidService(config, store, nil).(*bidService),
+		now:    time.Now(),
+	}
+	bt.wagers.now = func() time.Time { return bt.now }
+	bt.bids.now = func() time.Time { return bt.now }
+
+	wager, err := bt.wagers.CreateWager(request)
+	require.NoError(t, err)
+	return bt, wager
+}
+
+func (bt *bidTest) placeBids(t *testing.T, requests ...model.PlaceBidRequest) []*model.Bid {
+	bids := make([]*model.Bid, 0, len(requests))
+	for _, request := range requests {
+		bid, err := bt.bids.PlaceBid(request)
+		require.NoError(t, err)
+		bids = append(bids, bid)
+	}
+	return bids
+}
+
+var bidTestWager = model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, MinPurchase: 1, Seller: "sam"}
+
+func Test_PlaceBid(t *testing.T) {
+	bt, wager := newBidTest(t, conf.GetDefaultConfig(), bidTestWager)
+
+	tests := []struct {
+		name    string
+		request model.PlaceBidRequest
+		err     error
+	}{
+		{name: "At the price", request: model.PlaceBidRequest{WagerID: wager.ID, FaceValue: 20, BidPrice: 20}, err: ErrBidNotBelowPrice},
+		{name: "Larger than the wager", request: model.PlaceBidRequest{WagerID: wager.ID, FaceValue: 200, BidPrice: 10}, err: ErrBidTooLarge},
+		{name: "Below the minimum", request: model.PlaceBidRequest{WagerID: wager.ID, FaceValue: 20, BidPrice: 0.5}, err: ErrInvalidPurchaseSize},
+		{name: "TTL too long", request: model.PlaceBidRequest{WagerID: wager.ID, FaceValue: 20, BidPrice: 10, TTLSeconds: 30 * 24 * 3600}, err: ErrTTLTooLong},
+		{name: "Unknown wager", request: model.PlaceBidRequest{WagerID: 100, FaceValue: 20, BidPrice: 10}, err: repository.ErrNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := bt.bids.PlaceBid(tc.request)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	bids := bt.placeBids(t,
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "alice", FaceValue: 20, BidPrice: 15},
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "bob", FaceValue: 10, BidPrice: 9, TTLSeconds: 60},
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "carol", FaceValue: 10, BidPrice: 7},
+	)
+	assert.Equal(t, model.BID_STATUS_OPEN, bids[0].Status)
+	assert.Equal(t, bt.now.Add(24*time.Hour).Unix(), bids[0].ExpiresAt)
+	assert.Equal(t, bt.now.Add(time.Minute).Unix(), bids[1].ExpiresAt)
+
+	book, err := bt.bids.GetBook(wager.ID)
+	require.NoError(t, err)
+	assert.Equal(t, float64(100), book.CurrentSellingPrice)
+	require.Equal(t, 3, len(book.Bids))
+	assert.Equal(t, []uint{bids[1].ID, bids[0].ID, bids[2].ID}, []uint{book.Bids[0].BidID, book.Bids[1].BidID, book.Bids[2].BidID})
+
+	// expired bids leave the book before they are swept
+	bt.now = bt.now.Add(2 * time.Minute)
+	book, err = bt.bids.GetBook(wager.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(book.Bids))
+}
+
+func Test_LowerPrice(t *testing.T) {
+	bt, wager := newBidTest(t, conf.GetDefaultConfig(), bidTestWager)
+	bids := bt.placeBids(t,
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "alice", FaceValue: 20, BidPrice: 15},
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "bob", FaceValue: 10, BidPrice: 9},
+	)
+
+	_, err := bt.bids.LowerPrice(model.LowerPriceRequest{WagerID: wager.ID, Seller: "bob", AskPrice: 80})
+	assert.ErrorIs(t, err, ErrNotSeller)
+
+	response, err := bt.bids.LowerPrice(model.LowerPriceRequest{WagerID: wager.ID, Seller: "sam", AskPrice: 80})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(response.Purchases))
+	assert.Equal(t, "bob", response.Purchases[0].Buyer)
+	assert.Equal(t, float64(9), response.Purchases[0].BuyingPrice)
+	assert.Equal(t, float64(10), response.Purchases[0].FaceValue)
+	assert.Equal(t, float64(72), response.Wager.CurrentSellingPrice)
+
+	filled, err := bt.bids.store.Bids().GetByID(bids[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.BID_STATUS_FILLED, filled.Status)
+	assert.Equal(t, response.Purchases[0].PurchaseID, filled.PurchaseID)
+
+	_, err = bt.bids.LowerPrice(model.LowerPriceRequest{WagerID: wager.ID, Seller: "sam", AskPrice: 80})
+	assert.ErrorIs(t, err, ErrAskPriceNotLower)
+
+	response, err = bt.bids.LowerPrice(model.LowerPriceRequest{WagerID: wager.ID, Seller: "sam", AskPrice: 75})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(response.Purchases))
+	assert.Equal(t, "alice", response.Purchases[0].Buyer)
+
+	// buyers pay the lowered price for what is left
+	got, err := bt.wagers.GetWager(wager.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 52.5, got.CurrentSellingPrice)
+	assert.Equal(t, uint(30), got.PercentageSold.Uint)
+	assert.Equal(t, float64(24), got.AmountSold.Float64)
+
+	purchase, err := bt.wagers.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 52.5})
+	require.NoError(t, err)
+	assert.Equal(t, float64(70), purchase.FaceValue)
+}
+
+func Test_LowerPrice_BuyerLimits(t *testing.T) {
+	config := conf.GetDefaultConfig()
+	config.BuyerLimit.MaxPurchasesPerWager = 1
+	bt, wager := newBidTest(t, config, bidTestWager)
+
+	_, err := bt.wagers.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 10})
+	require.NoError(t, err)
+	bids := bt.placeBids(t,
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "alice", FaceValue: 10, BidPrice: 9},
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "bob", FaceValue: 10, BidPrice: 8},
+	)
+
+	// the bid over its buyer's limits keeps resting, the next one is filled
+	response, err := bt.bids.LowerPrice(model.LowerPriceRequest{WagerID: wager.ID, Seller: "sam", AskPrice: 50})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(response.Purchases))
+	assert.Equal(t, "bob", response.Purchases[0].Buyer)
+
+	book, err := bt.bids.GetBook(wager.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(book.Bids))
+	assert.Equal(t, bids[0].ID, book.Bids[0].BidID)
+
+	_, err = bt.bids.AcceptBid(model.AcceptBidRequest{BidID: bids[0].ID, Seller: "sam"})
+	assert.ErrorIs(t, err, ErrBuyerLimitExceeded)
+}
+
+func Test_AcceptBid(t *testing.T) {
+	bt, wager := newBidTest(t, conf.GetDefaultConfig(), bidTestWager)
+	bids := bt.placeBids(t,
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "carol", FaceValue: 10, BidPrice: 7},
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "dave", FaceValue: 95, BidPrice: 50},
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "erin", FaceValue: 10, BidPrice: 5, TTLSeconds: 60},
+	)
+
+	// only the seller of the wager accepts its bids
+	_, err := bt.bids.AcceptBid(model.AcceptBidRequest{BidID: bids[0].ID, Seller: "carol"})
+	assert.ErrorIs(t, err, ErrNotSeller)
+
+	purchase, err := bt.bids.AcceptBid(model.AcceptBidRequest{BidID: bids[0].ID, Seller: "sam"})
+	require.NoError(t, err)
+	assert.Equal(t, "carol", purchase.Buyer)
+	assert.Equal(t, float64(7), purchase.BuyingPrice)
+	assert.Equal(t, float64(10), purchase.FaceValue)
+
+	got, err := bt.wagers.GetWager(wager.ID)
+	require.NoError(t, err)
+	assert.Equal(t, float64(90), got.CurrentSellingPrice)
+	assert.Equal(t, uint(10), got.PercentageSold.Uint)
+
+	_, err = bt.bids.AcceptBid(model.AcceptBidRequest{BidID: bids[0].ID, Seller: "sam"})
+	assert.ErrorIs(t, err, ErrBidNotOpen)
+	_, err = bt.bids.AcceptBid(model.AcceptBidRequest{BidID: bids[1].ID, Seller: "sam"})
+	assert.ErrorIs(t, err, ErrBidTooLarge)
+	_, err = bt.bids.AcceptBid(model.AcceptBidRequest{BidID: 100, Seller: "sam"})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	bt.now = bt.now.Add(2 * time.Minute)
+	_, err = bt.bids.AcceptBid(model.AcceptBidRequest{BidID: bids[2].ID, Seller: "sam"})
+	assert.ErrorIs(t, err, ErrBidExpired)
+}
+
+func Test_CancelAndExpireBids(t *testing.T) {
+	bt, wager := newBidTest(t, conf.GetDefaultConfig(), bidTestWager)
+	bids := bt.placeBids(t,
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "alice", FaceValue: 10, BidPrice: 7},
+		model.PlaceBidRequest{WagerID: wager.ID, FaceValue: 10, BidPrice: 6, TTLSeconds: 60},
+		model.PlaceBidRequest{WagerID: wager.ID, FaceValue: 10, BidPrice: 5, TTLSeconds: 60},
+	)
+
+	// only the buyer who placed a bid cancels it
+	_, err := bt.bids.CancelBid(model.CancelBidRequest{BidID: bids[0].ID, Buyer: "bob"})
+	assert.ErrorIs(t, err, ErrNotBidder)
+
+	cancelled, err := bt.bids.CancelBid(model.CancelBidRequest{BidID: bids[0].ID, Buyer: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, model.BID_STATUS_CANCELLED, cancelled.Status)
+	_, err = bt.bids.CancelBid(model.CancelBidRequest{BidID: bids[0].ID, Buyer: "alice"})
+	assert.ErrorIs(t, err, ErrBidNotOpen)
+
+	expired, err := bt.bids.ExpireBids()
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+
+	bt.now = bt.now.Add(time.Minute)
+	expired, err = bt.bids.ExpireBids()
+	require.NoError(t, err)
+	assert.Equal(t, 2, expired)
+
+	got, err := bt.bids.store.Bids().GetByID(bids[2].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.BID_STATUS_EXPIRED, got.Status)
+	_, err = bt.bids.CancelBid(model.CancelBidRequest{BidID: bids[2].ID})
+	assert.ErrorIs(t, err, ErrBidNotOpen)
+}
+
+func Test_MatchScheduledBids(t *testing.T) {
+	request := bidTestWager
+	request.PriceSchedule = model.PRICE_SCHEDULE_LINEAR
+	request.FloorPrice = 50
+	request.DecaySeconds = 1000
+	bt, wager := newBidTest(t, conf.GetDefaultConfig(), request)
+	placeAt := bt.now
+	bids := bt.placeBids(t,
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "alice", FaceValue: 10, BidPrice: 6},
+		model.PlaceBidRequest{WagerID: wager.ID, Buyer: "bob", FaceValue: 10, BidPrice: 5},
+	)
+
+	bt.now = placeAt.Add(500 * time.Second)
+	purchases, err := bt.bids.MatchScheduledBids()
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(purchases))
+
+	// 10 of face value costs 5.5 once the price is down to 55
+	bt.now = placeAt.Add(900 * time.Second)
+	purchases, err = bt.bids.MatchScheduledBids()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(purchases))
+	assert.Equal(t, "alice", purchases[0].Buyer)
+	assert.Equal(t, float64(6), purchases[0].BuyingPrice)
+
+	// a new bid goes after the resting bids the price reached
+	bt.now = placeAt.Add(1000 * time.Second)
+	_, err = bt.bids.PlaceBid(model.PlaceBidRequest{WagerID: wager.ID, Buyer: "carol", FaceValue: 10, BidPrice: 4.5})
+	require.NoError(t, err)
+	got, err := bt.bids.store.Bids().GetByID(bids[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.BID_STATUS_FILLED, got.Status)
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"wager/conf"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	LIMIT_WAGER_PERCENTAGE    = "wager_percentage"
+	LIMIT_OPEN_EXPOSURE       = "open_exposure"
+	LIMIT_PURCHASES_PER_WAGER = "purchases_per_wager"
+)
+
+var (
+	ErrBuyerLimitExceeded = errorcode.NewClientError("buyer limit exceeded")
+	ErrBuyerRequired      = errorcode.NewClientError("buyer is required")
+)
+
+// BuyerLimitError is returned for a purchase which would take its buyer over Limit,
+// it matches ErrBuyerLimitExceeded and is a client error with a code
+type BuyerLimitError struct {
+	Buyer   string
+	WagerID uint
+	Limit   string
+	// Value is what the buyer would hold after the purchase, Max is the limit
+	Value float64
+	Max   float64
+}
+
+func (e *BuyerLimitError) Error() string {
+	return fmt.Sprintf("%v: %v would be %v, at most %v is allowed", ErrBuyerLimitExceeded, e.Limit, e.Value, e.Max)
+}
+
+func (e *BuyerLimitError) Unwrap() error {
+	return ErrBuyerLimitExceeded
+}
+
+func (e *BuyerLimitError) Code() string {
+	return errorcode.BUYER_LIMIT_EXCEEDED
+}
+
+// checkBuyerLimits checks buyer taking faceValue of wager, by a purchase or a resale,
+// against the limits of the buyer. It must run in the transaction which locked wager,
+// it locks the buyer too, so the purchases of a buyer are counted one after the other
+// whichever wagers they buy. Wagers are locked before buyers, a transaction locking
+// several of either, like a batch or a bid match, can deadlock with another one and
+// is then retried.
+func checkBuyerLimits(limits conf.BuyerLimitConfig, store repository.Store, wager *model.Wager, buyer string, faceValue float64) error {
+	if limits.Enabled() && buyer != "" {
+		if err := store.Buyers().Lock(buyer); err != nil {
+			return err
+		}
+	}
+	return checkBuyerExposure(limits, store, wager, buyer, faceValue)
+}
+
+// checkBuyerExposure checks buyer taking faceValue of wager against the face value its
+// positions hold without locking anything
+func checkBuyerExposure(limits conf.BuyerLimitConfig, store repository.Store, wager *model.Wager, buyer string, faceValue float64) error {
+	if !limits.Enabled() {
+		return nil
+	}
+	if buyer == "" {
+		return fmt.Errorf("%w when buyer limits are set", ErrBuyerRequired)
+	}
+
+	exposure, err := store.Positions().BuyerExposure(buyer, wager.ID, wager.Currency)
+	if err != nil {
+		return err
+	}
+
+	var limitErr *BuyerLimitError
+	percentage := (exposure.WagerFaceValue + faceValue) / wager.SellingPrice * 100
+	switch {
+	case limits.MaxPurchasesPerWager > 0 && exposure.WagerPositions+1 > limits.MaxPurchasesPerWager:
+		limitErr = &BuyerLimitError{Limit: LIMIT_PURCHASES_PER_WAGER, Value: float64(exposure.WagerPositions + 1), Max: float64(limits.MaxPurchasesPerWager)}
+	case limits.MaxWagerPercentage > 0 && percentage > limits.MaxWagerPercentage+1e-9:
+		limitErr = &BuyerLimitError{Limit: LIMIT_WAGER_PERCENTAGE, Value: math.Round(percentage*100) / 100, Max: limits.MaxWagerPercentage}
+	case limits.MaxOpenExposure > 0 && toMinor(wager, exposure.OpenFaceValue+faceValue) > toMinor(wager, limits.MaxOpenExposure):
+		limitErr = &BuyerLimitError{Limit: LIMIT_OPEN_EXPOSURE, Value: exposure.OpenFaceValue + faceValue, Max: limits.MaxOpenExposure}
+	default:
+		return nil
+	}
+
+	limitErr.Buyer = buyer
+	limitErr.WagerID = wager.ID
+	logrus.WithFields(logrus.Fields{
+		"buyer":      buyer,
+		"wager_id":   wager.ID,
+		"face_value": faceValue,
+		"limit":      limitErr.Limit,
+		"value":      limitErr.Value,
+		"max":        limitErr.Max,
+	}).Warn("buyer limit exceeded")
+	return limitErr
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"testing"
+	"wager/conf"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newBuyerLimitService(t *testing.T, limits conf.BuyerLimitConfig) (WagerService, []uint) {
+	config := conf.GetDefaultConfig()
+	config.BuyerLimit = limits
+	wagerService := NewWagerService(config, repository.NewMemoryStore(), nil)
+
+	ids := []uint{}
+	for i := 0; i < 2; i++ {
+		wager, err := wagerService.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100})
+		assert.NoError(t, err)
+		ids = append(ids, wager.ID)
+	}
+	return wagerService, ids
+}
+
+func Test_BuyWager_BuyerLimits(t *testing.T) {
+	buy := func(wagerService WagerService, wagerID uint, buyer string, buyingPrice float64) error {
+		_, err := wagerService.BuyWager(model.BuyWagerRequest{WagerID: wagerID, Buyer: buyer, BuyingPrice: buyingPrice})
+		return err
+	}
+
+	t.Run("No limits", func(t *testing.T) {
+		wagerService, ids := newBuyerLimitService(t, conf.BuyerLimitConfig{})
+		assert.NoError(t, buy(wagerService, ids[0], "", 100))
+	})
+
+	t.Run("Buyer required", func(t *testing.T) {
+		wagerService, ids := newBuyerLimitService(t, conf.BuyerLimitConfig{MaxPurchasesPerWager: 1})
+		assert.ErrorIs(t, buy(wagerService, ids[0], "", 10), ErrBuyerRequired)
+	})
+
+	t.Run("Max wager percentage", func(t *testing.T) {
+		wagerService, ids := newBuyerLimitService(t, conf.BuyerLimitConfig{MaxWagerPercentage: 30})
+		assert.NoError(t, buy(wagerService, ids[0], "alice", 20))
+		err := buy(wagerService, ids[0], "alice", 10.01)
+		limitErr := &BuyerLimitError{}
+		assert.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, BuyerLimitError{Buyer: "alice", WagerID: ids[0], Limit: LIMIT_WAGER_PERCENTAGE, Value: 30.01, Max: 30}, *limitErr)
+		assert.EqualError(t, err, "buyer limit exceeded: wager_percentage would be 30.01, at most 30 is allowed")
+
+		assert.NoError(t, buy(wagerService, ids[0], "alice", 10))
+		assert.NoError(t, buy(wagerService, ids[0], "bob", 30))
+		assert.NoError(t, buy(wagerService, ids[1], "alice", 30))
+	})
+
+	t.Run("Max open exposure", func(t *testing.T) {
+		wagerService, ids := newBuyerLimitService(t, conf.BuyerLimitConfig{MaxOpenExposure: 50})
+		assert.NoError(t, buy(wagerService, ids[0], "alice", 30))
+		assert.ErrorIs(t, buy(wagerService, ids[1], "alice", 25), ErrBuyerLimitExceeded)
+		assert.NoError(t, buy(wagerService, ids[1], "alice", 20))
+	})
+
+	t.Run("Max open exposure across concurrent purchases", func(t *testing.T) {
+		wagerService, ids := newBuyerLimitService(t, conf.BuyerLimitConfig{MaxOpenExposure: 50})
+		errs := make(chan error, len(ids))
+		for _, id := range ids {
+			go func(id uint) {
+				errs <- buy(wagerService, id, "alice", 30)
+			}(id)
+		}
+
+		failed := 0
+		for range ids {
+			if err := <-errs; err != nil {
+				assert.ErrorIs(t, err, ErrBuyerLimitExceeded)
+				failed++
+			}
+		}
+		assert.Equal(t, 1, failed)
+	})
+
+	t.Run("Quote", func(t *testing.T) {
+		wagerService, ids := newBuyerLimitService(t, conf.BuyerLimitConfig{MaxOpenExposure: 50})
+		assert.NoError(t, buy(wagerService, ids[0], "alice", 30))
+
+		request := model.BuyWagerRequest{WagerID: ids[1], Buyer: "alice", BuyingPrice: 25}
+		_, err := wagerService.QuoteWager(request)
+		assert.ErrorIs(t, err, ErrBuyerLimitExceeded)
+		assert.ErrorIs(t, buy(wagerService, ids[1], "alice", 25), ErrBuyerLimitExceeded)
+
+		request.BuyingPrice = 20
+		_, err = wagerService.QuoteWager(request)
+		assert.NoError(t, err)
+		_, err = wagerService.QuoteWager(model.BuyWagerRequest{WagerID: ids[1], BuyingPrice: 20})
+		assert.ErrorIs(t, err, ErrBuyerRequired)
+	})
+
+	t.Run("Max purchases per wager", func(t *testing.T) {
+		wagerService, ids := newBuyerLimitService(t, conf.BuyerLimitConfig{MaxPurchasesPerWager: 2})
+		assert.NoError(t, buy(wagerService, ids[0], "alice", 1))
+		assert.NoError(t, buy(wagerService, ids[0], "alice", 1))
+		err := buy(wagerService, ids[0], "alice", 1)
+		assert.ErrorIs(t, err, ErrBuyerLimitExceeded)
+		assert.EqualError(t, err, "buyer limit exceeded: purchases_per_wager would be 3, at most 2 is allowed")
+		assert.NoError(t, buy(wagerService, ids[1], "alice", 1))
+
+		// a breach leaves the wager untouched
+		wager, err := wagerService.GetWager(ids[0])
+		assert.NoError(t, err)
+		assert.Equal(t, float64(98), wager.CurrentSellingPrice)
+	})
+
+	t.Run("Refunded purchases do not count", func(t *testing.T) {
+		config := conf.GetDefaultConfig()
+		config.BuyerLimit.MaxPurchasesPerWager = 1
+		store := repository.NewMemoryStore()
+		wagerService := NewWagerService(config, store, nil)
+		purchaseService := NewPurchaseService(config, store, nil)
+		wager, err := wagerService.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100})
+		assert.NoError(t, err)
+
+		purchase, err := wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 10})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, buy(wagerService, wager.ID, "alice", 10), ErrBuyerLimitExceeded)
+		_, err = purchaseService.RefundPurchase(purchase.PurchaseID)
+		assert.NoError(t, err)
+		assert.NoError(t, buy(wagerService, wager.ID, "alice", 10))
+	})
+
+	t.Run("Resale", func(t *testing.T) {
+		config := conf.GetDefaultConfig()
+		config.BuyerLimit.MaxOpenExposure = 50
+		store := repository.NewMemoryStore()
+		wagerService := NewWagerService(config, store, nil)
+		resale := NewResaleService(config, store)
+		ids := []uint{}
+		for i := 0; i < 2; i++ {
+			wager, err := wagerService.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100})
+			assert.NoError(t, err)
+			ids = append(ids, wager.ID)
+		}
+		assert.NoError(t, buy(wagerService, ids[0], "alice", 30))
+		assert.NoError(t, buy(wagerService, ids[1], "bob", 40))
+		positions, err := resale.GetPositionList(model.GetPositionListRequest{Filter: model.PositionFilter{Holder: "bob"}, Page: 1, Limit: 10})
+		assert.NoError(t, err)
+
+		large, err := resale.CreateListing(model.CreateListingRequest{PositionID: positions[0].ID, Seller: "bob", FaceValue: 25, Price: 20})
+		assert.NoError(t, err)
+		small, err := resale.CreateListing(model.CreateListingRequest{PositionID: positions[0].ID, Seller: "bob", FaceValue: 15, Price: 12})
+		assert.NoError(t, err)
+		_, err = resale.BuyListing(model.BuyListingRequest{ListingID: large.ID, Buyer: "alice"})
+		assert.ErrorIs(t, err, ErrBuyerLimitExceeded)
+		_, err = resale.BuyListing(model.BuyListingRequest{ListingID: small.ID, Buyer: "alice"})
+		assert.NoError(t, err)
+
+		// alice holds 45 of face value, bob only 25 after selling 15 of it
+		assert.ErrorIs(t, buy(wagerService, ids[0], "alice", 10), ErrBuyerLimitExceeded)
+		assert.NoError(t, buy(wagerService, ids[0], "bob", 25))
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		wagerService, ids := newBuyerLimitService(t, conf.BuyerLimitConfig{MaxPurchasesPerWager: 1})
+		_, err := wagerService.BuyWagers(model.BatchPurchaseRequest{Items: []model.BatchPurchaseItem{
+			{WagerID: ids[0], Buyer: "alice", BuyingPrice: 1},
+			{WagerID: ids[0], Buyer: "alice", BuyingPrice: 1},
+		}})
+		batchErr := &model.BatchError{}
+		assert.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, []model.BatchItemError{
+			{Index: 1, WagerID: ids[0], Error: "buyer limit exceeded: purchases_per_wager would be 2, at most 1 is allowed", Code: "buyer_limit_exceeded"},
+		}, batchErr.Items)
+	})
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"time"
+	"wager/cache"
+	"wager/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	LIST_GENERATION_KEY = "wagers:generation"
+)
+
+// CacheStats counts cache hits and misses by kind of read, published at /debug/vars
+var CacheStats = expvar.NewMap("wager_cache")
+
+// cachedWagerService serves single wagers and list pages from a cache. Every write
+// deletes the wager it changed and bumps the list generation, which is part of the
+// key of every list page, so all cached pages are dropped at once. Single wagers are
+// read through primary, a service reading from the primary database, so that the
+// wager a write just deleted is not filled back from a lagging replica. List pages
+// are read through next, from the replicas, and are not cached when the generation
+// changed during the read. A read racing with a write may still cache the old wager,
+// and a page may come from a replica behind a write made just before it, either then
+// lives until its TTL.
+// Wagers whose price is decaying change without writes, they are never cached.
+type cachedWagerService struct {
+	next    WagerService
+	primary WagerService
+	cache   cache.Cache
+	ttl     time.Duration
+}
+
+func NewCachedWagerService(next WagerService, primary WagerService, c cache.Cache, ttl time.Duration) WagerService {
+	return &cachedWagerService{
+		next:    next,
+		primary: primary,
+		cache:   c,
+		ttl:     ttl,
+	}
+}
+
+func (cs *cachedWagerService) CreateWager(request model.CreateWagerRequest) (*model.Wager, error) {
+	wager, err := cs.next.CreateWager(request)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.invalidateLists()
+	return wager, nil
+}
+
+func (cs *cachedWagerService) CreateWagers(request model.CreateWagersRequest) ([]model.Wager, error) {
+	wagers, err := cs.next.CreateWagers(request)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.invalidateLists()
+	return wagers, nil
+}
+
+func (cs *cachedWagerService) GetWagerList(request model.GetWagerListRequest) (*model.GetWagerListResponse, error) {
+	generation, err := cs.cache.Counter(LIST_GENERATION_KEY)
+	if err != nil {
+		logrus.WithError(err).Error("failed to read wager list generation")
+		return cs.next.GetWagerList(request)
+	}
+
+	filter := request.Filter
+	key := fmt.Sprintf("wagers:%v:%v:%v:%v:%v:%v", generation, filter.Currency, filter.EventID, filter.MarketID, request.Page, request.Limit)
+	result := &model.GetWagerListResponse{}
+	if cs.get("list", key, &result.Wagers) {
+		return result, nil
+	}
+
+	readAt := time.Now().UTC().Unix()
+	result, err = cs.next.GetWagerList(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// a page read while a write committed may miss it, it is left for the next read
+	if !cacheable(result.Wagers, readAt) || !cs.sameGeneration(generation) {
+		return result, nil
+	}
+	cs.set(key, result.Wagers)
+	return result, nil
+}
+
+// sameGeneration reports whether the list generation is still generation
+func (cs *cachedWagerService) sameGeneration(generation int64) bool {
+	current, err := cs.cache.Counter(LIST_GENERATION_KEY)
+	if err != nil {
+		logrus.WithError(err).Error("failed to read wager list generation")
+		return false
+	}
+	return current == generation
+}
+
+func (cs *cachedWagerService) GetWager(id uint) (*model.Wager, error) {
+	key := wagerKey(id)
+	wager := &model.Wager{}
+	if cs.get("wager", key, wager) {
+		return wager, nil
+	}
+
+	readAt := time.Now().UTC().Unix()
+	wager, err := cs.primary.GetWager(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable([]model.Wager{*wager}, readAt) {
+		cs.set(key, wager)
+	}
+	return wager, nil
+}
+
+func (cs *cachedWagerService) BuyWager(request model.BuyWagerRequest) (*model.Purchase, error) {
+	purchase, err := cs.next.BuyWager(request)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.invalidateWager(request.WagerID)
+	return purchase, nil
+}
+
+func (cs *cachedWagerService) BuyWagers(request model.BatchPurchaseRequest) ([]model.Purchase, error) {
+	purchases, err := cs.next.BuyWagers(request)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range request.Items {
+		cs.invalidateWager(item.WagerID)
+	}
+	return purchases, nil
+}
+
+// QuoteWager is not cached, a quote must reflect the latest state of the wager
+func (cs *cachedWagerService) QuoteWager(request model.BuyWagerRequest) (*model.Quote, error) {
+	return cs.next.QuoteWager(request)
+}
+
+// Session shares the cache, list misses are read through the session
+func (cs *cachedWagerService) Session() WagerService {
+	return &cachedWagerService{
+		next:    Session(cs.next),
+		primary: cs.primary,
+		cache:   cs.cache,
+		ttl:     cs.ttl,
+	}
+}
+
+func (cs *cachedWagerService) invalidateWager(id uint) {
+	invalidateWager(cs.cache, id)
+}
+
+func (cs *cachedWagerService) invalidateLists() {
+	invalidateLists(cs.cache)
+}
+
+// cacheable reports whether none of wagers was decaying at readAt, taken before they
+// were read, so their prices can only change with a write
+func cacheable(wagers []model.Wager, readAt int64) bool {
+	for i := range wagers {
+		if priceDecaying(&wagers[i], readAt) {
+			return false
+		}
+	}
+	return true
+}
+
+func invalidateWager(c cache.Cache, id uint) {
+	if err := c.Delete(wagerKey(id)); err != nil {
+		logrus.WithError(err).WithField("wager_id", id).Error("failed to invalidate cached wager")
+	}
+	invalidateLists(c)
+}
+
+func invalidateLists(c cache.Cache) {
+	if _, err := c.Incr(LIST_GENERATION_KEY); err != nil {
+		logrus.WithError(err).Error("failed to invalidate cached wager lists")
+	}
+}
+
+// get reports whether key was found and decoded into value. Cache failures are
+// logged and treated as misses.
+func (cs *cachedWagerService) get(kind string, key string, value interface{}) bool {
+	data, ok, err := cs.cache.Get(key)
+	if err != nil {
+		logrus.WithError(err).WithField("key", key).Error("failed to read cache")
+	}
+	if ok && err == nil && json.Unmarshal(data, value) == nil {
+		CacheStats.Add(kind+"_hits", 1)
+		return true
+	}
+
+	CacheStats.Add(kind+"_misses", 1)
+	return false
+}
+
+func (cs *cachedWagerService) set(key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		logrus.WithError(err).WithField("key", key).Error("failed to encode cache value")
+		return
+	}
+	if err := cs.cache.Set(key, data, cs.ttl); err != nil {
+		logrus.WithError(err).WithField("key", key).Error("failed to write cache")
+	}
+}
+
+func wagerKey(id uint) string {
+	return fmt.Sprintf("wager:%v", id)
+}
+
+// cachedPurchaseService drops the cached wagers changed by purchase writes, purchases
+// themselves are not cached
+type cachedPurchaseService struct {
+	PurchaseService
+	cache cache.Cache
+}
+
+func NewCachedPurchaseService(next PurchaseService, c cache.Cache) PurchaseService {
+	return &cachedPurchaseService{
+		PurchaseService: next,
+		cache:           c,
+	}
+}
+
+func (cs *cachedPurchaseService) RefundPurchase(id uint) (*model.Purchase, error) {
+	purchase, err := cs.PurchaseService.RefundPurchase(id)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateWager(cs.cache, purchase.WagerID)
+	return purchase, nil
+}
+
+// cachedReservationService drops the cached wagers whose held amount changed
+type cachedReservationService struct {
+	ReservationService
+	cache cache.Cache
+}
+
+func NewCachedReservationService(next ReservationService, c cache.Cache) ReservationService {
+	return &cachedReservationService{
+		ReservationService: next,
+		cache:              c,
+	}
+}
+
+func (cs *cachedReservationService) ReserveWager(request model.ReserveWagerRequest) (*model.Reservation, error) {
+	reservation, err := cs.ReservationService.ReserveWager(request)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateWager(cs.cache, reservation.WagerID)
+	return reservation, nil
+}
+
+func (cs *cachedReservationService) ConfirmReservation(id uint) (*model.Purchase, error) {
+	purchase, err := cs.ReservationService.ConfirmReservation(id)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateWager(cs.cache, purchase.WagerID)
+	return purchase, nil
+}
+
+func (cs *cachedReservationService) ReleaseReservation(id uint) (*model.Reservation, error) {
+	reservation, err := cs.ReservationService.ReleaseReservation(id)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateWager(cs.cache, reservation.WagerID)
+	return reservation, nil
+}
+
+// ReleaseExpired drops the wagers of the released reservations, also when it failed
+// after releasing some
+func (cs *cachedReservationService) ReleaseExpired() ([]model.Reservation, error) {
+	released, err := cs.ReservationService.ReleaseExpired()
+	for _, reservation := range released {
+		invalidateWager(cs.cache, reservation.WagerID)
+	}
+	return released, err
+}
+
+// cachedBidService drops the cached wagers sold or repriced by bids, books are not
+// cached
+type cachedBidService struct {
+	BidService
+	cache cache.Cache
+}
+
+func NewCachedBidService(next BidService, c cache.Cache) BidService {
+	return &cachedBidService{
+		BidService: next,
+		cache:      c,
+	}
+}
+
+// PlaceBid may fill resting bids the price decayed onto before placing the new one
+func (cs *cachedBidService) PlaceBid(request model.PlaceBidRequest) (*model.Bid, error) {
+	bid, err := cs.BidService.PlaceBid(request)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateWager(cs.cache, bid.WagerID)
+	return bid, nil
+}
+
+func (cs *cachedBidService) AcceptBid(request model.AcceptBidRequest) (*model.Purchase, error) {
+	purchase, err := cs.BidService.AcceptBid(request)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateWager(cs.cache, purchase.WagerID)
+	return purchase, nil
+}
+
+func (cs *cachedBidService) LowerPrice(request model.LowerPriceRequest) (*model.LowerPriceResponse, error) {
+	response, err := cs.BidService.LowerPrice(request)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateWager(cs.cache, request.WagerID)
+	return response, nil
+}
+
+func (cs *cachedBidService) MatchScheduledBids() ([]model.Purchase, error) {
+	purchases, err := cs.BidService.MatchScheduledBids()
+	invalidated := make(map[uint]bool)
+	for _, purchase := range purchases {
+		if !invalidated[purchase.WagerID] {
+			invalidateWager(cs.cache, purchase.WagerID)
+			invalidated[purchase.WagerID] = true
+		}
+	}
+	return purchases, err
+}
+
+// cachedEventService drops the cached wagers settled or voided with their market,
+// events and markets are not cached
+type cachedEventService struct {
+	EventService
+	cache cache.Cache
+}
+
+func NewCachedEventService(next EventService, c cache.Cache) EventService {
+	return &cachedEventService{
+		EventService: next,
+		cache:        c,
+	}
+}
+
+func (cs *cachedEventService) UpdateEvent(request model.UpdateEventRequest) (*model.UpdateEventResponse, error) {
+	response, err := cs.EventService.UpdateEvent(request)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, settlement := range response.Settlements {
+		for _, wager := range settlement.Wagers {
+			invalidateWager(cs.cache, wager.ID)
+		}
+	}
+	invalidateLists(cs.cache)
+	return response, nil
+}
+
+func (cs *cachedEventService) SettleMarket(request model.SettleMarketRequest) (*model.Settlement, error) {
+	settlement, err := cs.EventService.SettleMarket(request)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, wager := range settlement.Wagers {
+		invalidateWager(cs.cache, wager.ID)
+	}
+	return settlement, nil
+}
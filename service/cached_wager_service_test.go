@@ -0,0 +1,339 @@
+package service
+
+import (
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+	"wager/cache"
+	"wager/conf"
+	"wager/mocks"
+	"wager/model"
+	"wager/repository"
+	"wager/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cacheStat(name string) int64 {
+	if count, ok := CacheStats.Get(name).(*expvar.Int); ok {
+		return count.Value()
+	}
+	return 0
+}
+
+func newTestCaches(t *testing.T) map[string]cache.Cache {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]cache.Cache{
+		"LRU":   cache.NewLRU(100),
+		"Redis": cache.NewRedis(client),
+	}
+}
+
+func Test_CachedWagerService_GetWagerList(t *testing.T) {
+	for name, c := range newTestCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			next := mocks.NewMockWagerService(ctrl)
+			cachedService := NewCachedWagerService(next, next, c, time.Minute)
+
+			req := model.GetWagerListRequest{Page: 1, Limit: 2}
+			page := &model.GetWagerListResponse{Wagers: []model.Wager{
+				{ID: 1, SellingPrice: 10, CurrentSellingPrice: 10},
+				{ID: 2, SellingPrice: 10, CurrentSellingPrice: 5, PercentageSold: utils.NewNullUint(50)},
+			}}
+			next.EXPECT().GetWagerList(req).Return(page, nil).Times(1)
+
+			hits, misses := cacheStat("list_hits"), cacheStat("list_misses")
+			for i := 0; i < 3; i++ {
+				res, err := cachedService.GetWagerList(req)
+				assert.NoError(t, err)
+				assert.Equal(t, page.Wagers, res.Wagers)
+			}
+			assert.Equal(t, hits+2, cacheStat("list_hits"))
+			assert.Equal(t, misses+1, cacheStat("list_misses"))
+
+			// a buy drops every cached page
+			next.EXPECT().BuyWager(model.BuyWagerRequest{WagerID: 2, BuyingPrice: 5}).Return(&model.Purchase{PurchaseID: 1}, nil)
+			_, err := cachedService.BuyWager(model.BuyWagerRequest{WagerID: 2, BuyingPrice: 5})
+			assert.NoError(t, err)
+
+			next.EXPECT().GetWagerList(req).Return(page, nil).Times(1)
+			_, err = cachedService.GetWagerList(req)
+			assert.NoError(t, err)
+
+			// so does a new wager
+			next.EXPECT().CreateWager(gomock.Any()).Return(&model.Wager{ID: 3}, nil)
+			_, err = cachedService.CreateWager(model.CreateWagerRequest{})
+			assert.NoError(t, err)
+
+			next.EXPECT().GetWagerList(req).Return(page, nil).Times(1)
+			_, err = cachedService.GetWagerList(req)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_CachedWagerService_GetWager(t *testing.T) {
+	for name, c := range newTestCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			next := mocks.NewMockWagerService(ctrl)
+			cachedService := NewCachedWagerService(next, next, c, time.Minute)
+
+			wager := &model.Wager{ID: 1, SellingPrice: 10, CurrentSellingPrice: 10}
+			next.EXPECT().GetWager(uint(1)).Return(wager, nil).Times(1)
+			for i := 0; i < 2; i++ {
+				res, err := cachedService.GetWager(1)
+				assert.NoError(t, err)
+				assert.Equal(t, wager, res)
+			}
+
+			// failed buys leave the cache alone
+			next.EXPECT().BuyWager(gomock.Any()).Return(nil, errors.New("custom error"))
+			_, err := cachedService.BuyWager(model.BuyWagerRequest{WagerID: 1, BuyingPrice: 50})
+			assert.Error(t, err)
+			_, err = cachedService.GetWager(1)
+			assert.NoError(t, err)
+
+			next.EXPECT().BuyWager(gomock.Any()).Return(&model.Purchase{PurchaseID: 1}, nil)
+			_, err = cachedService.BuyWager(model.BuyWagerRequest{WagerID: 1, BuyingPrice: 5})
+			assert.NoError(t, err)
+
+			bought := &model.Wager{ID: 1, SellingPrice: 10, CurrentSellingPrice: 5}
+			next.EXPECT().GetWager(uint(1)).Return(bought, nil).Times(1)
+			res, err := cachedService.GetWager(1)
+			assert.NoError(t, err)
+			assert.Equal(t, bought, res)
+
+			next.EXPECT().BuyWagers(gomock.Any()).Return([]model.Purchase{{PurchaseID: 2}}, nil)
+			_, err = cachedService.BuyWagers(model.BatchPurchaseRequest{Items: []model.BatchPurchaseItem{{WagerID: 1, BuyingPrice: 5}}})
+			assert.NoError(t, err)
+
+			soldOut := &model.Wager{ID: 1, SellingPrice: 10, CurrentSellingPrice: 0}
+			next.EXPECT().GetWager(uint(1)).Return(soldOut, nil).Times(1)
+			res, err = cachedService.GetWager(1)
+			assert.NoError(t, err)
+			assert.Equal(t, soldOut, res)
+		})
+	}
+}
+
+func Test_CachedWagerService_DecayingPrice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	next := mocks.NewMockWagerService(ctrl)
+	cachedService := NewCachedWagerService(next, next, cache.NewLRU(100), time.Minute)
+
+	now := time.Now().UTC().Unix()
+	decaying := &model.Wager{ID: 1, SellingPrice: 10, CurrentSellingPrice: 10, PlaceAt: now, PriceSchedule: model.PRICE_SCHEDULE_LINEAR, FloorPrice: 5, DecaySeconds: 3600}
+	decayed := &model.Wager{ID: 2, SellingPrice: 10, CurrentSellingPrice: 5, PlaceAt: now - 7200, PriceSchedule: model.PRICE_SCHEDULE_LINEAR, FloorPrice: 5, DecaySeconds: 3600}
+
+	next.EXPECT().GetWager(uint(1)).Return(decaying, nil).Times(2)
+	next.EXPECT().GetWager(uint(2)).Return(decayed, nil).Times(1)
+	for i := 0; i < 2; i++ {
+		_, err := cachedService.GetWager(1)
+		assert.NoError(t, err)
+		_, err = cachedService.GetWager(2)
+		assert.NoError(t, err)
+	}
+
+	req := model.GetWagerListRequest{Page: 1, Limit: 2}
+	next.EXPECT().GetWagerList(req).Return(&model.GetWagerListResponse{Wagers: []model.Wager{*decaying, *decayed}}, nil).Times(2)
+	for i := 0; i < 2; i++ {
+		_, err := cachedService.GetWagerList(req)
+		assert.NoError(t, err)
+	}
+}
+
+func Test_CachedWagerService_CacheDown(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	defer client.Close()
+	server.Close()
+
+	ctrl := gomock.NewController(t)
+	next := mocks.NewMockWagerService(ctrl)
+	cachedService := NewCachedWagerService(next, next, cache.NewRedis(client), time.Minute)
+
+	wager := &model.Wager{ID: 1}
+	next.EXPECT().GetWager(uint(1)).Return(wager, nil).Times(2)
+	for i := 0; i < 2; i++ {
+		res, err := cachedService.GetWager(1)
+		assert.NoError(t, err)
+		assert.Equal(t, wager, res)
+	}
+}
+
+func Test_CachedPurchaseService_RefundPurchase(t *testing.T) {
+	for name, c := range newTestCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			nextWagers := mocks.NewMockWagerService(ctrl)
+			nextPurchases := mocks.NewMockPurchaseService(ctrl)
+			cachedWagers := NewCachedWagerService(nextWagers, nextWagers, c, time.Minute)
+			cachedPurchases := NewCachedPurchaseService(nextPurchases, c)
+
+			wager := &model.Wager{ID: 1, SellingPrice: 10, CurrentSellingPrice: 5}
+			nextWagers.EXPECT().GetWager(uint(1)).Return(wager, nil).Times(1)
+			_, err := cachedWagers.GetWager(1)
+			assert.NoError(t, err)
+
+			nextPurchases.EXPECT().RefundPurchase(uint(3)).Return(nil, errors.New("custom error"))
+			_, err = cachedPurchases.RefundPurchase(3)
+			assert.Error(t, err)
+			_, err = cachedWagers.GetWager(1)
+			assert.NoError(t, err)
+
+			nextPurchases.EXPECT().RefundPurchase(uint(3)).Return(&model.Purchase{PurchaseID: 3, WagerID: 1}, nil)
+			_, err = cachedPurchases.RefundPurchase(3)
+			assert.NoError(t, err)
+
+			refunded := &model.Wager{ID: 1, SellingPrice: 10, CurrentSellingPrice: 10}
+			nextWagers.EXPECT().GetWager(uint(1)).Return(refunded, nil).Times(1)
+			res, err := cachedWagers.GetWager(1)
+			assert.NoError(t, err)
+			assert.Equal(t, refunded, res)
+		})
+	}
+}
+
+func Test_CachedReservationService_ReleaseExpired(t *testing.T) {
+	for name, c := range newTestCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			nextWagers := mocks.NewMockWagerService(ctrl)
+			nextReservations := mocks.NewMockReservationService(ctrl)
+			cachedWagers := NewCachedWagerService(nextWagers, nextWagers, c, time.Minute)
+			cachedReservations := NewCachedReservationService(nextReservations, c)
+
+			reserved := &model.Wager{ID: 1, SellingPrice: 10, CurrentSellingPrice: 5, ReservedAmount: 5}
+			nextWagers.EXPECT().GetWager(uint(1)).Return(reserved, nil).Times(1)
+			_, err := cachedWagers.GetWager(1)
+			assert.NoError(t, err)
+
+			// the sweep failed after releasing one reservation, its wager is read again
+			nextReservations.EXPECT().ReleaseExpired().Return([]model.Reservation{{ID: 2, WagerID: 1}}, errors.New("custom error"))
+			_, err = cachedReservations.ReleaseExpired()
+			assert.Error(t, err)
+
+			released := &model.Wager{ID: 1, SellingPrice: 10, CurrentSellingPrice: 10}
+			nextWagers.EXPECT().GetWager(uint(1)).Return(released, nil).Times(1)
+			res, err := cachedWagers.GetWager(1)
+			assert.NoError(t, err)
+			assert.Equal(t, released, res)
+		})
+	}
+}
+
+func Test_CachedEventService_UpdateEvent(t *testing.T) {
+	for name, c := range newTestCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			nextWagers := mocks.NewMockWagerService(ctrl)
+			nextEvents := mocks.NewMockEventService(ctrl)
+			cachedWagers := NewCachedWagerService(nextWagers, nextWagers, c, time.Minute)
+			cachedEvents := NewCachedEventService(nextEvents, c)
+
+			wager := &model.Wager{ID: 1, Status: model.WAGER_STATUS_OPEN}
+			nextWagers.EXPECT().GetWager(uint(1)).Return(wager, nil).Times(1)
+			_, err := cachedWagers.GetWager(1)
+			assert.NoError(t, err)
+
+			// cancelling the event voids the wager, which is read again
+			request := model.UpdateEventRequest{ID: 2, Name: "Final", StartsAt: 100, Status: model.EVENT_STATUS_CANCELLED}
+			voided := &model.Wager{ID: 1, Status: model.WAGER_STATUS_VOID}
+			nextEvents.EXPECT().UpdateEvent(request).Return(&model.UpdateEventResponse{
+				Event:       model.Event{ID: 2, Status: model.EVENT_STATUS_CANCELLED},
+				Settlements: []model.Settlement{{Market: model.Market{ID: 3}, Wagers: []model.Wager{*voided}}},
+			}, nil)
+			_, err = cachedEvents.UpdateEvent(request)
+			assert.NoError(t, err)
+
+			nextWagers.EXPECT().GetWager(uint(1)).Return(voided, nil).Times(1)
+			res, err := cachedWagers.GetWager(1)
+			assert.NoError(t, err)
+			assert.Equal(t, voided, res)
+		})
+	}
+}
+
+// laggingStore reads wagers from replica, a copy taken before the latest writes,
+// while its transactions and UsePrimary go to the store it embeds
+type laggingStore struct {
+	repository.Store
+	replica repository.Store
+}
+
+func (s *laggingStore) Wagers() repository.WagerRepository {
+	return s.replica.Wagers()
+}
+
+func (s *laggingStore) UsePrimary() repository.Store {
+	return s.Store
+}
+
+func Test_CachedWagerService_LaggingReplica(t *testing.T) {
+	config := conf.GetDefaultConfig()
+	request := model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100}
+	primary, replica := repository.NewMemoryStore(), repository.NewMemoryStore()
+	for _, s := range []repository.Store{primary, replica} {
+		_, err := NewWagerService(config, s, nil).CreateWager(request)
+		require.NoError(t, err)
+	}
+
+	store := &laggingStore{Store: primary, replica: replica}
+	cachedService := NewCachedWagerService(NewWagerService(config, store, nil), NewWagerService(config, store.UsePrimary(), nil), cache.NewLRU(100), time.Minute)
+	_, err := cachedService.BuyWager(model.BuyWagerRequest{WagerID: 1, BuyingPrice: 25})
+	require.NoError(t, err)
+
+	// the replica has not seen the purchase yet, the wager is filled from the primary
+	stale, err := NewWagerService(config, store, nil).GetWager(1)
+	require.NoError(t, err)
+	assert.Equal(t, float64(100), stale.CurrentSellingPrice)
+	for i := 0; i < 2; i++ {
+		wager, err := cachedService.GetWager(1)
+		require.NoError(t, err)
+		assert.Equal(t, float64(75), wager.CurrentSellingPrice)
+	}
+
+	// list pages are read from the replica
+	list, err := cachedService.GetWagerList(model.GetWagerListRequest{Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, float64(100), list.Wagers[0].CurrentSellingPrice)
+}
+
+func Test_CachedWagerService_ListRacingWrite(t *testing.T) {
+	for name, c := range newTestCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			next := mocks.NewMockWagerService(ctrl)
+			cachedService := NewCachedWagerService(next, next, c, time.Minute)
+
+			req := model.GetWagerListRequest{Page: 1, Limit: 2}
+			stale := &model.GetWagerListResponse{Wagers: []model.Wager{{ID: 1, SellingPrice: 10, CurrentSellingPrice: 10}}}
+			fresh := &model.GetWagerListResponse{Wagers: []model.Wager{{ID: 1, SellingPrice: 10, CurrentSellingPrice: 5}}}
+			// a purchase commits while the page is read, the page is not cached
+			next.EXPECT().GetWagerList(req).DoAndReturn(func(model.GetWagerListRequest) (*model.GetWagerListResponse, error) {
+				invalidateWager(c, 1)
+				return stale, nil
+			})
+			res, err := cachedService.GetWagerList(req)
+			require.NoError(t, err)
+			assert.Equal(t, stale.Wagers, res.Wagers)
+
+			next.EXPECT().GetWagerList(req).Return(fresh, nil).Times(1)
+			for i := 0; i < 2; i++ {
+				res, err = cachedService.GetWagerList(req)
+				require.NoError(t, err)
+				assert.Equal(t, fresh.Wagers, res.Wagers)
+			}
+		})
+	}
+}
@@ -0,0 +1,509 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"wager/conf"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// SETTLE_BATCH_SIZE is how many wagers or positions a settlement reads at a time
+	SETTLE_BATCH_SIZE = 100
+)
+
+var (
+	ErrEventNotOpen = errorcode.NewClientError("event is finished or cancelled")
+	// ErrEventTransition is wrapped with the current and the requested status
+	ErrEventTransition = errorcode.NewClientError("event status can not change")
+	ErrEventHasMarkets = errorcode.NewClientError("event has markets")
+	ErrMarketNotOpen   = errorcode.NewClientError("market is not open")
+	ErrMarketHasWagers = errorcode.NewClientError("market has wagers")
+	// ErrSelectionNotFound is wrapped with the id of the selection
+	ErrSelectionNotFound = errorcode.NewClientError("selection not found")
+	ErrNotInMarket       = errorcode.NewClientError("winning selection is not a selection of the market")
+)
+
+// EventService manages the events and markets wagers are placed on. Settling a
+// market settles all of its wagers: wagers on the winning selection are won and pay
+// the holders of their positions, the others are lost.
+type EventService interface {
+	CreateEvent(request model.CreateEventRequest) (*model.Event, error)
+	GetEventList(request model.GetEventListRequest) ([]model.Event, error)
+	GetEvent(id uint) (*model.Event, error)
+	// UpdateEvent refuses status changes other than those of model.UpdateEventRequest
+	// with ErrEventTransition. Cancelling an event voids its open markets, which
+	// voids their wagers, the response has their settlements.
+	UpdateEvent(request model.UpdateEventRequest) (*model.UpdateEventResponse, error)
+	// DeleteEvent deletes an event without markets
+	DeleteEvent(id uint) error
+	// CreateMarket adds a market to an event which is not finished or cancelled
+	CreateMarket(request model.CreateMarketRequest) (*model.Market, error)
+	// GetMarkets returns the markets of an event
+	GetMarkets(eventID uint) ([]model.Market, error)
+	GetMarket(id uint) (*model.Market, error)
+	UpdateMarket(request model.UpdateMarketRequest) (*model.Market, error)
+	// DeleteMarket deletes a market without wagers
+	DeleteMarket(id uint) error
+	SettleMarket(request model.SettleMarketRequest) (*model.Settlement, error)
+}
+
+type eventService struct {
+	config *conf.Config
+	store  repository.Store
+	// publisher receives the settlements and the wagers they settled, it may be nil
+	publisher WagerPublisher
+	now       func() time.Time
+}
+
+func NewEventService(config *conf.Config, store repository.Store, publisher WagerPublisher) EventService {
+	return &eventService{
+		config:    config,
+		store:     store,
+		publisher: publisher,
+		now:       time.Now,
+	}
+}
+
+func (es *eventService) CreateEvent(request model.CreateEventRequest) (*model.Event, error) {
+	event := &model.Event{
+		Name:     request.Name,
+		StartsAt: request.StartsAt,
+		Status:   request.Status,
+	}
+	if event.Status == "" {
+		event.Status = model.EVENT_STATUS_SCHEDULED
+	}
+
+	if err := es.store.Events().Create(event); err != nil {
+		logrus.WithError(err).Error("cannot create event")
+		return nil, err
+	}
+	return event, nil
+}
+
+func (es *eventService) GetEventList(request model.GetEventListRequest) ([]model.Event, error) {
+	if request.Page == 0 || request.Limit == 0 {
+		return nil, errors.New("invalid request params")
+	}
+	offset := (request.Page - 1) * request.Limit
+
+	return es.store.Events().List(request.Filter, offset, request.Limit)
+}
+
+func (es *eventService) GetEvent(id uint) (*model.Event, error) {
+	return es.store.Events().GetByID(id)
+}
+
+func (es *eventService) UpdateEvent(request model.UpdateEventRequest) (*model.UpdateEventResponse, error) {
+	var event *model.Event
+	var settlements []*model.Settlement
+	err := es.store.RunInTx(func(store repository.Store) error {
+		var err error
+		event, err = store.Events().GetByIDForUpdate(request.ID)
+		if err != nil {
+			return err
+		}
+		if !canMoveEvent(event.Status, request.Status) {
+			return fmt.Errorf("%w from %v to %v", ErrEventTransition, event.Status, request.Status)
+		}
+		cancelled := event.Status != model.EVENT_STATUS_CANCELLED && request.Status == model.EVENT_STATUS_CANCELLED
+
+		event.Name = request.Name
+		event.StartsAt = request.StartsAt
+		event.Status = request.Status
+		if err := store.Events().Update(event); err != nil {
+			return err
+		}
+
+		settlements = nil
+		if cancelled {
+			settlements, err = voidMarkets(store, event.ID, es.now().UTC().Unix())
+		}
+		return err
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("event_id", request.ID).Error("cannot update event")
+		return nil, err
+	}
+
+	response := &model.UpdateEventResponse{Event: *event}
+	for _, settlement := range settlements {
+		logrus.WithFields(logrus.Fields{
+			"market_id": settlement.Market.ID,
+			"wagers":    len(settlement.Wagers),
+		}).Info("Voided market")
+		publish(es.publisher, settlementEvents(settlement)...)
+		response.Settlements = append(response.Settlements, *settlement)
+	}
+	return response, nil
+}
+
+// eventTransitions are the statuses each status can move to besides itself
+var eventTransitions = map[string][]string{
+	model.EVENT_STATUS_SCHEDULED: {model.EVENT_STATUS_LIVE, model.EVENT_STATUS_FINISHED, model.EVENT_STATUS_CANCELLED},
+	model.EVENT_STATUS_LIVE:      {model.EVENT_STATUS_FINISHED, model.EVENT_STATUS_CANCELLED},
+}
+
+func canMoveEvent(from string, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, status := range eventTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// voidMarkets voids the open markets of a cancelled event and their wagers, it runs
+// in the transaction which locked the event
+func voidMarkets(store repository.Store, eventID uint, at int64) ([]*model.Settlement, error) {
+	markets, err := store.Markets().ListByEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	settlements := make([]*model.Settlement, 0, len(markets))
+	for i := range markets {
+		market, err := store.Markets().GetByIDForUpdate(markets[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		if market.Status != model.MARKET_STATUS_OPEN {
+			continue
+		}
+
+		market.Status = model.MARKET_STATUS_VOID
+		market.SettledAt = at
+		if err := store.Markets().Update(market); err != nil {
+			return nil, err
+		}
+		settlement := &model.Settlement{Market: *market, Wagers: []model.Wager{}, Payouts: []model.Payout{}}
+		err = settleWagers(store, settlement, func(wager *model.Wager) string {
+			return model.WAGER_STATUS_VOID
+		})
+		if err != nil {
+			return nil, err
+		}
+		settlements = append(settlements, settlement)
+	}
+	return settlements, nil
+}
+
+func (es *eventService) DeleteEvent(id uint) error {
+	err := es.store.RunInTx(func(store repository.Store) error {
+		if _, err := store.Events().GetByIDForUpdate(id); err != nil {
+			return err
+		}
+		markets, err := store.Markets().ListByEvent(id)
+		if err != nil {
+			return err
+		}
+		if len(markets) > 0 {
+			return ErrEventHasMarkets
+		}
+
+		return store.Events().Delete(id)
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("event_id", id).Error("cannot delete event")
+	}
+	return err
+}
+
+func (es *eventService) CreateMarket(request model.CreateMarketRequest) (*model.Market, error) {
+	market := &model.Market{
+		EventID:    request.EventID,
+		Name:       request.Name,
+		Status:     model.MARKET_STATUS_OPEN,
+		Selections: make([]model.Selection, 0, len(request.Selections)),
+	}
+	for _, name := range request.Selections {
+		market.Selections = append(market.Selections, model.Selection{Name: name})
+	}
+
+	err := es.store.RunInTx(func(store repository.Store) error {
+		// the event stays locked so that it is not deleted under the market
+		event, err := store.Events().GetByIDForUpdate(request.EventID)
+		if err != nil {
+			return err
+		}
+		if event.Status == model.EVENT_STATUS_FINISHED || event.Status == model.EVENT_STATUS_CANCELLED {
+			return ErrEventNotOpen
+		}
+
+		return store.Markets().Create(market)
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("event_id", request.EventID).Error("cannot create market")
+		return nil, err
+	}
+
+	return market, nil
+}
+
+func (es *eventService) GetMarkets(eventID uint) ([]model.Market, error) {
+	if _, err := es.store.Events().GetByID(eventID); err != nil {
+		return nil, err
+	}
+	return es.store.Markets().ListByEvent(eventID)
+}
+
+func (es *eventService) GetMarket(id uint) (*model.Market, error) {
+	return es.store.Markets().GetByID(id)
+}
+
+func (es *eventService) UpdateMarket(request model.UpdateMarketRequest) (*model.Market, error) {
+	var market *model.Market
+	err := es.store.RunInTx(func(store repository.Store) error {
+		var err error
+		market, err = store.Markets().GetByIDForUpdate(request.ID)
+		if err != nil {
+			return err
+		}
+
+		market.Name = request.Name
+		return store.Markets().Update(market)
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("market_id", request.ID).Error("cannot update market")
+		return nil, err
+	}
+
+	return market, nil
+}
+
+func (es *eventService) DeleteMarket(id uint) error {
+	err := es.store.RunInTx(func(store repository.Store) error {
+		// wagers are placed with the market locked, none can be added before it is gone
+		if _, err := store.Markets().GetByIDForUpdate(id); err != nil {
+			return err
+		}
+		wagers, err := store.Wagers().List(model.WagerFilter{MarketID: id}, 0, 1)
+		if err != nil {
+			return err
+		}
+		if len(wagers) > 0 {
+			return ErrMarketHasWagers
+		}
+
+		return store.Markets().Delete(id)
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("market_id", id).Error("cannot delete market")
+	}
+	return err
+}
+
+func (es *eventService) SettleMarket(request model.SettleMarketRequest) (*model.Settlement, error) {
+	var settlement *model.Settlement
+	err := es.store.RunInTx(func(store repository.Store) error {
+		market, err := store.Markets().GetByIDForUpdate(request.MarketID)
+		if err != nil {
+			return err
+		}
+		if market.Status != model.MARKET_STATUS_OPEN {
+			return ErrMarketNotOpen
+		}
+		if !hasSelection(market, request.WinningSelectionID) {
+			return ErrNotInMarket
+		}
+
+		market.Status = model.MARKET_STATUS_SETTLED
+		market.WinningSelectionID = request.WinningSelectionID
+		market.SettledAt = es.now().UTC().Unix()
+		if err := store.Markets().Update(market); err != nil {
+			return err
+		}
+
+		settlement = &model.Settlement{Market: *market, Wagers: []model.Wager{}, Payouts: []model.Payout{}}
+		return settleWagers(store, settlement, func(wager *model.Wager) string {
+			if wager.SelectionID == request.WinningSelectionID {
+				return model.WAGER_STATUS_WON
+			}
+			return model.WAGER_STATUS_LOST
+		})
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("market_id", request.MarketID).Error("cannot settle market")
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"market_id": settlement.Market.ID,
+		"wagers":    len(settlement.Wagers),
+		"payouts":   len(settlement.Payouts),
+	}).Info("Settled market")
+	publish(es.publisher, settlementEvents(settlement)...)
+	return settlement, nil
+}
+
+// settlementEvents reports the new status of every wager of settlement, then the
+// settlement itself
+func settlementEvents(settlement *model.Settlement) []model.WagerEvent {
+	at := settlement.Market.SettledAt
+	events := make([]model.WagerEvent, 0, len(settlement.Wagers)+1)
+	wagerIDs := make([]uint, 0, len(settlement.Wagers))
+	for _, wager := range settlement.Wagers {
+		events = append(events, wagerEvent(model.WAGER_EVENT_STATUS, wager, at))
+		wagerIDs = append(wagerIDs, wager.ID)
+	}
+	market := settlement.Market
+	return append(events, model.WagerEvent{Type: model.WAGER_EVENT_SETTLEMENT, At: at, Market: &market, WagerIDs: wagerIDs})
+}
+
+func hasSelection(market *model.Market, selectionID uint) bool {
+	for _, selection := range market.Selections {
+		if selection.ID == selectionID {
+			return true
+		}
+	}
+	return false
+}
+
+// settleWagers settles the open wagers of the market of settlement with the status
+// outcome gives each of them
+func settleWagers(store repository.Store, settlement *model.Settlement, outcome func(wager *model.Wager) string) error {
+	// settling does not change which wagers are on the market, so pages are stable
+	for offset := 0; ; offset += SETTLE_BATCH_SIZE {
+		wagers, err := store.Wagers().List(model.WagerFilter{MarketID: settlement.Market.ID}, offset, SETTLE_BATCH_SIZE)
+		if err != nil {
+			return err
+		}
+		for _, wager := range wagers {
+			if err := settleWager(store, settlement, wager.ID, outcome); err != nil {
+				return err
+			}
+		}
+		if len(wagers) < SETTLE_BATCH_SIZE {
+			return nil
+		}
+	}
+}
+
+// settleWager settles an open wager of a settled or void market and adds it and the
+// payouts of its positions to settlement. Its held reservations are released and its
+// open bids and listings cancelled, since nothing can be bought from a settled wager.
+func settleWager(store repository.Store, settlement *model.Settlement, wagerID uint, outcome func(wager *model.Wager) string) error {
+	wager, err := store.Wagers().GetByIDForUpdate(wagerID)
+	if err != nil {
+		return err
+	}
+	if wager.Status != model.WAGER_STATUS_OPEN {
+		return nil
+	}
+
+	wager.Status = outcome(wager)
+	if err := store.Wagers().UpdateStatus(wager); err != nil {
+		return err
+	}
+	if err := releaseHeldReservations(store, wager); err != nil {
+		return err
+	}
+	settlement.Wagers = append(settlement.Wagers, *wager)
+
+	bids, err := store.Bids().ListOpen(wager.ID)
+	if err != nil {
+		return err
+	}
+	for i := range bids {
+		bids[i].Status = model.BID_STATUS_CANCELLED
+		if err := store.Bids().Update(&bids[i]); err != nil {
+			return err
+		}
+	}
+
+	// cancelled listings leave the open ones, the first page is always the next one
+	for {
+		listings, err := store.Listings().ListOpen(wager.ID, 0, SETTLE_BATCH_SIZE)
+		if err != nil {
+			return err
+		}
+		if len(listings) == 0 {
+			break
+		}
+		for i := range listings {
+			listings[i].Status = model.LISTING_STATUS_CANCELLED
+			if err := store.Listings().Update(&listings[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	transfers := map[uint][]model.Transfer{}
+	for offset := 0; ; offset += SETTLE_BATCH_SIZE {
+		positions, err := store.Positions().List(model.PositionFilter{WagerID: wager.ID}, offset, SETTLE_BATCH_SIZE)
+		if err != nil {
+			return err
+		}
+		for i := range positions {
+			position := &positions[i]
+			position.ListedFaceValue = 0
+			switch wager.Status {
+			case model.WAGER_STATUS_WON:
+				position.Payout = roundMinor(wager, payoutOf(wager, position.FaceValue))
+			case model.WAGER_STATUS_VOID:
+				stake, err := paidStake(store, wager, position, transfers)
+				if err != nil {
+					return err
+				}
+				position.Payout = stake
+			}
+			if err := store.Positions().Update(position); err != nil {
+				return err
+			}
+			settlement.Payouts = append(settlement.Payouts, model.Payout{
+				WagerID:    wager.ID,
+				PositionID: position.ID,
+				Holder:     position.Holder,
+				Amount:     position.Payout,
+				Currency:   wager.Currency,
+			})
+		}
+		if len(positions) < SETTLE_BATCH_SIZE {
+			return nil
+		}
+	}
+}
+
+// paidStake returns what the holder of position paid for the face value it still
+// holds, its share of the price of the transfer which created the position, or of the
+// purchase for the position of the original buyer. Transfers caches the transfers of
+// the purchases of wager.
+func paidStake(store repository.Store, wager *model.Wager, position *model.Position, transfers map[uint][]model.Transfer) (float64, error) {
+	purchaseTransfers, ok := transfers[position.PurchaseID]
+	if !ok {
+		var err error
+		purchaseTransfers, err = store.Transfers().ListByPurchase(position.PurchaseID)
+		if err != nil {
+			return 0, err
+		}
+		transfers[position.PurchaseID] = purchaseTransfers
+	}
+
+	for _, transfer := range purchaseTransfers {
+		if transfer.ToPositionID == position.ID {
+			return shareOf(wager, transfer.Price, transfer.FaceValue, position.FaceValue), nil
+		}
+	}
+	purchase, err := store.Purchases().GetByID(position.PurchaseID)
+	if err != nil {
+		return 0, err
+	}
+	return shareOf(wager, purchase.BuyingPrice, purchase.FaceValue, position.FaceValue), nil
+}
+
+// shareOf returns the part of price paid for faceValue which held of it is worth
+func shareOf(wager *model.Wager, price float64, faceValue float64, held float64) float64 {
+	if faceValue == 0 || held == faceValue {
+		return price
+	}
+	return roundMinor(wager, price*held/faceValue)
+}
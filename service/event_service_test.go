@@ -0,0 +1,322 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"wager/conf"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type eventTest struct {
+	store  repository.Store
+	wagers WagerService
+	events *eventService
+	now    time.Time
+}
+
+func newEventTest(t *testing.T) (*eventTest, *model.Market) {
+	config := conf.GetDefaultConfig()
+	store := repository.NewMemoryStore()
+	et := &eventTest{
+		store:  store,
+		wagers: NewWagerService(config, store, nil),
+		events: NewEventService(config, store, nil).(*eventService),
+		now:    time.Unix(1642484487, 0),
+	}
+	et.events.now = func() time.Time { return et.now }
+
+	event, err := et.events.CreateEvent(model.CreateEventRequest{Name: "Final", StartsAt: 1642500000})
+	require.NoError(t, err)
+	assert.Equal(t, model.EVENT_STATUS_SCHEDULED, event.Status)
+	market, err := et.events.CreateMarket(model.CreateMarketRequest{EventID: event.ID, Name: "Winner", Selections: []string{"Home", "Away"}})
+	require.NoError(t, err)
+	return et, market
+}
+
+func (et *eventTest) placeWager(t *testing.T, selectionID uint) *model.Wager {
+	wager, err := et.wagers.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, SelectionID: selectionID})
+	require.NoError(t, err)
+	return wager
+}
+
+func Test_CreateMarket(t *testing.T) {
+	et, market := newEventTest(t)
+	assert.Equal(t, model.MARKET_STATUS_OPEN, market.Status)
+	require.Len(t, market.Selections, 2)
+	assert.Equal(t, "Away", market.Selections[1].Name)
+
+	markets, err := et.events.GetMarkets(market.EventID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Market{*market}, markets)
+	_, err = et.events.GetMarkets(100)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, err = et.events.CreateMarket(model.CreateMarketRequest{EventID: 100, Name: "Winner", Selections: []string{"Home", "Away"}})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, err = et.events.UpdateEvent(model.UpdateEventRequest{ID: market.EventID, Name: "Final", StartsAt: 1642500000, Status: model.EVENT_STATUS_FINISHED})
+	require.NoError(t, err)
+	_, err = et.events.CreateMarket(model.CreateMarketRequest{EventID: market.EventID, Name: "Goals", Selections: []string{"Under", "Over"}})
+	assert.ErrorIs(t, err, ErrEventNotOpen)
+}
+
+func Test_CreateWager_OnSelection(t *testing.T) {
+	et, market := newEventTest(t)
+
+	wager := et.placeWager(t, market.Selections[1].ID)
+	assert.Equal(t, market.EventID, wager.EventID)
+	assert.Equal(t, market.ID, wager.MarketID)
+	assert.Equal(t, market.Selections[1].ID, wager.SelectionID)
+	et.placeWager(t, 0)
+
+	list, err := et.wagers.GetWagerList(model.GetWagerListRequest{Filter: model.WagerFilter{MarketID: market.ID}, Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []model.Wager{*wager}, list.Wagers)
+
+	_, err = et.wagers.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, SelectionID: 100})
+	assert.ErrorIs(t, err, ErrSelectionNotFound)
+
+	_, err = et.wagers.CreateWagers(model.CreateWagersRequest{Wagers: []model.CreateWagerRequest{
+		{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, SelectionID: market.Selections[0].ID},
+		{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, SelectionID: 100},
+	}})
+	batchErr := &model.BatchError{}
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, []model.BatchItemError{{Index: 1, Error: "selection not found: 100"}}, batchErr.Items)
+}
+
+func Test_SettleMarket(t *testing.T) {
+	et, market := newEventTest(t)
+	home, away := market.Selections[0].ID, market.Selections[1].ID
+
+	won := et.placeWager(t, home)
+	lost := et.placeWager(t, away)
+	_, err := et.wagers.BuyWager(model.BuyWagerRequest{WagerID: won.ID, Buyer: "alice", BuyingPrice: 40})
+	require.NoError(t, err)
+	_, err = et.wagers.BuyWager(model.BuyWagerRequest{WagerID: lost.ID, Buyer: "bob", BuyingPrice: 30})
+	require.NoError(t, err)
+
+	bid := &model.Bid{WagerID: won.ID, Buyer: "carol", FaceValue: 10, BidPrice: 5, Status: model.BID_STATUS_OPEN}
+	require.NoError(t, et.store.Bids().Create(bid))
+	positions, err := et.store.Positions().List(model.PositionFilter{Holder: "alice"}, 0, 10)
+	require.NoError(t, err)
+	listing := &model.Listing{PositionID: positions[0].ID, WagerID: won.ID, Seller: "alice", FaceValue: 10, Price: 12, Status: model.LISTING_STATUS_OPEN}
+	require.NoError(t, et.store.Listings().Create(listing))
+
+	_, err = et.events.SettleMarket(model.SettleMarketRequest{MarketID: market.ID, WinningSelectionID: 100})
+	assert.ErrorIs(t, err, ErrNotInMarket)
+
+	settlement, err := et.events.SettleMarket(model.SettleMarketRequest{MarketID: market.ID, WinningSelectionID: home})
+	require.NoError(t, err)
+	assert.Equal(t, model.MARKET_STATUS_SETTLED, settlement.Market.Status)
+	assert.Equal(t, home, settlement.Market.WinningSelectionID)
+	assert.Equal(t, et.now.Unix(), settlement.Market.SettledAt)
+	require.Len(t, settlement.Wagers, 2)
+	assert.Equal(t, model.WAGER_STATUS_WON, settlement.Wagers[0].Status)
+	assert.Equal(t, model.WAGER_STATUS_LOST, settlement.Wagers[1].Status)
+	// alice holds 40% of the selling price, which is 20% of the wager at odds of 2
+	assert.Equal(t, []model.Payout{
+		{WagerID: won.ID, PositionID: positions[0].ID, Holder: "alice", Amount: 40, Currency: "USD"},
+		{WagerID: lost.ID, PositionID: positions[0].ID + 1, Holder: "bob", Amount: 0, Currency: "USD"},
+	}, settlement.Payouts)
+
+	position, err := et.store.Positions().GetByID(positions[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, float64(40), position.Payout)
+	assert.Zero(t, position.ListedFaceValue)
+	gotBid, err := et.store.Bids().GetByID(bid.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.BID_STATUS_CANCELLED, gotBid.Status)
+	gotListing, err := et.store.Listings().GetByID(listing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.LISTING_STATUS_CANCELLED, gotListing.Status)
+
+	// nothing more can be bought, placed or settled
+	_, err = et.wagers.BuyWager(model.BuyWagerRequest{WagerID: won.ID, Buyer: "bob", BuyingPrice: 10})
+	assert.ErrorIs(t, err, ErrWagerNotOpen)
+	_, err = et.wagers.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, SelectionID: home})
+	assert.ErrorIs(t, err, ErrMarketNotOpen)
+	_, err = et.events.SettleMarket(model.SettleMarketRequest{MarketID: market.ID, WinningSelectionID: away})
+	assert.ErrorIs(t, err, ErrMarketNotOpen)
+}
+
+func Test_DeleteEventAndMarket(t *testing.T) {
+	et, market := newEventTest(t)
+	et.placeWager(t, market.Selections[0].ID)
+
+	assert.ErrorIs(t, et.events.DeleteEvent(market.EventID), ErrEventHasMarkets)
+	assert.ErrorIs(t, et.events.DeleteMarket(market.ID), ErrMarketHasWagers)
+
+	empty, err := et.events.CreateMarket(model.CreateMarketRequest{EventID: market.EventID, Name: "Goals", Selections: []string{"Under", "Over"}})
+	require.NoError(t, err)
+	require.NoError(t, et.events.DeleteMarket(empty.ID))
+	_, err = et.events.GetMarket(empty.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	event, err := et.events.CreateEvent(model.CreateEventRequest{Name: "Friendly", StartsAt: 1642600000})
+	require.NoError(t, err)
+	require.NoError(t, et.events.DeleteEvent(event.ID))
+	assert.ErrorIs(t, et.events.DeleteEvent(event.ID), repository.ErrNotFound)
+}
+
+func Test_UpdateEvent_Transitions(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		ok   bool
+	}{
+		{from: model.EVENT_STATUS_SCHEDULED, to: model.EVENT_STATUS_SCHEDULED, ok: true},
+		{from: model.EVENT_STATUS_SCHEDULED, to: model.EVENT_STATUS_LIVE, ok: true},
+		{from: model.EVENT_STATUS_SCHEDULED, to: model.EVENT_STATUS_CANCELLED, ok: true},
+		{from: model.EVENT_STATUS_LIVE, to: model.EVENT_STATUS_FINISHED, ok: true},
+		{from: model.EVENT_STATUS_LIVE, to: model.EVENT_STATUS_CANCELLED, ok: true},
+		{from: model.EVENT_STATUS_LIVE, to: model.EVENT_STATUS_SCHEDULED},
+		{from: model.EVENT_STATUS_FINISHED, to: model.EVENT_STATUS_FINISHED, ok: true},
+		{from: model.EVENT_STATUS_FINISHED, to: model.EVENT_STATUS_LIVE},
+		{from: model.EVENT_STATUS_FINISHED, to: model.EVENT_STATUS_CANCELLED},
+		{from: model.EVENT_STATUS_CANCELLED, to: model.EVENT_STATUS_SCHEDULED},
+		{from: model.EVENT_STATUS_CANCELLED, to: model.EVENT_STATUS_FINISHED},
+	}
+	et, _ := newEventTest(t)
+	for _, tc := range tests {
+		t.Run(tc.from+" to "+tc.to, func(t *testing.T) {
+			event, err := et.events.CreateEvent(model.CreateEventRequest{Name: "Final", StartsAt: 1642500000, Status: tc.from})
+			require.NoError(t, err)
+
+			updated, err := et.events.UpdateEvent(model.UpdateEventRequest{ID: event.ID, Name: "Final", StartsAt: 1642500000, Status: tc.to})
+			if !tc.ok {
+				assert.ErrorIs(t, err, ErrEventTransition)
+				got, err := et.events.GetEvent(event.ID)
+				require.NoError(t, err)
+				assert.Equal(t, tc.from, got.Status)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.to, updated.Status)
+		})
+	}
+}
+
+func Test_CancelEvent(t *testing.T) {
+	et, market := newEventTest(t)
+	home := market.Selections[0].ID
+	wager := et.placeWager(t, home)
+	_, err := et.wagers.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 40})
+	require.NoError(t, err)
+
+	bid := &model.Bid{WagerID: wager.ID, Buyer: "carol", FaceValue: 10, BidPrice: 5, Status: model.BID_STATUS_OPEN}
+	require.NoError(t, et.store.Bids().Create(bid))
+	positions, err := et.store.Positions().List(model.PositionFilter{Holder: "alice"}, 0, 10)
+	require.NoError(t, err)
+	// bob buys 20 of alice's face value for 30, and resells 5 of it to dave
+	resales := NewResaleService(conf.GetDefaultConfig(), et.store)
+	resold, err := resales.CreateListing(model.CreateListingRequest{PositionID: positions[0].ID, Seller: "alice", FaceValue: 20, Price: 30})
+	require.NoError(t, err)
+	bought, err := resales.BuyListing(model.BuyListingRequest{ListingID: resold.ID, Buyer: "bob"})
+	require.NoError(t, err)
+	resold, err = resales.CreateListing(model.CreateListingRequest{PositionID: bought.ToPositionID, Seller: "bob", FaceValue: 5, Price: 9})
+	require.NoError(t, err)
+	_, err = resales.BuyListing(model.BuyListingRequest{ListingID: resold.ID, Buyer: "dave"})
+	require.NoError(t, err)
+	listing := &model.Listing{PositionID: positions[0].ID, WagerID: wager.ID, Seller: "alice", FaceValue: 10, Price: 12, Status: model.LISTING_STATUS_OPEN}
+	require.NoError(t, et.store.Listings().Create(listing))
+
+	// a market settled before the event is cancelled keeps its outcome
+	settled, err := et.events.CreateMarket(model.CreateMarketRequest{EventID: market.EventID, Name: "First goal", Selections: []string{"Home", "Away"}})
+	require.NoError(t, err)
+	settledWager := et.placeWager(t, settled.Selections[0].ID)
+	_, err = et.events.SettleMarket(model.SettleMarketRequest{MarketID: settled.ID, WinningSelectionID: settled.Selections[0].ID})
+	require.NoError(t, err)
+
+	response, err := et.events.UpdateEvent(model.UpdateEventRequest{ID: market.EventID, Name: "Final", StartsAt: 1642500000, Status: model.EVENT_STATUS_CANCELLED})
+	require.NoError(t, err)
+	assert.Equal(t, model.EVENT_STATUS_CANCELLED, response.Status)
+	require.Len(t, response.Settlements, 1)
+	assert.Equal(t, market.ID, response.Settlements[0].Market.ID)
+	assert.Equal(t, []uint{wager.ID}, []uint{response.Settlements[0].Wagers[0].ID})
+
+	gotMarket, err := et.events.GetMarket(market.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.MARKET_STATUS_VOID, gotMarket.Status)
+	assert.Equal(t, et.now.Unix(), gotMarket.SettledAt)
+	gotMarket, err = et.events.GetMarket(settled.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.MARKET_STATUS_SETTLED, gotMarket.Status)
+
+	gotWager, err := et.wagers.GetWager(wager.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.WAGER_STATUS_VOID, gotWager.Status)
+	gotWager, err = et.wagers.GetWager(settledWager.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.WAGER_STATUS_WON, gotWager.Status)
+
+	// each holder is paid back what was paid for the face value it holds, alice 40
+	// for 40 of which 20 are left, bob 30 for 20 of which 15 are left, dave 9 for 5
+	payouts := map[string]float64{}
+	for _, payout := range response.Settlements[0].Payouts {
+		payouts[payout.Holder] = payout.Amount
+	}
+	assert.Equal(t, map[string]float64{"alice": 20, "bob": 22.5, "dave": 9}, payouts)
+	position, err := et.store.Positions().GetByID(positions[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, float64(20), position.Payout)
+	assert.Zero(t, position.ListedFaceValue)
+	gotBid, err := et.store.Bids().GetByID(bid.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.BID_STATUS_CANCELLED, gotBid.Status)
+	gotListing, err := et.store.Listings().GetByID(listing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.LISTING_STATUS_CANCELLED, gotListing.Status)
+
+	_, err = et.wagers.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, Buyer: "bob", BuyingPrice: 10})
+	assert.ErrorIs(t, err, ErrWagerNotOpen)
+	_, err = et.events.SettleMarket(model.SettleMarketRequest{MarketID: market.ID, WinningSelectionID: home})
+	assert.ErrorIs(t, err, ErrMarketNotOpen)
+	_, err = et.events.UpdateEvent(model.UpdateEventRequest{ID: market.EventID, Name: "Final", StartsAt: 1642500000, Status: model.EVENT_STATUS_SCHEDULED})
+	assert.ErrorIs(t, err, ErrEventTransition)
+}
+
+func Test_SettleMarket_Reservations(t *testing.T) {
+	et, market := newEventTest(t)
+	reservations := NewReservationService(conf.GetDefaultConfig(), et.store, nil)
+	wager := et.placeWager(t, market.Selections[0].ID)
+	reservation, err := reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 30})
+	require.NoError(t, err)
+
+	settlement, err := et.events.SettleMarket(model.SettleMarketRequest{MarketID: market.ID, WinningSelectionID: market.Selections[0].ID})
+	require.NoError(t, err)
+	require.Len(t, settlement.Wagers, 1)
+	assert.Zero(t, settlement.Wagers[0].ReservedAmount)
+	assert.Equal(t, float64(70), settlement.Wagers[0].CurrentSellingPrice)
+
+	got, err := et.store.Reservations().GetByID(reservation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.RESERVATION_STATUS_RELEASED, got.Status)
+	_, err = reservations.ConfirmReservation(reservation.ID)
+	assert.ErrorIs(t, err, ErrReservationNotHeld)
+	gotWager, err := et.wagers.GetWager(wager.ID)
+	require.NoError(t, err)
+	assert.Equal(t, settlement.Wagers[0], *gotWager)
+}
+
+func Test_ReleaseReservation_ClosedWager(t *testing.T) {
+	et, _ := newEventTest(t)
+	reservations := NewReservationService(conf.GetDefaultConfig(), et.store, nil)
+	wager := et.placeWager(t, 0)
+	reservation, err := reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 30})
+	require.NoError(t, err)
+	wager.Status = model.WAGER_STATUS_LOST
+	require.NoError(t, et.store.Wagers().UpdateStatus(wager))
+
+	// the reserved face value is not put back on sale
+	_, err = reservations.ReleaseReservation(reservation.ID)
+	require.NoError(t, err)
+	got, err := et.wagers.GetWager(wager.ID)
+	require.NoError(t, err)
+	assert.Zero(t, got.ReservedAmount)
+	assert.Equal(t, float64(70), got.CurrentSellingPrice)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"math"
+	"wager/conf"
+	"wager/currency"
+	"wager/model"
+	"wager/repository"
+)
+
+// purchaseFees returns the fees of a purchase of buyingPrice on wager by buyer from
+// the seller of wager
+func purchaseFees(config conf.FeeConfig, store repository.Store, wager *model.Wager, buyer string, buyingPrice float64) (model.Fees, error) {
+	return tradeFees(config, store, wager, wager.Seller, buyer, buyingPrice)
+}
+
+// tradeFees returns the fees of a trade of price on wager between seller and buyer.
+// The maker fee is charged at the rate of the seller's tier and the taker fee at the
+// rate of the buyer's, users without a name are charged the base rates.
+func tradeFees(config conf.FeeConfig, store repository.Store, wager *model.Wager, seller string, buyer string, price float64) (model.Fees, error) {
+	c := currency.Of(wager.Currency)
+	makerRate := config.Maker.Percentage
+	takerRate := config.Taker.Percentage
+	if len(config.Tiers) > 0 && (buyer != "" || seller != "") {
+		volume, err := store.Purchases().TradingVolume(buyer, seller, wager.Currency)
+		if err != nil {
+			return model.Fees{}, err
+		}
+		if tier := config.Tier(volume.Sold); tier != nil && seller != "" {
+			makerRate = tier.MakerPercentage
+		}
+		if tier := config.Tier(volume.Bought); tier != nil && buyer != "" {
+			takerRate = tier.TakerPercentage
+		}
+	}
+
+	return model.Fees{
+		MakerFee:  fee(c, config.Maker, makerRate, price),
+		MakerRate: makerRate,
+		TakerFee:  fee(c, config.Taker, takerRate, price),
+		TakerRate: takerRate,
+	}, nil
+}
+
+// fee charges rate percent of buyingPrice within the bounds of schedule, rounded to
+// the minor unit of c
+func fee(c currency.Currency, schedule conf.FeeSchedule, rate float64, buyingPrice float64) float64 {
+	amount := buyingPrice * rate / 100
+	if schedule.Min > 0 {
+		amount = math.Max(amount, schedule.Min)
+	}
+	if schedule.Max > 0 {
+		amount = math.Min(amount, schedule.Max)
+	}
+	return math.Min(c.Round(amount), buyingPrice)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"testing"
+	"wager/conf"
+	"wager/currency"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Fee(t *testing.T) {
+	tests := []struct {
+		name        string
+		currency    string
+		schedule    conf.FeeSchedule
+		rate        float64
+		buyingPrice float64
+		fee         float64
+	}{
+		{name: "No fee", buyingPrice: 100},
+		{name: "Percentage", rate: 1.5, buyingPrice: 100, fee: 1.5},
+		{name: "Rounds to cents", rate: 1.5, buyingPrice: 10.1, fee: 0.15},
+		{name: "Minimum", schedule: conf.FeeSchedule{Min: 0.5}, rate: 1, buyingPrice: 10, fee: 0.5},
+		{name: "Above minimum", schedule: conf.FeeSchedule{Min: 0.5}, rate: 1, buyingPrice: 100, fee: 1},
+		{name: "Maximum", schedule: conf.FeeSchedule{Max: 5}, rate: 1, buyingPrice: 1000, fee: 5},
+		{name: "Minimum without percentage", schedule: conf.FeeSchedule{Min: 0.25}, buyingPrice: 100, fee: 0.25},
+		{name: "Never above the buying price", schedule: conf.FeeSchedule{Min: 1}, rate: 1, buyingPrice: 0.5, fee: 0.5},
+		{name: "Rounds to yen", currency: "JPY", rate: 1.5, buyingPrice: 1010, fee: 15},
+		{name: "Rounds to fils", currency: "KWD", rate: 1.5, buyingPrice: 10.1, fee: 0.152},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.fee, fee(currency.Of(tc.currency), tc.schedule, tc.rate, tc.buyingPrice))
+		})
+	}
+}
+
+func Test_BuyWager_Fees(t *testing.T) {
+	config := conf.GetDefaultConfig()
+	config.Fee = conf.FeeConfig{
+		Maker: conf.FeeSchedule{Percentage: 1},
+		Taker: conf.FeeSchedule{Percentage: 2, Min: 0.5},
+		Tiers: []conf.FeeTier{{Volume: 50, MakerPercentage: 0.5, TakerPercentage: 1}},
+	}
+	store := repository.NewMemoryStore()
+	wagerService := NewWagerService(config, store, nil)
+	stats := NewStatsService(config, store)
+
+	wager, err := wagerService.CreateWager(model.CreateWagerRequest{TotalWagerValue: 200, Odds: "2", SellingPercentage: 50, SellingPrice: 200, Seller: "bookie"})
+	require.NoError(t, err)
+
+	quote, err := wagerService.QuoteWager(model.BuyWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 60})
+	require.NoError(t, err)
+	assert.Equal(t, model.Fees{MakerFee: 0.6, MakerRate: 1, TakerFee: 1.2, TakerRate: 2}, quote.Fees)
+	assert.Equal(t, 61.2, quote.TotalCost)
+
+	purchase, err := wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 60})
+	require.NoError(t, err)
+	assert.Equal(t, quote.Fees, purchase.Fees)
+
+	// alice and bookie reached the tier with the first purchase
+	purchase, err = wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 10})
+	require.NoError(t, err)
+	assert.Equal(t, model.Fees{MakerFee: 0.05, MakerRate: 0.5, TakerFee: 0.5, TakerRate: 1}, purchase.Fees)
+
+	// anonymous buyers are charged the base rate
+	purchase, err = wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 10})
+	require.NoError(t, err)
+	assert.Equal(t, model.Fees{MakerFee: 0.05, MakerRate: 0.5, TakerFee: 0.5, TakerRate: 2}, purchase.Fees)
+
+	got, err := stats.GetStats(model.GetStatsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []model.FeeRevenue{{Currency: "USD", Purchases: 3, Volume: 80, MakerFees: 0.7, TakerFees: 2.2, Total: 2.9}}, got.FeeRevenue)
+
+	_, err = stats.GetStats(model.GetStatsRequest{From: 200, To: 100})
+	assert.Error(t, err)
+}
+
+func Test_BuyListing_Fees(t *testing.T) {
+	config := conf.GetDefaultConfig()
+	config.Fee = conf.FeeConfig{
+		Maker: conf.FeeSchedule{Percentage: 1},
+		Taker: conf.FeeSchedule{Percentage: 2},
+	}
+	store := repository.NewMemoryStore()
+	wagerService := NewWagerService(config, store, nil)
+	resale := NewResaleService(config, store)
+	stats := NewStatsService(config, store)
+
+	wager, err := wagerService.CreateWager(model.CreateWagerRequest{TotalWagerValue: 200, Odds: "2", SellingPercentage: 50, SellingPrice: 200, Seller: "bookie"})
+	require.NoError(t, err)
+	_, err = wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 40})
+	require.NoError(t, err)
+	positions, err := resale.GetPositionList(model.GetPositionListRequest{Filter: model.PositionFilter{Holder: "alice"}, Page: 1, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+
+	// the seller of the listing is the maker of the resale
+	listing, err := resale.CreateListing(model.CreateListingRequest{PositionID: positions[0].ID, Seller: "alice", FaceValue: 20, Price: 30})
+	require.NoError(t, err)
+	transfer, err := resale.BuyListing(model.BuyListingRequest{ListingID: listing.ID, Buyer: "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, model.Fees{MakerFee: 0.3, MakerRate: 1, TakerFee: 0.6, TakerRate: 2}, transfer.Fees)
+
+	transfers, err := resale.GetTransfers(transfer.PurchaseID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Transfer{*transfer}, transfers)
+
+	got, err := stats.GetStats(model.GetStatsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []model.FeeRevenue{{Currency: "USD", Purchases: 1, Transfers: 1, Volume: 70, MakerFees: 0.7, TakerFees: 1.4, Total: 2.1}}, got.FeeRevenue)
+}
+
+func Test_MergeRevenues(t *testing.T) {
+	purchases := []model.FeeRevenue{{Currency: "EUR", Purchases: 1, Volume: 10, MakerFees: 0.1}, {Currency: "USD", Purchases: 2, Volume: 20, TakerFees: 0.4}}
+	transfers := []model.FeeRevenue{{Currency: "GBP", Transfers: 1, Volume: 5}, {Currency: "USD", Transfers: 3, Volume: 30, MakerFees: 0.3}}
+	assert.Equal(t, []model.FeeRevenue{
+		{Currency: "EUR", Purchases: 1, Volume: 10, MakerFees: 0.1},
+		{Currency: "GBP", Transfers: 1, Volume: 5},
+		{Currency: "USD", Purchases: 2, Transfers: 3, Volume: 50, MakerFees: 0.3, TakerFees: 0.4},
+	}, mergeRevenues(purchases, transfers))
+	assert.Empty(t, mergeRevenues(nil, nil))
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"wager/currency"
+	"wager/model"
+)
+
+// sellingPriceAt is what the whole SellingPrice of wager costs at the unix time at,
+// an ask price set by the seller caps the price of the schedule
+func sellingPriceAt(wager *model.Wager, at int64) float64 {
+	price := scheduledPriceAt(wager, at)
+	if wager.AskPrice > 0 && wager.AskPrice < price {
+		return wager.AskPrice
+	}
+	return price
+}
+
+func scheduledPriceAt(wager *model.Wager, at int64) float64 {
+	if wager.PriceSchedule == "" {
+		return wager.SellingPrice
+	}
+
+	elapsed := at - wager.PlaceAt
+	if elapsed <= 0 {
+		return wager.SellingPrice
+	}
+	if elapsed >= wager.DecaySeconds {
+		return wager.FloorPrice
+	}
+
+	decayed := float64(elapsed) / float64(wager.DecaySeconds)
+	if wager.PriceSchedule == model.PRICE_SCHEDULE_STEP {
+		steps := float64(wager.DecaySteps)
+		decayed = math.Floor(decayed*steps) / steps
+	}
+	return roundMinor(wager, wager.SellingPrice-(wager.SellingPrice-wager.FloorPrice)*decayed)
+}
+
+// priceDecaying reports whether the price of wager is still going down at at
+func priceDecaying(wager *model.Wager, at int64) bool {
+	return wager.PriceSchedule != "" && at < wager.PlaceAt+wager.DecaySeconds
+}
+
+// repriced reports whether wager may cost less than its face value
+func repriced(wager *model.Wager) bool {
+	return wager.PriceSchedule != "" || wager.AskPrice > 0
+}
+
+// currentSellingPriceAt is what the unsold part of wager costs at at
+func currentSellingPriceAt(wager *model.Wager, at int64) float64 {
+	if !repriced(wager) {
+		return wager.CurrentSellingPrice
+	}
+	return roundMinor(wager, wager.CurrentSellingPrice*sellingPriceAt(wager, at)/wager.SellingPrice)
+}
+
+// faceValueAt is the part of the undecayed CurrentSellingPrice of wager bought by
+// buyingPrice at at. Buying all of currentPrice buys all that is left.
+func faceValueAt(wager *model.Wager, currentPrice float64, buyingPrice float64, at int64) float64 {
+	if !repriced(wager) {
+		return buyingPrice
+	}
+	if isRemainder(wager, currentPrice, buyingPrice) {
+		return wager.CurrentSellingPrice
+	}
+	return math.Min(roundMinor(wager, buyingPrice*wager.SellingPrice/sellingPriceAt(wager, at)), wager.CurrentSellingPrice)
+}
+
+// faceValuePriceAt is what faceValue of wager costs at at
+func faceValuePriceAt(wager *model.Wager, faceValue float64, at int64) float64 {
+	if !repriced(wager) {
+		return faceValue
+	}
+	return roundMinor(wager, faceValue*sellingPriceAt(wager, at)/wager.SellingPrice)
+}
+
+// priceWagers sets the CurrentSellingPrice of wagers to their price at at
+func priceWagers(wagers []model.Wager, at int64) {
+	for i := range wagers {
+		wagers[i].CurrentSellingPrice = currentSellingPriceAt(&wagers[i], at)
+	}
+}
+
+// toMinor converts amount, in the currency of wager, to the minor unit of the
+// currency like cents
+func toMinor(wager *model.Wager, amount float64) int64 {
+	return currency.Of(wager.Currency).ToMinor(amount)
+}
+
+// roundMinor rounds amount, in the currency of wager, to the minor unit
+func roundMinor(wager *model.Wager, amount float64) float64 {
+	return currency.Of(wager.Currency).Round(amount)
+}
+
+// payoutOf is what faceValue of wager pays if the wager wins. The selling price buys
+// SellingPercentage percent of the wager, a winning wager pays its total value
+// multiplied by the odds.
+func payoutOf(wager *model.Wager, faceValue float64) float64 {
+	share := faceValue / wager.SellingPrice * float64(wager.SellingPercentage)
+	return share / 100 * float64(wager.TotalWagerValue) * wager.Odds.Decimal()
+}
+
+// checkCurrency checks that a request on wager is in its currency: code, the
+// currency named by the request, must be the currency of the wager when it is given
+// and amounts must fit its minor unit
+func checkCurrency(wager *model.Wager, code string, amounts ...float64) error {
+	c := currency.Of(wager.Currency)
+	if code != "" && code != c.Code {
+		return fmt.Errorf("%w, which is in %v", ErrCurrencyMismatch, c.Code)
+	}
+	for _, amount := range amounts {
+		if !c.Exact(amount) {
+			return fmt.Errorf("%w: %v has %v decimal places", ErrInvalidAmount, c.Code, c.MinorUnits)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"wager/conf"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SellingPriceAt(t *testing.T) {
+	linear := &model.Wager{SellingPrice: 100, PlaceAt: 1000, PriceSchedule: model.PRICE_SCHEDULE_LINEAR, FloorPrice: 40, DecaySeconds: 600}
+	step := &model.Wager{SellingPrice: 100, PlaceAt: 1000, PriceSchedule: model.PRICE_SCHEDULE_STEP, FloorPrice: 40, DecaySeconds: 600, DecaySteps: 3}
+	fixed := &model.Wager{SellingPrice: 100, PlaceAt: 1000}
+	asked := &model.Wager{SellingPrice: 100, PlaceAt: 1000, PriceSchedule: model.PRICE_SCHEDULE_LINEAR, FloorPrice: 40, DecaySeconds: 600, AskPrice: 85}
+	yen := &model.Wager{SellingPrice: 10000, PlaceAt: 1000, PriceSchedule: model.PRICE_SCHEDULE_LINEAR, FloorPrice: 4000, DecaySeconds: 700, Currency: "JPY"}
+
+	tests := []struct {
+		name     string
+		wager    *model.Wager
+		at       int64
+		expected float64
+	}{
+		{name: "Fixed", wager: fixed, at: 5000, expected: 100},
+		{name: "Linear before placement", wager: linear, at: 900, expected: 100},
+		{name: "Linear at placement", wager: linear, at: 1000, expected: 100},
+		{name: "Linear halfway", wager: linear, at: 1300, expected: 70},
+		{name: "Linear rounded to cents", wager: linear, at: 1001, expected: 99.9},
+		{name: "Linear at floor", wager: linear, at: 1600, expected: 40},
+		{name: "Linear after decay", wager: linear, at: 9000, expected: 40},
+		{name: "Step before first step", wager: step, at: 1199, expected: 100},
+		{name: "Step first step", wager: step, at: 1200, expected: 80},
+		{name: "Step second step", wager: step, at: 1599, expected: 60},
+		{name: "Step at floor", wager: step, at: 1600, expected: 40},
+		{name: "Ask price below schedule", wager: asked, at: 1000, expected: 85},
+		{name: "Schedule below ask price", wager: asked, at: 1300, expected: 70},
+		{name: "Linear rounded to yen", wager: yen, at: 1001, expected: 9991},
+		{name: "Fixed with ask price", wager: &model.Wager{SellingPrice: 100, AskPrice: 60}, at: 5000, expected: 60},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, sellingPriceAt(test.wager, test.at))
+		})
+	}
+}
+
+func Test_DecayingPrice(t *testing.T) {
+	now := time.Now().UTC().Add(-time.Minute)
+	store := repository.NewMemoryStore()
+	ws := NewWagerService(conf.GetDefaultConfig(), store, nil).(*wagerService)
+	ws.now = func() time.Time { return now }
+	purchaseService := NewPurchaseService(conf.GetDefaultConfig(), store, nil)
+
+	wager, err := ws.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100,
+		PriceSchedule: model.PRICE_SCHEDULE_LINEAR, FloorPrice: 50, DecaySeconds: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(100), wager.CurrentSellingPrice)
+
+	// list and buy agree on the price at the same instant
+	now = now.Add(500 * time.Second)
+	list, err := ws.GetWagerList(model.GetWagerListRequest{Page: 1, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(75), list.Wagers[0].CurrentSellingPrice)
+
+	_, err = ws.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 75.01})
+	assert.ErrorIs(t, err, ErrBuyingPriceTooHigh)
+
+	quote, err := ws.QuoteWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 30})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(40), quote.FaceValue)
+	assert.Equal(t, float64(20), quote.SharePercentage)
+
+	purchase, err := ws.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 30})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(40), purchase.FaceValue)
+	assert.Equal(t, now.Unix(), purchase.BoughtAt)
+
+	got, err := ws.GetWager(wager.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(45), got.CurrentSellingPrice)
+	assert.Equal(t, float64(30), got.AmountSold.Float64)
+	assert.Equal(t, uint(40), got.PercentageSold.Uint)
+
+	// a refund gives back the part of the wager the purchase took
+	_, err = purchaseService.RefundPurchase(purchase.PurchaseID)
+	assert.NoError(t, err)
+	got, err = ws.GetWager(wager.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(75), got.CurrentSellingPrice)
+	assert.Equal(t, uint(0), got.PercentageSold.Uint)
+
+	// the remainder is sold out at the floor price
+	now = now.Add(time.Hour)
+	_, err = ws.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 50})
+	assert.NoError(t, err)
+	got, err = ws.GetWager(wager.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), got.CurrentSellingPrice)
+	assert.Equal(t, uint(100), got.PercentageSold.Uint)
+}
+
+func Test_DecayingPrice_Reservation(t *testing.T) {
+	now := time.Now().UTC()
+	config := conf.GetDefaultConfig()
+	store := repository.NewMemoryStore()
+	ws := NewWagerService(config, store, nil).(*wagerService)
+	ws.now = func() time.Time { return now }
+	rs := NewReservationService(config, store, nil).(*reservationService)
+	rs.now = func() time.Time { return now }
+
+	wager, err := ws.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100,
+		PriceSchedule: model.PRICE_SCHEDULE_STEP, FloorPrice: 20, DecaySeconds: 100, DecaySteps: 2})
+	assert.NoError(t, err)
+
+	now = now.Add(50 * time.Second)
+	reservation, err := rs.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 30})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(50), reservation.FaceValue)
+
+	got, err := ws.GetWager(wager.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(30), got.CurrentSellingPrice)
+	assert.Equal(t, float64(50), got.ReservedAmount)
+
+	// the price keeps decaying, the reservation keeps the price it was made at
+	now = now.Add(50 * time.Second)
+	got, err = ws.GetWager(wager.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(10), got.CurrentSellingPrice)
+
+	purchase, err := rs.ConfirmReservation(reservation.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(30), purchase.BuyingPrice)
+	assert.Equal(t, float64(50), purchase.FaceValue)
+
+	got, err = ws.GetWager(wager.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), got.ReservedAmount)
+	assert.Equal(t, uint(50), got.PercentageSold.Uint)
+}
+
+func Test_Currency(t *testing.T) {
+	store := repository.NewMemoryStore()
+	ws := NewWagerService(conf.GetDefaultConfig(), store, nil)
+
+	dollars, err := ws.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", dollars.Currency)
+	yen, err := ws.CreateWager(model.CreateWagerRequest{TotalWagerValue: 10000, Odds: "3", SellingPercentage: 50, SellingPrice: 9000, Currency: "JPY"})
+	assert.NoError(t, err)
+	assert.Equal(t, "JPY", yen.Currency)
+
+	_, err = ws.BuyWager(model.BuyWagerRequest{WagerID: yen.ID, BuyingPrice: 1000, Currency: "USD"})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = ws.QuoteWager(model.BuyWagerRequest{WagerID: yen.ID, BuyingPrice: 1000.5})
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	purchase, err := ws.BuyWager(model.BuyWagerRequest{WagerID: yen.ID, BuyingPrice: 1000, Currency: "JPY"})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1000), purchase.FaceValue)
+
+	list, err := ws.GetWagerList(model.GetWagerListRequest{Filter: model.WagerFilter{Currency: "JPY"}, Page: 1, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(list.Wagers))
+	assert.Equal(t, yen.ID, list.Wagers[0].ID)
+	assert.Equal(t, float64(8000), list.Wagers[0].CurrentSellingPrice)
+}
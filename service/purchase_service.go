@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"time"
+	"wager/conf"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrAlreadyRefunded     = errorcode.NewClientError("purchase is already refunded")
+	ErrRefundWindowExpired = errorcode.NewClientError("refund window has expired")
+	ErrWagerNotOpen        = errorcode.NewClientError("wager is not open")
+)
+
+type PurchaseService interface {
+	GetPurchaseList(request model.GetPurchaseListRequest) (*model.GetPurchaseListResponse, error)
+	GetPurchase(id uint) (*model.Purchase, error)
+	// RefundPurchase reverses a purchase made less than the refund window ago on a
+	// wager which is still open
+	RefundPurchase(id uint) (*model.Purchase, error)
+}
+
+type purchaseService struct {
+	config *conf.Config
+	store  repository.Store
+	// publisher receives the wagers of refunded purchases, it may be nil
+	publisher WagerPublisher
+}
+
+func NewPurchaseService(config *conf.Config, store repository.Store, publisher WagerPublisher) PurchaseService {
+	return &purchaseService{
+		config:    config,
+		store:     store,
+		publisher: publisher,
+	}
+}
+
+func (ps *purchaseService) GetPurchaseList(request model.GetPurchaseListRequest) (*model.GetPurchaseListResponse, error) {
+	if request.Page == 0 || request.Limit == 0 {
+		return nil, errors.New("invalid request params")
+	}
+	if request.Filter.BoughtTo != 0 && request.Filter.BoughtTo < request.Filter.BoughtFrom {
+		return nil, errors.New("bought_to must not be before bought_from")
+	}
+	offset := (request.Page - 1) * request.Limit
+
+	purchases, err := ps.store.Purchases().List(request.Filter, offset, request.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithField("count", len(purchases)).Info("getPurchaseList")
+	return &model.GetPurchaseListResponse{Purchases: purchases}, nil
+}
+
+func (ps *purchaseService) GetPurchase(id uint) (*model.Purchase, error) {
+	return ps.store.Purchases().GetByID(id)
+}
+
+func (ps *purchaseService) RefundPurchase(id uint) (*model.Purchase, error) {
+	var purchase *model.Purchase
+	var event model.WagerEvent
+	err := ps.store.RunInTx(func(store repository.Store) error {
+		pur, wager, err := ps.refundPurchase(store, id)
+		if err != nil {
+			return err
+		}
+		purchase = pur
+		event = wagerEvent(model.WAGER_EVENT_REFUND, *wager, pur.RefundedAt)
+		return nil
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("purchase_id", id).Error("cannot refund purchase")
+		return nil, err
+	}
+
+	publish(ps.publisher, event)
+	return purchase, nil
+}
+
+// refundPurchase refunds a purchase and returns it with the wager it left
+func (ps *purchaseService) refundPurchase(store repository.Store, id uint) (*model.Purchase, *model.Wager, error) {
+	// the wager is locked before the purchase, in the same order as BuyWager, so
+	// that a buy and a refund never wait on each other
+	purchase, err := store.Purchases().GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	wager, err := store.Wagers().GetByIDForUpdate(purchase.WagerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	purchase, err = store.Purchases().GetByIDForUpdate(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().UTC()
+	if purchase.RefundedAt != 0 {
+		return nil, nil, ErrAlreadyRefunded
+	}
+	if now.After(time.Unix(purchase.BoughtAt, 0).Add(ps.config.Purchase.RefundWindow)) {
+		return nil, nil, ErrRefundWindowExpired
+	}
+	if wager.Status != model.WAGER_STATUS_OPEN {
+		return nil, nil, ErrWagerNotOpen
+	}
+
+	if err := closePurchasePosition(store, purchase); err != nil {
+		return nil, nil, err
+	}
+
+	wager.CurrentSellingPrice += purchase.FaceValue
+	addAmountSold(wager, -purchase.BuyingPrice)
+	if err := store.Wagers().UpdateSale(wager); err != nil {
+		return nil, nil, err
+	}
+
+	purchase.RefundedAt = now.Unix()
+	if err := store.Purchases().Refund(purchase); err != nil {
+		return nil, nil, err
+	}
+
+	return purchase, wager, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"wager/conf"
+	"wager/model"
+	"wager/repository"
+	"wager/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMockPurchaseService(ctrl *gomock.Controller) (PurchaseService, *mockStore) {
+	_, m := NewMockWagerService(ctrl)
+	return NewPurchaseService(conf.GetDefaultConfig(), m.store, nil), m
+}
+
+func Test_GetPurchaseList_InvalidParams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	purchaseService, _ := newMockPurchaseService(ctrl)
+
+	requests := []model.GetPurchaseListRequest{
+		{Page: 0, Limit: 1},
+		{Page: 1, Limit: 0},
+		{Page: 1, Limit: 1, Filter: model.PurchaseFilter{BoughtFrom: 200, BoughtTo: 100}},
+	}
+
+	for _, req := range requests {
+		list, err := purchaseService.GetPurchaseList(req)
+		assert.Nil(t, list)
+		assert.Error(t, err)
+	}
+}
+
+func Test_GetPurchaseList_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	purchaseService, mockStore := newMockPurchaseService(ctrl)
+
+	filter := model.PurchaseFilter{WagerID: 1, BoughtFrom: 100}
+	mockStore.purchases.EXPECT().List(filter, 4, 2).Return([]model.Purchase{{PurchaseID: 5}, {PurchaseID: 6}}, nil)
+
+	list, err := purchaseService.GetPurchaseList(model.GetPurchaseListRequest{Filter: filter, Page: 3, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(list.Purchases))
+	assert.Equal(t, uint(5), list.Purchases[0].PurchaseID)
+}
+
+func Test_GetPurchaseList_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	purchaseService, mockStore := newMockPurchaseService(ctrl)
+
+	mockStore.purchases.EXPECT().List(model.PurchaseFilter{}, 0, 10).Return(nil, errors.New("custom error"))
+	list, err := purchaseService.GetPurchaseList(model.GetPurchaseListRequest{Page: 1, Limit: 10})
+	assert.Nil(t, list)
+	assert.EqualError(t, err, "custom error")
+}
+
+func Test_RefundPurchase(t *testing.T) {
+	config := conf.GetDefaultConfig()
+	store := repository.NewMemoryStore()
+	wagerService := NewWagerService(config, store, nil)
+	purchaseService := NewPurchaseService(config, store, nil)
+
+	wager, err := wagerService.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100})
+	assert.NoError(t, err)
+	first, err := wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 30})
+	assert.NoError(t, err)
+	_, err = wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 20})
+	assert.NoError(t, err)
+
+	refunded, err := purchaseService.RefundPurchase(first.PurchaseID)
+	assert.NoError(t, err)
+	assert.NotZero(t, refunded.RefundedAt)
+
+	wager, err = wagerService.GetWager(wager.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(80), wager.CurrentSellingPrice)
+	assert.Equal(t, float64(20), wager.AmountSold.Float64)
+	assert.Equal(t, utils.NewNullUint(20), wager.PercentageSold)
+
+	_, err = purchaseService.RefundPurchase(first.PurchaseID)
+	assert.ErrorIs(t, err, ErrAlreadyRefunded)
+
+	_, err = purchaseService.RefundPurchase(100)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func Test_RefundPurchase_Rejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	purchaseService, mockStore := newMockPurchaseService(ctrl)
+	now := time.Now().UTC().Unix()
+
+	tests := []struct {
+		name     string
+		wager    *model.Wager
+		purchase *model.Purchase
+		err      error
+	}{
+		{
+			name:     "Refund window expired",
+			wager:    &model.Wager{ID: 1, Status: model.WAGER_STATUS_OPEN},
+			purchase: &model.Purchase{PurchaseID: 2, WagerID: 1, BoughtAt: now - 3600},
+			err:      ErrRefundWindowExpired,
+		},
+		{
+			name:     "Wager not open",
+			wager:    &model.Wager{ID: 1, Status: "settled"},
+			purchase: &model.Purchase{PurchaseID: 2, WagerID: 1, BoughtAt: now},
+			err:      ErrWagerNotOpen,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// no UpdateSale or Refund is expected
+			mockStore.purchases.EXPECT().GetByID(test.purchase.PurchaseID).Return(test.purchase, nil)
+			mockStore.wagers.EXPECT().GetByIDForUpdate(test.wager.ID).Return(test.wager, nil)
+			mockStore.purchases.EXPECT().GetByIDForUpdate(test.purchase.PurchaseID).Return(test.purchase, nil)
+
+			purchase, err := purchaseService.RefundPurchase(test.purchase.PurchaseID)
+			assert.Nil(t, purchase)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"errors"
+	"time"
+	"wager/conf"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrNotHolder       = errorcode.NewClientError("seller does not hold the position")
+	ErrListingTooLarge = errorcode.NewClientError("listing face value must be equal or smaller than the unlisted face value of the position")
+	ErrListingNotOpen  = errorcode.NewClientError("listing is not open")
+	ErrBuyerIsSeller   = errorcode.NewClientError("buyer must not be the seller of the listing")
+	ErrPurchaseResold  = errorcode.NewClientError("purchase was resold or is listed for resale")
+)
+
+// ResaleService lets holders resell all or part of their positions at their own
+// price. A sold listing moves its face value to a new position of the buyer, under
+// the same original purchase, and records the transfer.
+type ResaleService interface {
+	GetPositionList(request model.GetPositionListRequest) ([]model.Position, error)
+	// CreateListing offers part of a position, which stays with its holder until the
+	// listing is sold
+	CreateListing(request model.CreateListingRequest) (*model.Listing, error)
+	GetListingList(request model.GetListingListRequest) ([]model.Listing, error)
+	BuyListing(request model.BuyListingRequest) (*model.Transfer, error)
+	// CancelListing is refused with ErrNotHolder unless the seller of request holds the
+	// listed position
+	CancelListing(request model.CancelListingRequest) (*model.Listing, error)
+	// GetTransfers returns the ownership history of an original purchase
+	GetTransfers(purchaseID uint) ([]model.Transfer, error)
+}
+
+type resaleService struct {
+	config *conf.Config
+	store  repository.Store
+	now    func() time.Time
+}
+
+func NewResaleService(config *conf.Config, store repository.Store) ResaleService {
+	return &resaleService{
+		config: config,
+		store:  store,
+		now:    time.Now,
+	}
+}
+
+func (rs *resaleService) GetPositionList(request model.GetPositionListRequest) ([]model.Position, error) {
+	if request.Page == 0 || request.Limit == 0 {
+		return nil, errors.New("invalid request params")
+	}
+	offset := (request.Page - 1) * request.Limit
+
+	return rs.store.Positions().List(request.Filter, offset, request.Limit)
+}
+
+func (rs *resaleService) CreateListing(request model.CreateListingRequest) (*model.Listing, error) {
+	var listing *model.Listing
+	err := rs.store.RunInTx(func(store repository.Store) error {
+		wager, position, err := lockPosition(store, request.PositionID)
+		if err != nil {
+			return err
+		}
+		if wager.Status != model.WAGER_STATUS_OPEN {
+			return ErrWagerNotOpen
+		}
+		if position.Holder != request.Seller {
+			return ErrNotHolder
+		}
+		if err := checkCurrency(wager, "", request.FaceValue, request.Price); err != nil {
+			return err
+		}
+		if toMinor(wager, request.FaceValue) > toMinor(wager, position.FaceValue-position.ListedFaceValue) {
+			return ErrListingTooLarge
+		}
+
+		position.ListedFaceValue = roundMinor(wager, position.ListedFaceValue+request.FaceValue)
+		if err := store.Positions().Update(position); err != nil {
+			return err
+		}
+
+		listing = &model.Listing{
+			PositionID: position.ID,
+			PurchaseID: position.PurchaseID,
+			WagerID:    position.WagerID,
+			Seller:     request.Seller,
+			FaceValue:  request.FaceValue,
+			Price:      request.Price,
+			Status:     model.LISTING_STATUS_OPEN,
+			CreatedAt:  rs.now().UTC().Unix(),
+		}
+		return store.Listings().Create(listing)
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("position_id", request.PositionID).Error("cannot create listing")
+		return nil, err
+	}
+
+	return listing, nil
+}
+
+func (rs *resaleService) GetListingList(request model.GetListingListRequest) ([]model.Listing, error) {
+	if request.Page == 0 || request.Limit == 0 {
+		return nil, errors.New("invalid request params")
+	}
+	offset := (request.Page - 1) * request.Limit
+
+	return rs.store.Listings().ListOpen(request.WagerID, offset, request.Limit)
+}
+
+func (rs *resaleService) BuyListing(request model.BuyListingRequest) (*model.Transfer, error) {
+	var transfer *model.Transfer
+	err := rs.store.RunInTx(func(store repository.Store) error {
+		wager, listing, position, err := lockListing(store, request.ListingID)
+		if err != nil {
+			return err
+		}
+		if wager.Status != model.WAGER_STATUS_OPEN {
+			return ErrWagerNotOpen
+		}
+		if request.Buyer == listing.Seller {
+			return ErrBuyerIsSeller
+		}
+		if err := checkCurrency(wager, request.Currency); err != nil {
+			return err
+		}
+		if err := checkBuyerLimits(rs.config.BuyerLimit, store, wager, request.Buyer, listing.FaceValue); err != nil {
+			return err
+		}
+		fees, err := tradeFees(rs.config.Fee, store, wager, listing.Seller, request.Buyer, listing.Price)
+		if err != nil {
+			return err
+		}
+
+		now := rs.now().UTC().Unix()
+		position.FaceValue = roundMinor(wager, position.FaceValue-listing.FaceValue)
+		position.ListedFaceValue = roundMinor(wager, position.ListedFaceValue-listing.FaceValue)
+		if err := store.Positions().Update(position); err != nil {
+			return err
+		}
+
+		bought := &model.Position{
+			PurchaseID: listing.PurchaseID,
+			WagerID:    listing.WagerID,
+			Holder:     request.Buyer,
+			FaceValue:  listing.FaceValue,
+			AcquiredAt: now,
+		}
+		if err := store.Positions().Create(bought); err != nil {
+			return err
+		}
+
+		transfer = &model.Transfer{
+			PurchaseID:     listing.PurchaseID,
+			ListingID:      listing.ID,
+			FromPositionID: position.ID,
+			ToPositionID:   bought.ID,
+			Seller:         listing.Seller,
+			Buyer:          request.Buyer,
+			FaceValue:      listing.FaceValue,
+			Price:          listing.Price,
+			Fees:           fees,
+			TransferredAt:  now,
+		}
+		if err := store.Transfers().Create(transfer); err != nil {
+			return err
+		}
+
+		listing.Status = model.LISTING_STATUS_SOLD
+		listing.TransferID = transfer.ID
+		return store.Listings().Update(listing)
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("listing_id", request.ListingID).Error("cannot buy listing")
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (rs *resaleService) CancelListing(request model.CancelListingRequest) (*model.Listing, error) {
+	var listing *model.Listing
+	err := rs.store.RunInTx(func(store repository.Store) error {
+		wager, lst, position, err := lockListing(store, request.ListingID)
+		if err != nil {
+			return err
+		}
+		if position.Holder != request.Seller {
+			return ErrNotHolder
+		}
+
+		position.ListedFaceValue = roundMinor(wager, position.ListedFaceValue-lst.FaceValue)
+		if err := store.Positions().Update(position); err != nil {
+			return err
+		}
+
+		lst.Status = model.LISTING_STATUS_CANCELLED
+		listing = lst
+		return store.Listings().Update(listing)
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("listing_id", request.ListingID).Error("cannot cancel listing")
+		return nil, err
+	}
+
+	return listing, nil
+}
+
+func (rs *resaleService) GetTransfers(purchaseID uint) ([]model.Transfer, error) {
+	if _, err := rs.store.Purchases().GetByID(purchaseID); err != nil {
+		return nil, err
+	}
+	return rs.store.Transfers().ListByPurchase(purchaseID)
+}
+
+// createPurchase charges the fees of purchase on wager, creates it and opens the
+// position of its buyer
+func createPurchase(store repository.Store, config conf.FeeConfig, wager *model.Wager, purchase *model.Purchase) error {
+	fees, err := purchaseFees(config, store, wager, purchase.Buyer, purchase.BuyingPrice)
+	if err != nil {
+		return err
+	}
+	purchase.Fees = fees
+	if err := store.Purchases().Create(purchase); err != nil {
+		return err
+	}
+
+	return store.Positions().Create(&model.Position{
+		PurchaseID: purchase.PurchaseID,
+		WagerID:    purchase.WagerID,
+		Holder:     purchase.Buyer,
+		FaceValue:  purchase.FaceValue,
+		AcquiredAt: purchase.BoughtAt,
+	})
+}
+
+// closePurchasePosition empties the position of a purchase being refunded, which is
+// only possible while its buyer still holds all of it and none of it is listed
+func closePurchasePosition(store repository.Store, purchase *model.Purchase) error {
+	positions, err := store.Positions().ListByPurchase(purchase.PurchaseID)
+	if err != nil {
+		return err
+	}
+	// purchases refunded before positions existed have none
+	if len(positions) == 0 {
+		return nil
+	}
+	if len(positions) > 1 || positions[0].ListedFaceValue > 0 {
+		return ErrPurchaseResold
+	}
+
+	position, err := store.Positions().GetByIDForUpdate(positions[0].ID)
+	if err != nil {
+		return err
+	}
+	if position.ListedFaceValue > 0 {
+		return ErrPurchaseResold
+	}
+	position.FaceValue = 0
+	return store.Positions().Update(position)
+}
+
+// lockPosition locks the wager of a position, then the position, in the same order
+// as BuyWager locks the wager before writing the purchase
+func lockPosition(store repository.Store, id uint) (*model.Wager, *model.Position, error) {
+	position, err := store.Positions().GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	wager, err := store.Wagers().GetByIDForUpdate(position.WagerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	position, err = store.Positions().GetByIDForUpdate(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return wager, position, nil
+}
+
+// lockListing locks the wager of an open listing, then the listing and the position
+// it sells
+func lockListing(store repository.Store, id uint) (*model.Wager, *model.Listing, *model.Position, error) {
+	listing, err := store.Listings().GetByID(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	wager, err := store.Wagers().GetByIDForUpdate(listing.WagerID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	listing, err = store.Listings().GetByIDForUpdate(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if listing.Status != model.LISTING_STATUS_OPEN {
+		return nil, nil, nil, ErrListingNotOpen
+	}
+
+	position, err := store.Positions().GetByIDForUpdate(listing.PositionID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return wager, listing, position, nil
+}
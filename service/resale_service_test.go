@@ -0,0 +1,153 @@
+package service
+
+import (
+	"testing"
+	"wager/conf"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type resaleTest struct {
+	wagers    WagerService
+	purchases PurchaseService
+	resale    ResaleService
+}
+
+func newResaleTest(t *testing.T) (*resaleTest, *model.Purchase) {
+	config := conf.GetDefaultConfig()
+	store := repository.NewMemoryStore()
+	rt := &resaleTest{
+		wagers:    NewWagerService(config, store, nil),
+		purchases: NewPurchaseService(config, store, nil),
+		resale:    NewResaleService(config, store),
+	}
+
+	wager, err := rt.wagers.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100})
+	require.NoError(t, err)
+	purchase, err := rt.wagers.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 40})
+	require.NoError(t, err)
+	return rt, purchase
+}
+
+func (rt *resaleTest) position(t *testing.T, holder string) model.Position {
+	positions, err := rt.resale.GetPositionList(model.GetPositionListRequest{Filter: model.PositionFilter{Holder: holder}, Page: 1, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	return positions[0]
+}
+
+func Test_CreateListing(t *testing.T) {
+	rt, purchase := newResaleTest(t)
+	position := rt.position(t, "alice")
+	assert.Equal(t, purchase.PurchaseID, position.PurchaseID)
+	assert.Equal(t, purchase.FaceValue, position.FaceValue)
+
+	tests := []struct {
+		name    string
+		request model.CreateListingRequest
+		err     error
+	}{
+		{name: "Not the holder", request: model.CreateListingRequest{PositionID: position.ID, Seller: "bob", FaceValue: 10, Price: 10}, err: ErrNotHolder},
+		{name: "Larger than the position", request: model.CreateListingRequest{PositionID: position.ID, Seller: "alice", FaceValue: 50, Price: 10}, err: ErrListingTooLarge},
+		{name: "Unknown position", request: model.CreateListingRequest{PositionID: 100, Seller: "alice", FaceValue: 10, Price: 10}, err: repository.ErrNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := rt.resale.CreateListing(tc.request)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	listing, err := rt.resale.CreateListing(model.CreateListingRequest{PositionID: position.ID, Seller: "alice", FaceValue: 30, Price: 33})
+	require.NoError(t, err)
+	assert.Equal(t, model.LISTING_STATUS_OPEN, listing.Status)
+	assert.Equal(t, purchase.PurchaseID, listing.PurchaseID)
+
+	// the listed part can't be listed twice
+	_, err = rt.resale.CreateListing(model.CreateListingRequest{PositionID: position.ID, Seller: "alice", FaceValue: 20, Price: 20})
+	assert.ErrorIs(t, err, ErrListingTooLarge)
+
+	listings, err := rt.resale.GetListingList(model.GetListingListRequest{WagerID: purchase.WagerID, Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []model.Listing{*listing}, listings)
+
+	_, err = rt.resale.CancelListing(model.CancelListingRequest{ListingID: listing.ID, Seller: "bob"})
+	assert.ErrorIs(t, err, ErrNotHolder)
+
+	cancelled, err := rt.resale.CancelListing(model.CancelListingRequest{ListingID: listing.ID, Seller: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, model.LISTING_STATUS_CANCELLED, cancelled.Status)
+	assert.Zero(t, rt.position(t, "alice").ListedFaceValue)
+
+	_, err = rt.resale.CancelListing(model.CancelListingRequest{ListingID: listing.ID, Seller: "alice"})
+	assert.ErrorIs(t, err, ErrListingNotOpen)
+}
+
+func Test_BuyListing(t *testing.T) {
+	rt, purchase := newResaleTest(t)
+	position := rt.position(t, "alice")
+
+	listing, err := rt.resale.CreateListing(model.CreateListingRequest{PositionID: position.ID, Seller: "alice", FaceValue: 15, Price: 18})
+	require.NoError(t, err)
+
+	_, err = rt.resale.BuyListing(model.BuyListingRequest{ListingID: listing.ID, Buyer: "alice"})
+	assert.ErrorIs(t, err, ErrBuyerIsSeller)
+
+	transfer, err := rt.resale.BuyListing(model.BuyListingRequest{ListingID: listing.ID, Buyer: "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, position.ID, transfer.FromPositionID)
+	assert.Equal(t, float64(15), transfer.FaceValue)
+	assert.Equal(t, float64(18), transfer.Price)
+
+	_, err = rt.resale.BuyListing(model.BuyListingRequest{ListingID: listing.ID, Buyer: "carol"})
+	assert.ErrorIs(t, err, ErrListingNotOpen)
+
+	assert.Equal(t, float64(25), rt.position(t, "alice").FaceValue)
+	bought := rt.position(t, "bob")
+	assert.Equal(t, transfer.ToPositionID, bought.ID)
+	assert.Equal(t, purchase.PurchaseID, bought.PurchaseID)
+	assert.Equal(t, float64(15), bought.FaceValue)
+
+	// bob resells all of his position to carol
+	listing, err = rt.resale.CreateListing(model.CreateListingRequest{PositionID: bought.ID, Seller: "bob", FaceValue: 15, Price: 20})
+	require.NoError(t, err)
+	_, err = rt.resale.BuyListing(model.BuyListingRequest{ListingID: listing.ID, Buyer: "carol"})
+	require.NoError(t, err)
+	assert.Equal(t, float64(15), rt.position(t, "carol").FaceValue)
+
+	positions, err := rt.resale.GetPositionList(model.GetPositionListRequest{Filter: model.PositionFilter{Holder: "bob"}, Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+
+	transfers, err := rt.resale.GetTransfers(purchase.PurchaseID)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	assert.Equal(t, []string{"alice", "bob"}, []string{transfers[0].Seller, transfers[1].Seller})
+	assert.Equal(t, []string{"bob", "carol"}, []string{transfers[0].Buyer, transfers[1].Buyer})
+
+	_, err = rt.resale.GetTransfers(100)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func Test_RefundPurchase_Resold(t *testing.T) {
+	rt, purchase := newResaleTest(t)
+	position := rt.position(t, "alice")
+
+	listing, err := rt.resale.CreateListing(model.CreateListingRequest{PositionID: position.ID, Seller: "alice", FaceValue: 10, Price: 10})
+	require.NoError(t, err)
+	_, err = rt.purchases.RefundPurchase(purchase.PurchaseID)
+	assert.ErrorIs(t, err, ErrPurchaseResold)
+
+	_, err = rt.resale.CancelListing(model.CancelListingRequest{ListingID: listing.ID, Seller: "alice"})
+	require.NoError(t, err)
+	refunded, err := rt.purchases.RefundPurchase(purchase.PurchaseID)
+	require.NoError(t, err)
+	assert.NotZero(t, refunded.RefundedAt)
+
+	positions, err := rt.resale.GetPositionList(model.GetPositionListRequest{Filter: model.PositionFilter{WagerID: purchase.WagerID}, Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"wager/conf"
+	errorcode "wager/error_code"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrReservationNotHeld = errorcode.NewClientError("reservation is not held")
+	ErrReservationExpired = errorcode.NewClientError("reservation has expired")
+	ErrTTLTooLong         = errorcode.NewClientError("ttl is too long")
+)
+
+// ReservationService holds part of a wager for a buyer while the payment is made
+// elsewhere. The held amount is taken off the current selling price of the wager,
+// so BuyWager only sees the unreserved part.
+type ReservationService interface {
+	ReserveWager(request model.ReserveWagerRequest) (*model.Reservation, error)
+	// ConfirmReservation turns a held reservation into a purchase
+	ConfirmReservation(id uint) (*model.Purchase, error)
+	// ReleaseReservation gives the held amount back to the wager
+	ReleaseReservation(id uint) (*model.Reservation, error)
+	// ReleaseExpired releases the held reservations which have expired and returns
+	// them, with the ones released before a failure
+	ReleaseExpired() ([]model.Reservation, error)
+}
+
+type reservationService struct {
+	config *conf.Config
+	store  repository.Store
+	// publisher receives the wagers reserved, released and bought, it may be nil
+	publisher WagerPublisher
+	now       func() time.Time
+}
+
+func NewReservationService(config *conf.Config, store repository.Store, publisher WagerPublisher) ReservationService {
+	return &reservationService{
+		config:    config,
+		store:     store,
+		publisher: publisher,
+		now:       time.Now,
+	}
+}
+
+func (rs *reservationService) ReserveWager(request model.ReserveWagerRequest) (*model.Reservation, error) {
+	ttl := rs.config.Reservation.TTL
+	if request.TTLSeconds > 0 {
+		ttl = time.Duration(request.TTLSeconds) * time.Second
+	}
+	if ttl > rs.config.Reservation.MaxTTL {
+		return nil, fmt.Errorf("%w, it must be at most %v seconds", ErrTTLTooLong, int(rs.config.Reservation.MaxTTL.Seconds()))
+	}
+
+	now := rs.now().UTC()
+	reservation := &model.Reservation{
+		WagerID:     request.WagerID,
+		Buyer:       request.Buyer,
+		BuyingPrice: request.BuyingPrice,
+		Status:      model.RESERVATION_STATUS_HELD,
+		CreatedAt:   now.Unix(),
+		ExpiresAt:   now.Add(ttl).Unix(),
+	}
+
+	var event model.WagerEvent
+	err := rs.store.RunInTx(func(store repository.Store) error {
+		wager, err := store.Wagers().GetByIDForUpdate(request.WagerID)
+		if err != nil {
+			return err
+		}
+		if wager.Status != model.WAGER_STATUS_OPEN {
+			return ErrWagerNotOpen
+		}
+		if err := checkCurrency(wager, request.Currency, request.BuyingPrice); err != nil {
+			return err
+		}
+
+		currentPrice := currentSellingPriceAt(wager, reservation.CreatedAt)
+		if currentPrice < request.BuyingPrice {
+			return ErrBuyingPriceTooHigh
+		}
+		if err := checkPurchaseSize(wager, currentPrice, request.BuyingPrice); err != nil {
+			return err
+		}
+		reservation.FaceValue = faceValueAt(wager, currentPrice, request.BuyingPrice, reservation.CreatedAt)
+		wager.CurrentSellingPrice -= reservation.FaceValue
+		wager.ReservedAmount += reservation.FaceValue
+		if err := store.Wagers().UpdateSale(wager); err != nil {
+			return err
+		}
+
+		event = wagerEvent(model.WAGER_EVENT_RESERVATION, *wager, reservation.CreatedAt)
+		return store.Reservations().Create(reservation)
+	})
+	if err != nil {
+		logrus.WithError(err).Error("cannot reserve wager")
+		return nil, err
+	}
+
+	publish(rs.publisher, event)
+	return reservation, nil
+}
+
+func (rs *reservationService) ConfirmReservation(id uint) (*model.Purchase, error) {
+	var purchase *model.Purchase
+	var event model.WagerEvent
+	err := rs.store.RunInTx(func(store repository.Store) error {
+		now := rs.now().UTC().Unix()
+		wager, reservation, err := lockReservation(store, id)
+		if err != nil {
+			return err
+		}
+		if reservation.ExpiresAt <= now {
+			return ErrReservationExpired
+		}
+		if wager.Status != model.WAGER_STATUS_OPEN {
+			return ErrWagerNotOpen
+		}
+		if err := checkBuyerLimits(rs.config.BuyerLimit, store, wager, reservation.Buyer, reservation.FaceValue); err != nil {
+			return err
+		}
+
+		wager.ReservedAmount -= reservation.FaceValue
+		addAmountSold(wager, reservation.BuyingPrice)
+		if err := store.Wagers().UpdateSale(wager); err != nil {
+			return err
+		}
+
+		purchase = &model.Purchase{
+			WagerID:     reservation.WagerID,
+			Buyer:       reservation.Buyer,
+			BuyingPrice: reservation.BuyingPrice,
+			FaceValue:   reservation.FaceValue,
+			BoughtAt:    now,
+		}
+		if err := createPurchase(store, rs.config.Fee, wager, purchase); err != nil {
+			return err
+		}
+
+		reservation.Status = model.RESERVATION_STATUS_CONFIRMED
+		reservation.PurchaseID = purchase.PurchaseID
+		event = wagerEvent(model.WAGER_EVENT_PURCHASE, *wager, now)
+		return store.Reservations().Update(reservation)
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("reservation_id", id).Error("cannot confirm reservation")
+		return nil, err
+	}
+
+	publish(rs.publisher, event)
+	return purchase, nil
+}
+
+func (rs *reservationService) ReleaseReservation(id uint) (*model.Reservation, error) {
+	var reservation *model.Reservation
+	var event model.WagerEvent
+	err := rs.store.RunInTx(func(store repository.Store) error {
+		wager, res, err := releaseReservation(store, id, model.RESERVATION_STATUS_RELEASED)
+		if err != nil {
+			return err
+		}
+		reservation = res
+		event = wagerEvent(model.WAGER_EVENT_RESERVATION, *wager, rs.now().UTC().Unix())
+		return nil
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("reservation_id", id).Error("cannot release reservation")
+		return nil, err
+	}
+
+	publish(rs.publisher, event)
+	return reservation, nil
+}
+
+func (rs *reservationService) ReleaseExpired() ([]model.Reservation, error) {
+	released := []model.Reservation{}
+	for {
+		now := rs.now().UTC().Unix()
+		expired, err := rs.store.UsePrimary().Reservations().ListExpired(now, rs.config.Reservation.SweepBatchSize)
+		if err != nil {
+			return released, err
+		}
+
+		for _, reservation := range expired {
+			var event model.WagerEvent
+			var expiredReservation *model.Reservation
+			err := rs.store.RunInTx(func(store repository.Store) error {
+				wager, updated, err := releaseReservation(store, reservation.ID, model.RESERVATION_STATUS_EXPIRED)
+				if err != nil {
+					return err
+				}
+				expiredReservation = updated
+				event = wagerEvent(model.WAGER_EVENT_RESERVATION, *wager, now)
+				return nil
+			})
+			// the reservation may have been confirmed or released since it was listed
+			if errors.Is(err, ErrReservationNotHeld) {
+				continue
+			}
+			if err != nil {
+				return released, err
+			}
+			publish(rs.publisher, event)
+			released = append(released, *expiredReservation)
+		}
+
+		if len(expired) < rs.config.Reservation.SweepBatchSize {
+			return released, nil
+		}
+	}
+}
+
+// lockReservation locks the wager of a held reservation, then the reservation, in
+// the same order as BuyWager locks the wager before writing the purchase
+func lockReservation(store repository.Store, id uint) (*model.Wager, *model.Reservation, error) {
+	reservation, err := store.Reservations().GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	wager, err := store.Wagers().GetByIDForUpdate(reservation.WagerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reservation, err = store.Reservations().GetByIDForUpdate(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if reservation.Status != model.RESERVATION_STATUS_HELD {
+		return nil, nil, ErrReservationNotHeld
+	}
+	return wager, reservation, nil
+}
+
+// releaseReservation gives a held reservation back to its wager with status and
+// returns the wager it left
+func releaseReservation(store repository.Store, id uint, status string) (*model.Wager, *model.Reservation, error) {
+	wager, reservation, err := lockReservation(store, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the face value of a settled wager is not put back on sale
+	if wager.Status == model.WAGER_STATUS_OPEN {
+		wager.CurrentSellingPrice += reservation.FaceValue
+	}
+	wager.ReservedAmount -= reservation.FaceValue
+	if err := store.Wagers().UpdateSale(wager); err != nil {
+		return nil, nil, err
+	}
+
+	reservation.Status = status
+	if err := store.Reservations().Update(reservation); err != nil {
+		return nil, nil, err
+	}
+	return wager, reservation, nil
+}
+
+// releaseHeldReservations releases the held reservations of a wager being settled,
+// which the caller locked, without putting their face value back on sale
+func releaseHeldReservations(store repository.Store, wager *model.Wager) error {
+	released := false
+	// released reservations leave the held ones, the first page is always the next one
+	for {
+		reservations, err := store.Reservations().ListHeld(wager.ID, SETTLE_BATCH_SIZE)
+		if err != nil {
+			return err
+		}
+		if len(reservations) == 0 {
+			break
+		}
+		for i := range reservations {
+			reservation := &reservations[i]
+			reservation.Status = model.RESERVATION_STATUS_RELEASED
+			if err := store.Reservations().Update(reservation); err != nil {
+				return err
+			}
+			wager.ReservedAmount -= reservation.FaceValue
+			released = true
+		}
+	}
+
+	if !released {
+		return nil
+	}
+	return store.Wagers().UpdateSale(wager)
+}
+
+// SweepReservations releases expired reservations every interval until ctx is done
+func SweepReservations(ctx context.Context, rs ReservationService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			released, err := rs.ReleaseExpired()
+			if err != nil {
+				logrus.WithError(err).Error("failed to release expired reservations")
+			}
+			if len(released) > 0 {
+				logrus.WithField("released", len(released)).Info("Released expired reservations")
+			}
+		}
+	}
+}
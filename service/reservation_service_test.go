@@ -0,0 +1,142 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"wager/conf"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reservationTest struct {
+	wagers       WagerService
+	reservations *reservationService
+	now          time.Time
+}
+
+func newReservationTest(t *testing.T) (*reservationTest, *model.Wager) {
+	config := conf.GetDefaultConfig()
+	config.Reservation.SweepBatchSize = 1
+	store := repository.NewMemoryStore()
+	rt := &reservationTest{
+		wagers:       NewWagerService(config, store, nil),
+		reservations: NewReservationService(config, store, nil).(*reservationService),
+		now:          time.Now(),
+	}
+	rt.reservations.now = func() time.Time { return rt.now }
+
+	wager, err := rt.wagers.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, MinPurchase: 1})
+	assert.NoError(t, err)
+	return rt, wager
+}
+
+func (rt *reservationTest) getWager(t *testing.T, id uint) *model.Wager {
+	wager, err := rt.wagers.GetWager(id)
+	assert.NoError(t, err)
+	return wager
+}
+
+func Test_ReserveWager(t *testing.T) {
+	rt, wager := newReservationTest(t)
+
+	reservation, err := rt.reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 70})
+	assert.NoError(t, err)
+	assert.Equal(t, model.RESERVATION_STATUS_HELD, reservation.Status)
+	assert.Equal(t, rt.now.Add(2*time.Minute).Unix(), reservation.ExpiresAt)
+
+	got := rt.getWager(t, wager.ID)
+	assert.Equal(t, float64(30), got.CurrentSellingPrice)
+	assert.Equal(t, float64(70), got.ReservedAmount)
+	assert.False(t, got.AmountSold.Valid)
+	_, err = rt.reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, BuyingPrice: 0.5})
+	assert.ErrorIs(t, err, ErrInvalidPurchaseSize)
+
+	// buys only see the unreserved part
+	_, err = rt.wagers.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 40})
+	assert.ErrorIs(t, err, ErrBuyingPriceTooHigh)
+	_, err = rt.reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, BuyingPrice: 40})
+	assert.ErrorIs(t, err, ErrBuyingPriceTooHigh)
+	_, err = rt.wagers.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 30})
+	assert.NoError(t, err)
+
+	_, err = rt.reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, BuyingPrice: 1, TTLSeconds: 3600})
+	assert.ErrorIs(t, err, ErrTTLTooLong)
+	_, err = rt.reservations.ReserveWager(model.ReserveWagerRequest{WagerID: 100, BuyingPrice: 1})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func Test_ConfirmReservation(t *testing.T) {
+	rt, wager := newReservationTest(t)
+	reservation, err := rt.reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 25})
+	assert.NoError(t, err)
+
+	purchase, err := rt.reservations.ConfirmReservation(reservation.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", purchase.Buyer)
+	assert.Equal(t, float64(25), purchase.BuyingPrice)
+
+	got := rt.getWager(t, wager.ID)
+	assert.Equal(t, float64(75), got.CurrentSellingPrice)
+	assert.Equal(t, float64(0), got.ReservedAmount)
+	assert.Equal(t, float64(25), got.AmountSold.Float64)
+	assert.Equal(t, uint(25), got.PercentageSold.Uint)
+
+	_, err = rt.reservations.ConfirmReservation(reservation.ID)
+	assert.ErrorIs(t, err, ErrReservationNotHeld)
+	_, err = rt.reservations.ReleaseReservation(reservation.ID)
+	assert.ErrorIs(t, err, ErrReservationNotHeld)
+}
+
+func Test_ReleaseReservation(t *testing.T) {
+	rt, wager := newReservationTest(t)
+	reservation, err := rt.reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, BuyingPrice: 25})
+	assert.NoError(t, err)
+
+	released, err := rt.reservations.ReleaseReservation(reservation.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.RESERVATION_STATUS_RELEASED, released.Status)
+
+	got := rt.getWager(t, wager.ID)
+	assert.Equal(t, float64(100), got.CurrentSellingPrice)
+	assert.Equal(t, float64(0), got.ReservedAmount)
+
+	_, err = rt.reservations.ConfirmReservation(reservation.ID)
+	assert.ErrorIs(t, err, ErrReservationNotHeld)
+}
+
+func Test_ReleaseExpired(t *testing.T) {
+	rt, wager := newReservationTest(t)
+	ids := []uint{}
+	for _, ttl := range []int{60, 120, 600} {
+		reservation, err := rt.reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, BuyingPrice: 10, TTLSeconds: ttl})
+		assert.NoError(t, err)
+		ids = append(ids, reservation.ID)
+	}
+
+	rt.now = rt.now.Add(2 * time.Minute)
+	_, err := rt.reservations.ConfirmReservation(ids[1])
+	assert.ErrorIs(t, err, ErrReservationExpired)
+
+	// more expired reservations than the sweep batch size
+	released, err := rt.reservations.ReleaseExpired()
+	assert.NoError(t, err)
+	require.Len(t, released, 2)
+	assert.Equal(t, []uint{ids[0], ids[1]}, []uint{released[0].ID, released[1].ID})
+	assert.Equal(t, model.RESERVATION_STATUS_EXPIRED, released[0].Status)
+
+	got := rt.getWager(t, wager.ID)
+	assert.Equal(t, float64(90), got.CurrentSellingPrice)
+	assert.Equal(t, float64(10), got.ReservedAmount)
+
+	_, err = rt.reservations.ConfirmReservation(ids[0])
+	assert.ErrorIs(t, err, ErrReservationNotHeld)
+	_, err = rt.reservations.ConfirmReservation(ids[2])
+	assert.NoError(t, err)
+
+	released, err = rt.reservations.ReleaseExpired()
+	assert.NoError(t, err)
+	assert.Empty(t, released)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"wager/conf"
+	"wager/currency"
+	"wager/model"
+	"wager/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+type StatsService interface {
+	// GetStats reports the fee revenue of the purchases bought and the listings resold
+	// in the period of request
+	GetStats(request model.GetStatsRequest) (*model.Stats, error)
+}
+
+type statsService struct {
+	config *conf.Config
+	store  repository.Store
+}
+
+func NewStatsService(config *conf.Config, store repository.Store) StatsService {
+	return &statsService{
+		config: config,
+		store:  store,
+	}
+}
+
+func (ss *statsService) GetStats(request model.GetStatsRequest) (*model.Stats, error) {
+	if request.To != 0 && request.To < request.From {
+		return nil, errors.New("to must not be before from")
+	}
+
+	revenues, err := ss.store.Purchases().FeeRevenue(request.From, request.To)
+	if err != nil {
+		logrus.WithError(err).Error("cannot get fee revenue")
+		return nil, err
+	}
+	transferRevenues, err := ss.store.Transfers().FeeRevenue(request.From, request.To)
+	if err != nil {
+		logrus.WithError(err).Error("cannot get transfer fee revenue")
+		return nil, err
+	}
+	revenues = mergeRevenues(revenues, transferRevenues)
+	// sums of floats drift off the minor unit
+	for i := range revenues {
+		revenue := &revenues[i]
+		c := currency.Of(revenue.Currency)
+		revenue.Volume = c.Round(revenue.Volume)
+		revenue.MakerFees = c.Round(revenue.MakerFees)
+		revenue.TakerFees = c.Round(revenue.TakerFees)
+		revenue.Total = c.Round(revenue.MakerFees + revenue.TakerFees)
+	}
+
+	return &model.Stats{From: request.From, To: request.To, FeeRevenue: revenues}, nil
+}
+
+// mergeRevenues adds up two lists of revenues in currency code order into one
+func mergeRevenues(a []model.FeeRevenue, b []model.FeeRevenue) []model.FeeRevenue {
+	merged := make([]model.FeeRevenue, 0, len(a)+len(b))
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || (len(a) > 0 && a[0].Currency < b[0].Currency):
+			merged, a = append(merged, a[0]), a[1:]
+		case len(a) == 0 || b[0].Currency < a[0].Currency:
+			merged, b = append(merged, b[0]), b[1:]
+		default:
+			revenue := a[0]
+			revenue.Purchases += b[0].Purchases
+			revenue.Transfers += b[0].Transfers
+			revenue.Volume += b[0].Volume
+			revenue.MakerFees += b[0].MakerFees
+			revenue.TakerFees += b[0].TakerFees
+			merged, a, b = append(merged, revenue), a[1:], b[1:]
+		}
+	}
+	return merged
+}
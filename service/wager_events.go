@@ -0,0 +1,27 @@
+package service
+
+import (
+	"wager/model"
+)
+
+// WagerPublisher receives wager events. Services publish an event only once the
+// transaction of its change committed, events of concurrent changes to a wager may
+// still be published out of commit order. The wager of an event carries the version
+// its change left, the publisher drops the events older than one it already has.
+type WagerPublisher interface {
+	Publish(events ...model.WagerEvent)
+}
+
+// publish hands events to publisher, a nil publisher drops them
+func publish(publisher WagerPublisher, events ...model.WagerEvent) {
+	if publisher == nil || len(events) == 0 {
+		return
+	}
+	publisher.Publish(events...)
+}
+
+// wagerEvent reports a change of wager at the unix time at, the event holds its own
+// copy of the wager
+func wagerEvent(eventType string, wager model.Wager, at int64) model.WagerEvent {
+	return model.WagerEvent{Type: eventType, At: at, Wager: &wager}
+}
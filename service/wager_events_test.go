@@ -0,0 +1,280 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"wager/conf"
+	"wager/model"
+	"wager/repository"
+	"wager/stream"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// committedPublisher records events and checks that the wager of each event was
+// committed when it was published. A read of the memory store waits for running
+// transactions, so an event published before its commit times out the read. The
+// last event of a wager in a publish is the state the commit left.
+type committedPublisher struct {
+	t      *testing.T
+	store  repository.Store
+	events []model.WagerEvent
+}
+
+func (p *committedPublisher) Publish(events ...model.WagerEvent) {
+	last := map[uint]model.Wager{}
+	for _, event := range events {
+		if event.Wager != nil {
+			last[event.Wager.ID] = *event.Wager
+		}
+		p.events = append(p.events, event)
+	}
+
+	for id, published := range last {
+		read := make(chan *model.Wager, 1)
+		go func(id uint) {
+			wager, err := p.store.Wagers().GetByID(id)
+			assert.NoError(p.t, err)
+			read <- wager
+		}(id)
+		select {
+		case wager := <-read:
+			assert.Equal(p.t, *wager, published)
+		case <-time.After(time.Second):
+			p.t.Errorf("wager %v was published before its commit", id)
+		}
+	}
+}
+
+func (p *committedPublisher) types() []string {
+	types := []string{}
+	for _, event := range p.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+// brokerPublisher checks every publish like committedPublisher and hands it on to a
+// broker, like the server does
+type brokerPublisher struct {
+	*committedPublisher
+	broker *stream.Broker
+}
+
+func (p *brokerPublisher) Publish(events ...model.WagerEvent) {
+	p.committedPublisher.Publish(events...)
+	p.broker.Publish(events...)
+}
+
+// received returns the events subscription got so far, publishing is synchronous
+func received(subscription *stream.Subscription) []model.WagerEvent {
+	events := []model.WagerEvent{}
+	for {
+		select {
+		case event := <-subscription.Events():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func receivedTypes(subscription *stream.Subscription) []string {
+	types := []string{}
+	for _, event := range received(subscription) {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func Test_WagerEvents(t *testing.T) {
+	config := conf.GetDefaultConfig()
+	store := repository.NewMemoryStore()
+	publisher := &committedPublisher{t: t, store: store}
+	wagers := NewWagerService(config, store, publisher)
+	events := NewEventService(config, store, publisher)
+
+	event, err := events.CreateEvent(model.CreateEventRequest{Name: "Final", StartsAt: 1642500000})
+	require.NoError(t, err)
+	market, err := events.CreateMarket(model.CreateMarketRequest{EventID: event.ID, Name: "Winner", Selections: []string{"Home", "Away"}})
+	require.NoError(t, err)
+
+	request := model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, SelectionID: market.Selections[0].ID}
+	wager, err := wagers.CreateWager(request)
+	require.NoError(t, err)
+	_, err = wagers.CreateWagers(model.CreateWagersRequest{Wagers: []model.CreateWagerRequest{request, request}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"created", "created", "created"}, publisher.types())
+	assert.Equal(t, *wager, *publisher.events[0].Wager)
+
+	publisher.events = nil
+	_, err = wagers.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 25})
+	require.NoError(t, err)
+	_, err = wagers.BuyWagers(model.BatchPurchaseRequest{Items: []model.BatchPurchaseItem{
+		{WagerID: wager.ID, BuyingPrice: 10},
+		{WagerID: wager.ID + 1, BuyingPrice: 10},
+		{WagerID: wager.ID, BuyingPrice: 5},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"purchase", "purchase", "purchase", "purchase"}, publisher.types())
+	// every purchase reports the price it left
+	assert.Equal(t, float64(75), publisher.events[0].Wager.CurrentSellingPrice)
+	assert.Equal(t, float64(65), publisher.events[1].Wager.CurrentSellingPrice)
+	assert.Equal(t, float64(60), publisher.events[2].Wager.CurrentSellingPrice)
+	assert.Equal(t, wager.ID+1, publisher.events[3].Wager.ID)
+
+	// failed changes publish nothing
+	publisher.events = nil
+	_, err = wagers.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 1000})
+	assert.ErrorIs(t, err, ErrBuyingPriceTooHigh)
+	_, err = wagers.BuyWagers(model.BatchPurchaseRequest{Items: []model.BatchPurchaseItem{
+		{WagerID: wager.ID, BuyingPrice: 10},
+		{WagerID: 100, BuyingPrice: 10},
+	}})
+	assert.Error(t, err)
+	assert.Empty(t, publisher.events)
+
+	_, err = events.SettleMarket(model.SettleMarketRequest{MarketID: market.ID, WinningSelectionID: market.Selections[0].ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status", "status", "status", "settlement"}, publisher.types())
+	assert.Equal(t, model.WAGER_STATUS_WON, publisher.events[0].Wager.Status)
+	settlement := publisher.events[3]
+	assert.Equal(t, market.ID, settlement.Market.ID)
+	assert.Equal(t, []uint{wager.ID, wager.ID + 1, wager.ID + 2}, settlement.WagerIDs)
+}
+
+func Test_WagerEvents_CancelEvent(t *testing.T) {
+	config := conf.GetDefaultConfig()
+	store := repository.NewMemoryStore()
+	publisher := &committedPublisher{t: t, store: store}
+	wagers := NewWagerService(config, store, publisher)
+	events := NewEventService(config, store, publisher)
+
+	event, err := events.CreateEvent(model.CreateEventRequest{Name: "Final", StartsAt: 1642500000})
+	require.NoError(t, err)
+	market, err := events.CreateMarket(model.CreateMarketRequest{EventID: event.ID, Name: "Winner", Selections: []string{"Home", "Away"}})
+	require.NoError(t, err)
+	wager, err := wagers.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, SelectionID: market.Selections[1].ID})
+	require.NoError(t, err)
+
+	publisher.events = nil
+	_, err = events.UpdateEvent(model.UpdateEventRequest{ID: event.ID, Name: "Final", StartsAt: 1642500000, Status: model.EVENT_STATUS_CANCELLED})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status", "settlement"}, publisher.types())
+	assert.Equal(t, model.WAGER_STATUS_VOID, publisher.events[0].Wager.Status)
+	assert.Equal(t, model.MARKET_STATUS_VOID, publisher.events[1].Market.Status)
+	assert.Equal(t, []uint{wager.ID}, publisher.events[1].WagerIDs)
+}
+
+func Test_WagerEvents_Broker(t *testing.T) {
+	config := conf.GetDefaultConfig()
+	store := repository.NewMemoryStore()
+	broker := stream.NewBroker(100, 100)
+	publisher := &brokerPublisher{committedPublisher: &committedPublisher{t: t, store: store}, broker: broker}
+	wagers := NewWagerService(config, store, publisher).(*wagerService)
+	bids := NewBidService(config, store, publisher).(*bidService)
+	reservations := NewReservationService(config, store, publisher).(*reservationService)
+	purchases := NewPurchaseService(config, store, publisher)
+	now := time.Now()
+	wagers.now = func() time.Time { return now }
+	bids.now = func() time.Time { return now }
+	reservations.now = func() time.Time { return now }
+
+	wager, err := wagers.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, MinPurchase: 1})
+	require.NoError(t, err)
+	subscription, err := broker.Subscribe(nil, "")
+	require.NoError(t, err)
+	defer subscription.Close()
+
+	t.Run("Reservations", func(t *testing.T) {
+		reservation, err := reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 20})
+		require.NoError(t, err)
+		events := received(subscription)
+		require.Len(t, events, 1)
+		assert.Equal(t, model.WAGER_EVENT_RESERVATION, events[0].Type)
+		assert.Equal(t, float64(20), events[0].Wager.ReservedAmount)
+
+		_, err = reservations.ReleaseReservation(reservation.ID)
+		require.NoError(t, err)
+		events = received(subscription)
+		require.Len(t, events, 1)
+		assert.Equal(t, model.WAGER_EVENT_RESERVATION, events[0].Type)
+		assert.Zero(t, events[0].Wager.ReservedAmount)
+
+		reservation, err = reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 10})
+		require.NoError(t, err)
+		_, err = reservations.ConfirmReservation(reservation.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"reservation", "purchase"}, receivedTypes(subscription))
+	})
+
+	t.Run("Refund", func(t *testing.T) {
+		purchase, err := wagers.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, Buyer: "bob", BuyingPrice: 5})
+		require.NoError(t, err)
+		_, err = purchases.RefundPurchase(purchase.PurchaseID)
+		require.NoError(t, err)
+		events := received(subscription)
+		require.Len(t, events, 2)
+		assert.Equal(t, model.WAGER_EVENT_REFUND, events[1].Type)
+		assert.Equal(t, float64(90), events[1].Wager.CurrentSellingPrice)
+	})
+
+	t.Run("Bids", func(t *testing.T) {
+		bid, err := bids.PlaceBid(model.PlaceBidRequest{WagerID: wager.ID, Buyer: "bob", FaceValue: 10, BidPrice: 5})
+		require.NoError(t, err)
+		assert.Empty(t, received(subscription))
+		_, err = bids.AcceptBid(model.AcceptBidRequest{BidID: bid.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"purchase"}, receivedTypes(subscription))
+
+		// 10 of face value costs 9 at an ask price of 90 and 7 at 70
+		_, err = bids.PlaceBid(model.PlaceBidRequest{WagerID: wager.ID, Buyer: "carol", FaceValue: 10, BidPrice: 7})
+		require.NoError(t, err)
+		_, err = bids.LowerPrice(model.LowerPriceRequest{WagerID: wager.ID, AskPrice: 90})
+		require.NoError(t, err)
+		events := received(subscription)
+		require.Len(t, events, 1)
+		assert.Equal(t, model.WAGER_EVENT_PRICE, events[0].Type)
+		assert.Equal(t, float64(90), events[0].Wager.AskPrice)
+		_, err = bids.LowerPrice(model.LowerPriceRequest{WagerID: wager.ID, AskPrice: 70})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"purchase"}, receivedTypes(subscription))
+	})
+
+	t.Run("Expired reservations", func(t *testing.T) {
+		_, err := reservations.ReserveWager(model.ReserveWagerRequest{WagerID: wager.ID, Buyer: "alice", BuyingPrice: 7})
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+		released, err := reservations.ReleaseExpired()
+		require.NoError(t, err)
+		assert.Len(t, released, 1)
+		assert.Equal(t, []string{"reservation", "reservation"}, receivedTypes(subscription))
+	})
+
+	t.Run("Scheduled bids", func(t *testing.T) {
+		scheduled, err := wagers.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, MinPurchase: 1,
+			PriceSchedule: model.PRICE_SCHEDULE_LINEAR, FloorPrice: 50, DecaySeconds: 1000})
+		require.NoError(t, err)
+		placeAt := now
+		_, err = bids.PlaceBid(model.PlaceBidRequest{WagerID: scheduled.ID, Buyer: "alice", FaceValue: 10, BidPrice: 6})
+		require.NoError(t, err)
+		_, err = bids.PlaceBid(model.PlaceBidRequest{WagerID: scheduled.ID, Buyer: "bob", FaceValue: 10, BidPrice: 5})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"created"}, receivedTypes(subscription))
+
+		now = placeAt.Add(900 * time.Second)
+		_, err = bids.MatchScheduledBids()
+		require.NoError(t, err)
+		events := received(subscription)
+		require.Len(t, events, 1)
+		assert.Equal(t, model.WAGER_EVENT_PURCHASE, events[0].Type)
+		assert.Equal(t, scheduled.ID, events[0].Wager.ID)
+
+		// the resting bid the price reached is filled before the new bid is placed
+		now = placeAt.Add(1000 * time.Second)
+		_, err = bids.PlaceBid(model.PlaceBidRequest{WagerID: scheduled.ID, Buyer: "carol", FaceValue: 10, BidPrice: 4.5})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"purchase"}, receivedTypes(subscription))
+	})
+}
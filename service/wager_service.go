@@ -3,215 +3,436 @@ package service
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 	"wager/conf"
-	"wager/database"
+	"wager/currency"
+	errorcode "wager/error_code"
 	"wager/model"
+	"wager/repository"
 	"wager/utils"
+	"wager/validator"
 
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	ErrBuyingPriceTooHigh = errorcode.NewClientError("buying price must be equal or smaller than current selling price")
+	// ErrInvalidPurchaseSize is wrapped with the purchase size bound which was not met
+	ErrInvalidPurchaseSize = errorcode.NewClientError("invalid purchase size")
+	ErrInvalidOdds         = errorcode.NewClientError("invalid odds")
+	// ErrCurrencyMismatch and ErrInvalidAmount are wrapped with the currency of the
+	// wager
+	ErrCurrencyMismatch = errorcode.NewClientError("currency does not match the wager")
+	ErrInvalidAmount    = errorcode.NewClientError("amount has more decimal places than the currency of the wager")
+)
+
 type WagerService interface {
 	CreateWager(request model.CreateWagerRequest) (*model.Wager, error)
+	// CreateWagers validates every wager and creates all of them or none, failed
+	// wagers are reported by a *model.BatchError
+	CreateWagers(request model.CreateWagersRequest) ([]model.Wager, error)
 	GetWagerList(request model.GetWagerListRequest) (*model.GetWagerListResponse, error)
+	GetWager(id uint) (*model.Wager, error)
 	BuyWager(request model.BuyWagerRequest) (*model.Purchase, error)
+	// QuoteWager runs the checks of BuyWager and returns its outcome without buying
+	QuoteWager(request model.BuyWagerRequest) (*model.Quote, error)
+	// BuyWagers buys all the items or none of them, failed items are reported by a
+	// *model.BatchError
+	BuyWagers(request model.BatchPurchaseRequest) ([]model.Purchase, error)
+}
+
+// Session returns a WagerService for the calls of one long lived request whose reads
+// see the writes of its earlier calls. It is ws itself when ws has no sessions.
+func Session(ws WagerService) WagerService {
+	if sessions, ok := ws.(interface{ Session() WagerService }); ok {
+		return sessions.Session()
+	}
+	return ws
 }
 
 type wagerService struct {
 	config *conf.Config
-	db     database.DBManager
+	store  repository.Store
+	// publisher receives the created and bought wagers, it may be nil
+	publisher WagerPublisher
+	// now is the clock which places wagers and prices them
+	now func() time.Time
 }
 
-func NewWagerService(config *conf.Config, db database.DBManager) WagerService {
+func NewWagerService(config *conf.Config, store repository.Store, publisher WagerPublisher) WagerService {
 	return &wagerService{
-		config: config,
-		db:     db,
+		config:    config,
+		store:     store,
+		publisher: publisher,
+		now:       time.Now,
 	}
 }
 
+func (ws *wagerService) Session() WagerService {
+	session := *ws
+	session.store = ws.store.Session()
+	return &session
+}
+
 func (ws *wagerService) CreateWager(request model.CreateWagerRequest) (*model.Wager, error) {
-	wager := model.Wager{
-		TotalWagerValue:     request.TotalWagerValue,
-		Odds:                request.Odds,
-		SellingPercentage:   request.SellingPercentage,
-		SellingPrice:        request.SellingPrice,
-		CurrentSellingPrice: request.SellingPrice,
-		PlaceAt:             time.Now().UTC().Unix(),
+	wager, err := newWager(request, ws.now().UTC().Unix())
+	if err != nil {
+		return nil, err
 	}
 
-	err := ws.createWager(&wager)
+	err = ws.store.RunInTx(func(store repository.Store) error {
+		if err := placeOnSelection(store, &wager, request.SelectionID); err != nil {
+			return err
+		}
+		return store.Wagers().Create(&wager)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create wager: %v", err)
+		return nil, fmt.Errorf("failed to create wager: %w", err)
 	}
 
+	publish(ws.publisher, wagerEvent(model.WAGER_EVENT_CREATED, wager, wager.PlaceAt))
 	return &wager, nil
 }
 
-func (ws *wagerService) createWager(wager *model.Wager) error {
-	insertQuery := fmt.Sprintf("INSERT INTO %v (total_wager_value, odds, selling_percentage, selling_price, current_selling_price, place_at) VALUES (?, ?, ?, ?, ?, ?)", ws.config.SQL.WagerTable)
-	res, err := ws.db.Exec(insertQuery, wager.TotalWagerValue, wager.Odds, wager.SellingPercentage, wager.SellingPrice, wager.CurrentSellingPrice, wager.PlaceAt)
-	if err != nil {
-		return fmt.Errorf("failed to add wager: %v", err)
+func (ws *wagerService) CreateWagers(request model.CreateWagersRequest) ([]model.Wager, error) {
+	batchErr := &model.BatchError{}
+	placeAt := ws.now().UTC().Unix()
+	wagers := make([]model.Wager, 0, len(request.Wagers))
+	// indexes are the indexes in the request of wagers
+	indexes := make([]int, 0, len(request.Wagers))
+	for i, req := range request.Wagers {
+		if msgs := validator.CreateWagerErrors(req); msgs != nil {
+			batchErr.Items = append(batchErr.Items, model.BatchItemError{Index: i, Error: strings.Join(msgs, ", ")})
+			continue
+		}
+		wager, err := newWager(req, placeAt)
+		if err != nil {
+			batchErr.Items = append(batchErr.Items, model.BatchItemError{Index: i, Error: err.Error()})
+			continue
+		}
+		wagers = append(wagers, wager)
+		indexes = append(indexes, i)
+	}
+	if len(batchErr.Items) > 0 {
+		return nil, batchErr
 	}
 
-	id, err := res.LastInsertId()
+	err := ws.store.RunInTx(func(store repository.Store) error {
+		for i := range wagers {
+			err := placeOnSelection(store, &wagers[i], request.Wagers[indexes[i]].SelectionID)
+			if errors.Is(err, ErrSelectionNotFound) || errors.Is(err, ErrMarketNotOpen) {
+				batchErr.Items = append(batchErr.Items, model.BatchItemError{Index: indexes[i], Error: err.Error()})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if len(batchErr.Items) > 0 {
+			return batchErr
+		}
+		return store.Wagers().CreateMany(wagers)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get wager id: %v", err)
+		return nil, fmt.Errorf("failed to create wagers: %w", err)
 	}
 
-	wager.ID = uint(id)
-	return nil
+	events := make([]model.WagerEvent, 0, len(wagers))
+	for _, wager := range wagers {
+		events = append(events, wagerEvent(model.WAGER_EVENT_CREATED, wager, placeAt))
+	}
+	publish(ws.publisher, events...)
+	return wagers, nil
 }
 
-func (ws *wagerService) GetWagerList(request model.GetWagerListRequest) (*model.GetWagerListResponse, error) {
-	if request.Page == 0 || request.Limit == 0 {
-		return nil, errors.New("invalid request params")
+func newWager(request model.CreateWagerRequest, placeAt int64) (model.Wager, error) {
+	wagerOdds, err := request.Odds.Parse(request.OddsFormat)
+	if err != nil {
+		return model.Wager{}, fmt.Errorf("%w: %v", ErrInvalidOdds, err)
 	}
-	offset := (request.Page - 1) * request.Limit
-	return ws.getWagerList(offset, request.Limit)
+	code := request.Currency
+	if code == "" {
+		code = currency.DEFAULT
+	}
+
+	return model.Wager{
+		TotalWagerValue:     request.TotalWagerValue,
+		Odds:                wagerOdds,
+		SellingPercentage:   request.SellingPercentage,
+		SellingPrice:        request.SellingPrice,
+		CurrentSellingPrice: request.SellingPrice,
+		PlaceAt:             placeAt,
+		Status:              model.WAGER_STATUS_OPEN,
+		MinPurchase:         request.MinPurchase,
+		MaxPurchase:         request.MaxPurchase,
+		PurchaseIncrement:   request.PurchaseIncrement,
+		PriceSchedule:       request.PriceSchedule,
+		FloorPrice:          request.FloorPrice,
+		DecaySeconds:        request.DecaySeconds,
+		DecaySteps:          request.DecaySteps,
+		Seller:              request.Seller,
+		Currency:            code,
+	}, nil
 }
 
-func (ws *wagerService) getWagerList(offset int, limit int) (*model.GetWagerListResponse, error) {
-	result := &model.GetWagerListResponse{}
-	query := fmt.Sprintf("SELECT * from %v LIMIT ? OFFSET ?", ws.config.SQL.WagerTable)
-	rows, err := ws.db.Query(query, limit, offset)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get wagers: %v", err)
+// placeOnSelection places wager on a selection of an open market, 0 leaves the
+// wager off markets. The market stays locked until the wager is created, so that it
+// is not settled without it.
+func placeOnSelection(store repository.Store, wager *model.Wager, selectionID uint) error {
+	if selectionID == 0 {
+		return nil
 	}
-	defer rows.Close()
 
-	wagerList := make([]model.Wager, 0)
-	for rows.Next() {
-		if wager, err := ws.scanSingleWager(rows); err == nil {
-			wagerList = append(wagerList, *wager)
-		}
+	selection, err := store.Markets().GetSelection(selectionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("%w: %v", ErrSelectionNotFound, selectionID)
+	}
+	if err != nil {
+		return err
+	}
+	market, err := store.Markets().GetByIDForUpdate(selection.MarketID)
+	if err != nil {
+		return err
+	}
+	if market.Status != model.MARKET_STATUS_OPEN {
+		return ErrMarketNotOpen
 	}
 
-	logrus.WithField("wager_list", wagerList).Info("getWagerList")
-	result.Wagers = wagerList
-	return result, nil
+	wager.EventID = market.EventID
+	wager.MarketID = market.ID
+	wager.SelectionID = selection.ID
+	return nil
 }
 
-func (ws *wagerService) scanSingleWager(rows database.DBRows) (*model.Wager, error) {
-	if rows == nil {
-		return nil, errors.New("invalid rows object")
+func (ws *wagerService) GetWagerList(request model.GetWagerListRequest) (*model.GetWagerListResponse, error) {
+	if request.Page == 0 || request.Limit == 0 {
+		return nil, errors.New("invalid request params")
 	}
+	offset := (request.Page - 1) * request.Limit
 
-	wager := model.Wager{}
-	err := rows.Scan(&wager.ID,
-		&wager.TotalWagerValue,
-		&wager.Odds,
-		&wager.SellingPercentage,
-		&wager.SellingPrice,
-		&wager.CurrentSellingPrice,
-		&wager.PercentageSold,
-		&wager.AmountSold,
-		&wager.PlaceAt)
-
+	wagerList, err := ws.store.Wagers().List(request.Filter, offset, request.Limit)
 	if err != nil {
-		logrus.WithError(err).Error("scanSingleWager")
 		return nil, err
 	}
+	priceWagers(wagerList, ws.now().UTC().Unix())
 
-	return &wager, nil
+	logrus.WithField("wager_list", wagerList).Info("getWagerList")
+	return &model.GetWagerListResponse{Wagers: wagerList}, nil
 }
 
-func (ws *wagerService) getWagerByID(id uint) (*model.Wager, error) {
-	query := fmt.Sprintf("SELECT * from %v WHERE id=?", ws.config.SQL.WagerTable)
-	rows, err := ws.db.Query(query, id)
+func (ws *wagerService) GetWager(id uint) (*model.Wager, error) {
+	wager, err := ws.store.Wagers().GetByID(id)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		wager, err := ws.scanSingleWager(rows)
+	wager.CurrentSellingPrice = currentSellingPriceAt(wager, ws.now().UTC().Unix())
+	return wager, nil
+}
+
+func (ws *wagerService) BuyWager(request model.BuyWagerRequest) (*model.Purchase, error) {
+	var purchase *model.Purchase
+	var event model.WagerEvent
+	at := ws.now().UTC().Unix()
+	err := ws.store.RunInTx(func(store repository.Store) error {
+		pur, wager, err := ws.buyWager(store, &request, at)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		return wager, nil
+		purchase = pur
+		event = wagerEvent(model.WAGER_EVENT_PURCHASE, *wager, at)
+		return nil
+	})
+	if err != nil {
+		logrus.WithError(err).Error("cannot buy wager")
+		return nil, err
 	}
 
-	return nil, errors.New("id not found")
+	publish(ws.publisher, event)
+	return purchase, nil
 }
 
-func (ws *wagerService) BuyWager(request model.BuyWagerRequest) (*model.Purchase, error) {
-	wager, err := ws.getWagerByID(request.WagerID)
+// buyWager buys at the unix time at, the purchase is priced and dated at that
+// instant. It returns the purchase and the wager it was bought from.
+func (ws *wagerService) buyWager(store repository.Store, request *model.BuyWagerRequest, at int64) (*model.Purchase, *model.Wager, error) {
+	wager, err := store.Wagers().GetByIDForUpdate(request.WagerID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if err := checkCurrency(wager, request.Currency, request.BuyingPrice); err != nil {
+		return nil, nil, err
 	}
 
-	if wager == nil {
-		return nil, errors.New("invalid wager object")
+	quote, err := quotePurchase(wager, request.BuyingPrice, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkBuyerLimits(ws.config.BuyerLimit, store, wager, request.Buyer, quote.FaceValue); err != nil {
+		return nil, nil, err
 	}
 
-	if wager.CurrentSellingPrice < request.BuyingPrice {
-		logrus.WithFields(logrus.Fields{
-			"current_selling_price": wager.CurrentSellingPrice,
-			"buying_price":          request.BuyingPrice,
-		}).Info("buying_price must be <= selling_price")
-		return nil, errors.New("buying price must be equal or smaller than current selling price")
+	if err := store.Wagers().UpdateSale(wager); err != nil {
+		return nil, nil, err
 	}
 
-	wager.CurrentSellingPrice -= request.BuyingPrice
-	wager.AmountSold.Float64 += request.BuyingPrice
-	wager.AmountSold.Valid = true
-	wager.PercentageSold = utils.NewNullUint(uint(wager.AmountSold.Float64 / wager.SellingPrice * 100))
+	purchase := &model.Purchase{
+		WagerID:     request.WagerID,
+		Buyer:       request.Buyer,
+		BuyingPrice: request.BuyingPrice,
+		FaceValue:   quote.FaceValue,
+		BoughtAt:    at,
+	}
+	if err := createPurchase(store, ws.config.Fee, wager, purchase); err != nil {
+		return nil, nil, err
+	}
 
-	pur, err := ws.buyWager(&request, wager)
+	return purchase, wager, nil
+}
+
+func (ws *wagerService) BuyWagers(request model.BatchPurchaseRequest) ([]model.Purchase, error) {
+	// wagers are locked in ascending id order, so two batches sharing wagers never
+	// wait on each other in a cycle
+	order := make([]int, len(request.Items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return request.Items[order[a]].WagerID < request.Items[order[b]].WagerID
+	})
+
+	var purchases []model.Purchase
+	var events []model.WagerEvent
+	at := ws.now().UTC().Unix()
+	err := ws.store.RunInTx(func(store repository.Store) error {
+		purchases = make([]model.Purchase, len(request.Items))
+		// events are in the order the wagers were bought, later purchases of a
+		// wager report its later state
+		events = make([]model.WagerEvent, 0, len(request.Items))
+		batchErr := &model.BatchError{}
+		for _, i := range order {
+			item := request.Items[i]
+			purchase, wager, err := ws.buyWager(store, &model.BuyWagerRequest{WagerID: item.WagerID, Buyer: item.Buyer, BuyingPrice: item.BuyingPrice, Currency: item.Currency}, at)
+			// errors of the item fail the batch, failures of the service abort it
+			var clientErr errorcode.ClientError
+			if errors.As(err, &clientErr) {
+				batchErr.Items = append(batchErr.Items, model.BatchItemError{Index: i, WagerID: item.WagerID, Error: err.Error(), Code: clientErr.Code()})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			purchases[i] = *purchase
+			events = append(events, wagerEvent(model.WAGER_EVENT_PURCHASE, *wager, at))
+		}
+
+		if len(batchErr.Items) > 0 {
+			sort.Slice(batchErr.Items, func(a, b int) bool {
+				return batchErr.Items[a].Index < batchErr.Items[b].Index
+			})
+			return batchErr
+		}
+		return nil
+	})
 	if err != nil {
-		logrus.WithError(err).Error("cannot buy wager")
+		logrus.WithError(err).Error("cannot buy wagers")
 		return nil, err
 	}
 
-	return pur, nil
+	publish(ws.publisher, events...)
+	return purchases, nil
 }
 
-func (ws *wagerService) buyWager(request *model.BuyWagerRequest, wager *model.Wager) (*model.Purchase, error) {
-	tx, err := ws.db.BeginTx()
+func (ws *wagerService) QuoteWager(request model.BuyWagerRequest) (*model.Quote, error) {
+	wager, err := ws.store.Wagers().GetByID(request.WagerID)
 	if err != nil {
-		logrus.WithError(err).Error("cannot begin transaction")
+		return nil, err
+	}
+	if err := checkCurrency(wager, request.Currency, request.BuyingPrice); err != nil {
 		return nil, err
 	}
 
-	updateQuery := fmt.Sprintf("UPDATE %v SET current_selling_price=?, percentage_sold=?, amount_sold=? WHERE id=?", ws.config.SQL.WagerTable)
-	_, err = ws.db.Exec(updateQuery, wager.CurrentSellingPrice, wager.PercentageSold.Uint, wager.AmountSold.Float64, wager.ID)
+	quote, err := quotePurchase(wager, request.BuyingPrice, ws.now().UTC().Unix())
 	if err != nil {
-		ws.db.RollbackTx(tx)
 		return nil, err
 	}
-
-	purchase := &model.Purchase{
-		WagerID:     request.WagerID,
-		BuyingPrice: request.BuyingPrice,
-		BoughtAt:    time.Now().UTC().Unix(),
+	// the buyer is not locked, a purchase racing with the quote may still be refused
+	if err := checkBuyerExposure(ws.config.BuyerLimit, ws.store, wager, request.Buyer, quote.FaceValue); err != nil {
+		return nil, err
 	}
-	if err := ws.createPurchase(purchase); err != nil {
-		ws.db.RollbackTx(tx)
+	quote.Fees, err = purchaseFees(ws.config.Fee, ws.store, wager, request.Buyer, request.BuyingPrice)
+	if err != nil {
 		return nil, err
 	}
+	quote.TotalCost = roundMinor(wager, request.BuyingPrice+quote.Fees.TakerFee)
+	return quote, nil
+}
 
-	if err := ws.db.CommitTx(tx); err != nil {
-		ws.db.RollbackTx(tx)
+// quotePurchase checks a purchase of buyingPrice at the unix time at and applies it
+// to wager in memory. BuyWager persists the updated wager, QuoteWager only reports it.
+func quotePurchase(wager *model.Wager, buyingPrice float64, at int64) (*model.Quote, error) {
+	if wager.Status != model.WAGER_STATUS_OPEN {
+		return nil, ErrWagerNotOpen
+	}
+	currentPrice := currentSellingPriceAt(wager, at)
+	if currentPrice < buyingPrice {
+		logrus.WithFields(logrus.Fields{
+			"current_selling_price": currentPrice,
+			"buying_price":          buyingPrice,
+		}).Info("buying_price must be <= selling_price")
+		return nil, ErrBuyingPriceTooHigh
+	}
+	if err := checkPurchaseSize(wager, currentPrice, buyingPrice); err != nil {
 		return nil, err
 	}
 
-	return purchase, nil
+	faceValue := faceValueAt(wager, currentPrice, buyingPrice, at)
+	wager.CurrentSellingPrice -= faceValue
+	addAmountSold(wager, buyingPrice)
+
+	share := faceValue / wager.SellingPrice * float64(wager.SellingPercentage)
+	return &model.Quote{
+		WagerID:             wager.ID,
+		BuyingPrice:         buyingPrice,
+		FaceValue:           faceValue,
+		CurrentSellingPrice: currentSellingPriceAt(wager, at),
+		PercentageSold:      wager.PercentageSold,
+		AmountSold:          wager.AmountSold,
+		SharePercentage:     share,
+		PotentialPayout:     payoutOf(wager, faceValue),
+	}, nil
 }
 
-func (ws *wagerService) createPurchase(purchase *model.Purchase) error {
-	query := fmt.Sprintf("INSERT INTO %v (wager_id, buying_price, bought_at) VALUES (?, ?, ?)", ws.config.SQL.PurchaseTable)
-	res, err := ws.db.Exec(query, purchase.WagerID, purchase.BuyingPrice, purchase.BoughtAt)
-	if err != nil {
-		return fmt.Errorf("failed to create purchase: %v", err)
+// checkPurchaseSize checks buyingPrice against the purchase size bounds of wager.
+// Buying all that is left of a wager is allowed below the minimum and off the
+// increment, so a wager can always be sold out.
+func checkPurchaseSize(wager *model.Wager, currentPrice float64, buyingPrice float64) error {
+	if wager.MaxPurchase > 0 && buyingPrice > wager.MaxPurchase {
+		return fmt.Errorf("%w: buying price must be at most %v", ErrInvalidPurchaseSize, wager.MaxPurchase)
 	}
-
-	id, err := res.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("failed to get purchase id: %v", err)
+	if isRemainder(wager, currentPrice, buyingPrice) {
+		return nil
+	}
+	if buyingPrice < wager.MinPurchase {
+		return fmt.Errorf("%w: buying price must be at least %v", ErrInvalidPurchaseSize, wager.MinPurchase)
+	}
+	if wager.PurchaseIncrement > 0 && toMinor(wager, buyingPrice)%toMinor(wager, wager.PurchaseIncrement) != 0 {
+		return fmt.Errorf("%w: buying price must be a multiple of %v", ErrInvalidPurchaseSize, wager.PurchaseIncrement)
 	}
-
-	purchase.PurchaseID = uint(id)
 	return nil
 }
+
+func isRemainder(wager *model.Wager, currentPrice float64, buyingPrice float64) bool {
+	return toMinor(wager, buyingPrice) == toMinor(wager, currentPrice)
+}
+
+// addAmountSold adds amount, which is negative for refunds, to the amount sold of
+// wager and updates its percentage sold. The percentage is what is neither for sale
+// nor reserved, so CurrentSellingPrice and ReservedAmount must be updated first.
+func addAmountSold(wager *model.Wager, amount float64) {
+	wager.AmountSold.Float64 += amount
+	wager.AmountSold.Valid = true
+	sold := toMinor(wager, wager.SellingPrice) - toMinor(wager, wager.CurrentSellingPrice) - toMinor(wager, wager.ReservedAmount)
+	wager.PercentageSold = utils.NewNullUint(uint(sold * 100 / toMinor(wager, wager.SellingPrice)))
+}
@@ -3,38 +3,54 @@ package service
 import (
 	"database/sql"
 	"errors"
-	"log"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 	"wager/conf"
+	"wager/database"
 	"wager/mocks"
 	"wager/model"
+	"wager/odds"
+	"wager/repository"
+	sqlmigration "wager/sql_migration"
+	"wager/utils"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang/mock/gomock"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
-type mockSQLResult struct {
-	lastInsertedId int64
-	err            error
-	rowsAffected   int64
+type mockStore struct {
+	store     *mocks.MockStore
+	wagers    *mocks.MockWagerRepository
+	purchases *mocks.MockPurchaseRepository
+	positions *mocks.MockPositionRepository
 }
 
-func (r *mockSQLResult) LastInsertId() (int64, error) {
-	return r.lastInsertedId, r.err
-}
-
-func (r *mockSQLResult) RowsAffected() (int64, error) {
-	return r.rowsAffected, r.err
-}
+func NewMockWagerService(ctrl *gomock.Controller) (WagerService, *mockStore) {
+	m := &mockStore{
+		store:     mocks.NewMockStore(ctrl),
+		wagers:    mocks.NewMockWagerRepository(ctrl),
+		purchases: mocks.NewMockPurchaseRepository(ctrl),
+		positions: mocks.NewMockPositionRepository(ctrl),
+	}
+	m.store.EXPECT().Wagers().Return(m.wagers).AnyTimes()
+	m.store.EXPECT().Purchases().Return(m.purchases).AnyTimes()
+	m.store.EXPECT().Positions().Return(m.positions).AnyTimes()
+	m.store.EXPECT().RunInTx(gomock.Any()).DoAndReturn(func(fn func(repository.Store) error) error {
+		return fn(m.store)
+	}).AnyTimes()
 
-func NewMockWagerService(ctrl *gomock.Controller) (WagerService, *mocks.MockDBManager) {
-	mockDb := mocks.NewMockDBManager(ctrl)
 	wagerService := &wagerService{
 		config: conf.GetDefaultConfig(),
-		db:     mockDb,
+		store:  m.store,
+		now:    time.Now,
 	}
-	return wagerService, mockDb
+	return wagerService, m
 }
 
 func Test_GetWagerList_InvalidParams(t *testing.T) {
@@ -55,18 +71,14 @@ func Test_GetWagerList_InvalidParams(t *testing.T) {
 
 func Test_GetWagerList_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
-	mockService, mockDB := NewMockWagerService(ctrl)
+	mockService, mockStore := NewMockWagerService(ctrl)
 	req := model.GetWagerListRequest{
-		Page:  1,
-		Limit: 2,
+		Filter: model.WagerFilter{Currency: "EUR"},
+		Page:   2,
+		Limit:  2,
 	}
-	mockRows := mocks.NewMockDBRows(ctrl)
 
-	mockDB.EXPECT().Query(gomock.Any(), 2, 0).Return(mockRows, nil)
-	mockRows.EXPECT().Next().Return(true).Times(2)
-	mockRows.EXPECT().Scan(gomock.Any()).Times(2)
-	mockRows.EXPECT().Next().Return(false)
-	mockRows.EXPECT().Close()
+	mockStore.wagers.EXPECT().List(model.WagerFilter{Currency: "EUR"}, 2, 2).Return([]model.Wager{{ID: 3}, {ID: 4}}, nil)
 
 	res, err := mockService.GetWagerList(req)
 
@@ -74,69 +86,419 @@ func Test_GetWagerList_Success(t *testing.T) {
 	assert.Equal(t, 2, len(res.Wagers))
 }
 
+func Test_GetWagerList_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockService, mockStore := NewMockWagerService(ctrl)
+
+	mockStore.wagers.EXPECT().List(model.WagerFilter{}, 0, 10).Return(nil, errors.New("custom error"))
+
+	res, err := mockService.GetWagerList(model.GetWagerListRequest{Page: 1, Limit: 10})
+	assert.Nil(t, res)
+	assert.Error(t, err)
+}
+
 func Test_CreateWager_Error(t *testing.T) {
 	ctrl := gomock.NewController(t)
-	wagerService, mockDB := NewMockWagerService(ctrl)
+	wagerService, mockStore := NewMockWagerService(ctrl)
 	req := model.CreateWagerRequest{
 		TotalWagerValue:   1,
-		Odds:              1,
+		Odds:              "2",
 		SellingPercentage: 1,
 		SellingPrice:      1,
 	}
 
-	mockResult := &mockSQLResult{}
-
-	mockDB.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(mockResult, errors.New("custom error"))
+	mockStore.wagers.EXPECT().Create(gomock.Any()).Return(errors.New("custom error"))
 	_, err := wagerService.CreateWager(req)
 	assert.Error(t, err)
 }
 
 func Test_CreateWager_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
-	wagerService, mockDB := NewMockWagerService(ctrl)
+	wagerService, mockStore := NewMockWagerService(ctrl)
 	req := model.CreateWagerRequest{
 		TotalWagerValue:   1,
-		Odds:              1,
+		Odds:              "2",
 		SellingPercentage: 1,
 		SellingPrice:      1,
 	}
 
-	mockResult := &mockSQLResult{
-		lastInsertedId: 1,
-		err:            nil,
-	}
-
-	mockDB.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(mockResult, nil)
+	mockStore.wagers.EXPECT().Create(gomock.Any()).DoAndReturn(func(wager *model.Wager) error {
+		wager.ID = 1
+		return nil
+	})
 
 	res, err := wagerService.CreateWager(req)
 
-	assert.Equal(t, uint(mockResult.lastInsertedId), res.ID)
 	assert.NoError(t, err)
+	assert.Equal(t, uint(1), res.ID)
+	assert.Equal(t, req.SellingPrice, res.CurrentSellingPrice)
 }
 
-func NewDBMock() (*sql.DB, sqlmock.Sqlmock) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
+func Test_CreateWagers(t *testing.T) {
+	wagerService := NewWagerService(conf.GetDefaultConfig(), repository.NewMemoryStore(), nil)
+	valid := model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 60}
 
-	return db, mock
+	t.Run("Invalid wagers", func(t *testing.T) {
+		wagers, err := wagerService.CreateWagers(model.CreateWagersRequest{Wagers: []model.CreateWagerRequest{
+			valid,
+			{TotalWagerValue: 100, Odds: "0", SellingPercentage: 50, SellingPrice: 60},
+			{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 40},
+			{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 60, MinPurchase: 20, MaxPurchase: 10, PurchaseIncrement: 70},
+		}})
+		assert.Nil(t, wagers)
+		batchErr := &model.BatchError{}
+		assert.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, []model.BatchItemError{
+			{Index: 1, Error: "Odds must be decimal odds larger than 1, like 2.5"},
+			{Index: 2, Error: "SellingPrice must be larger than TotalWagerValue * SellingPercentage"},
+			{Index: 3, Error: "MaxPurchase must be larger than or equal MinPurchase, PurchaseIncrement must be less than or equal SellingPrice, MaxPurchase must be larger than or equal PurchaseIncrement"},
+		}, batchErr.Items)
+
+		list, err := wagerService.GetWagerList(model.GetWagerListRequest{Page: 1, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(list.Wagers))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		wagers, err := wagerService.CreateWagers(model.CreateWagersRequest{Wagers: []model.CreateWagerRequest{valid, valid}})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(wagers))
+		assert.Equal(t, uint(1), wagers[0].ID)
+		assert.Equal(t, uint(2), wagers[1].ID)
+		assert.Equal(t, valid.SellingPrice, wagers[1].CurrentSellingPrice)
+	})
+}
+
+func Test_CreateWagers_StoreError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	wagerService, mockStore := NewMockWagerService(ctrl)
+
+	mockStore.wagers.EXPECT().CreateMany(gomock.Any()).Return(errors.New("custom error"))
+	wagers, err := wagerService.CreateWagers(model.CreateWagersRequest{Wagers: []model.CreateWagerRequest{
+		{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 60},
+	}})
+	assert.Nil(t, wagers)
+	assert.EqualError(t, err, "failed to create wagers: custom error")
 }
 
-// TODO Add more BuyWager tests
 func Test_BuyWager_BadRequests(t *testing.T) {
 	ctrl := gomock.NewController(t)
-	//db, mockSQL := NewDBMock()
-	wagerService, mockDB := NewMockWagerService(ctrl)
-	mockRows := mocks.NewMockDBRows(ctrl)
+	wagerService, mockStore := NewMockWagerService(ctrl)
 
-	req := model.BuyWagerRequest{WagerID: 1, BuyingPrice: 1}
+	req := model.BuyWagerRequest{WagerID: 1, BuyingPrice: 10}
 
 	t.Run("WagerID not found", func(t *testing.T) {
-		mockDB.EXPECT().Query(gomock.Any(), req.WagerID).Return(mockRows, nil)
-		mockRows.EXPECT().Next().Return(false)
-		mockRows.EXPECT().Close()
+		mockStore.wagers.EXPECT().GetByIDForUpdate(req.WagerID).Return(nil, repository.ErrNotFound)
 		_, err := wagerService.BuyWager(req)
 		assert.Contains(t, err.Error(), "id not found")
 	})
+
+	t.Run("BuyingPrice larger than CurrentSellingPrice", func(t *testing.T) {
+		wager := &model.Wager{ID: 1, Status: model.WAGER_STATUS_OPEN, SellingPrice: 100, CurrentSellingPrice: 5}
+		mockStore.wagers.EXPECT().GetByIDForUpdate(req.WagerID).Return(wager, nil)
+		_, err := wagerService.BuyWager(req)
+		assert.EqualError(t, err, "buying price must be equal or smaller than current selling price")
+	})
+
+	t.Run("Failed to create purchase", func(t *testing.T) {
+		wager := &model.Wager{ID: 1, Status: model.WAGER_STATUS_OPEN, SellingPrice: 100, CurrentSellingPrice: 100}
+		mockStore.wagers.EXPECT().GetByIDForUpdate(req.WagerID).Return(wager, nil)
+		mockStore.wagers.EXPECT().UpdateSale(wager).Return(nil)
+		mockStore.purchases.EXPECT().Create(gomock.Any()).Return(errors.New("custom error"))
+		_, err := wagerService.BuyWager(req)
+		assert.Error(t, err)
+	})
+}
+
+func Test_BuyWager_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	wagerService, mockStore := NewMockWagerService(ctrl)
+
+	req := model.BuyWagerRequest{WagerID: 1, BuyingPrice: 25}
+	wager := &model.Wager{ID: 1, Status: model.WAGER_STATUS_OPEN, SellingPrice: 100, CurrentSellingPrice: 100}
+
+	mockStore.wagers.EXPECT().GetByIDForUpdate(req.WagerID).Return(wager, nil)
+	mockStore.wagers.EXPECT().UpdateSale(gomock.Any()).DoAndReturn(func(w *model.Wager) error {
+		assert.Equal(t, float64(75), w.CurrentSellingPrice)
+		assert.Equal(t, utils.NewNullUint(25), w.PercentageSold)
+		assert.Equal(t, float64(25), w.AmountSold.Float64)
+		return nil
+	})
+	mockStore.purchases.EXPECT().Create(gomock.Any()).DoAndReturn(func(p *model.Purchase) error {
+		p.PurchaseID = 7
+		return nil
+	})
+	mockStore.positions.EXPECT().Create(gomock.Any()).DoAndReturn(func(p *model.Position) error {
+		assert.Equal(t, uint(7), p.PurchaseID)
+		assert.Equal(t, float64(25), p.FaceValue)
+		return nil
+	})
+
+	res, err := wagerService.BuyWager(req)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), res.PurchaseID)
+	assert.Equal(t, req.BuyingPrice, res.BuyingPrice)
+}
+
+func Test_QuoteWager(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	wagerService, mockStore := NewMockWagerService(ctrl)
+
+	t.Run("Not found", func(t *testing.T) {
+		req := model.BuyWagerRequest{WagerID: 2, BuyingPrice: 10}
+		mockStore.wagers.EXPECT().GetByID(req.WagerID).Return(nil, repository.ErrNotFound)
+		quote, err := wagerService.QuoteWager(req)
+		assert.Nil(t, quote)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Buying price larger than current selling price", func(t *testing.T) {
+		req := model.BuyWagerRequest{WagerID: 1, BuyingPrice: 150}
+		wager := &model.Wager{ID: 1, Status: model.WAGER_STATUS_OPEN, SellingPrice: 200, CurrentSellingPrice: 100}
+		mockStore.wagers.EXPECT().GetByID(req.WagerID).Return(wager, nil)
+		quote, err := wagerService.QuoteWager(req)
+		assert.Nil(t, quote)
+		assert.Error(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		// no UpdateSale or purchase Create is expected, a quote never writes
+		req := model.BuyWagerRequest{WagerID: 1, BuyingPrice: 50}
+		wager := &model.Wager{ID: 1, Status: model.WAGER_STATUS_OPEN, TotalWagerValue: 100, Odds: 2 * odds.SCALE, SellingPercentage: 40, SellingPrice: 200, CurrentSellingPrice: 200}
+		mockStore.wagers.EXPECT().GetByID(req.WagerID).Return(wager, nil)
+		quote, err := wagerService.QuoteWager(req)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), quote.WagerID)
+		assert.Equal(t, float64(150), quote.CurrentSellingPrice)
+		assert.Equal(t, utils.NewNullUint(25), quote.PercentageSold)
+		assert.Equal(t, float64(50), quote.AmountSold.Float64)
+		assert.Equal(t, float64(10), quote.SharePercentage)
+		assert.Equal(t, float64(20), quote.PotentialPayout)
+	})
+}
+
+func Test_BuyWager_PurchaseSize(t *testing.T) {
+	wagerService := NewWagerService(conf.GetDefaultConfig(), repository.NewMemoryStore(), nil)
+	wager, err := wagerService.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100, MinPurchase: 10, MaxPurchase: 40, PurchaseIncrement: 2.5})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(10), wager.MinPurchase)
+
+	tests := []struct {
+		name        string
+		buyingPrice float64
+		err         string
+	}{
+		{name: "Below minimum", buyingPrice: 7.5, err: "invalid purchase size: buying price must be at least 10"},
+		{name: "Above maximum", buyingPrice: 42.5, err: "invalid purchase size: buying price must be at most 40"},
+		{name: "Off increment", buyingPrice: 11, err: "invalid purchase size: buying price must be a multiple of 2.5"},
+		{name: "Maximum", buyingPrice: 40},
+		{name: "Increment", buyingPrice: 12.5},
+		{name: "Minimum", buyingPrice: 10},
+		{name: "All but the remainder", buyingPrice: 35},
+		// 2.5 are left, which is below the minimum
+		{name: "Above remainder", buyingPrice: 5, err: ErrBuyingPriceTooHigh.Error()},
+		{name: "Remainder", buyingPrice: 2.5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: test.buyingPrice})
+			if test.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, test.err)
+		})
+	}
+
+	wager, err = wagerService.GetWager(wager.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), wager.CurrentSellingPrice)
+
+	t.Run("Remainder off increment", func(t *testing.T) {
+		wager, err := wagerService.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 51, PurchaseIncrement: 5})
+		assert.NoError(t, err)
+		_, err = wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 50})
+		assert.NoError(t, err)
+		_, err = wagerService.QuoteWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 0.5})
+		assert.ErrorIs(t, err, ErrInvalidPurchaseSize)
+		_, err = wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 1})
+		assert.NoError(t, err)
+	})
+}
+
+func Test_BuyWagers_LockOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	wagerService, mockStore := NewMockWagerService(ctrl)
+
+	req := model.BatchPurchaseRequest{Items: []model.BatchPurchaseItem{
+		{WagerID: 3, BuyingPrice: 10},
+		{WagerID: 1, BuyingPrice: 10},
+		{WagerID: 2, BuyingPrice: 10},
+	}}
+	gomock.InOrder(
+		mockStore.wagers.EXPECT().GetByIDForUpdate(uint(1)).Return(&model.Wager{ID: 1, Status: model.WAGER_STATUS_OPEN, SellingPrice: 100, CurrentSellingPrice: 100}, nil),
+		mockStore.wagers.EXPECT().GetByIDForUpdate(uint(2)).Return(&model.Wager{ID: 2, Status: model.WAGER_STATUS_OPEN, SellingPrice: 100, CurrentSellingPrice: 100}, nil),
+		mockStore.wagers.EXPECT().GetByIDForUpdate(uint(3)).Return(&model.Wager{ID: 3, Status: model.WAGER_STATUS_OPEN, SellingPrice: 100, CurrentSellingPrice: 100}, nil),
+	)
+	mockStore.wagers.EXPECT().UpdateSale(gomock.Any()).Return(nil).Times(3)
+	mockStore.purchases.EXPECT().Create(gomock.Any()).DoAndReturn(func(p *model.Purchase) error {
+		p.PurchaseID = p.WagerID + 10
+		return nil
+	}).Times(3)
+	mockStore.positions.EXPECT().Create(gomock.Any()).Return(nil).Times(3)
+
+	purchases, err := wagerService.BuyWagers(req)
+	assert.NoError(t, err)
+	// purchases keep the order of the request
+	for i, item := range req.Items {
+		assert.Equal(t, item.WagerID, purchases[i].WagerID)
+		assert.Equal(t, item.WagerID+10, purchases[i].PurchaseID)
+	}
+}
+
+func Test_BuyWagers_StoreError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	wagerService, mockStore := NewMockWagerService(ctrl)
+
+	req := model.BatchPurchaseRequest{Items: []model.BatchPurchaseItem{{WagerID: 1, BuyingPrice: 10}}}
+	mockStore.wagers.EXPECT().GetByIDForUpdate(uint(1)).Return(nil, errors.New("custom error"))
+
+	purchases, err := wagerService.BuyWagers(req)
+	assert.Nil(t, purchases)
+	assert.EqualError(t, err, "custom error")
+}
+
+func Test_BuyWagers_AllOrNothing(t *testing.T) {
+	wagerService := NewWagerService(conf.GetDefaultConfig(), repository.NewMemoryStore(), nil)
+	for i := 0; i < 2; i++ {
+		_, err := wagerService.CreateWager(model.CreateWagerRequest{TotalWagerValue: 100, Odds: "2", SellingPercentage: 50, SellingPrice: 100})
+		assert.NoError(t, err)
+	}
+
+	t.Run("Failed items", func(t *testing.T) {
+		// the second item only fails because the first one bought most of wager 1
+		purchases, err := wagerService.BuyWagers(model.BatchPurchaseRequest{Items: []model.BatchPurchaseItem{
+			{WagerID: 1, BuyingPrice: 60},
+			{WagerID: 1, BuyingPrice: 60},
+			{WagerID: 2, BuyingPrice: 10},
+			{WagerID: 3, BuyingPrice: 10},
+		}})
+		assert.Nil(t, purchases)
+		batchErr := &model.BatchError{}
+		assert.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, []model.BatchItemError{
+			{Index: 1, WagerID: 1, Error: ErrBuyingPriceTooHigh.Error()},
+			{Index: 3, WagerID: 3, Error: repository.ErrNotFound.Error()},
+		}, batchErr.Items)
+
+		for id := uint(1); id <= 2; id++ {
+			wager, err := wagerService.GetWager(id)
+			assert.NoError(t, err)
+			assert.Equal(t, float64(100), wager.CurrentSellingPrice)
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		purchases, err := wagerService.BuyWagers(model.BatchPurchaseRequest{Items: []model.BatchPurchaseItem{
+			{WagerID: 2, BuyingPrice: 30},
+			{WagerID: 1, BuyingPrice: 60},
+			{WagerID: 1, BuyingPrice: 40},
+		}})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(purchases))
+
+		wager, err := wagerService.GetWager(1)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(0), wager.CurrentSellingPrice)
+		wager, err = wagerService.GetWager(2)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(70), wager.CurrentSellingPrice)
+	})
+}
+
+func Test_BuyWager_ConcurrentBuys(t *testing.T) {
+	wagerService := NewWagerService(conf.GetDefaultConfig(), repository.NewMemoryStore(), nil)
+	wager, err := wagerService.CreateWager(model.CreateWagerRequest{
+		TotalWagerValue:   100,
+		Odds:              "2",
+		SellingPercentage: 50,
+		SellingPrice:      100,
+	})
+	assert.NoError(t, err)
+
+	// 30 buyers compete for a wager that only has room for 20 of them
+	var wg sync.WaitGroup
+	var succeeded int32
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 5}); err == nil {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	res, err := wagerService.GetWagerList(model.GetWagerListRequest{Page: 1, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(20), succeeded)
+	assert.Equal(t, float64(0), res.Wagers[0].CurrentSellingPrice)
+	assert.Equal(t, float64(100), res.Wagers[0].AmountSold.Float64)
+	assert.Equal(t, uint(100), res.Wagers[0].PercentageSold.Uint)
+}
+
+func newSQLiteWagerService(b *testing.B, prepareStatements bool) WagerService {
+	config := conf.GetDefaultConfig()
+	config.SQL.Dialect = conf.DIALECT_SQLITE
+	config.SQL.DatabaseAddress = filepath.Join(b.TempDir(), "wager.db")
+	config.SQL.PrepareStatements = prepareStatements
+
+	dialect, err := database.GetDialect(config.SQL.Dialect)
+	if err != nil {
+		b.Fatal(err)
+	}
+	sqlDB, err := sql.Open(dialect.DriverName(), dialect.DataSourceName(config.SQL))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { sqlDB.Close() })
+
+	db := database.NewDB(sqlDB)
+	if err := sqlmigration.Migrate(db, dialect); err != nil {
+		b.Fatal(err)
+	}
+	store, err := repository.NewSQLStore(config.SQL, dialect, db)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return NewWagerService(config, store, nil)
+}
+
+// BenchmarkBuyWager compares buy throughput with and without the prepared statement cache
+func BenchmarkBuyWager(b *testing.B) {
+	logrus.SetLevel(logrus.WarnLevel)
+	defer logrus.SetLevel(logrus.InfoLevel)
+
+	for _, prepareStatements := range []bool{false, true} {
+		b.Run(fmt.Sprintf("PrepareStatements=%v", prepareStatements), func(b *testing.B) {
+			wagerService := newSQLiteWagerService(b, prepareStatements)
+			wager, err := wagerService.CreateWager(model.CreateWagerRequest{
+				TotalWagerValue:   100,
+				Odds:              "2",
+				SellingPercentage: 50,
+				SellingPrice:      float64(b.N),
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := wagerService.BuyWager(model.BuyWagerRequest{WagerID: wager.ID, BuyingPrice: 1}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
 }
@@ -0,0 +1,98 @@
+package sqlmigration
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"wager/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var migrations embed.FS
+
+// Migrate applies the migration set in the directory named after the dialect.
+// Files are applied in lexical order and recorded in schema_migrations, so each
+// file runs only once per database.
+func Migrate(db database.DBManager, dialect database.Dialect) error {
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version varchar(255) not null primary key)"); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+
+	files, err := fs.Glob(migrations, dialect.Name()+"/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := path.Base(file)
+		if applied[version] {
+			continue
+		}
+
+		if err := applyMigration(db, dialect, file, version); err != nil {
+			return fmt.Errorf("failed to apply migration %v: %v", version, err)
+		}
+		logrus.WithField("version", version).Info("Applied migration")
+	}
+
+	return nil
+}
+
+func appliedVersions(db database.DBManager) (map[string]bool, error) {
+	rows, err := db.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+func applyMigration(db database.DBManager, dialect database.Dialect, file string, version string) error {
+	content, err := migrations.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range strings.Split(string(content), ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if _, err := tx.Exec(statement); err != nil {
+			db.RollbackTx(tx)
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(dialect.Rebind("INSERT INTO schema_migrations (version) VALUES (?)"), version); err != nil {
+		db.RollbackTx(tx)
+		return err
+	}
+
+	return db.CommitTx(tx)
+}
@@ -0,0 +1 @@
+ALTER TABLE wagers ADD CONSTRAINT wagers_current_selling_price_check CHECK (current_selling_price >= 0)
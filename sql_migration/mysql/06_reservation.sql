@@ -0,0 +1,13 @@
+ALTER TABLE wagers ADD COLUMN reserved_amount decimal(15, 2) not null default 0;
+CREATE TABLE if NOT EXISTS reservation (
+    id bigint unsigned not null auto_increment primary key,
+    wager_id bigint unsigned not null,
+    buyer varchar(64) not null default '',
+    buying_price decimal(15, 2) not null,
+    status varchar(16) not null,
+    created_at bigint not null,
+    expires_at bigint not null,
+    purchase_id bigint unsigned not null default 0,
+    foreign key (wager_id) references wagers (id)
+);
+CREATE INDEX reservation_status_expires_at ON reservation (status, expires_at)
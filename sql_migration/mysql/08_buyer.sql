@@ -0,0 +1,3 @@
+CREATE TABLE if NOT EXISTS buyer (
+    name varchar(64) not null primary key
+)
\ No newline at end of file
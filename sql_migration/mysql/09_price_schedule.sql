@@ -0,0 +1,8 @@
+ALTER TABLE wagers ADD COLUMN price_schedule varchar(16) not null default '';
+ALTER TABLE wagers ADD COLUMN floor_price decimal(15, 2) not null default 0;
+ALTER TABLE wagers ADD COLUMN decay_seconds bigint not null default 0;
+ALTER TABLE wagers ADD COLUMN decay_steps int not null default 0;
+ALTER TABLE purchase ADD COLUMN face_value decimal(15, 2) not null default 0;
+UPDATE purchase SET face_value = buying_price;
+ALTER TABLE reservation ADD COLUMN face_value decimal(15, 2) not null default 0;
+UPDATE reservation SET face_value = buying_price
@@ -0,0 +1,15 @@
+ALTER TABLE wagers ADD COLUMN ask_price decimal(15, 2) not null default 0;
+CREATE TABLE if NOT EXISTS bid (
+    id bigint unsigned not null auto_increment primary key,
+    wager_id bigint unsigned not null,
+    buyer varchar(64) not null default '',
+    face_value decimal(15, 2) not null,
+    bid_price decimal(15, 2) not null,
+    status varchar(16) not null,
+    created_at bigint not null,
+    expires_at bigint not null,
+    purchase_id bigint unsigned not null default 0,
+    foreign key (wager_id) references wagers (id)
+);
+CREATE INDEX bid_wager_id_status ON bid (wager_id, status);
+CREATE INDEX bid_status_expires_at ON bid (status, expires_at)
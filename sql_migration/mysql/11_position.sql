@@ -0,0 +1,43 @@
+CREATE TABLE if NOT EXISTS position (
+    id bigint unsigned not null auto_increment primary key,
+    purchase_id bigint unsigned not null,
+    wager_id bigint unsigned not null,
+    holder varchar(64) not null default '',
+    face_value decimal(15, 2) not null,
+    listed_face_value decimal(15, 2) not null default 0,
+    acquired_at bigint not null,
+    foreign key (purchase_id) references purchase (id),
+    foreign key (wager_id) references wagers (id)
+);
+CREATE INDEX position_holder ON position (holder);
+CREATE INDEX position_purchase_id ON position (purchase_id);
+INSERT INTO position (purchase_id, wager_id, holder, face_value, acquired_at)
+    SELECT id, wager_id, buyer, face_value, bought_at FROM purchase WHERE refunded_at = 0;
+CREATE TABLE if NOT EXISTS listing (
+    id bigint unsigned not null auto_increment primary key,
+    position_id bigint unsigned not null,
+    purchase_id bigint unsigned not null,
+    wager_id bigint unsigned not null,
+    seller varchar(64) not null,
+    face_value decimal(15, 2) not null,
+    price decimal(15, 2) not null,
+    status varchar(16) not null,
+    created_at bigint not null,
+    transfer_id bigint unsigned not null default 0,
+    foreign key (position_id) references position (id)
+);
+CREATE INDEX listing_wager_id_status ON listing (wager_id, status);
+CREATE TABLE if NOT EXISTS transfer (
+    id bigint unsigned not null auto_increment primary key,
+    purchase_id bigint unsigned not null,
+    listing_id bigint unsigned not null,
+    from_position_id bigint unsigned not null,
+    to_position_id bigint unsigned not null,
+    seller varchar(64) not null,
+    buyer varchar(64) not null,
+    face_value decimal(15, 2) not null,
+    price decimal(15, 2) not null,
+    transferred_at bigint not null,
+    foreign key (purchase_id) references purchase (id)
+);
+CREATE INDEX transfer_purchase_id ON transfer (purchase_id)
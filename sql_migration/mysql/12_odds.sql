@@ -0,0 +1 @@
+UPDATE wagers SET odds = odds * 10000
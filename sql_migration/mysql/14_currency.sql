@@ -0,0 +1,9 @@
+ALTER TABLE wagers ADD COLUMN currency char(3) not null default 'USD';
+CREATE INDEX wagers_currency ON wagers (currency);
+ALTER TABLE wagers MODIFY selling_price decimal(15, 3) not null, MODIFY current_selling_price decimal(15, 3) not null, MODIFY amount_sold decimal(15, 3), MODIFY reserved_amount decimal(15, 3) not null default 0, MODIFY min_purchase decimal(15, 3) not null default 0, MODIFY max_purchase decimal(15, 3) not null default 0, MODIFY purchase_increment decimal(15, 3) not null default 0, MODIFY floor_price decimal(15, 3) not null default 0, MODIFY ask_price decimal(15, 3) not null default 0;
+ALTER TABLE purchase MODIFY buying_price decimal(15, 3) not null, MODIFY face_value decimal(15, 3) not null default 0, MODIFY maker_fee decimal(15, 3) not null default 0, MODIFY taker_fee decimal(15, 3) not null default 0;
+ALTER TABLE reservation MODIFY buying_price decimal(15, 3) not null, MODIFY face_value decimal(15, 3) not null default 0;
+ALTER TABLE bid MODIFY face_value decimal(15, 3) not null, MODIFY bid_price decimal(15, 3) not null;
+ALTER TABLE position MODIFY face_value decimal(15, 3) not null, MODIFY listed_face_value decimal(15, 3) not null default 0;
+ALTER TABLE listing MODIFY face_value decimal(15, 3) not null, MODIFY price decimal(15, 3) not null;
+ALTER TABLE transfer MODIFY face_value decimal(15, 3) not null, MODIFY price decimal(15, 3) not null
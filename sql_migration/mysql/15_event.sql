@@ -0,0 +1,30 @@
+CREATE TABLE if NOT EXISTS event (
+    id bigint unsigned not null auto_increment primary key,
+    name varchar(128) not null,
+    starts_at bigint not null,
+    status varchar(16) not null
+);
+CREATE INDEX event_starts_at ON event (starts_at);
+CREATE TABLE if NOT EXISTS market (
+    id bigint unsigned not null auto_increment primary key,
+    event_id bigint unsigned not null,
+    name varchar(128) not null,
+    status varchar(16) not null,
+    winning_selection_id bigint unsigned not null default 0,
+    settled_at bigint not null default 0,
+    foreign key (event_id) references event (id)
+);
+CREATE INDEX market_event_id ON market (event_id);
+CREATE TABLE if NOT EXISTS selection (
+    id bigint unsigned not null auto_increment primary key,
+    market_id bigint unsigned not null,
+    name varchar(128) not null,
+    foreign key (market_id) references market (id)
+);
+CREATE INDEX selection_market_id ON selection (market_id);
+ALTER TABLE wagers ADD COLUMN event_id bigint unsigned not null default 0;
+ALTER TABLE wagers ADD COLUMN market_id bigint unsigned not null default 0;
+ALTER TABLE wagers ADD COLUMN selection_id bigint unsigned not null default 0;
+CREATE INDEX wagers_event_id ON wagers (event_id);
+CREATE INDEX wagers_market_id ON wagers (market_id);
+ALTER TABLE position ADD COLUMN payout decimal(15, 3) not null default 0
@@ -0,0 +1,5 @@
+ALTER TABLE transfer ADD COLUMN maker_fee decimal(15, 3) not null default 0;
+ALTER TABLE transfer ADD COLUMN maker_rate decimal(7, 4) not null default 0;
+ALTER TABLE transfer ADD COLUMN taker_fee decimal(15, 3) not null default 0;
+ALTER TABLE transfer ADD COLUMN taker_rate decimal(7, 4) not null default 0;
+CREATE INDEX transfer_transferred_at ON transfer (transferred_at)
\ No newline at end of file
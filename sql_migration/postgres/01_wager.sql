@@ -0,0 +1,11 @@
+CREATE TABLE if NOT EXISTS wagers (
+    id bigserial primary key,
+    total_wager_value integer not null,
+    odds integer not null,
+    selling_percentage integer not null,
+    selling_price numeric(15, 2) not null,
+    current_selling_price numeric(15, 2) not null check (current_selling_price >= 0),
+    percentage_sold integer,
+    amount_sold numeric(15, 2),
+    place_at bigint not null
+)
@@ -0,0 +1,6 @@
+CREATE TABLE if NOT EXISTS purchase (
+    id bigserial primary key,
+    wager_id bigint not null references wagers (id),
+    buying_price numeric(15, 2) not null,
+    bought_at bigint not null
+)
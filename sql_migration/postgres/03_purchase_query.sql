@@ -0,0 +1,3 @@
+ALTER TABLE purchase ADD COLUMN buyer varchar(64) not null default '';
+CREATE INDEX purchase_wager_id_bought_at ON purchase (wager_id, bought_at);
+CREATE INDEX purchase_buyer_bought_at ON purchase (buyer, bought_at)
@@ -0,0 +1,2 @@
+ALTER TABLE wagers ADD COLUMN status varchar(16) not null default 'open';
+ALTER TABLE purchase ADD COLUMN refunded_at bigint not null default 0
@@ -0,0 +1,12 @@
+ALTER TABLE wagers ADD COLUMN reserved_amount numeric(15, 2) not null default 0;
+CREATE TABLE if NOT EXISTS reservation (
+    id bigserial primary key,
+    wager_id bigint not null references wagers (id),
+    buyer varchar(64) not null default '',
+    buying_price numeric(15, 2) not null,
+    status varchar(16) not null,
+    created_at bigint not null,
+    expires_at bigint not null,
+    purchase_id bigint not null default 0
+);
+CREATE INDEX reservation_status_expires_at ON reservation (status, expires_at)
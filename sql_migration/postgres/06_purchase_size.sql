@@ -0,0 +1,3 @@
+ALTER TABLE wagers ADD COLUMN min_purchase numeric(15, 2) not null default 0;
+ALTER TABLE wagers ADD COLUMN max_purchase numeric(15, 2) not null default 0;
+ALTER TABLE wagers ADD COLUMN purchase_increment numeric(15, 2) not null default 0
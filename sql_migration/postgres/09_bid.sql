@@ -0,0 +1,14 @@
+ALTER TABLE wagers ADD COLUMN ask_price numeric(15, 2) not null default 0;
+CREATE TABLE if NOT EXISTS bid (
+    id bigserial primary key,
+    wager_id bigint not null references wagers (id),
+    buyer varchar(64) not null default '',
+    face_value numeric(15, 2) not null,
+    bid_price numeric(15, 2) not null,
+    status varchar(16) not null,
+    created_at bigint not null,
+    expires_at bigint not null,
+    purchase_id bigint not null default 0
+);
+CREATE INDEX bid_wager_id_status ON bid (wager_id, status);
+CREATE INDEX bid_status_expires_at ON bid (status, expires_at)
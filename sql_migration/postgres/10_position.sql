@@ -0,0 +1,39 @@
+CREATE TABLE if NOT EXISTS position (
+    id bigserial primary key,
+    purchase_id bigint not null references purchase (id),
+    wager_id bigint not null references wagers (id),
+    holder varchar(64) not null default '',
+    face_value numeric(15, 2) not null,
+    listed_face_value numeric(15, 2) not null default 0,
+    acquired_at bigint not null
+);
+CREATE INDEX position_holder ON position (holder);
+CREATE INDEX position_purchase_id ON position (purchase_id);
+INSERT INTO position (purchase_id, wager_id, holder, face_value, acquired_at)
+    SELECT id, wager_id, buyer, face_value, bought_at FROM purchase WHERE refunded_at = 0;
+CREATE TABLE if NOT EXISTS listing (
+    id bigserial primary key,
+    position_id bigint not null references position (id),
+    purchase_id bigint not null,
+    wager_id bigint not null,
+    seller varchar(64) not null,
+    face_value numeric(15, 2) not null,
+    price numeric(15, 2) not null,
+    status varchar(16) not null,
+    created_at bigint not null,
+    transfer_id bigint not null default 0
+);
+CREATE INDEX listing_wager_id_status ON listing (wager_id, status);
+CREATE TABLE if NOT EXISTS transfer (
+    id bigserial primary key,
+    purchase_id bigint not null references purchase (id),
+    listing_id bigint not null,
+    from_position_id bigint not null,
+    to_position_id bigint not null,
+    seller varchar(64) not null,
+    buyer varchar(64) not null,
+    face_value numeric(15, 2) not null,
+    price numeric(15, 2) not null,
+    transferred_at bigint not null
+);
+CREATE INDEX transfer_purchase_id ON transfer (purchase_id)
@@ -0,0 +1,6 @@
+ALTER TABLE wagers ADD COLUMN seller varchar(64) not null default '';
+CREATE INDEX wagers_seller ON wagers (seller);
+ALTER TABLE purchase ADD COLUMN maker_fee numeric(15, 2) not null default 0;
+ALTER TABLE purchase ADD COLUMN maker_rate numeric(7, 4) not null default 0;
+ALTER TABLE purchase ADD COLUMN taker_fee numeric(15, 2) not null default 0;
+ALTER TABLE purchase ADD COLUMN taker_rate numeric(7, 4) not null default 0
@@ -0,0 +1,9 @@
+ALTER TABLE wagers ADD COLUMN currency char(3) not null default 'USD';
+CREATE INDEX wagers_currency ON wagers (currency);
+ALTER TABLE wagers ALTER COLUMN selling_price TYPE numeric(15, 3), ALTER COLUMN current_selling_price TYPE numeric(15, 3), ALTER COLUMN amount_sold TYPE numeric(15, 3), ALTER COLUMN reserved_amount TYPE numeric(15, 3), ALTER COLUMN min_purchase TYPE numeric(15, 3), ALTER COLUMN max_purchase TYPE numeric(15, 3), ALTER COLUMN purchase_increment TYPE numeric(15, 3), ALTER COLUMN floor_price TYPE numeric(15, 3), ALTER COLUMN ask_price TYPE numeric(15, 3);
+ALTER TABLE purchase ALTER COLUMN buying_price TYPE numeric(15, 3), ALTER COLUMN face_value TYPE numeric(15, 3), ALTER COLUMN maker_fee TYPE numeric(15, 3), ALTER COLUMN taker_fee TYPE numeric(15, 3);
+ALTER TABLE reservation ALTER COLUMN buying_price TYPE numeric(15, 3), ALTER COLUMN face_value TYPE numeric(15, 3);
+ALTER TABLE bid ALTER COLUMN face_value TYPE numeric(15, 3), ALTER COLUMN bid_price TYPE numeric(15, 3);
+ALTER TABLE position ALTER COLUMN face_value TYPE numeric(15, 3), ALTER COLUMN listed_face_value TYPE numeric(15, 3);
+ALTER TABLE listing ALTER COLUMN face_value TYPE numeric(15, 3), ALTER COLUMN price TYPE numeric(15, 3);
+ALTER TABLE transfer ALTER COLUMN face_value TYPE numeric(15, 3), ALTER COLUMN price TYPE numeric(15, 3)
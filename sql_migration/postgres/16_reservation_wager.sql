@@ -0,0 +1 @@
+CREATE INDEX reservation_wager_id_status ON reservation (wager_id, status)
\ No newline at end of file
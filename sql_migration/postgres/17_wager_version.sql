@@ -0,0 +1 @@
+ALTER TABLE wagers ADD COLUMN version bigint not null default 0
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE if NOT EXISTS wagers (
+    id integer primary key autoincrement,
+    total_wager_value integer not null,
+    odds integer not null,
+    selling_percentage integer not null,
+    selling_price real not null,
+    current_selling_price real not null check (current_selling_price >= 0),
+    percentage_sold integer,
+    amount_sold real,
+    place_at integer not null
+)
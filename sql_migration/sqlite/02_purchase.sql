@@ -0,0 +1,6 @@
+CREATE TABLE if NOT EXISTS purchase (
+    id integer primary key autoincrement,
+    wager_id integer not null references wagers (id),
+    buying_price real not null,
+    bought_at integer not null
+)
@@ -0,0 +1,12 @@
+ALTER TABLE wagers ADD COLUMN reserved_amount real not null default 0;
+CREATE TABLE if NOT EXISTS reservation (
+    id integer primary key autoincrement,
+    wager_id integer not null references wagers (id),
+    buyer varchar(64) not null default '',
+    buying_price real not null,
+    status varchar(16) not null,
+    created_at integer not null,
+    expires_at integer not null,
+    purchase_id integer not null default 0
+);
+CREATE INDEX reservation_status_expires_at ON reservation (status, expires_at)
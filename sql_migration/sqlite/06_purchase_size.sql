@@ -0,0 +1,3 @@
+ALTER TABLE wagers ADD COLUMN min_purchase real not null default 0;
+ALTER TABLE wagers ADD COLUMN max_purchase real not null default 0;
+ALTER TABLE wagers ADD COLUMN purchase_increment real not null default 0
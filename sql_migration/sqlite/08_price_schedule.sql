@@ -0,0 +1,8 @@
+ALTER TABLE wagers ADD COLUMN price_schedule varchar(16) not null default '';
+ALTER TABLE wagers ADD COLUMN floor_price real not null default 0;
+ALTER TABLE wagers ADD COLUMN decay_seconds integer not null default 0;
+ALTER TABLE wagers ADD COLUMN decay_steps integer not null default 0;
+ALTER TABLE purchase ADD COLUMN face_value real not null default 0;
+UPDATE purchase SET face_value = buying_price;
+ALTER TABLE reservation ADD COLUMN face_value real not null default 0;
+UPDATE reservation SET face_value = buying_price
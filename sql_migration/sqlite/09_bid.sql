@@ -0,0 +1,14 @@
+ALTER TABLE wagers ADD COLUMN ask_price real not null default 0;
+CREATE TABLE if NOT EXISTS bid (
+    id integer primary key autoincrement,
+    wager_id integer not null references wagers (id),
+    buyer varchar(64) not null default '',
+    face_value real not null,
+    bid_price real not null,
+    status varchar(16) not null,
+    created_at integer not null,
+    expires_at integer not null,
+    purchase_id integer not null default 0
+);
+CREATE INDEX bid_wager_id_status ON bid (wager_id, status);
+CREATE INDEX bid_status_expires_at ON bid (status, expires_at)
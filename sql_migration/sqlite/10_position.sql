@@ -0,0 +1,39 @@
+CREATE TABLE if NOT EXISTS position (
+    id integer primary key autoincrement,
+    purchase_id integer not null references purchase (id),
+    wager_id integer not null references wagers (id),
+    holder varchar(64) not null default '',
+    face_value real not null,
+    listed_face_value real not null default 0,
+    acquired_at integer not null
+);
+CREATE INDEX position_holder ON position (holder);
+CREATE INDEX position_purchase_id ON position (purchase_id);
+INSERT INTO position (purchase_id, wager_id, holder, face_value, acquired_at)
+    SELECT id, wager_id, buyer, face_value, bought_at FROM purchase WHERE refunded_at = 0;
+CREATE TABLE if NOT EXISTS listing (
+    id integer primary key autoincrement,
+    position_id integer not null references position (id),
+    purchase_id integer not null,
+    wager_id integer not null,
+    seller varchar(64) not null,
+    face_value real not null,
+    price real not null,
+    status varchar(16) not null,
+    created_at integer not null,
+    transfer_id integer not null default 0
+);
+CREATE INDEX listing_wager_id_status ON listing (wager_id, status);
+CREATE TABLE if NOT EXISTS transfer (
+    id integer primary key autoincrement,
+    purchase_id integer not null references purchase (id),
+    listing_id integer not null,
+    from_position_id integer not null,
+    to_position_id integer not null,
+    seller varchar(64) not null,
+    buyer varchar(64) not null,
+    face_value real not null,
+    price real not null,
+    transferred_at integer not null
+);
+CREATE INDEX transfer_purchase_id ON transfer (purchase_id)
@@ -0,0 +1,6 @@
+ALTER TABLE wagers ADD COLUMN seller varchar(64) not null default '';
+CREATE INDEX wagers_seller ON wagers (seller);
+ALTER TABLE purchase ADD COLUMN maker_fee real not null default 0;
+ALTER TABLE purchase ADD COLUMN maker_rate real not null default 0;
+ALTER TABLE purchase ADD COLUMN taker_fee real not null default 0;
+ALTER TABLE purchase ADD COLUMN taker_rate real not null default 0
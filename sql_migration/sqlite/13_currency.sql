@@ -0,0 +1,2 @@
+ALTER TABLE wagers ADD COLUMN currency char(3) not null default 'USD';
+CREATE INDEX wagers_currency ON wagers (currency)
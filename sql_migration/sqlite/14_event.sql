@@ -0,0 +1,28 @@
+CREATE TABLE if NOT EXISTS event (
+    id integer primary key autoincrement,
+    name varchar(128) not null,
+    starts_at integer not null,
+    status varchar(16) not null
+);
+CREATE INDEX event_starts_at ON event (starts_at);
+CREATE TABLE if NOT EXISTS market (
+    id integer primary key autoincrement,
+    event_id integer not null references event (id),
+    name varchar(128) not null,
+    status varchar(16) not null,
+    winning_selection_id integer not null default 0,
+    settled_at integer not null default 0
+);
+CREATE INDEX market_event_id ON market (event_id);
+CREATE TABLE if NOT EXISTS selection (
+    id integer primary key autoincrement,
+    market_id integer not null references market (id),
+    name varchar(128) not null
+);
+CREATE INDEX selection_market_id ON selection (market_id);
+ALTER TABLE wagers ADD COLUMN event_id integer not null default 0;
+ALTER TABLE wagers ADD COLUMN market_id integer not null default 0;
+ALTER TABLE wagers ADD COLUMN selection_id integer not null default 0;
+CREATE INDEX wagers_event_id ON wagers (event_id);
+CREATE INDEX wagers_market_id ON wagers (market_id);
+ALTER TABLE position ADD COLUMN payout real not null default 0
@@ -0,0 +1,252 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"wager/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MIN_VERSION_WINDOW is the least number of events during which the broker remembers
+// the version of a wager
+const MIN_VERSION_WINDOW = 1000
+
+// ErrEventID is returned for a last event id which the broker did not give out
+var ErrEventID = errors.New("invalid event id")
+
+// Broker fans the wager events published by the services out to subscribers. It
+// numbers the events and keeps the last ones in a bounded replay buffer, from which
+// a subscriber resumes after the last event it saw. Event ids are <epoch>-<number>,
+// the epoch changes on every start so that the ids of an earlier process are told
+// apart from the ones of this process. Subscribers which do not keep up
+// are dropped rather than slowing down publishers, they resume like any other.
+//
+// Services publish after their transaction committed, so the events of concurrent
+// changes to a wager may arrive out of order. The broker drops an event whose wager
+// is not newer than the last one published for it, the last event of a wager is
+// then always its latest state. Versions are remembered for the last versionWindow
+// events only, an event published later than that after a newer one is not caught.
+type Broker struct {
+	mu    sync.Mutex
+	epoch string
+	// lastID is the number of the last published event
+	lastID uint64
+	// replay is a ring of the last published events, next is where the next event
+	// goes and full tells whether the ring wrapped
+	replay []numberedEvent
+	next   int
+	full   bool
+	// bufferSize is the number of events a subscriber can lag behind
+	bufferSize  int
+	subscribers map[*Subscription]bool
+	// versions holds the last published version of each wager, with the id of the
+	// event which published it
+	versions      map[uint]publishedVersion
+	versionWindow uint64
+}
+
+type numberedEvent struct {
+	number uint64
+	event  model.WagerEvent
+}
+
+type publishedVersion struct {
+	version uint64
+	eventID uint64
+}
+
+func NewBroker(replaySize int, bufferSize int) *Broker {
+	versionWindow := replaySize
+	if versionWindow < MIN_VERSION_WINDOW {
+		versionWindow = MIN_VERSION_WINDOW
+	}
+	return &Broker{
+		epoch:         strconv.FormatInt(time.Now().UnixNano(), 36),
+		replay:        make([]numberedEvent, replaySize),
+		bufferSize:    bufferSize,
+		subscribers:   make(map[*Subscription]bool),
+		versions:      make(map[uint]publishedVersion),
+		versionWindow: uint64(versionWindow),
+	}
+}
+
+// Publish numbers events in order and hands them to the subscribers they concern,
+// events older than the last one published for their wager are dropped
+func (b *Broker) Publish(events ...model.WagerEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, event := range events {
+		if b.stale(event) {
+			logrus.WithFields(logrus.Fields{
+				"wager_id": event.Wager.ID,
+				"version":  event.Wager.Version,
+			}).Debug("Dropped stale wager event")
+			continue
+		}
+		b.lastID++
+		event.ID = fmt.Sprintf("%v-%v", b.epoch, b.lastID)
+		if event.Wager != nil {
+			b.versions[event.Wager.ID] = publishedVersion{version: event.Wager.Version, eventID: b.lastID}
+			b.forgetVersions()
+		}
+		if len(b.replay) > 0 {
+			b.replay[b.next] = numberedEvent{number: b.lastID, event: event}
+			b.next = (b.next + 1) % len(b.replay)
+			b.full = b.full || b.next == 0
+		}
+
+		for subscription := range b.subscribers {
+			if !event.Concerns(subscription.wagerIDs) {
+				continue
+			}
+			select {
+			case subscription.events <- event:
+			default:
+				logrus.WithField("event_id", event.ID).Warn("Dropped slow wager stream subscriber")
+				b.drop(subscription)
+			}
+		}
+	}
+}
+
+// stale reports whether a newer version of the wager of event was already published,
+// b.mu must be held
+func (b *Broker) stale(event model.WagerEvent) bool {
+	if event.Wager == nil {
+		return false
+	}
+	last, ok := b.versions[event.Wager.ID]
+	return ok && event.Wager.Version <= last.version
+}
+
+// forgetVersions drops the versions published more than versionWindow events ago
+// once there are twice as many as the window, b.mu must be held
+func (b *Broker) forgetVersions() {
+	if uint64(len(b.versions)) < 2*b.versionWindow {
+		return
+	}
+	for wagerID, last := range b.versions {
+		if last.eventID+b.versionWindow <= b.lastID {
+			delete(b.versions, wagerID)
+		}
+	}
+}
+
+// Subscribe follows the events about wagerIDs, or every event when it is empty.
+// After is the id of the last event the subscriber saw, empty for a new subscriber,
+// which only gets the events published from now on. A resuming subscriber gets the
+// buffered events after it first, preceded by a WAGER_EVENT_RESET event when some
+// were already dropped from the buffer or after is from before a restart.
+func (b *Broker) Subscribe(wagerIDs []uint, after string) (*Subscription, error) {
+	epoch, afterID, err := parseEventID(after)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscription := &Subscription{
+		broker:   b,
+		wagerIDs: make(map[uint]bool, len(wagerIDs)),
+	}
+	for _, id := range wagerIDs {
+		subscription.wagerIDs[id] = true
+	}
+
+	replay := []model.WagerEvent{}
+	if after != "" {
+		buffered := b.buffered()
+		switch {
+		case epoch != b.epoch || afterID > b.lastID:
+			// the events of the earlier process are gone, nothing is replayed
+			replay = append(replay, model.WagerEvent{Type: model.WAGER_EVENT_RESET})
+			afterID = b.lastID
+		case afterID < b.lastID && (len(buffered) == 0 || buffered[0].number > afterID+1):
+			replay = append(replay, model.WagerEvent{Type: model.WAGER_EVENT_RESET})
+		}
+		for _, buffer := range buffered {
+			if buffer.number > afterID && buffer.event.Concerns(subscription.wagerIDs) {
+				replay = append(replay, buffer.event)
+			}
+		}
+	}
+
+	// the replay always fits, the buffer is left for the events published later
+	subscription.events = make(chan model.WagerEvent, len(replay)+b.bufferSize)
+	for _, event := range replay {
+		subscription.events <- event
+	}
+	b.subscribers[subscription] = true
+	return subscription, nil
+}
+
+// parseEventID splits an event id into its epoch and number, an empty id is valid
+// and has neither
+func parseEventID(id string) (string, uint64, error) {
+	if id == "" {
+		return "", 0, nil
+	}
+	parts := strings.SplitN(id, "-", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", 0, ErrEventID
+	}
+	number, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || number == 0 {
+		return "", 0, ErrEventID
+	}
+	return parts[0], number, nil
+}
+
+// buffered returns the events of the replay buffer from the oldest
+func (b *Broker) buffered() []numberedEvent {
+	if !b.full {
+		return b.replay[:b.next]
+	}
+	return append(append([]numberedEvent{}, b.replay[b.next:]...), b.replay[:b.next]...)
+}
+
+// drop closes the events of subscription, b.mu must be held
+func (b *Broker) drop(subscription *Subscription) {
+	if !b.subscribers[subscription] {
+		return
+	}
+	delete(b.subscribers, subscription)
+	close(subscription.events)
+}
+
+// Subscription receives the events of a subscriber
+type Subscription struct {
+	broker   *Broker
+	wagerIDs map[uint]bool
+	events   chan model.WagerEvent
+}
+
+// Events is closed when the subscription is closed or the subscriber was dropped
+// for falling behind
+func (s *Subscription) Events() <-chan model.WagerEvent {
+	return s.events
+}
+
+// SetWagerIDs changes the wagers the subscription follows from the next published
+// event, an empty wagerIDs follows every wager
+func (s *Subscription) SetWagerIDs(wagerIDs []uint) {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.wagerIDs = make(map[uint]bool, len(wagerIDs))
+	for _, id := range wagerIDs {
+		s.wagerIDs[id] = true
+	}
+}
+
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.drop(s)
+}
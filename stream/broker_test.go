@@ -0,0 +1,205 @@
+package stream
+
+import (
+	"fmt"
+	"testing"
+	"wager/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lastVersion makes each event of wagerEvent newer than the ones before
+var lastVersion uint64
+
+func wagerEvent(eventType string, wagerID uint) model.WagerEvent {
+	lastVersion++
+	return model.WagerEvent{Type: eventType, Wager: &model.Wager{ID: wagerID, Version: lastVersion}}
+}
+
+// received drains the events already sent to subscription
+func received(subscription *Subscription) []model.WagerEvent {
+	events := []model.WagerEvent{}
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+// ids returns the numbers of the ids of events, 0 for the ones without an id
+func ids(events []model.WagerEvent) []uint64 {
+	result := make([]uint64, 0, len(events))
+	for _, event := range events {
+		_, number, _ := parseEventID(event.ID)
+		result = append(result, number)
+	}
+	return result
+}
+
+// eventID is the id broker gives to its event number
+func eventID(broker *Broker, number uint64) string {
+	return fmt.Sprintf("%v-%v", broker.epoch, number)
+}
+
+// subscribe subscribes to broker, failing t on an invalid after
+func subscribe(t *testing.T, broker *Broker, wagerIDs []uint, after string) *Subscription {
+	subscription, err := broker.Subscribe(wagerIDs, after)
+	require.NoError(t, err)
+	return subscription
+}
+
+func Test_Broker_Publish(t *testing.T) {
+	broker := NewBroker(10, 10)
+	all := subscribe(t, broker, nil, "")
+	filtered := subscribe(t, broker, []uint{2, 3}, "")
+
+	broker.Publish(wagerEvent(model.WAGER_EVENT_CREATED, 1), wagerEvent(model.WAGER_EVENT_CREATED, 2))
+	broker.Publish(model.WagerEvent{Type: model.WAGER_EVENT_SETTLEMENT, Market: &model.Market{ID: 1}, WagerIDs: []uint{1, 3}})
+
+	assert.Equal(t, []uint64{1, 2, 3}, ids(received(all)))
+	events := received(filtered)
+	assert.Equal(t, []uint64{2, 3}, ids(events))
+	assert.Equal(t, uint(2), events[0].Wager.ID)
+	assert.Equal(t, model.WAGER_EVENT_SETTLEMENT, events[1].Type)
+
+	// closed subscriptions get nothing more
+	filtered.Close()
+	broker.Publish(wagerEvent(model.WAGER_EVENT_PURCHASE, 2))
+	_, ok := <-filtered.Events()
+	assert.False(t, ok)
+	assert.Equal(t, []uint64{4}, ids(received(all)))
+}
+
+func Test_Broker_Replay(t *testing.T) {
+	broker := NewBroker(3, 10)
+	for i := uint(1); i <= 5; i++ {
+		broker.Publish(wagerEvent(model.WAGER_EVENT_PURCHASE, i%2))
+	}
+
+	tests := []struct {
+		name     string
+		wagerIDs []uint
+		after    string
+		types    []string
+		ids      []uint64
+	}{
+		{name: "New subscriber", after: "", types: []string{}, ids: []uint64{}},
+		{name: "Resume from the buffer", after: eventID(broker, 2), types: []string{"purchase", "purchase", "purchase"}, ids: []uint64{3, 4, 5}},
+		{name: "Resume filtered", wagerIDs: []uint{1}, after: eventID(broker, 3), types: []string{"purchase"}, ids: []uint64{5}},
+		{name: "Up to date", after: eventID(broker, 5), types: []string{}, ids: []uint64{}},
+		{name: "Missed events", after: eventID(broker, 1), types: []string{"reset", "purchase", "purchase", "purchase"}, ids: []uint64{0, 3, 4, 5}},
+		{name: "After a restart", after: eventID(broker, 9), types: []string{"reset"}, ids: []uint64{0}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			subscription := subscribe(t, broker, tc.wagerIDs, tc.after)
+			defer subscription.Close()
+			events := received(subscription)
+			types := []string{}
+			for _, event := range events {
+				types = append(types, event.Type)
+			}
+			assert.Equal(t, tc.types, types)
+			assert.Equal(t, tc.ids, ids(events))
+		})
+	}
+
+	// the replay comes before the events published after the subscription
+	subscription := subscribe(t, broker, nil, eventID(broker, 4))
+	broker.Publish(wagerEvent(model.WAGER_EVENT_PURCHASE, 1))
+	assert.Equal(t, []uint64{5, 6}, ids(received(subscription)))
+}
+
+func Test_Broker_Restart(t *testing.T) {
+	before := NewBroker(10, 10)
+	for i := uint(1); i <= 5; i++ {
+		before.Publish(wagerEvent(model.WAGER_EVENT_PURCHASE, i))
+	}
+	broker := NewBroker(10, 10)
+	broker.epoch = before.epoch + "0"
+	for i := uint(1); i <= 8; i++ {
+		broker.Publish(wagerEvent(model.WAGER_EVENT_PURCHASE, i))
+	}
+
+	// the ids of the earlier process are not replayed from, even below the last id
+	subscription := subscribe(t, broker, nil, eventID(before, 5))
+	events := received(subscription)
+	require.Len(t, events, 1)
+	assert.Equal(t, model.WAGER_EVENT_RESET, events[0].Type)
+	broker.Publish(wagerEvent(model.WAGER_EVENT_PURCHASE, 1))
+	assert.Equal(t, []string{eventID(broker, 9)}, []string{received(subscription)[0].ID})
+}
+
+func Test_Broker_InvalidEventID(t *testing.T) {
+	broker := NewBroker(10, 10)
+	for _, after := range []string{"5", "-5", "abc-x", "abc-0"} {
+		_, err := broker.Subscribe(nil, after)
+		assert.Equal(t, ErrEventID, err, after)
+	}
+}
+
+func Test_Broker_SlowSubscriber(t *testing.T) {
+	broker := NewBroker(10, 2)
+	slow := subscribe(t, broker, nil, "")
+	other := subscribe(t, broker, []uint{9}, "")
+
+	for i := 0; i < 3; i++ {
+		broker.Publish(wagerEvent(model.WAGER_EVENT_PURCHASE, 1))
+	}
+
+	// the slow subscriber is dropped once its buffer is full, and can resume
+	assert.Equal(t, []uint64{1, 2}, ids(received(slow)))
+	_, ok := <-slow.Events()
+	assert.False(t, ok)
+	slow.Close()
+	resumed := subscribe(t, broker, nil, eventID(broker, 2))
+	assert.Equal(t, []uint64{3}, ids(received(resumed)))
+
+	// subscribers of other wagers are not held back
+	broker.Publish(wagerEvent(model.WAGER_EVENT_PURCHASE, 9))
+	require.Equal(t, []uint64{4}, ids(received(other)))
+}
+
+func Test_Broker_StaleEvents(t *testing.T) {
+	broker := NewBroker(10, 10)
+	subscription := subscribe(t, broker, nil, "")
+
+	// the purchase committed first is published after the one which followed it
+	broker.Publish(model.WagerEvent{Type: model.WAGER_EVENT_CREATED, Wager: &model.Wager{ID: 1}})
+	broker.Publish(model.WagerEvent{Type: model.WAGER_EVENT_PURCHASE, Wager: &model.Wager{ID: 1, CurrentSellingPrice: 50, Version: 2}})
+	broker.Publish(model.WagerEvent{Type: model.WAGER_EVENT_PURCHASE, Wager: &model.Wager{ID: 1, CurrentSellingPrice: 75, Version: 1}})
+	broker.Publish(model.WagerEvent{Type: model.WAGER_EVENT_CREATED, Wager: &model.Wager{ID: 2}})
+	broker.Publish(model.WagerEvent{Type: model.WAGER_EVENT_SETTLEMENT, Market: &model.Market{ID: 1}, WagerIDs: []uint{1}})
+
+	events := received(subscription)
+	assert.Equal(t, []uint64{1, 2, 3, 4}, ids(events))
+	assert.Equal(t, float64(50), events[1].Wager.CurrentSellingPrice)
+
+	// a repeated version is dropped too
+	broker.Publish(model.WagerEvent{Type: model.WAGER_EVENT_STATUS, Wager: &model.Wager{ID: 1, Version: 2}})
+	assert.Empty(t, received(subscription))
+}
+
+func Test_Broker_VersionWindow(t *testing.T) {
+	broker := NewBroker(0, 4*MIN_VERSION_WINDOW)
+	subscription := subscribe(t, broker, nil, "")
+
+	broker.Publish(model.WagerEvent{Type: model.WAGER_EVENT_PURCHASE, Wager: &model.Wager{ID: 1, Version: 5}})
+	for i := uint(2); i <= 2*MIN_VERSION_WINDOW+1; i++ {
+		broker.Publish(model.WagerEvent{Type: model.WAGER_EVENT_CREATED, Wager: &model.Wager{ID: i}})
+	}
+	// the versions of wagers not published for a window are forgotten, the recent
+	// ones are kept
+	assert.Less(t, len(broker.versions), 2*MIN_VERSION_WINDOW)
+	_, ok := broker.versions[1]
+	assert.False(t, ok)
+	broker.Publish(model.WagerEvent{Type: model.WAGER_EVENT_CREATED, Wager: &model.Wager{ID: 2*MIN_VERSION_WINDOW + 1}})
+	assert.Equal(t, 2*MIN_VERSION_WINDOW+1, len(received(subscription)))
+}
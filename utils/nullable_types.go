@@ -50,6 +50,10 @@ func (n NullUint) MarshalJSON() ([]byte, error) {
 }
 
 func (n *NullUint) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		n.Uint, n.Valid = 0, false
+		return nil
+	}
 	err := json.Unmarshal(b, &n.Uint)
 	n.Valid = (err == nil)
 	return err
@@ -68,6 +72,10 @@ func (nf NullFloat64) MarshalJSON() ([]byte, error) {
 }
 
 func (nf *NullFloat64) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		nf.Float64, nf.Valid = 0, false
+		return nil
+	}
 	err := json.Unmarshal(b, &nf.Float64)
 	nf.Valid = (err == nil)
 	return err
@@ -2,8 +2,11 @@ package validator
 
 import (
 	"fmt"
-	"math"
+	"reflect"
+	"wager/currency"
 	errorcode "wager/error_code"
+	"wager/model"
+	"wager/odds"
 
 	go_validate "github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
@@ -13,20 +16,143 @@ var validate *go_validate.Validate
 
 func init() {
 	validate = go_validate.New()
-	err := validate.RegisterValidation("monetary-format", validateMonetaryFormat)
+	err := validate.RegisterValidation("monetary", validateMonetary)
 	if err != nil {
 		logrus.WithError(err).Fatal("failed to register monetary validator")
 	}
+	err = validate.RegisterValidation("currency", validateCurrency)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to register currency validator")
+	}
+	validate.RegisterStructValidation(validateCreateWager, model.CreateWagerRequest{})
 }
 
 func Validate(v interface{}) error {
 	return validate.Struct(v)
 }
 
-func validateMonetaryFormat(field go_validate.FieldLevel) bool {
-	eps := 1e-8
-	value := field.Field().Float()
-	return value*1e2-math.Floor(value*1e2) < eps
+// validateMonetary checks amounts given before their currency is known, like bids
+// on a wager, against the most precise currency. The service checks them against
+// the currency of the wager.
+func validateMonetary(field go_validate.FieldLevel) bool {
+	return currency.Exact(field.Field().Float(), currency.MAX_MINOR_UNITS)
+}
+
+func validateCurrency(field go_validate.FieldLevel) bool {
+	return currency.Valid(field.Field().String())
+}
+
+func validateCreateWager(sl go_validate.StructLevel) {
+	req := sl.Current().Interface().(model.CreateWagerRequest)
+	validateOdds(sl, req)
+	validateAmounts(sl, req)
+}
+
+// validateOdds checks that the odds of a wager can be read in its odds format, the
+// format is the param of the error
+func validateOdds(sl go_validate.StructLevel, req model.CreateWagerRequest) {
+	// missing odds and unknown formats are reported by the field rules
+	if req.Odds == "" || validate.Var(req.OddsFormat, "omitempty,oneof=decimal fractional american") != nil {
+		return
+	}
+	if _, err := req.Odds.Parse(req.OddsFormat); err != nil {
+		sl.ReportError(req.Odds, "Odds", "Odds", "odds", req.OddsFormat)
+	}
+}
+
+// validateAmounts checks that the amounts of a wager fit the minor unit of its
+// currency, the currency is the param of the error
+func validateAmounts(sl go_validate.StructLevel, req model.CreateWagerRequest) {
+	code := req.Currency
+	if code == "" {
+		code = currency.DEFAULT
+	}
+	// unknown currencies are reported by the field rules
+	c, err := currency.Get(code)
+	if err != nil {
+		return
+	}
+
+	amounts := []struct {
+		name  string
+		value float64
+	}{
+		{name: "SellingPrice", value: req.SellingPrice},
+		{name: "MinPurchase", value: req.MinPurchase},
+		{name: "MaxPurchase", value: req.MaxPurchase},
+		{name: "PurchaseIncrement", value: req.PurchaseIncrement},
+		{name: "FloorPrice", value: req.FloorPrice},
+	}
+	for _, amount := range amounts {
+		if !c.Exact(amount.value) {
+			sl.ReportError(amount.value, amount.name, amount.name, "monetary", c.Code)
+		}
+	}
+}
+
+// CreateWagerErrors checks a wager with its field rules and the cross-check of its
+// selling price, it returns the messages of the failed checks
+func CreateWagerErrors(req model.CreateWagerRequest) []string {
+	if err := Validate(req); err != nil {
+		return ErrorMsg(err).Error.([]string)
+	}
+
+	if req.SellingPrice <= float64(req.TotalWagerValue*req.SellingPercentage)/100 {
+		return []string{"SellingPrice must be larger than TotalWagerValue * SellingPercentage"}
+	}
+
+	if msgs := purchaseSizeErrors(req); msgs != nil {
+		return msgs
+	}
+	return priceScheduleErrors(req)
+}
+
+// priceScheduleErrors checks that a price schedule decays to a positive floor
+func priceScheduleErrors(req model.CreateWagerRequest) []string {
+	if req.PriceSchedule == "" {
+		return nil
+	}
+
+	result := []string{}
+	if req.FloorPrice <= 0 || req.FloorPrice >= req.SellingPrice {
+		result = append(result, "FloorPrice must be larger than 0 and less than SellingPrice")
+	}
+	if req.DecaySeconds <= 0 {
+		result = append(result, "DecaySeconds must be larger than 0")
+	}
+	if req.PriceSchedule == model.PRICE_SCHEDULE_STEP && req.DecaySteps <= 0 {
+		result = append(result, "DecaySteps must be larger than 0")
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// purchaseSizeErrors checks that the purchase size bounds of a wager can be met
+func purchaseSizeErrors(req model.CreateWagerRequest) []string {
+	result := []string{}
+	if req.MinPurchase > req.SellingPrice {
+		result = append(result, "MinPurchase must be less than or equal SellingPrice")
+	}
+	if req.MaxPurchase > req.SellingPrice {
+		result = append(result, "MaxPurchase must be less than or equal SellingPrice")
+	}
+	if req.MaxPurchase > 0 && req.MaxPurchase < req.MinPurchase {
+		result = append(result, "MaxPurchase must be larger than or equal MinPurchase")
+	}
+	if req.PurchaseIncrement > req.SellingPrice {
+		result = append(result, "PurchaseIncrement must be less than or equal SellingPrice")
+	}
+	if req.PurchaseIncrement > 0 && req.MaxPurchase > 0 && req.MaxPurchase < req.PurchaseIncrement {
+		result = append(result, "MaxPurchase must be larger than or equal PurchaseIncrement")
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
 }
 
 func ErrorMsg(err error) errorcode.ErrorResponse {
@@ -39,15 +165,39 @@ func ErrorMsg(err error) errorcode.ErrorResponse {
 
 func fieldErrorMsg(fieldError go_validate.FieldError) string {
 	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("%v is required", fieldError.Field())
 	case "gt":
 		return fmt.Sprintf("%v must be larger than %s", fieldError.Field(), fieldError.Param())
 	case "gte":
 		return fmt.Sprintf("%v must be larger than or equal %s", fieldError.Field(), fieldError.Param())
 	case "lte":
 		return fmt.Sprintf("%v must be less than or equal %s", fieldError.Field(), fieldError.Param())
-	case "monetary-format":
-		return fmt.Sprintf("%v must be in monetary format with maximum 2 decimal places", fieldError.Field())
+	case "min":
+		return fmt.Sprintf("%v must have at least %s %v", fieldError.Field(), fieldError.Param(), lengthUnit(fieldError))
+	case "max":
+		return fmt.Sprintf("%v must have at most %s %v", fieldError.Field(), fieldError.Param(), lengthUnit(fieldError))
+	case "oneof":
+		return fmt.Sprintf("%v must be one of %s", fieldError.Field(), fieldError.Param())
+	case "odds":
+		_, err := odds.Parse(fmt.Sprint(fieldError.Value()), fieldError.Param())
+		return fmt.Sprintf("%v %v", fieldError.Field(), err)
+	case "monetary":
+		if fieldError.Param() == "" {
+			return fmt.Sprintf("%v must have at most %v decimal places", fieldError.Field(), currency.MAX_MINOR_UNITS)
+		}
+		return fmt.Sprintf("%v must have at most %v decimal places in %v", fieldError.Field(), currency.Of(fieldError.Param()).MinorUnits, fieldError.Param())
+	case "currency":
+		return fmt.Sprintf("%v %v", fieldError.Field(), currency.ErrUnknown)
 	default:
 		return fieldError.Error()
 	}
 }
+
+// lengthUnit names what min and max count for the field
+func lengthUnit(fieldError go_validate.FieldError) string {
+	if fieldError.Kind() == reflect.String {
+		return "characters"
+	}
+	return "items"
+}